@@ -29,6 +29,7 @@ import (
 	ro "github.com/trickstercache/trickster/cmd/trickster/config/reload/options"
 	"github.com/trickstercache/trickster/pkg/backends/alb"
 	"github.com/trickstercache/trickster/pkg/backends/healthcheck"
+	"github.com/trickstercache/trickster/pkg/backends/warmer"
 	"github.com/trickstercache/trickster/pkg/cache"
 	"github.com/trickstercache/trickster/pkg/cache/memory"
 	"github.com/trickstercache/trickster/pkg/cache/providers"
@@ -46,6 +47,7 @@ import (
 
 var cfgLock = &sync.Mutex{}
 var hc healthcheck.HealthChecker
+var wl warmer.Lookup
 
 func runConfig(oldConf *config.Config, wg *sync.WaitGroup, logger *tl.Logger,
 	oldCaches map[string]cache.Cache, args []string, errorFunc func()) error {
@@ -150,9 +152,16 @@ func applyConfig(conf, oldConf *config.Config, wg *sync.WaitGroup, logger *tl.Lo
 		return err
 	}
 	alb.StartALBPools(o, hc.Statuses())
+	if wl != nil {
+		wl.Stop()
+	}
+	wl = o.StartWarmers(logger)
 	routing.RegisterDefaultBackendRoutes(router, o, logger, tracers)
 	routing.RegisterHealthHandler(mr, conf.Main.HealthHandlerPath, hc)
-	applyListenerConfigs(conf, oldConf, router, http.HandlerFunc(rh), mr, logger, tracers)
+	ph := handlers.PurgeHandleFunc(o, logger)
+	ch := handlers.CacheInspectHandleFunc(o, logger)
+	applyListenerConfigs(conf, oldConf, router, http.HandlerFunc(rh), http.HandlerFunc(ph),
+		http.HandlerFunc(ch), mr, logger, tracers)
 
 	metrics.LastReloadSuccessfulTimestamp.Set(float64(time.Now().Unix()))
 	metrics.LastReloadSuccessful.Set(1)
@@ -212,13 +221,20 @@ func applyCachingConfig(c, oc *config.Config, logger *tl.Logger,
 
 	if oc == nil || oldCaches == nil {
 		for k, v := range c.Caches {
+			if registration.IsTiered(v) {
+				continue
+			}
 			caches[k] = registration.NewCache(k, v, logger)
 		}
-		return caches
+		return applyTieredCachingConfig(c, caches, logger)
 	}
 
 	for k, v := range c.Caches {
 
+		if registration.IsTiered(v) {
+			continue
+		}
+
 		if w, ok := oldCaches[k]; ok {
 
 			ocfg := w.Configuration()
@@ -254,6 +270,18 @@ func applyCachingConfig(c, oc *config.Config, logger *tl.Logger,
 		// the newly-named cache is not in the old config or couldn't be reused, so make it anew
 		caches[k] = registration.NewCache(k, v, logger)
 	}
+	return applyTieredCachingConfig(c, caches, logger)
+}
+
+// applyTieredCachingConfig adds the configured tiered caches to the provided caches. Since
+// they are composed of the other caches, tiered caches are always made anew
+func applyTieredCachingConfig(c *config.Config, caches map[string]cache.Cache,
+	logger *tl.Logger) map[string]cache.Cache {
+	for k, v := range c.Caches {
+		if registration.IsTiered(v) {
+			caches[k] = registration.NewTieredCache(k, v, caches, logger)
+		}
+	}
 	return caches
 }
 
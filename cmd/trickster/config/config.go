@@ -89,6 +89,10 @@ type MainConfig struct {
 	ReloadHandlerPath string `yaml:"reload_handler_path,omitempty"`
 	// HeatlHandlerPath provides the base Health Check Handler path
 	HealthHandlerPath string `yaml:"health_handler_path,omitempty"`
+	// PurgeHandlerPath provides the path to register the Cache Purge Handler on the reload listener
+	PurgeHandlerPath string `yaml:"purge_handler_path,omitempty"`
+	// CacheHandlerPath provides the path to register the Cache Inspection Handler on the reload listener
+	CacheHandlerPath string `yaml:"cache_handler_path,omitempty"`
 	// PprofServer provides the name of the http listener that will host the pprof debugging routes
 	// Options are: "metrics", "reload", "both", or "off"; default is both
 	PprofServer string `yaml:"pprof_server,omitempty"`
@@ -130,6 +134,8 @@ func NewConfig() *Config {
 			PingHandlerPath:   DefaultPingHandlerPath,
 			ReloadHandlerPath: reload.DefaultReloadHandlerPath,
 			HealthHandlerPath: DefaultHealthHandlerPath,
+			PurgeHandlerPath:  DefaultPurgeHandlerPath,
+			CacheHandlerPath:  DefaultCacheHandlerPath,
 			PprofServer:       DefaultPprofServerName,
 			ServerName:        hn,
 		},
@@ -273,6 +279,8 @@ func (c *Config) Clone() *Config {
 	nc.Main.PingHandlerPath = c.Main.PingHandlerPath
 	nc.Main.ReloadHandlerPath = c.Main.ReloadHandlerPath
 	nc.Main.HealthHandlerPath = c.Main.HealthHandlerPath
+	nc.Main.PurgeHandlerPath = c.Main.PurgeHandlerPath
+	nc.Main.CacheHandlerPath = c.Main.CacheHandlerPath
 	nc.Main.PprofServer = c.Main.PprofServer
 	nc.Main.ServerName = c.Main.ServerName
 
@@ -23,6 +23,10 @@ const (
 	DefaultPingHandlerPath = "/trickster/ping"
 	// DefaultHealthHandlerPath defines the default path for the Health Handler
 	DefaultHealthHandlerPath = "/trickster/health"
+	// DefaultPurgeHandlerPath defines the default path for the Cache Purge Handler
+	DefaultPurgeHandlerPath = "/trickster/purge"
+	// DefaultCacheHandlerPath defines the default path for the Cache Inspection Handler
+	DefaultCacheHandlerPath = "/trickster/cache"
 	// DefaultPprofServerName defines the default Pprof Server Name
 	DefaultPprofServerName = "both"
 )
@@ -34,7 +34,7 @@ import (
 var lg = listener.NewListenerGroup()
 
 func applyListenerConfigs(conf, oldConf *config.Config,
-	router, reloadHandler http.Handler, metricsRouter *http.ServeMux, log *tl.Logger,
+	router, reloadHandler, purgeHandler, cacheHandler http.Handler, metricsRouter *http.ServeMux, log *tl.Logger,
 	tracers tracing.Tracers) {
 
 	var err error
@@ -46,6 +46,12 @@ func applyListenerConfigs(conf, oldConf *config.Config,
 
 	adminRouter := http.NewServeMux()
 	adminRouter.Handle(conf.ReloadConfig.HandlerPath, reloadHandler)
+	if conf.Main.PurgeHandlerPath != "" {
+		adminRouter.Handle(conf.Main.PurgeHandlerPath, purgeHandler)
+	}
+	if conf.Main.CacheHandlerPath != "" {
+		adminRouter.Handle(conf.Main.CacheHandlerPath, cacheHandler)
+	}
 
 	// No changes in frontend config
 	if oldConf != nil && oldConf.Frontend != nil &&
@@ -158,6 +164,12 @@ func applyListenerConfigs(conf, oldConf *config.Config,
 		lg.DrainAndClose("reloadListener", time.Millisecond*500)
 		rr.HandleFunc(conf.Main.ConfigHandlerPath, ph.ConfigHandleFunc(conf))
 		rr.Handle(conf.ReloadConfig.HandlerPath, reloadHandler)
+		if conf.Main.PurgeHandlerPath != "" {
+			rr.Handle(conf.Main.PurgeHandlerPath, purgeHandler)
+		}
+		if conf.Main.CacheHandlerPath != "" {
+			rr.Handle(conf.Main.CacheHandlerPath, cacheHandler)
+		}
 		if conf.Main.PprofServer == "both" || conf.Main.PprofServer == "reload" {
 			routing.RegisterPprofRoutes("reload", rr, log)
 		}
@@ -167,6 +179,12 @@ func applyListenerConfigs(conf, oldConf *config.Config,
 	} else {
 		rr.HandleFunc(conf.Main.ConfigHandlerPath, ph.ConfigHandleFunc(conf))
 		rr.Handle(conf.ReloadConfig.HandlerPath, reloadHandler)
+		if conf.Main.PurgeHandlerPath != "" {
+			rr.Handle(conf.Main.PurgeHandlerPath, purgeHandler)
+		}
+		if conf.Main.CacheHandlerPath != "" {
+			rr.Handle(conf.Main.CacheHandlerPath, cacheHandler)
+		}
 		lg.UpdateRouter("reloadListener", rr)
 	}
 }
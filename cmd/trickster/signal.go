@@ -28,9 +28,14 @@ import (
 )
 
 var hups = make(chan os.Signal, 1)
+var shutdowns = make(chan os.Signal, 1)
+
+// shutdownFunc is called once the caches have been closed upon a shutdown signal
+var shutdownFunc = func() { os.Exit(0) }
 
 func init() {
 	signal.Notify(hups, syscall.SIGHUP)
+	signal.Notify(shutdowns, syscall.SIGINT, syscall.SIGTERM)
 }
 
 func startHupMonitor(conf *config.Config, wg *sync.WaitGroup, log *tl.Logger,
@@ -54,9 +59,27 @@ func startHupMonitor(conf *config.Config, wg *sync.WaitGroup, log *tl.Logger,
 				}
 				conf.Main.ReloaderLock.Unlock()
 				tl.Warn(log, "configuration NOT reloaded", tl.Pairs{})
+			case sig := <-shutdowns:
+				tl.Warn(log, "shutting down", tl.Pairs{"signal": sig.String()})
+				closeCaches(caches, log)
+				shutdownFunc()
+				return
 			case <-conf.Resources.QuitChan:
 				return
 			}
 		}
 	}()
 }
+
+// closeCaches closes each of the provided caches, so that those persisting their
+// contents (e.g., memory caches with snapshots) can do so before exiting
+func closeCaches(caches map[string]cache.Cache, log *tl.Logger) {
+	for k, c := range caches {
+		if c == nil {
+			continue
+		}
+		if err := c.Close(); err != nil {
+			tl.Error(log, "cache close failed", tl.Pairs{"cacheName": k, "detail": err.Error()})
+		}
+	}
+}
@@ -43,4 +43,16 @@ func TestStartHupMonitor(t *testing.T) {
 	time.Sleep(time.Millisecond * 100)
 	hups <- syscall.SIGHUP
 	time.Sleep(time.Millisecond * 100)
+
+	// a shutdown signal closes the caches before exiting
+	exited := make(chan bool, 1)
+	shutdownFunc = func() { exited <- true }
+	startHupMonitor(conf, nil, logger, nil, nil)
+	time.Sleep(time.Millisecond * 100)
+	shutdowns <- syscall.SIGTERM
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Error("expected shutdown")
+	}
 }
@@ -21,8 +21,10 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/trickstercache/trickster/pkg/backends"
+	ao "github.com/trickstercache/trickster/pkg/backends/alb/options"
 	"github.com/trickstercache/trickster/pkg/backends/alb/pool"
 	"github.com/trickstercache/trickster/pkg/backends/healthcheck"
 	bo "github.com/trickstercache/trickster/pkg/backends/options"
@@ -32,6 +34,7 @@ import (
 	"github.com/trickstercache/trickster/pkg/proxy/methods"
 	"github.com/trickstercache/trickster/pkg/proxy/paths/matching"
 	po "github.com/trickstercache/trickster/pkg/proxy/paths/options"
+	strutil "github.com/trickstercache/trickster/pkg/util/strings"
 )
 
 // Client Implements the Proxy Client Interface
@@ -41,8 +44,9 @@ type Client struct {
 	pool            pool.Pool
 	handler         http.Handler // this is the actual handler for all request to this backend
 	fgr             bool
-	mergePaths      []string     // paths handled by the alb client that are enabled for tsmerge
-	nonmergeHandler http.Handler // when methodology is tsmerge, this handler is for non-mergable paths
+	mergePaths      []string                   // paths handled by the alb client that are enabled for tsmerge
+	nonmergeHandler http.Handler               // when methodology is tsmerge, this handler is for non-mergable paths
+	keyer           backends.TimeseriesBackend // when methodology is ch, parses time series requests for hashing
 }
 
 // Handlers returns a map of the HTTP Handlers the client has registered
@@ -73,6 +77,8 @@ func NewClient(name string, o *bo.Options, router http.Handler,
 			c.fgr = true
 		case pool.NewestLastModified.String():
 			c.handler = http.HandlerFunc(c.handleNewestResponse)
+		case pool.ConsistentHash.String():
+			c.handler = http.HandlerFunc(c.handleConsistentHash)
 		case pool.TimeSeriesMerge.String():
 			c.handler = http.HandlerFunc(c.handleResponseMerge)
 			c.nonmergeHandler = http.HandlerFunc(c.handleRoundRobin)
@@ -117,6 +123,14 @@ func StartALBPools(clients backends.Backends, hcs healthcheck.StatusLookup) erro
 			}
 		}
 	}
+	// the targets of ALBs that are no longer configured are not inherited by any pool
+	startedTargetsMtx.Lock()
+	for n := range startedTargets {
+		if _, ok := clients[n].(*Client); !ok {
+			delete(startedTargets, n)
+		}
+	}
+	startedTargetsMtx.Unlock()
 	return nil
 }
 
@@ -148,6 +162,11 @@ func (c *Client) ValidatePool(clients backends.Backends) error {
 			return fmt.Errorf("invalid pool member name [%s] in backend [%s]", n, c.Name())
 		}
 	}
+	for n := range c.Configuration().ALBOptions.Weights {
+		if strutil.IndexInSlice(c.Configuration().ALBOptions.Pool, n) == -1 {
+			return fmt.Errorf("invalid weighted pool member name [%s] in backend [%s]", n, c.Name())
+		}
+	}
 	return nil
 }
 
@@ -171,17 +190,43 @@ func (c *Client) ValidateAndStartPool(clients backends.Backends, hcs healthcheck
 			return fmt.Errorf("invalid pool member name [%s] in backend [%s]", n, c.Name())
 		}
 		hc, _ := hcs[n]
-		targets = append(targets, pool.NewTarget(tc.Router(), hc))
+		w, ok := o.Weights[n]
+		if !ok {
+			w = 1
+		}
+		targets = append(targets, pool.NewWeightedTarget(n, tc.Router(), hc, w))
+		// the first time series pool member is used to parse requests when hashing by cache key
+		if tsc, ok := tc.(backends.TimeseriesBackend); ok && c.keyer == nil &&
+			m == pool.ConsistentHash && o.HashSource == ao.HashSourceCacheKey {
+			c.keyer = tsc
+		}
 	}
+	// the pool is rebuilt on every config reload, so the new targets inherit the
+	// outstanding requests of the pool being replaced
+	startedTargetsMtx.Lock()
+	pool.InheritOutstanding(targets, startedTargets[c.Name()])
+	startedTargets[c.Name()] = targets
+	startedTargetsMtx.Unlock()
 	c.pool = pool.New(m, targets, o.HealthyFloor)
 	return nil
 }
 
+var (
+	// startedTargets holds the pool targets most recently started by each configured ALB, by name
+	startedTargets    = make(map[string][]*pool.Target)
+	startedTargetsMtx sync.Mutex
+)
+
 // Boilerplate Interface Functions (to EOF)
 
 // DefaultPathConfigs returns the default PathConfigs for the given Provider
 func (c *Client) DefaultPathConfigs(o *bo.Options) map[string]*po.Options {
 	m := methods.CacheableHTTPMethods()
+	// some mergeable time series queries (e.g., InfluxDB Flux) are only sent via POST
+	if o != nil && o.ALBOptions != nil &&
+		o.ALBOptions.MechanismName == pool.TimeSeriesMerge.String() {
+		m = append(m, http.MethodPost)
+	}
 	paths := map[string]*po.Options{
 		"/" + strings.Join(m, "-"): {
 			Path:          "/",
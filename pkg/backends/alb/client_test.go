@@ -21,7 +21,11 @@ import (
 
 	"github.com/trickstercache/trickster/pkg/backends"
 	ao "github.com/trickstercache/trickster/pkg/backends/alb/options"
+	"github.com/trickstercache/trickster/pkg/backends/alb/pool"
+	"github.com/trickstercache/trickster/pkg/backends/clickhouse"
 	"github.com/trickstercache/trickster/pkg/backends/healthcheck"
+	"github.com/trickstercache/trickster/pkg/backends/influxdb"
+	"github.com/trickstercache/trickster/pkg/backends/irondb"
 	bo "github.com/trickstercache/trickster/pkg/backends/options"
 	"github.com/trickstercache/trickster/pkg/backends/prometheus"
 	"github.com/trickstercache/trickster/pkg/backends/providers/registration/types"
@@ -71,11 +75,62 @@ func TestHandlers(t *testing.T) {
 
 }
 
+func TestNewClientTimeSeriesMerge(t *testing.T) {
+
+	lookup := types.Lookup{
+		"influxdb":   influxdb.NewClient,
+		"clickhouse": clickhouse.NewClient,
+		"irondb":     irondb.NewClient,
+	}
+
+	tests := []struct {
+		outputFormat string
+		mergePath    string
+	}{
+		{"influxdb", "/query"},
+		{"clickhouse", "/"},
+		{"irondb", "/rollup/"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.outputFormat, func(t *testing.T) {
+			o := bo.New()
+			o.ALBOptions = &ao.Options{MechanismName: "tsm", OutputFormat: test.outputFormat}
+			cl, err := NewClient("test", o, nil, nil, nil, lookup)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var found bool
+			for _, p := range cl.(*Client).mergePaths {
+				if p == test.mergePath {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("expected merge path %s in %v", test.mergePath, cl.(*Client).mergePaths)
+			}
+		})
+	}
+
+	o := bo.New()
+	o.ALBOptions = &ao.Options{MechanismName: "tsm", OutputFormat: "rpc"}
+	if _, err := NewClient("test", o, nil, nil, nil, lookup); err != ErrInvalidTimeSeriesMergeProvider {
+		t.Errorf("expected %v got %v", ErrInvalidTimeSeriesMergeProvider, err)
+	}
+}
+
 func TestDefaultPathConfigs(t *testing.T) {
 	m := (&Client{}).DefaultPathConfigs(nil)
 	if len(m) != 1 {
 		t.Error("expected 1 got", len(m))
 	}
+	o := bo.New()
+	o.ALBOptions = &ao.Options{MechanismName: "tsm"}
+	m = (&Client{}).DefaultPathConfigs(o)
+	if _, ok := m["/GET-HEAD-POST"]; !ok {
+		t.Errorf("expected POST to be supported for tsm, got %v", m)
+	}
 }
 
 func TestStartALBPools(t *testing.T) {
@@ -90,6 +145,24 @@ func TestStartALBPools(t *testing.T) {
 	if err == nil || err.Error() != "invalid options" {
 		t.Error("expected err for invalid options, got", err)
 	}
+
+	// the started targets of ALBs removed from the config are released
+	o.ALBOptions = ao.New()
+	o.ALBOptions.MechanismName = "rr"
+	startedTargetsMtx.Lock()
+	startedTargets["removed"] = []*pool.Target{}
+	startedTargetsMtx.Unlock()
+	err = StartALBPools(b, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	startedTargetsMtx.Lock()
+	_, removed := startedTargets["removed"]
+	_, started := startedTargets["test"]
+	startedTargetsMtx.Unlock()
+	if removed || !started {
+		t.Errorf("unexpected started targets: removed=%t started=%t", removed, started)
+	}
 }
 
 func TestValidatePools(t *testing.T) {
@@ -125,6 +198,20 @@ func TestValidatePools(t *testing.T) {
 		t.Error(err)
 	}
 
+	a.MechanismName = "wrr"
+	a.Weights = map[string]int{"invalid": 2}
+	err = ValidatePools(b)
+	expected = `invalid weighted pool member name [invalid] in backend [test]`
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %s got %v", expected, err)
+	}
+
+	a.Weights = map[string]int{"test": 2}
+	err = ValidatePools(b)
+	if err != nil {
+		t.Error(err)
+	}
+
 	o.Provider = "invalid"
 	err = ValidatePools(b)
 	if err != nil {
@@ -175,4 +262,35 @@ func TestValidateAndStartPool(t *testing.T) {
 		t.Error(err)
 	}
 
+	for _, m := range []string{"wrr", "lor"} {
+		a.MechanismName = m
+		a.Weights = map[string]int{"test": 3}
+		err = cl.ValidateAndStartPool(b, hcs)
+		if err != nil {
+			t.Error(err)
+		}
+		if cl.pool == nil {
+			t.Errorf("expected non-nil pool for mechanism %s", m)
+		}
+	}
+
+	// restarting the pool (e.g., on a config reload) records the new targets, which
+	// inherit the outstanding requests of the previous targets
+	startedTargetsMtx.Lock()
+	prev := startedTargets["test"]
+	startedTargetsMtx.Unlock()
+	if len(prev) != 1 {
+		t.Fatalf("expected %d got %d", 1, len(prev))
+	}
+	err = cl.ValidateAndStartPool(b, hcs)
+	if err != nil {
+		t.Error(err)
+	}
+	startedTargetsMtx.Lock()
+	next := startedTargets["test"]
+	startedTargetsMtx.Unlock()
+	if len(next) != 1 || next[0] == prev[0] {
+		t.Error("expected a new pool target")
+	}
+
 }
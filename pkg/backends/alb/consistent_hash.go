@@ -0,0 +1,94 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alb
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	ao "github.com/trickstercache/trickster/pkg/backends/alb/options"
+	"github.com/trickstercache/trickster/pkg/backends/alb/pool"
+	"github.com/trickstercache/trickster/pkg/proxy/engines"
+	"github.com/trickstercache/trickster/pkg/proxy/handlers"
+	"github.com/trickstercache/trickster/pkg/proxy/params"
+)
+
+func (c *Client) handleConsistentHash(w http.ResponseWriter, r *http.Request) {
+	hp, ok := c.pool.(pool.HashPool)
+	if !ok {
+		handlers.HandleBadGateway(w, r)
+		return
+	}
+	hl := hp.NextByKey(c.hashKey(r))
+	if len(hl) > 0 {
+		hl[0].ServeHTTP(w, r)
+		return
+	}
+	handlers.HandleBadGateway(w, r)
+}
+
+// hashKey returns the value of the request attribute configured for consistent hashing.
+// An empty key indicates that the request should be routed by round robin
+func (c *Client) hashKey(r *http.Request) string {
+	var o *ao.Options
+	if c.Configuration() != nil {
+		o = c.Configuration().ALBOptions
+	}
+	if o != nil {
+		switch o.HashSource {
+		case ao.HashSourceHeader:
+			return r.Header.Get(o.HashHeader)
+		case ao.HashSourceCacheKey:
+			if k := c.cacheKey(r); k != "" {
+				return k
+			}
+		}
+	}
+	_, s, isBody := params.GetRequestValues(r)
+	k := r.Method + "." + r.URL.Path + "?" + r.URL.Query().Encode()
+	if isBody {
+		k += "." + s
+	}
+	return k
+}
+
+// cacheKey returns the cache key that the first time series pool member would derive
+// for the request. For time range queries, this is the Delta Proxy Cache key, which
+// omits the time range, so that requests for the same query, which share a single
+// cache entry, are routed to the same pool member
+func (c *Client) cacheKey(r *http.Request) string {
+	if c.keyer == nil || c.keyer.Configuration() == nil {
+		return ""
+	}
+	// the body is buffered so that deriving the key leaves it intact for the pool member
+	var b []byte
+	if r.Body != nil {
+		b, _ = io.ReadAll(r.Body)
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	r2 := r.Clone(r.Context())
+	if r.Body != nil {
+		r2.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	keys := engines.DeriveCacheKeys(handlers.BackendRequest(r2, c.keyer, nil))
+	if len(keys) == 0 {
+		return ""
+	}
+	return keys[len(keys)-1]
+}
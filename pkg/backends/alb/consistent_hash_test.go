@@ -0,0 +1,152 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alb
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ao "github.com/trickstercache/trickster/pkg/backends/alb/options"
+	"github.com/trickstercache/trickster/pkg/backends/alb/pool"
+	"github.com/trickstercache/trickster/pkg/backends/healthcheck"
+	bo "github.com/trickstercache/trickster/pkg/backends/options"
+	"github.com/trickstercache/trickster/pkg/backends/prometheus"
+	"github.com/trickstercache/trickster/pkg/proxy/engines"
+	"github.com/trickstercache/trickster/pkg/proxy/handlers"
+)
+
+func TestHandleConsistentHash(t *testing.T) {
+
+	w := httptest.NewRecorder()
+	c := &Client{}
+	c.handleConsistentHash(w, nil)
+	if w.Code != http.StatusBadGateway {
+		t.Error("expected 502 got", w.Code)
+	}
+
+	o := bo.New()
+	o.ALBOptions = &ao.Options{MechanismName: "ch", HashSource: ao.HashSourcePath}
+	cl, err := NewClient("test", o, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = cl.(*Client)
+
+	var hits [3]int
+	targets := make([]*pool.Target, len(hits))
+	for i := range targets {
+		i := i
+		targets[i] = pool.NewWeightedTarget(string(rune('a'+i)), http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) { hits[i]++ }), &healthcheck.Status{}, 1)
+	}
+	c.pool = pool.New(pool.ConsistentHash, targets, 0)
+	time.Sleep(250 * time.Millisecond)
+
+	for i := 0; i < 10; i++ {
+		r, _ := http.NewRequest(http.MethodGet, "http://0/api/v1/query?query=up", nil)
+		c.handleConsistentHash(httptest.NewRecorder(), r)
+	}
+	var n int
+	for _, h := range hits {
+		if h != 0 && h != 10 {
+			t.Errorf("expected all requests to route to one member, got %v", hits)
+		}
+		n += h
+	}
+	if n != 10 {
+		t.Errorf("expected %d got %d", 10, n)
+	}
+}
+
+func TestHashKey(t *testing.T) {
+
+	o := bo.New()
+	o.ALBOptions = &ao.Options{MechanismName: "ch", HashSource: ao.HashSourcePath}
+	cl, _ := NewClient("test", o, nil, nil, nil, nil)
+	c := cl.(*Client)
+
+	// query parameter order does not affect the key
+	r1, _ := http.NewRequest(http.MethodGet, "http://0/api/v1/query?b=2&a=1", nil)
+	r2, _ := http.NewRequest(http.MethodGet, "http://0/api/v1/query?a=1&b=2", nil)
+	if c.hashKey(r1) != c.hashKey(r2) {
+		t.Errorf("expected %s got %s", c.hashKey(r1), c.hashKey(r2))
+	}
+
+	o.ALBOptions.HashSource = ao.HashSourceHeader
+	o.ALBOptions.HashHeader = "X-Tenant"
+	r1.Header.Set("X-Tenant", "tenant-1")
+	if k := c.hashKey(r1); k != "tenant-1" {
+		t.Errorf("expected %s got %s", "tenant-1", k)
+	}
+
+	// requests for the same query and step, but a different time range, share a key
+	o.ALBOptions.HashSource = ao.HashSourceCacheKey
+	pbo := bo.New()
+	pc, _ := prometheus.NewClient("test", pbo, nil, nil, nil, nil)
+	pbo.Paths = pc.DefaultPathConfigs(pbo)
+	c.keyer = pc.(*prometheus.Client)
+	const u = "http://0/api/v1/query_range?query=up&step=15"
+	r1, _ = http.NewRequest(http.MethodGet, u+"&start=1600000000&end=1600003600", nil)
+	r2, _ = http.NewRequest(http.MethodGet, u+"&start=1600000300&end=1600003900", nil)
+	k1 := c.hashKey(r1)
+	if k1 != c.hashKey(r2) {
+		t.Errorf("expected %s got %s", k1, c.hashKey(r2))
+	}
+	if strings.Contains(k1, "1600000000") {
+		t.Errorf("expected key without time range got %s", k1)
+	}
+	if kc := engines.DeriveCacheKeys(handlers.BackendRequest(r1, pc, nil)); len(kc) != 2 ||
+		kc[1] != k1 {
+		t.Errorf("expected the delta proxy cache key %v got %s", kc, k1)
+	}
+
+	// requests varying by a header in the path's cache key map to different keys
+	for _, p := range pbo.Paths {
+		p.CacheKeyHeaders = append(p.CacheKeyHeaders, "X-Scope-OrgID")
+	}
+	r1.Header.Set("X-Scope-OrgID", "tenant-1")
+	r2.Header.Set("X-Scope-OrgID", "tenant-2")
+	if k1 = c.hashKey(r1); k1 == c.hashKey(r2) {
+		t.Errorf("expected different keys for different tenants, got %s", k1)
+	}
+	r2.Header.Set("X-Scope-OrgID", "tenant-1")
+	if k1 != c.hashKey(r2) {
+		t.Errorf("expected %s got %s", k1, c.hashKey(r2))
+	}
+
+	// requests that are not time range queries use the object proxy cache key
+	r1, _ = http.NewRequest(http.MethodGet, "http://0/api/v1/labels", nil)
+	if kc := engines.DeriveCacheKeys(handlers.BackendRequest(r1, pc, nil)); len(kc) != 1 ||
+		c.hashKey(r1) != kc[0] {
+		t.Errorf("expected the object proxy cache key %v got %s", kc, c.hashKey(r1))
+	}
+
+	// the body of a POST request remains intact after hashing
+	r1, _ = http.NewRequest(http.MethodPost, "http://0/api/v1/query_range",
+		strings.NewReader("query=up&step=15&start=1600000000&end=1600003600"))
+	r1.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if k := c.hashKey(r1); k != c.hashKey(r1) || k == "" {
+		t.Errorf("expected a stable key got %s", k)
+	}
+	if b, _ := io.ReadAll(r1.Body); string(b) != "query=up&step=15&start=1600000000&end=1600003600" {
+		t.Errorf("unexpected body %s", string(b))
+	}
+}
@@ -40,10 +40,28 @@ type Options struct {
 	// OutputFormat accompanies the tsmerge Mechanism to indicate the provider output format
 	// options include any valid time seres backend like prometheus, influxdb or clickhouse
 	OutputFormat string `yaml:"output_format,omitempty"`
+	// Weights accompanies the wrr Mechanism to provide the relative weight of each pool member
+	// by backend name. Pool members that are not listed have a weight of 1
+	Weights map[string]int `yaml:"weights,omitempty"`
+	// HashSource accompanies the ch Mechanism to indicate the request attribute that is hashed
+	// to select a pool member. options are 'path' (default), 'header' or 'cache_key'
+	HashSource string `yaml:"hash_source,omitempty"`
+	// HashHeader accompanies the 'header' HashSource to provide the name of the hashed header
+	HashHeader string `yaml:"hash_header,omitempty"`
 }
 
 const defaultOutputFormat = "prometheus"
 
+const (
+	// HashSourcePath hashes the request path and query parameters
+	HashSourcePath = "path"
+	// HashSourceHeader hashes the value of the request header named by HashHeader
+	HashSourceHeader = "header"
+	// HashSourceCacheKey hashes the parts of the request that contribute to its cache key,
+	// excluding the time range of time series requests
+	HashSourceCacheKey = "cache_key"
+)
+
 // New returns a New Options object with the default values
 func New() *Options {
 	return &Options{}
@@ -55,8 +73,16 @@ func (o *Options) Clone() *Options {
 		MechanismName: o.MechanismName,
 		HealthyFloor:  o.HealthyFloor,
 		OutputFormat:  o.OutputFormat,
+		HashSource:    o.HashSource,
+		HashHeader:    o.HashHeader,
 	}
 	c.Pool = copiers.CopyStrings(o.Pool)
+	if o.Weights != nil {
+		c.Weights = make(map[string]int, len(o.Weights))
+		for k, v := range o.Weights {
+			c.Weights[k] = v
+		}
+	}
 	return c
 }
 
@@ -95,6 +121,43 @@ func SetDefaults(name string, options *Options, metadata yamlx.KeyLookup) (*Opti
 		}
 	}
 
+	if metadata.IsDefined("backends", name, "alb", "weights") && len(options.Weights) > 0 {
+		if o.MechanismName != "wrr" {
+			return nil, errors.New("'weights' option is only valid for provider 'alb' and mechanism 'wrr'")
+		}
+		for _, v := range options.Weights {
+			if v < 1 {
+				return nil, errors.New("values for 'weights' must be >= 1")
+			}
+		}
+		o.Weights = options.Weights
+	}
+
+	if metadata.IsDefined("backends", name, "alb", "hash_source") && options.HashSource != "" {
+		if o.MechanismName != "ch" {
+			return nil, errors.New("'hash_source' option is only valid for provider 'alb' and mechanism 'ch'")
+		}
+		switch options.HashSource {
+		case HashSourcePath, HashSourceHeader, HashSourceCacheKey:
+		default:
+			return nil, errors.New("value for 'hash_source' is invalid")
+		}
+		o.HashSource = options.HashSource
+	}
+
+	if metadata.IsDefined("backends", name, "alb", "hash_header") && options.HashHeader != "" {
+		o.HashHeader = options.HashHeader
+	}
+
+	if o.MechanismName == "ch" {
+		if o.HashSource == "" {
+			o.HashSource = HashSourcePath
+		}
+		if o.HashSource == HashSourceHeader && o.HashHeader == "" {
+			return nil, errors.New("'hash_header' is required when 'hash_source' is 'header'")
+		}
+	}
+
 	if strings.HasPrefix(o.MechanismName, "tsm") && o.OutputFormat == "" {
 		o.OutputFormat = defaultOutputFormat
 	}
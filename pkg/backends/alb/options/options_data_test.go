@@ -46,3 +46,67 @@ backends:
       healthy_floor: 1
       pool: [ 'test' ]
 `
+
+const testTOMLWeights = `
+backends:
+  test:
+    alb:
+      mechanism: wrr
+      pool: [ 'test1', 'test2' ]
+      weights:
+        test1: 3
+`
+
+const testTOMLBadWeights1 = `
+backends:
+  test:
+    alb:
+      mechanism: rr
+      pool: [ 'test1', 'test2' ]
+      weights:
+        test1: 3
+`
+
+const testTOMLBadWeights2 = `
+backends:
+  test:
+    alb:
+      mechanism: wrr
+      pool: [ 'test1', 'test2' ]
+      weights:
+        test1: 0
+`
+
+const testTOMLHashSource = `
+backends:
+  test:
+    alb:
+      mechanism: ch
+      pool: [ 'test1', 'test2' ]
+      hash_source: header
+      hash_header: X-Tenant
+`
+
+const testTOMLBadHashSource1 = `
+backends:
+  test:
+    alb:
+      mechanism: rr
+      hash_source: header
+`
+
+const testTOMLBadHashSource2 = `
+backends:
+  test:
+    alb:
+      mechanism: ch
+      hash_source: invalid
+`
+
+const testTOMLBadHashSource3 = `
+backends:
+  test:
+    alb:
+      mechanism: ch
+      hash_source: header
+`
@@ -69,6 +69,7 @@ func TestClone(t *testing.T) {
 
 	o := New()
 	o.Pool = []string{"test"}
+	o.Weights = map[string]int{"test": 2}
 	if o == nil {
 		t.Error("expected non-nil")
 	}
@@ -77,6 +78,10 @@ func TestClone(t *testing.T) {
 	if len(co.Pool) != 1 || co.Pool[0] != "test" {
 		t.Error("clone mismatch")
 	}
+
+	if co.Weights["test"] != 2 {
+		t.Error("clone mismatch")
+	}
 }
 
 func TestSetDefaults(t *testing.T) {
@@ -128,4 +133,51 @@ func TestSetDefaults(t *testing.T) {
 		t.Error("expected output_format error")
 	}
 
+	o, md, err = fromYAML(testTOMLWeights)
+	if err != nil {
+		t.Error(err)
+	}
+	o2, err = SetDefaults("test", o, md)
+	if err != nil {
+		t.Error(err)
+	}
+	if o2 == nil || o2.Weights["test1"] != 3 {
+		t.Error("expected weight of 3")
+	}
+
+	for _, conf := range []string{testTOMLBadWeights1, testTOMLBadWeights2} {
+		o, md, err = fromYAML(conf)
+		if err != nil {
+			t.Error(err)
+		}
+		_, err = SetDefaults("test", o, md)
+		if err == nil {
+			t.Error("expected weights error")
+		}
+	}
+
+	o, md, err = fromYAML(testTOMLHashSource)
+	if err != nil {
+		t.Error(err)
+	}
+	o2, err = SetDefaults("test", o, md)
+	if err != nil {
+		t.Error(err)
+	}
+	if o2 == nil || o2.HashSource != HashSourceHeader || o2.HashHeader != "X-Tenant" {
+		t.Error("expected header hash source")
+	}
+
+	for _, conf := range []string{testTOMLBadHashSource1, testTOMLBadHashSource2,
+		testTOMLBadHashSource3} {
+		o, md, err = fromYAML(conf)
+		if err != nil {
+			t.Error(err)
+		}
+		_, err = SetDefaults("test", o, md)
+		if err == nil {
+			t.Error("expected hash_source error")
+		}
+	}
+
 }
@@ -0,0 +1,62 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pool
+
+import (
+	"net/http"
+
+	"github.com/trickstercache/trickster/pkg/util/fnv"
+)
+
+// nextConsistentHash selects a healthy pool member for the key using rendezvous
+// hashing, in which each member is scored against the key and the highest score wins.
+// When a member leaves the healthy pool, only the keys that it had been selected for
+// are remapped, and they are spread evenly across the remaining members.
+func nextConsistentHash(p *pool, key string) []http.Handler {
+	p.mtx.RLock()
+	t := p.healthyTargets
+	p.mtx.RUnlock()
+	if len(t) == 0 {
+		return nil
+	}
+	k := hashString(key)
+	var s *Target
+	var max uint64
+	for _, c := range t {
+		if v := mix(k ^ c.hash); s == nil || v > max {
+			s, max = c, v
+		}
+	}
+	return []http.Handler{s.handler}
+}
+
+func hashString(s string) uint64 {
+	h := fnv.NewInlineFNV64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// mix is the splitmix64 finalizer, which evenly distributes the bits of the
+// combined key and member hashes so that scores are not correlated
+func mix(v uint64) uint64 {
+	v ^= v >> 30
+	v *= 0xbf58476d1ce4e5b9
+	v ^= v >> 27
+	v *= 0x94d049bb133111eb
+	v ^= v >> 31
+	return v
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pool
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestNextConsistentHash(t *testing.T) {
+
+	targets := make([]*Target, 4)
+	for i := range targets {
+		targets[i] = NewWeightedTarget("member-"+strconv.Itoa(i), testHandler(i), nil, 1)
+	}
+	p := &pool{healthyTargets: targets}
+
+	// the same key should always select the same member
+	const keys = 1000
+	selected := make([]http.Handler, keys)
+	counts := make(map[http.Handler]int)
+	for i := 0; i < keys; i++ {
+		h := nextConsistentHash(p, "key-"+strconv.Itoa(i))
+		if len(h) != 1 {
+			t.Fatalf("expected %d got %d", 1, len(h))
+		}
+		if h2 := nextConsistentHash(p, "key-"+strconv.Itoa(i)); h2[0] != h[0] {
+			t.Error("expected consistent selection")
+		}
+		selected[i] = h[0]
+		counts[h[0]]++
+	}
+	for _, tgt := range targets {
+		if counts[tgt.handler] < keys/8 {
+			t.Errorf("unbalanced distribution %v", counts)
+		}
+	}
+
+	// when a member leaves the healthy pool, only its keys are remapped
+	p.healthyTargets = targets[1:]
+	for i := 0; i < keys; i++ {
+		h := nextConsistentHash(p, "key-"+strconv.Itoa(i))
+		if selected[i] != targets[0].handler && h[0] != selected[i] {
+			t.Errorf("unexpected remapping of key-%d", i)
+		}
+	}
+
+	p = &pool{}
+	if h := nextConsistentHash(p, "key"); len(h) != 0 {
+		t.Errorf("expected %d got %d", 0, len(h))
+	}
+}
+
+func TestNextByKey(t *testing.T) {
+
+	tgt := NewWeightedTarget("member", testHandler(1), nil, 1)
+	p := &pool{healthyTargets: []*Target{tgt}, healthy: []http.Handler{tgt.handler},
+		f: nextRoundRobin}
+	if h := p.NextByKey("key"); len(h) != 1 {
+		t.Errorf("expected %d got %d", 1, len(h))
+	}
+	// an empty key falls back to the pool's selection function
+	if h := p.NextByKey(""); len(h) != 1 {
+		t.Errorf("expected %d got %d", 1, len(h))
+	}
+}
@@ -28,12 +28,18 @@ func (p *pool) checkHealth() {
 		case <-p.ch: // msg arrives whenever the healthy list must be rebuilt
 			p.mtx.Lock()
 			h := make([]http.Handler, 0, len(p.targets))
+			ht := make([]*Target, 0, len(p.targets))
 			for _, t := range p.targets {
 				if t.hcStatus.Get() >= p.healthyFloor {
 					h = append(h, t.handler)
+					ht = append(ht, t)
 				}
 			}
 			p.healthy = h
+			p.healthyTargets = ht
+			if p.mechanism == WeightedRoundRobin {
+				p.weighted = weightedSchedule(ht)
+			}
 			p.mtx.Unlock()
 		}
 	}
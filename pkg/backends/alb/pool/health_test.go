@@ -18,6 +18,7 @@ package pool
 
 import (
 	"context"
+	"net/http"
 	"testing"
 	"time"
 
@@ -60,3 +61,28 @@ func TestCheckHealth(t *testing.T) {
 // 		handler:  handler,
 // 	}
 // }
+
+func TestCheckHealthWeighted(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	tgt := NewWeightedTarget("", http.NotFoundHandler(), &healthcheck.Status{}, 2)
+	tgt2 := NewWeightedTarget("", http.NotFoundHandler(), &healthcheck.Status{}, 1)
+	tgt2.hcStatus.Set(-1)
+
+	p := &pool{mechanism: WeightedRoundRobin, ch: make(chan bool), ctx: ctx,
+		targets: []*Target{tgt, tgt2}, healthyFloor: 0}
+	go p.checkHealth()
+	p.ch <- true
+	time.Sleep(150 * time.Millisecond)
+	cancel()
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+	if len(p.healthyTargets) != 1 {
+		t.Errorf("expected %d got %d", 1, len(p.healthyTargets))
+	}
+	// the schedule for a single target is reduced to a single entry
+	if len(p.weighted) != 1 {
+		t.Errorf("expected %d got %d", 1, len(p.weighted))
+	}
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pool
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNextLeastOutstanding(t *testing.T) {
+
+	block := make(chan bool)
+	started := make(chan bool)
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- true
+		<-block
+	})
+
+	var hits int
+	fast := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	})
+
+	a := NewTarget(slow, nil)
+	b := NewTarget(fast, nil)
+	p := &pool{healthyTargets: []*Target{a, b}}
+
+	// occupy target a with an outstanding request
+	p.pos = 1
+	h := nextLeastOutstanding(p)
+	if len(h) != 1 {
+		t.Fatalf("expected %d got %d", 1, len(h))
+	}
+	go h[0].ServeHTTP(httptest.NewRecorder(), nil)
+	<-started
+	if a.Outstanding() != 1 {
+		t.Errorf("expected %d got %d", 1, a.Outstanding())
+	}
+
+	// every subsequent selection should avoid a until its request completes
+	for i := 0; i < 4; i++ {
+		h = nextLeastOutstanding(p)
+		if len(h) != 1 {
+			t.Fatalf("expected %d got %d", 1, len(h))
+		}
+		h[0].ServeHTTP(httptest.NewRecorder(), nil)
+	}
+	if hits != 4 {
+		t.Errorf("expected %d got %d", 4, hits)
+	}
+	if b.Outstanding() != 0 {
+		t.Errorf("expected %d got %d", 0, b.Outstanding())
+	}
+	close(block)
+
+	p = &pool{}
+	if h = nextLeastOutstanding(p); len(h) != 0 {
+		t.Errorf("expected %d got %d", 0, len(h))
+	}
+}
+
+func TestInheritOutstanding(t *testing.T) {
+
+	block := make(chan bool)
+	started := make(chan bool)
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- true
+		<-block
+	})
+
+	prev := []*Target{NewWeightedTarget("a", slow, nil, 1), NewWeightedTarget("b", slow, nil, 1)}
+	done := make(chan bool)
+	go func() {
+		prev[0].tracker.ServeHTTP(httptest.NewRecorder(), nil)
+		done <- true
+	}()
+	<-started
+
+	// the replacement pool drops b and adds c
+	targets := []*Target{NewWeightedTarget("a", slow, nil, 1), NewWeightedTarget("c", slow, nil, 1)}
+	InheritOutstanding(targets, prev)
+	if targets[0].Outstanding() != 1 {
+		t.Errorf("expected %d got %d", 1, targets[0].Outstanding())
+	}
+	if targets[1].Outstanding() != 0 {
+		t.Errorf("expected %d got %d", 0, targets[1].Outstanding())
+	}
+
+	// the request in flight on the previous pool is no longer counted once it completes
+	close(block)
+	<-done
+	if targets[0].Outstanding() != 0 {
+		t.Errorf("expected %d got %d", 0, targets[0].Outstanding())
+	}
+
+	InheritOutstanding(targets, nil)
+}
@@ -32,6 +32,12 @@ const (
 	NewestLastModified
 	// TimeSeriesMerge defines the Time Series Merge load balancing mechanism
 	TimeSeriesMerge
+	// WeightedRoundRobin defines the Weighted Round Robin load balancing mechanism
+	WeightedRoundRobin
+	// LeastOutstandingRequests defines the Least Outstanding Requests load balancing mechanism
+	LeastOutstandingRequests
+	// ConsistentHash defines the Consistent Hash load balancing mechanism
+	ConsistentHash
 )
 
 // MechanismLookup provides for looking up Mechanisms by name
@@ -41,6 +47,9 @@ var MechanismLookup = map[string]Mechanism{
 	"fgr": FirstGoodResponse,
 	"nlm": NewestLastModified,
 	"tsm": TimeSeriesMerge,
+	"wrr": WeightedRoundRobin,
+	"lor": LeastOutstandingRequests,
+	"ch":  ConsistentHash,
 }
 
 // MechanismValues provides for looking up Mechanism by names
@@ -21,6 +21,7 @@ import (
 	"context"
 	"net/http"
 	"sync"
+	"sync/atomic"
 
 	"github.com/trickstercache/trickster/pkg/backends/healthcheck"
 )
@@ -30,12 +31,24 @@ type Pool interface {
 	Next() []http.Handler
 }
 
+// HashPool defines the interface for a load balancer pool that can select
+// pool members by consistently hashing a key
+type HashPool interface {
+	Pool
+	NextByKey(key string) []http.Handler
+}
+
 type selectionFunc func(*pool) []http.Handler
 
 // Target defines an alb pool target
 type Target struct {
-	hcStatus *healthcheck.Status
-	handler  http.Handler
+	name        string
+	hash        uint64
+	hcStatus    *healthcheck.Status
+	handler     http.Handler
+	weight      int
+	outstanding *int64
+	tracker     http.Handler // wraps handler to track the number of outstanding requests
 }
 
 // New returns a new pool
@@ -64,34 +77,97 @@ func New(mechanism Mechanism, targets []*Target, healthyFloor int) Pool {
 
 // NewTarget returns a new Target using the provided inputs
 func NewTarget(handler http.Handler, hcStatus *healthcheck.Status) *Target {
-	return &Target{
-		hcStatus: hcStatus,
-		handler:  handler,
+	return NewWeightedTarget("", handler, hcStatus, 1)
+}
+
+// NewWeightedTarget returns a new Target with the provided name and weight. The name
+// identifies the Target to the Consistent Hash mechanism, and the weight is used by
+// the Weighted Round Robin mechanism. Weights less than 1 are treated as 1
+func NewWeightedTarget(name string, handler http.Handler, hcStatus *healthcheck.Status,
+	weight int) *Target {
+	if weight < 1 {
+		weight = 1
+	}
+	t := &Target{
+		name:        name,
+		hash:        hashString(name),
+		hcStatus:    hcStatus,
+		handler:     handler,
+		weight:      weight,
+		outstanding: new(int64),
+	}
+	t.tracker = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		o := t.outstanding
+		atomic.AddInt64(o, 1)
+		defer atomic.AddInt64(o, -1)
+		t.handler.ServeHTTP(w, r)
+	})
+	return t
+}
+
+// Outstanding returns the number of requests routed to the Target by the
+// Least Outstanding Requests mechanism that have not yet completed
+func (t *Target) Outstanding() int64 {
+	return atomic.LoadInt64(t.outstanding)
+}
+
+// InheritOutstanding shares the outstanding request counters of the previous Targets
+// with the provided Targets of the same name, so that requests still in flight on a
+// pool being replaced (e.g., on a config reload) are counted by its replacement.
+// It must be called before the provided Targets are added to a pool
+func InheritOutstanding(targets, previous []*Target) {
+	if len(previous) == 0 {
+		return
+	}
+	counters := make(map[string]*int64, len(previous))
+	for _, t := range previous {
+		if t != nil && t.name != "" {
+			counters[t.name] = t.outstanding
+		}
+	}
+	for _, t := range targets {
+		if o, ok := counters[t.name]; ok && t.name != "" {
+			t.outstanding = o
+		}
 	}
 }
 
 type pool struct {
-	mechanism    Mechanism
-	f            selectionFunc
-	targets      []*Target
-	healthy      []http.Handler
-	healthyFloor int
-	pos          uint64
-	mtx          sync.RWMutex
-	ctx          context.Context
-	ch           chan bool
+	mechanism      Mechanism
+	f              selectionFunc
+	targets        []*Target
+	healthy        []http.Handler
+	healthyTargets []*Target
+	weighted       []http.Handler // the healthy pool, scheduled by weight for wrr
+	healthyFloor   int
+	pos            uint64
+	mtx            sync.RWMutex
+	ctx            context.Context
+	ch             chan bool
 }
 
 func (p *pool) Next() []http.Handler {
 	return p.f(p)
 }
 
+// NextByKey returns the healthy pool member selected by the Consistent Hash mechanism
+// for the provided key. When the key is empty, Next is used instead
+func (p *pool) NextByKey(key string) []http.Handler {
+	if key == "" {
+		return p.Next()
+	}
+	return nextConsistentHash(p, key)
+}
+
 func mechsToFuncs() map[Mechanism]selectionFunc {
 	return map[Mechanism]selectionFunc{
-		RoundRobin:         nextRoundRobin,
-		FirstResponse:      nextFanout,
-		FirstGoodResponse:  nextFanout,
-		NewestLastModified: nextFanout,
-		TimeSeriesMerge:    nextFanout,
+		RoundRobin:               nextRoundRobin,
+		FirstResponse:            nextFanout,
+		FirstGoodResponse:        nextFanout,
+		NewestLastModified:       nextFanout,
+		TimeSeriesMerge:          nextFanout,
+		WeightedRoundRobin:       nextWeightedRoundRobin,
+		LeastOutstandingRequests: nextLeastOutstanding,
+		ConsistentHash:           nextRoundRobin,
 	}
 }
@@ -26,8 +26,8 @@ import (
 func TestMechsToFuncs(t *testing.T) {
 
 	m := mechsToFuncs()
-	if len(m) != 5 {
-		t.Errorf("expected %d got %d", 5, len(m))
+	if len(m) != 8 {
+		t.Errorf("expected %d got %d", 8, len(m))
 	}
 
 	if _, ok := m[RoundRobin]; !ok {
@@ -56,6 +56,13 @@ func TestNewTarget(t *testing.T) {
 	if tgt.hcStatus != s {
 		t.Error("unexpected mismatch")
 	}
+	if tgt.weight != 1 {
+		t.Errorf("expected %d got %d", 1, tgt.weight)
+	}
+	tgt = NewWeightedTarget("", http.NotFoundHandler(), s, -1)
+	if tgt.weight != 1 {
+		t.Errorf("expected %d got %d", 1, tgt.weight)
+	}
 }
 
 func TestNewPool(t *testing.T) {
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pool
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+func nextWeightedRoundRobin(p *pool) []http.Handler {
+	p.mtx.RLock()
+	t := p.weighted
+	p.mtx.RUnlock()
+	if len(t) == 0 {
+		return nil
+	}
+	i := atomic.AddUint64(&p.pos, 1) % uint64(len(t))
+	return []http.Handler{t[i]}
+}
+
+// weightedSchedule returns a rotation of the provided targets' handlers, in which each
+// target appears in proportion to its weight. The rotation is interleaved using the smooth
+// weighted round robin method, so that consecutive requests are spread across the targets
+func weightedSchedule(targets []*Target) []http.Handler {
+	if len(targets) == 0 {
+		return nil
+	}
+	// reduce the weights by their greatest common divisor to keep the rotation short
+	var d int
+	for _, t := range targets {
+		d = gcd(d, t.weight)
+	}
+	weights := make([]int, len(targets))
+	var total int
+	for i, t := range targets {
+		weights[i] = t.weight / d
+		total += weights[i]
+	}
+	current := make([]int, len(targets))
+	out := make([]http.Handler, 0, total)
+	for len(out) < total {
+		j := 0
+		for i := range targets {
+			current[i] += weights[i]
+			if current[i] > current[j] {
+				j = i
+			}
+		}
+		current[j] -= total
+		out = append(out, targets[j].handler)
+	}
+	return out
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pool
+
+import (
+	"net/http"
+	"testing"
+)
+
+type testHandler int
+
+func (h testHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {}
+
+func TestNextWeightedRoundRobin(t *testing.T) {
+
+	a := NewWeightedTarget("", testHandler(1), nil, 3)
+	b := NewWeightedTarget("", testHandler(2), nil, 1)
+	p := &pool{weighted: weightedSchedule([]*Target{a, b})}
+
+	counts := make(map[http.Handler]int)
+	for i := 0; i < 8; i++ {
+		h := nextWeightedRoundRobin(p)
+		if len(h) != 1 {
+			t.Fatalf("expected %d got %d", 1, len(h))
+		}
+		counts[h[0]]++
+	}
+	if counts[a.handler] != 6 || counts[b.handler] != 2 {
+		t.Errorf("unexpected distribution %v", counts)
+	}
+
+	p = &pool{}
+	if h := nextWeightedRoundRobin(p); len(h) != 0 {
+		t.Errorf("expected %d got %d", 0, len(h))
+	}
+}
+
+func TestWeightedSchedule(t *testing.T) {
+
+	if s := weightedSchedule(nil); s != nil {
+		t.Error("expected nil schedule")
+	}
+
+	a := NewWeightedTarget("", testHandler(1), nil, 4)
+	b := NewWeightedTarget("", testHandler(2), nil, 2)
+	// weights are reduced by their gcd and interleaved
+	s := weightedSchedule([]*Target{a, b})
+	expected := []http.Handler{a.handler, b.handler, a.handler}
+	if len(s) != len(expected) {
+		t.Fatalf("expected %d got %d", len(expected), len(s))
+	}
+	for i := range s {
+		if s[i] != expected[i] {
+			t.Errorf("unexpected handler at index %d", i)
+		}
+	}
+}
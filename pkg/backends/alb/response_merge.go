@@ -25,6 +25,7 @@ import (
 	tctx "github.com/trickstercache/trickster/pkg/proxy/context"
 	"github.com/trickstercache/trickster/pkg/proxy/handlers"
 	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/proxy/methods"
 	"github.com/trickstercache/trickster/pkg/proxy/request"
 	"github.com/trickstercache/trickster/pkg/proxy/response/merge"
 )
@@ -59,13 +60,19 @@ func (c *Client) handleResponseMerge(w http.ResponseWriter, r *http.Request) {
 	mgs := GetResponseGates(w, r, hl)
 	SetStatusHeader(w, mgs)
 
-	rsc := request.GetResources(mgs[0].Request)
-	if rsc != nil && rsc.ResponseMergeFunc != nil {
-		if f, ok := rsc.ResponseMergeFunc.(func(http.ResponseWriter,
+	// the merge function is provided by the first member whose handler supports merging;
+	// responses from handlers that do not (e.g., a proxied non-select query) aren't merged
+	for _, mg := range mgs {
+		if mg == nil || mg.Resources == nil || mg.Resources.ResponseMergeFunc == nil {
+			continue
+		}
+		if f, ok := mg.Resources.ResponseMergeFunc.(func(http.ResponseWriter,
 			*http.Request, merge.ResponseGates)); ok {
 			f(w, r, mgs)
+			return
 		}
 	}
+	merge.BestResponse(w, r, mgs)
 }
 
 // GetResponseGates make the client request to each fanout backend and returns a collection of responses
@@ -74,9 +81,15 @@ func GetResponseGates(w http.ResponseWriter, r *http.Request, hl []http.Handler)
 	var mtx sync.Mutex
 	l := len(hl)
 	mgs := make(merge.ResponseGates, l)
+	// each fanout request requires its own copy of the body
+	var body []byte
+	if methods.HasBody(r.Method) {
+		body = request.GetBody(r)
+	}
 	wg.Add(l)
 	for i := 0; i < l; i++ {
 		go func(j int) {
+			defer wg.Done()
 			if hl[j] == nil {
 				return
 			}
@@ -85,9 +98,11 @@ func GetResponseGates(w http.ResponseWriter, r *http.Request, hl []http.Handler)
 			mtx.Lock()
 			r2 := r.Clone(ctx)
 			mtx.Unlock()
+			if body != nil {
+				r2 = request.SetBody(r2, body)
+			}
 			mgs[j] = merge.NewResponseGate(w, r2, rsc)
 			hl[j].ServeHTTP(mgs[j], r2)
-			wg.Done()
 		}(i)
 	}
 	wg.Wait()
@@ -17,8 +17,10 @@
 package alb
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -84,3 +86,34 @@ func TestHandleResponseMerge(t *testing.T) {
 	}
 
 }
+
+func TestGetResponseGates(t *testing.T) {
+
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write(b)
+	})
+
+	r, _ := http.NewRequest(http.MethodPost, "http://trickstercache.io/",
+		strings.NewReader("body"))
+	w := httptest.NewRecorder()
+	mgs := GetResponseGates(w, r, []http.Handler{echo, nil, echo})
+	if len(mgs) != 3 || mgs[1] != nil {
+		t.Fatalf("unexpected response gates %v", mgs)
+	}
+	for _, i := range []int{0, 2} {
+		if string(mgs[i].Body()) != "body" {
+			t.Errorf("expected %s got %s", "body", string(mgs[i].Body()))
+		}
+		if mgs[i].StatusCode() != http.StatusAccepted {
+			t.Errorf("expected %d got %d", http.StatusAccepted, mgs[i].StatusCode())
+		}
+	}
+
+	// responses without a merge function fall back to the best response
+	merge.BestResponse(w, r, mgs)
+	if w.Code != http.StatusAccepted || w.Body.String() != "body" {
+		t.Errorf("expected %d %s got %d %s", http.StatusAccepted, "body", w.Code, w.Body.String())
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/trickstercache/trickster/pkg/backends/healthcheck"
 	ho "github.com/trickstercache/trickster/pkg/backends/healthcheck/options"
 	bo "github.com/trickstercache/trickster/pkg/backends/options"
+	"github.com/trickstercache/trickster/pkg/backends/warmer"
 	"github.com/trickstercache/trickster/pkg/cache"
 	"github.com/trickstercache/trickster/pkg/proxy"
 	po "github.com/trickstercache/trickster/pkg/proxy/paths/options"
@@ -59,6 +60,10 @@ type Backend interface {
 	DefaultHealthCheckConfig() *ho.Options
 	// HealthCheckHTTPClient returns the HTTP Client used for Health Checking
 	HealthCheckHTTPClient() *http.Client
+	// Warmer returns the Query Warmer for the Backend, or nil if its queries are not warmed
+	Warmer() *warmer.Warmer
+	// SetWarmer sets the Query Warmer for the Backend
+	SetWarmer(*warmer.Warmer)
 }
 
 type backend struct {
@@ -70,6 +75,7 @@ type backend struct {
 	handlers           map[string]http.Handler
 	handlersRegistered bool
 	healthProbe        healthcheck.DemandProbe
+	warmer             *warmer.Warmer
 	router             http.Handler
 	baseUpstreamURL    *url.URL
 	registrar          func(map[string]http.Handler)
@@ -190,3 +196,13 @@ func (b *backend) DefaultHealthCheckConfig() *ho.Options {
 func (b *backend) HealthCheckHTTPClient() *http.Client {
 	return b.healthCheckClient
 }
+
+// Warmer returns the Query Warmer for the Backend, or nil if its queries are not warmed
+func (b *backend) Warmer() *warmer.Warmer {
+	return b.warmer
+}
+
+// SetWarmer sets the Query Warmer for the Backend
+func (b *backend) SetWarmer(w *warmer.Warmer) {
+	b.warmer = w
+}
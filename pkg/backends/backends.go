@@ -22,6 +22,7 @@ import (
 
 	"github.com/trickstercache/trickster/pkg/backends/healthcheck"
 	bo "github.com/trickstercache/trickster/pkg/backends/options"
+	"github.com/trickstercache/trickster/pkg/backends/warmer"
 )
 
 // Backends represents a map of Backends keyed by Name
@@ -55,6 +56,27 @@ func (b Backends) StartHealthChecks(logger interface{}) (healthcheck.HealthCheck
 	return hc, nil
 }
 
+// StartWarmers iterates the backends to start a Query Warmer for each
+// Timeseries Backend that is configured for query warming
+func (b Backends) StartWarmers(logger interface{}) warmer.Lookup {
+	wl := make(warmer.Lookup)
+	for k, c := range b {
+		bo := c.Configuration()
+		if bo == nil || bo.Warmer == nil || !UsesCache(bo.Provider) || k == "frontend" {
+			continue
+		}
+		tsc, ok := c.(TimeseriesBackend)
+		if !ok {
+			continue
+		}
+		w := warmer.New(k, bo.Provider, bo.Warmer, tsc, logger)
+		c.SetWarmer(w)
+		w.Start()
+		wl[k] = w
+	}
+	return wl
+}
+
 // Get returns the named origin
 func (b Backends) Get(backendName string) Backend {
 	if c, ok := b[backendName]; ok {
@@ -22,6 +22,7 @@ import (
 	"github.com/gorilla/mux"
 	ho "github.com/trickstercache/trickster/pkg/backends/healthcheck/options"
 	bo "github.com/trickstercache/trickster/pkg/backends/options"
+	wo "github.com/trickstercache/trickster/pkg/backends/warmer/options"
 )
 
 func TestBackends(t *testing.T) {
@@ -132,3 +133,34 @@ func TestUsesCache(t *testing.T) {
 		t.Error("expected false")
 	}
 }
+
+func TestStartWarmers(t *testing.T) {
+
+	o1 := bo.New()
+	o1.Provider = "prometheus"
+	o1.Warmer = wo.New()
+	c1, _ := NewTimeseriesBackend("test1", o1, nil, mux.NewRouter(), nil, nil)
+
+	o2 := bo.New()
+	o2.Provider = "prometheus"
+	c2, _ := NewTimeseriesBackend("test2", o2, nil, mux.NewRouter(), nil, nil)
+
+	o3 := bo.New()
+	o3.Provider = "reverseproxy"
+	o3.Warmer = wo.New()
+	c3, _ := New("test3", o3, nil, mux.NewRouter(), nil)
+
+	b := Backends{"test1": c1, "test2": c2, "test3": c3}
+	wl := b.StartWarmers(nil)
+	defer wl.Stop()
+
+	if len(wl) != 1 {
+		t.Errorf("expected %d got %d", 1, len(wl))
+	}
+	if c1.Warmer() == nil || c1.Warmer() != wl["test1"] {
+		t.Error("expected warmer to be set for test1")
+	}
+	if c2.Warmer() != nil || c3.Warmer() != nil {
+		t.Error("expected nil warmers")
+	}
+}
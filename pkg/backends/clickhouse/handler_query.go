@@ -25,6 +25,7 @@ import (
 	"github.com/trickstercache/trickster/pkg/proxy/handlers"
 	"github.com/trickstercache/trickster/pkg/proxy/methods"
 	"github.com/trickstercache/trickster/pkg/proxy/request"
+	"github.com/trickstercache/trickster/pkg/proxy/response/merge"
 	"github.com/trickstercache/trickster/pkg/proxy/urls"
 )
 
@@ -56,6 +57,10 @@ func (c *Client) QueryHandler(w http.ResponseWriter, r *http.Request) {
 		c.ProxyHandler(w, r)
 		return
 	}
+	// if this request is part of a scatter/gather, provide a reconstitution function
+	if rsc := request.GetResources(r); rsc != nil && rsc.IsMergeMember {
+		rsc.ResponseMergeFunc = merge.Timeseries
+	}
 	r.URL = urls.BuildUpstreamURL(r, c.BaseUpstreamURL())
 	engines.DeltaProxyCacheRequest(w, r, c.Modeler())
 }
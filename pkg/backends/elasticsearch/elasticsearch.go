@@ -0,0 +1,81 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package elasticsearch provides the Elasticsearch and OpenSearch backend provider
+package elasticsearch
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/backends"
+	modeles "github.com/trickstercache/trickster/pkg/backends/elasticsearch/model"
+	bo "github.com/trickstercache/trickster/pkg/backends/options"
+	"github.com/trickstercache/trickster/pkg/backends/providers/registration/types"
+	"github.com/trickstercache/trickster/pkg/cache"
+	"github.com/trickstercache/trickster/pkg/proxy/errors"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+	"github.com/trickstercache/trickster/pkg/proxy/urls"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+)
+
+var _ backends.TimeseriesBackend = (*Client)(nil)
+
+// Client Implements the Proxy Client Interface
+type Client struct {
+	backends.TimeseriesBackend
+}
+
+var _ types.NewBackendClientFunc = NewClient
+
+// NewClient returns a new Client Instance
+func NewClient(name string, o *bo.Options, router http.Handler,
+	cache cache.Cache, _ backends.Backends,
+	_ types.Lookup) (backends.Backend, error) {
+	if o != nil {
+		o.FastForwardDisable = true
+	}
+	c := &Client{}
+	b, err := backends.NewTimeseriesBackend(name, o, c.RegisterHandlers, router, cache, modeles.NewModeler())
+	c.TimeseriesBackend = b
+	return c, err
+}
+
+// ParseTimeRangeQuery parses the key parts of a TimeRangeQuery from the inbound HTTP Request.
+// Searches that cannot be accelerated are proxied, so the returned canOPC is always false.
+func (c *Client) ParseTimeRangeQuery(r *http.Request) (*timeseries.TimeRangeQuery,
+	*timeseries.RequestOptions, bool, error) {
+
+	if r.Method != http.MethodPost || !(isSearch(r.URL.Path) || isMultiSearch(r.URL.Path)) {
+		return nil, nil, false, errors.ErrNotTimeRangeQuery
+	}
+	qi := r.URL.Query()
+	// typed_keys prefixes the aggregation names in the response with their types
+	if _, ok := qi[upTypedKeys]; ok {
+		return nil, nil, false, errors.ErrNotTimeRangeQuery
+	}
+
+	trq, rlo, err := parse(r.URL.Path, request.GetBody(r), time.Now())
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	// the tokenized searches are used in the cache key
+	trq.TemplateURL = urls.Clone(r.URL)
+	qi.Set(upBody, trq.Statement)
+	trq.TemplateURL.RawQuery = qi.Encode()
+
+	return trq, rlo, false, nil
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package elasticsearch
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/trickstercache/trickster/pkg/cache/evictionmethods"
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+	tu "github.com/trickstercache/trickster/pkg/util/testing"
+)
+
+const testQuerySearch = `{"size":0,"query":{"range":{"@timestamp":` +
+	`{"gte":1609459200000,"lte":1609459260000,"format":"epoch_millis"}}},` +
+	`"aggs":{"2":{"date_histogram":{"fixed_interval":"1m","field":"@timestamp"}}}}`
+
+const testSearchResponse = `{"took":1,"timed_out":false,"hits":{"total":{"value":3,"relation":"eq"},"hits":[]},` +
+	`"aggregations":{"2":{"buckets":[{"key_as_string":"2021-01-01T00:00:00.000Z","key":1609459200000,"doc_count":1},` +
+	`{"key_as_string":"2021-01-01T00:01:00.000Z","key":1609459260000,"doc_count":2}]}}}`
+
+func TestQueryHandler(t *testing.T) {
+
+	backendClient, err := NewClient("test", nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	ts, w, r, _, err := tu.NewTestInstance("", backendClient.DefaultPathConfigs, 200,
+		testSearchResponse, nil, "elasticsearch", "/logs/"+epSearch, "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	rsc := request.GetResources(r)
+	rsc.BackendOptions.TimeseriesEvictionMethod = evictionmethods.EvictionMethodLRU
+	backendClient, err = NewClient("test", rsc.BackendOptions, nil, nil, nil, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	client := backendClient.(*Client)
+	rsc.BackendClient = client
+	rsc.BackendOptions.HTTPClient = backendClient.HTTPClient()
+
+	r2, _ := http.NewRequest(http.MethodPost, r.URL.String(), bytes.NewReader([]byte(testQuerySearch)))
+	r2 = r2.WithContext(r.Context())
+	r2.Header.Set(headers.NameContentType, headers.ValueApplicationJSON)
+
+	client.QueryHandler(w, r2)
+	resp := w.Result()
+	if resp.StatusCode != 200 {
+		t.Errorf("expected 200 got %d.", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	const expected = `"aggregations":{"2":{"buckets":[` +
+		`{"doc_count":1,"key":1609459200000,"key_as_string":"2021-01-01T00:00:00.000Z"},` +
+		`{"doc_count":2,"key":1609459260000,"key_as_string":"2021-01-01T00:01:00.000Z"}]}}`
+	if !strings.Contains(string(body), expected) {
+		t.Errorf("expected %s in\n%s", expected, string(body))
+	}
+	if !strings.Contains(string(body), `"total":{"value":3,"relation":"eq"}`) {
+		t.Errorf("expected hits total of 3 in\n%s", string(body))
+	}
+}
+
+func TestQueryHandlerProxy(t *testing.T) {
+
+	backendClient, err := NewClient("test", nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	ts, w, r, _, err := tu.NewTestInstance("", backendClient.DefaultPathConfigs, 200,
+		"test", nil, "elasticsearch", "/_cat/indices", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	rsc := request.GetResources(r)
+	backendClient, err = NewClient("test", rsc.BackendOptions, nil, nil, nil, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	client := backendClient.(*Client)
+	rsc.BackendClient = client
+	rsc.BackendOptions.HTTPClient = backendClient.HTTPClient()
+
+	client.QueryHandler(w, r)
+	resp := w.Result()
+	if resp.StatusCode != 200 {
+		t.Errorf("expected 200 got %d.", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "test" {
+		t.Errorf("expected 'test' got %s.", body)
+	}
+}
@@ -0,0 +1,238 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+)
+
+type wfShards struct {
+	Total      int `json:"total"`
+	Successful int `json:"successful"`
+	Skipped    int `json:"skipped"`
+	Failed     int `json:"failed"`
+}
+
+type wfTotal struct {
+	Value    int64  `json:"value"`
+	Relation string `json:"relation"`
+}
+
+type wfHits struct {
+	Total    wfTotal       `json:"total"`
+	MaxScore *float64      `json:"max_score"`
+	Hits     []interface{} `json:"hits"`
+}
+
+type wfSearchResponse struct {
+	Took         int64                  `json:"took"`
+	TimedOut     bool                   `json:"timed_out"`
+	Shards       wfShards               `json:"_shards"`
+	Hits         wfHits                 `json:"hits"`
+	Aggregations map[string]interface{} `json:"aggregations"`
+	Status       int                    `json:"status,omitempty"`
+}
+
+type wfMultiSearchResponse struct {
+	Took      int64               `json:"took"`
+	Responses []*wfSearchResponse `json:"responses"`
+}
+
+type wfTermsAggregation struct {
+	DocCountErrorUpperBound int64                    `json:"doc_count_error_upper_bound"`
+	SumOtherDocCount        int64                    `json:"sum_other_doc_count"`
+	Buckets                 []map[string]interface{} `json:"buckets"`
+}
+
+type wfHistogramAggregation struct {
+	Buckets []map[string]interface{} `json:"buckets"`
+}
+
+// MarshalTimeseries converts a Timeseries into a JSON blob
+func MarshalTimeseries(ts timeseries.Timeseries, rlo *timeseries.RequestOptions, status int) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := MarshalTimeseriesWriter(ts, rlo, status, buf)
+	return buf.Bytes(), err
+}
+
+// MarshalTimeseriesWriter converts a Timeseries into a JSON blob via an io.Writer. Since
+// the DataSet only retains the date_histogram buckets, the enclosing aggregations
+// are rebuilt from the TimeRangeQuery's statement.
+func MarshalTimeseriesWriter(ts timeseries.Timeseries, rlo *timeseries.RequestOptions,
+	status int, w io.Writer) error {
+	if ts == nil {
+		return timeseries.ErrUnknownFormat
+	}
+	ds, ok := ts.(*dataset.DataSet)
+	if !ok {
+		return timeseries.ErrUnknownFormat
+	}
+	if ds.TimeRangeQuery == nil {
+		return timeseries.ErrNoTimerangeQuery
+	}
+	paths, err := ParseStatement(ds.TimeRangeQuery.Statement)
+	if err != nil {
+		return err
+	}
+
+	responses := make([]*wfSearchResponse, len(paths))
+	for i, path := range paths {
+		var sl dataset.SeriesList
+		for _, r := range ds.Results {
+			if r != nil && r.StatementID == i {
+				sl = append(sl, r.SeriesList...)
+			}
+		}
+		agg, docCount := marshalAggregation(sl, path, dataset.Tags{})
+		responses[i] = &wfSearchResponse{
+			Shards: wfShards{Total: 1, Successful: 1},
+			Hits: wfHits{
+				Total: wfTotal{Value: docCount, Relation: "eq"},
+				Hits:  []interface{}{},
+			},
+			Aggregations: map[string]interface{}{path[0]: agg},
+		}
+	}
+
+	var doc interface{} = responses[0]
+	if rlo != nil && rlo.OutputFormat == OutputFormatMultiSearch {
+		for _, r := range responses {
+			r.Status = http.StatusOK
+		}
+		doc = &wfMultiSearchResponse{Responses: responses}
+	}
+
+	if rw, ok := w.(http.ResponseWriter); ok {
+		rw.Header().Set(headers.NameContentType, headers.ValueApplicationJSON+"; charset=UTF-8")
+		rw.WriteHeader(status)
+	}
+	return json.NewEncoder(w).Encode(doc)
+}
+
+// marshalAggregation returns the wire format of the aggregation at the head of path,
+// for the series whose tags match the provided tags, along with its total doc_count
+func marshalAggregation(sl dataset.SeriesList, path []string,
+	tags dataset.Tags) (interface{}, int64) {
+	if len(path) == 1 {
+		for _, s := range sl {
+			if s != nil && s.Header.Name == path[0] && tagsMatch(s.Header.Tags, tags) {
+				return marshalHistogram(s)
+			}
+		}
+		return &wfHistogramAggregation{Buckets: []map[string]interface{}{}}, 0
+	}
+	// terms buckets are listed in the order in which their keys are first seen
+	keys := make([]string, 0, len(sl))
+	seen := make(map[string]struct{})
+	for _, s := range sl {
+		if s == nil || !tagsMatch(s.Header.Tags, tags) {
+			continue
+		}
+		k, ok := s.Header.Tags[path[0]]
+		if !ok {
+			continue
+		}
+		if _, ok := seen[k]; !ok {
+			seen[k] = struct{}{}
+			keys = append(keys, k)
+		}
+	}
+	agg := &wfTermsAggregation{Buckets: make([]map[string]interface{}, 0, len(keys))}
+	var total int64
+	for _, k := range keys {
+		t := tags.Clone()
+		t[path[0]] = k
+		sub, docCount := marshalAggregation(sl, path[1:], t)
+		agg.Buckets = append(agg.Buckets, map[string]interface{}{
+			FieldKey:      json.RawMessage(k),
+			FieldDocCount: docCount,
+			path[1]:       sub,
+		})
+		total += docCount
+	}
+	return agg, total
+}
+
+func marshalHistogram(s *dataset.Series) (interface{}, int64) {
+	agg := &wfHistogramAggregation{Buckets: make([]map[string]interface{}, 0, len(s.Points))}
+	var total int64
+	for _, p := range s.Points {
+		b := map[string]interface{}{FieldKey: int64(p.Epoch) / 1000000}
+		for i, fd := range s.Header.FieldsList {
+			if i >= len(p.Values) {
+				break
+			}
+			v := p.Values[i]
+			switch {
+			case fd.Name == FieldDocCount:
+				n := toInt64(v)
+				b[FieldDocCount] = n
+				total += n
+			case fd.Name == FieldKeyAsString:
+				if v != nil {
+					b[FieldKeyAsString] = v
+				}
+			case fd.SDataType == SDataTypeValue:
+				b[fd.Name] = map[string]interface{}{"value": v}
+			default:
+				switch t := v.(type) {
+				case string:
+					b[fd.Name] = json.RawMessage(t)
+				case []byte:
+					b[fd.Name] = json.RawMessage(t)
+				}
+			}
+		}
+		if _, ok := b[FieldDocCount]; !ok {
+			b[FieldDocCount] = 0
+		}
+		agg.Buckets = append(agg.Buckets, b)
+	}
+	return agg, total
+}
+
+func tagsMatch(t, filter dataset.Tags) bool {
+	for k, v := range filter {
+		if t[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func toInt64(v interface{}) int64 {
+	switch t := v.(type) {
+	case int64:
+		return t
+	case int:
+		return int64(t)
+	case int32:
+		return int64(t)
+	case uint64:
+		return int64(t)
+	case float64:
+		return int64(t)
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package model provides the Elasticsearch wire format and its conversion
+// to and from the Trickster DataSet
+package model
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+)
+
+// Output Formats
+const (
+	// OutputFormatSearch indicates the response is to a single _search request
+	OutputFormatSearch byte = iota
+	// OutputFormatMultiSearch indicates the response is to an _msearch request
+	OutputFormatMultiSearch
+)
+
+// Field Source Data Types, used to rebuild each bucket's metric aggregations
+const (
+	// SDataTypeValue is a single-value metric aggregation (e.g., avg), modeled as a float64
+	SDataTypeValue = "value"
+	// SDataTypeJSON is any other aggregation, modeled as its raw JSON
+	SDataTypeJSON = "json"
+)
+
+// Bucket field names
+const (
+	FieldKey         = "key"
+	FieldKeyAsString = "key_as_string"
+	FieldDocCount    = "doc_count"
+)
+
+// ErrUnsupportedAggregation is returned when a search's aggregations are not a chain
+// of terms aggregations ending in a single date_histogram aggregation
+var ErrUnsupportedAggregation = errors.New("unsupported aggregation structure")
+
+// NewModeler returns a collection of modeling functions for elasticsearch interoperability
+func NewModeler() *timeseries.Modeler {
+	return &timeseries.Modeler{
+		WireUnmarshalerReader: UnmarshalTimeseriesReader,
+		WireMarshaler:         MarshalTimeseries,
+		WireMarshalWriter:     MarshalTimeseriesWriter,
+		WireUnmarshaler:       UnmarshalTimeseries,
+		CacheMarshaler:        dataset.MarshalDataSet,
+		CacheUnmarshaler:      dataset.UnmarshalDataSet,
+	}
+}
+
+// Aggregations returns the aggregations of a search body or bucket aggregation
+func Aggregations(m map[string]interface{}) map[string]interface{} {
+	if a, ok := m["aggs"].(map[string]interface{}); ok {
+		return a
+	}
+	if a, ok := m["aggregations"].(map[string]interface{}); ok {
+		return a
+	}
+	return nil
+}
+
+// AggregationPath returns the ordered names of the bucket aggregations in the search body,
+// which are zero or more nested terms aggregations, ending with a date_histogram. The
+// date_histogram's options are also returned so they may be inspected or modified.
+func AggregationPath(body map[string]interface{}) ([]string, map[string]interface{}, error) {
+	path := make([]string, 0, 4)
+	a := Aggregations(body)
+	for a != nil {
+		var name string
+		var next, hist map[string]interface{}
+		for k, v := range a {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			h, isHist := m["date_histogram"].(map[string]interface{})
+			_, isTerms := m["terms"]
+			if !isHist && !isTerms {
+				// metric aggregations alongside the bucket path are permitted
+				continue
+			}
+			if name != "" {
+				return nil, nil, ErrUnsupportedAggregation
+			}
+			name, next, hist = k, m, h
+		}
+		if name == "" {
+			break
+		}
+		path = append(path, name)
+		if hist != nil {
+			return path, hist, nil
+		}
+		a = Aggregations(next)
+	}
+	return nil, nil, ErrUnsupportedAggregation
+}
+
+// ParseStatement returns the aggregation path for each search in the statement, which is
+// newline-delimited pairs of search headers and bodies, as with an _msearch request
+func ParseStatement(statement string) ([][]string, error) {
+	paths := make([][]string, 0, 1)
+	sc := bufio.NewScanner(strings.NewReader(statement))
+	sc.Buffer(make([]byte, 0, 64*1024), len(statement)+1)
+	var i int
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		i++
+		// odd lines are search headers
+		if i%2 == 1 {
+			continue
+		}
+		body := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(line), &body); err != nil {
+			return nil, err
+		}
+		path, _, err := AggregationPath(body)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		return nil, ErrUnsupportedAggregation
+	}
+	return paths, nil
+}
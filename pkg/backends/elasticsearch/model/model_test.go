@@ -0,0 +1,164 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+)
+
+const testStatement = "{}\n" +
+	`{"aggs":{"hosts":{"aggs":{"ts":{"aggs":{"avg_cpu":{"avg":{"field":"cpu"}}},` +
+	`"date_histogram":{"field":"@timestamp","fixed_interval":"1m"}}},"terms":{"field":"host"}}},"size":0}` +
+	"\n"
+
+const testResponse = `{"took":3,"timed_out":false,"hits":{"total":{"value":5,"relation":"eq"}},` +
+	`"aggregations":{"hosts":{"buckets":[` +
+	`{"key":"a","doc_count":3,"ts":{"buckets":[` +
+	`{"key_as_string":"2021-01-01T00:01:00Z","key":1609459260000,"doc_count":2,"avg_cpu":{"value":1.5}},` +
+	`{"key_as_string":"2021-01-01T00:00:00Z","key":1609459200000,"doc_count":1,"avg_cpu":{"value":null}}]}},` +
+	`{"key":"b","doc_count":2,"ts":{"buckets":[` +
+	`{"key_as_string":"2021-01-01T00:00:00Z","key":1609459200000,"doc_count":2,"avg_cpu":{"value":4}}]}}]}}}`
+
+func testTRQ() *timeseries.TimeRangeQuery {
+	return &timeseries.TimeRangeQuery{
+		Statement: testStatement,
+		Step:      time.Minute,
+		Extent: timeseries.Extent{Start: time.Unix(1609459200, 0),
+			End: time.Unix(1609459260, 0)},
+	}
+}
+
+func TestAggregationPath(t *testing.T) {
+
+	body := make(map[string]interface{})
+	json.Unmarshal([]byte(strings.Split(testStatement, "\n")[1]), &body)
+	path, hist, err := AggregationPath(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(path, ".") != "hosts.ts" {
+		t.Errorf("expected %s got %v", "hosts.ts", path)
+	}
+	if hist["field"] != "@timestamp" {
+		t.Errorf("expected %s got %v", "@timestamp", hist["field"])
+	}
+
+	// sibling bucket aggregations are not supported
+	body = make(map[string]interface{})
+	json.Unmarshal([]byte(`{"aggs":{"a":{"terms":{}},"b":{"date_histogram":{}}}}`), &body)
+	if _, _, err = AggregationPath(body); err != ErrUnsupportedAggregation {
+		t.Errorf("expected %v got %v", ErrUnsupportedAggregation, err)
+	}
+	if _, err = ParseStatement("{}\n"); err != ErrUnsupportedAggregation {
+		t.Errorf("expected %v got %v", ErrUnsupportedAggregation, err)
+	}
+}
+
+func TestUnmarshalTimeseries(t *testing.T) {
+
+	ts, err := UnmarshalTimeseries([]byte(testResponse), testTRQ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := ts.(*dataset.DataSet)
+	if len(ds.Results) != 1 || len(ds.Results[0].SeriesList) != 2 {
+		t.Fatalf("unexpected results %v", ds.Results)
+	}
+	s := ds.Results[0].SeriesList[0]
+	if s.Header.Name != "ts" || s.Header.Tags["hosts"] != `"a"` {
+		t.Errorf("unexpected header %s %s", s.Header.Name, s.Header.Tags.String())
+	}
+	if len(s.Header.FieldsList) != 3 || s.Header.FieldsList[2].SDataType != SDataTypeValue {
+		t.Errorf("unexpected fields %v", s.Header.FieldsList)
+	}
+	if len(s.Points) != 2 || s.Points[0].Epoch >= s.Points[1].Epoch {
+		t.Errorf("expected 2 sorted points got %v", s.Points)
+	}
+	if v, ok := s.Points[1].Values[2].(float64); !ok || v != 1.5 {
+		t.Errorf("expected %f got %v", 1.5, s.Points[1].Values[2])
+	}
+	if s.Points[0].Values[2] != nil {
+		t.Errorf("expected nil got %v", s.Points[0].Values[2])
+	}
+
+	_, err = UnmarshalTimeseries([]byte(`{"error":{"type":"parse_exception"},"status":400}`), testTRQ())
+	if err == nil || !strings.Contains(err.Error(), "parse_exception") {
+		t.Errorf("expected elasticsearch error got %v", err)
+	}
+
+	_, err = UnmarshalTimeseries([]byte(`{"responses":[{},{}]}`), testTRQ())
+	if err != timeseries.ErrInvalidBody {
+		t.Errorf("expected %v got %v", timeseries.ErrInvalidBody, err)
+	}
+}
+
+func TestMarshalTimeseries(t *testing.T) {
+
+	ts, err := UnmarshalTimeseries([]byte(testResponse), testTRQ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := MarshalTimeseries(ts, &timeseries.RequestOptions{}, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `"aggregations":{"hosts":{"doc_count_error_upper_bound":0,"sum_other_doc_count":0,` +
+		`"buckets":[{"doc_count":3,"key":"a","ts":{"buckets":[` +
+		`{"avg_cpu":{"value":null},"doc_count":1,"key":1609459200000,"key_as_string":"2021-01-01T00:00:00Z"},` +
+		`{"avg_cpu":{"value":1.5},"doc_count":2,"key":1609459260000,"key_as_string":"2021-01-01T00:01:00Z"}]}},` +
+		`{"doc_count":2,"key":"b","ts":{"buckets":[` +
+		`{"avg_cpu":{"value":4},"doc_count":2,"key":1609459200000,"key_as_string":"2021-01-01T00:00:00Z"}]}}]}}`
+	if !strings.Contains(string(b), expected) {
+		t.Errorf("expected\n%s\nin\n%s", expected, string(b))
+	}
+	if !strings.Contains(string(b), `"hits":{"total":{"value":5,"relation":"eq"}`) {
+		t.Errorf("expected hits total of 5 in %s", string(b))
+	}
+
+	// the response survives a round trip through the cache
+	cb, err := dataset.MarshalDataSet(ts, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts2, err := dataset.UnmarshalDataSet(cb, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := MarshalTimeseries(ts2, &timeseries.RequestOptions{}, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b2) != string(b) {
+		t.Errorf("expected\n%s\ngot\n%s", string(b), string(b2))
+	}
+
+	// multi-search responses are wrapped
+	b, err = MarshalTimeseries(ts, &timeseries.RequestOptions{OutputFormat: OutputFormatMultiSearch}, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), `{"took":0,"responses":[{`) ||
+		!strings.Contains(string(b), `"status":200`) {
+		t.Errorf("unexpected multi-search response %s", string(b))
+	}
+}
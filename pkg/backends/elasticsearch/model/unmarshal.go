@@ -0,0 +1,235 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+	"github.com/trickstercache/trickster/pkg/timeseries/epoch"
+)
+
+// WFResponse is the Wire Format Document for an Elasticsearch search response. For
+// _msearch responses, Responses holds the response to each search.
+type WFResponse struct {
+	Took         int64                      `json:"took"`
+	TimedOut     bool                       `json:"timed_out"`
+	Aggregations map[string]json.RawMessage `json:"aggregations,omitempty"`
+	Error        json.RawMessage            `json:"error,omitempty"`
+	Status       int                        `json:"status,omitempty"`
+	Responses    []*WFResponse              `json:"responses,omitempty"`
+}
+
+// WFAggregation is the Wire Format of a bucket aggregation
+type WFAggregation struct {
+	Buckets []map[string]json.RawMessage `json:"buckets"`
+}
+
+// UnmarshalTimeseries converts a JSON blob into a Timeseries
+func UnmarshalTimeseries(data []byte, trq *timeseries.TimeRangeQuery) (timeseries.Timeseries, error) {
+	return UnmarshalTimeseriesReader(bytes.NewReader(data), trq)
+}
+
+// UnmarshalTimeseriesReader converts a JSON blob into a Timeseries via io.Reader. Each
+// search response becomes a Result, and each date_histogram becomes a Series tagged
+// with the keys of the terms buckets it is nested within.
+func UnmarshalTimeseriesReader(reader io.Reader, trq *timeseries.TimeRangeQuery) (timeseries.Timeseries, error) {
+	if trq == nil {
+		return nil, timeseries.ErrNoTimerangeQuery
+	}
+	paths, err := ParseStatement(trq.Statement)
+	if err != nil {
+		return nil, err
+	}
+	wfr := &WFResponse{}
+	if err = json.NewDecoder(reader).Decode(wfr); err != nil {
+		return nil, err
+	}
+	responses := wfr.Responses
+	if responses == nil {
+		responses = []*WFResponse{wfr}
+	}
+	if len(responses) != len(paths) {
+		return nil, timeseries.ErrInvalidBody
+	}
+	ds := &dataset.DataSet{
+		TimeRangeQuery: trq,
+		ExtentList:     timeseries.ExtentList{trq.Extent},
+		Results:        make([]*dataset.Result, len(responses)),
+	}
+	for i, resp := range responses {
+		if resp == nil {
+			return nil, timeseries.ErrInvalidBody
+		}
+		if len(resp.Error) > 0 {
+			return nil, fmt.Errorf("elasticsearch error: %s", string(resp.Error))
+		}
+		ds.Results[i] = &dataset.Result{StatementID: i}
+		raw, ok := resp.Aggregations[paths[i][0]]
+		if !ok {
+			continue
+		}
+		if err = unmarshalAggregation(raw, paths[i], trq.Statement, dataset.Tags{},
+			ds.Results[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ds, nil
+}
+
+func unmarshalAggregation(raw json.RawMessage, path []string, statement string,
+	tags dataset.Tags, r *dataset.Result) error {
+	wfa := &WFAggregation{}
+	if err := json.Unmarshal(raw, wfa); err != nil {
+		return err
+	}
+	// terms aggregations add their bucket key to the tags of the nested series
+	if len(path) > 1 {
+		for _, b := range wfa.Buckets {
+			t := tags.Clone()
+			t[path[0]] = string(b[FieldKey])
+			if raw, ok := b[path[1]]; ok {
+				if err := unmarshalAggregation(raw, path[1:], statement, t, r); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if len(wfa.Buckets) == 0 {
+		return nil
+	}
+	sh := dataset.SeriesHeader{
+		Name:           path[0],
+		Tags:           tags,
+		FieldsList:     fieldsFromBucket(wfa.Buckets[0]),
+		QueryStatement: statement,
+	}
+	sh.CalculateSize()
+	s := &dataset.Series{Header: sh, Points: make(dataset.Points, 0, len(wfa.Buckets))}
+	for _, b := range wfa.Buckets {
+		p, err := pointFromBucket(b, sh.FieldsList)
+		if err != nil {
+			return err
+		}
+		s.Points = append(s.Points, p)
+		s.PointSize += int64(p.Size)
+	}
+	sort.Sort(s.Points)
+	r.SeriesList = append(r.SeriesList, s)
+	return nil
+}
+
+// fieldsFromBucket returns the list of fields for a date_histogram bucket, which are
+// its doc_count and key_as_string, followed by any metric aggregations in name order
+func fieldsFromBucket(b map[string]json.RawMessage) []timeseries.FieldDefinition {
+	fds := make([]timeseries.FieldDefinition, 0, len(b))
+	fds = append(fds, timeseries.FieldDefinition{Name: FieldDocCount, DataType: timeseries.Int64})
+	if _, ok := b[FieldKeyAsString]; ok {
+		fds = append(fds, timeseries.FieldDefinition{Name: FieldKeyAsString,
+			DataType: timeseries.String})
+	}
+	names := make([]string, 0, len(b))
+	for k := range b {
+		if k == FieldKey || k == FieldKeyAsString || k == FieldDocCount {
+			continue
+		}
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		fd := timeseries.FieldDefinition{Name: k, DataType: timeseries.String,
+			SDataType: SDataTypeJSON}
+		if _, ok := singleValue(b[k]); ok {
+			fd.DataType = timeseries.Float64
+			fd.SDataType = SDataTypeValue
+		}
+		fds = append(fds, fd)
+	}
+	for i := range fds {
+		fds[i].OutputPosition = i
+	}
+	return fds
+}
+
+// singleValue returns the value of a single-value metric aggregation (e.g., {"value": 1.5}),
+// and false if the aggregation is not a single-value metric aggregation
+func singleValue(raw json.RawMessage) (interface{}, bool) {
+	m := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(raw, &m); err != nil || len(m) != 1 {
+		return nil, false
+	}
+	v, ok := m["value"]
+	if !ok {
+		return nil, false
+	}
+	if string(v) == "null" {
+		return nil, true
+	}
+	f, err := strconv.ParseFloat(string(v), 64)
+	if err != nil {
+		return nil, false
+	}
+	return f, true
+}
+
+func pointFromBucket(b map[string]json.RawMessage,
+	fds []timeseries.FieldDefinition) (dataset.Point, error) {
+	p := dataset.Point{Values: make([]interface{}, len(fds)), Size: 12}
+	ms, err := strconv.ParseFloat(string(b[FieldKey]), 64)
+	if err != nil {
+		return p, timeseries.ErrInvalidTimeFormat
+	}
+	p.Epoch = epoch.Epoch(int64(ms) * 1000000)
+	for i, fd := range fds {
+		raw, ok := b[fd.Name]
+		if !ok {
+			continue
+		}
+		switch {
+		case fd.Name == FieldDocCount:
+			n, err := strconv.ParseInt(string(raw), 10, 64)
+			if err != nil {
+				return p, err
+			}
+			p.Values[i] = n
+			p.Size += 8
+		case fd.Name == FieldKeyAsString:
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return p, err
+			}
+			p.Values[i] = s
+			p.Size += len(s)
+		case fd.SDataType == SDataTypeValue:
+			if v, ok := singleValue(raw); ok && v != nil {
+				p.Values[i] = v
+			}
+			p.Size += 8
+		default:
+			p.Values[i] = string(raw)
+			p.Size += len(raw)
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,339 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	modeles "github.com/trickstercache/trickster/pkg/backends/elasticsearch/model"
+	"github.com/trickstercache/trickster/pkg/proxy/errors"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/util/timeconv"
+)
+
+// Tokens that are swapped into the search bodies in place of the query's time range
+const (
+	tkStart    = "<$START$>"
+	tkEnd      = "<$END$>"
+	tkRangeEnd = "<$RANGE_END$>"
+)
+
+const epochMillis = "epoch_millis"
+
+var reDateMath = regexp.MustCompile(`^now(?:([+-])([0-9]+)([wdhHms]))?(?:/([wdhHms]))?$`)
+
+var dateMathUnits = map[string]time.Duration{
+	"w": 7 * 24 * time.Hour,
+	"d": 24 * time.Hour,
+	"h": time.Hour,
+	"H": time.Hour,
+	"m": time.Minute,
+	"s": time.Second,
+}
+
+// calendarIntervals are the calendar intervals that have a fixed duration
+var calendarIntervals = map[string]time.Duration{
+	"1m":     time.Minute,
+	"minute": time.Minute,
+	"1h":     time.Hour,
+	"hour":   time.Hour,
+	"1d":     24 * time.Hour,
+	"day":    24 * time.Hour,
+}
+
+func isMultiSearch(path string) bool {
+	return strings.HasSuffix(path, "/"+epMultiSearch) || path == epMultiSearch
+}
+
+func isSearch(path string) bool {
+	return strings.HasSuffix(path, "/"+epSearch) || path == epSearch
+}
+
+// parse tokenizes the time range of each search in the request body and returns the
+// resulting TimeRangeQuery, whose Statement is newline-delimited pairs of search headers
+// and tokenized bodies. All searches in an _msearch request must share the same time
+// range and date_histogram interval.
+func parse(path string, body []byte, now time.Time) (*timeseries.TimeRangeQuery,
+	*timeseries.RequestOptions, error) {
+
+	rlo := &timeseries.RequestOptions{OutputFormat: modeles.OutputFormatSearch}
+	var lines []string
+	if isMultiSearch(path) {
+		rlo.OutputFormat = modeles.OutputFormatMultiSearch
+		for _, l := range strings.Split(string(body), "\n") {
+			if l = strings.TrimSpace(l); l != "" {
+				lines = append(lines, l)
+			}
+		}
+		if len(lines) == 0 || len(lines)%2 != 0 {
+			return nil, nil, errors.ErrNotTimeRangeQuery
+		}
+	} else {
+		lines = []string{"{}", string(body)}
+	}
+
+	trq := &timeseries.TimeRangeQuery{}
+	for i := 1; i < len(lines); i += 2 {
+		sb, e, step, field, err := parseSearch([]byte(lines[i]), now)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i == 1 {
+			trq.Extent = e
+			trq.Step = step
+			trq.TimestampDefinition.Name = field
+		} else if !e.Start.Equal(trq.Extent.Start) || !e.End.Equal(trq.Extent.End) ||
+			step != trq.Step {
+			return nil, nil, errors.ErrNotTimeRangeQuery
+		}
+		lines[i] = sb
+	}
+	trq.Statement = strings.Join(lines, "\n") + "\n"
+	return trq, rlo, nil
+}
+
+// parseSearch tokenizes a single search body and returns it along with its time range,
+// date_histogram interval and timestamp field name
+func parseSearch(b []byte, now time.Time) (string, timeseries.Extent, time.Duration,
+	string, error) {
+
+	var e timeseries.Extent
+	body := make(map[string]interface{})
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&body); err != nil {
+		return "", e, 0, "", errors.ParseRequestBody(err)
+	}
+
+	// searches that return documents are not time series
+	if size, ok := body["size"].(json.Number); !ok || size.String() != "0" {
+		return "", e, 0, "", errors.ErrNotTimeRangeQuery
+	}
+
+	_, hist, err := modeles.AggregationPath(body)
+	if err != nil {
+		return "", e, 0, "", errors.ErrNotTimeRangeQuery
+	}
+	field, _ := hist["field"].(string)
+	if field == "" {
+		return "", e, 0, "", errors.MissingRequestParam("field")
+	}
+	if _, ok := hist["offset"]; ok {
+		return "", e, 0, "", errors.ErrStepParse
+	}
+	if k, ok := hist["keyed"].(bool); ok && k {
+		return "", e, 0, "", errors.ErrNotTimeRangeQuery
+	}
+	step, err := parseInterval(hist)
+	if err != nil {
+		return "", e, 0, "", err
+	}
+	// buckets in other time zones align to the local time, which is only
+	// equivalent to UTC alignment for steps that divide the smallest zone offset
+	if tz, ok := hist["time_zone"].(string); ok && !isUTC(tz) && (15*time.Minute)%step != 0 {
+		return "", e, 0, "", errors.ErrStepParse
+	}
+
+	var found bool
+	if err = tokenizeRanges(body["query"], field, now, &e, &found); err != nil {
+		return "", e, 0, "", err
+	}
+	if !found {
+		return "", e, 0, "", errors.ErrNotTimeRangeQuery
+	}
+	for _, k := range []string{"extended_bounds", "hard_bounds"} {
+		if bounds, ok := hist[k].(map[string]interface{}); ok {
+			bounds["min"] = tkStart
+			bounds["max"] = tkEnd
+		}
+	}
+
+	buf := bytes.NewBuffer(nil)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err = enc.Encode(body); err != nil {
+		return "", e, 0, "", err
+	}
+	return strings.TrimSpace(buf.String()), e, step, field, nil
+}
+
+// tokenizeRanges replaces the bounds of every range clause on the field within v
+// with tokens, and ensures they all agree on the time range
+func tokenizeRanges(v interface{}, field string, now time.Time,
+	e *timeseries.Extent, found *bool) error {
+	switch t := v.(type) {
+	case []interface{}:
+		for _, x := range t {
+			if err := tokenizeRanges(x, field, now, e, found); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		for k, x := range t {
+			if k == "range" {
+				if rm, ok := x.(map[string]interface{}); ok {
+					if rc, ok := rm[field].(map[string]interface{}); ok {
+						re, err := tokenizeRange(rc, now)
+						if err != nil {
+							return err
+						}
+						if *found && (!re.Start.Equal(e.Start) || !re.End.Equal(e.End)) {
+							return errors.ErrNotTimeRangeQuery
+						}
+						*e = re
+						*found = true
+						continue
+					}
+				}
+			}
+			if err := tokenizeRanges(x, field, now, e, found); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func tokenizeRange(rc map[string]interface{}, now time.Time) (timeseries.Extent, error) {
+	var e timeseries.Extent
+	format, _ := rc["format"].(string)
+	lower, upper := firstOf(rc, "gte", "gt", "from"), firstOf(rc, "lte", "lt", "to")
+	if lower == nil || upper == nil {
+		return e, errors.ErrNotTimeRangeQuery
+	}
+	var err error
+	if e.Start, err = parseTime(lower, format, now); err != nil {
+		return e, err
+	}
+	if e.End, err = parseTime(upper, format, now); err != nil {
+		return e, err
+	}
+	for _, k := range []string{"gt", "lt", "from", "to", "include_lower", "include_upper"} {
+		delete(rc, k)
+	}
+	rc["gte"] = tkStart
+	rc["lte"] = tkRangeEnd
+	rc["format"] = epochMillis
+	return e, nil
+}
+
+func firstOf(m map[string]interface{}, keys ...string) interface{} {
+	for _, k := range keys {
+		if v, ok := m[k]; ok && v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// parseTime returns the time represented by a range bound, which may be an epoch in
+// milliseconds (or seconds, per the format), date math relative to now, or a date string
+func parseTime(v interface{}, format string, now time.Time) (time.Time, error) {
+	var s string
+	switch t := v.(type) {
+	case json.Number:
+		s = t.String()
+	case string:
+		s = strings.TrimSpace(t)
+	default:
+		return time.Time{}, errors.ErrNotTimeRangeQuery
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if strings.Contains(format, "epoch_second") && !strings.Contains(format, epochMillis) {
+			return time.Unix(i, 0), nil
+		}
+		return time.Unix(0, i*int64(time.Millisecond)), nil
+	}
+	if m := reDateMath.FindStringSubmatch(s); m != nil {
+		t := now
+		if m[2] != "" {
+			n, _ := strconv.ParseInt(m[2], 10, 64)
+			d := time.Duration(n) * dateMathUnits[m[3]]
+			if m[1] == "-" {
+				d = -d
+			}
+			t = t.Add(d)
+		}
+		if m[4] != "" {
+			t = t.Truncate(dateMathUnits[m[4]])
+		}
+		return t, nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.ErrNotTimeRangeQuery
+}
+
+// parseInterval returns the fixed duration of the date_histogram's interval
+func parseInterval(hist map[string]interface{}) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if s, ok := hist["fixed_interval"].(string); ok {
+		d, err = parseFixedInterval(s)
+	} else if s, ok := hist["calendar_interval"].(string); ok {
+		if d, ok = calendarIntervals[s]; !ok {
+			return 0, errors.ErrStepParse
+		}
+	} else if v, ok := hist["interval"]; ok {
+		// the legacy interval may be a number of milliseconds or a duration string
+		switch t := v.(type) {
+		case json.Number:
+			var i int64
+			if i, err = t.Int64(); err == nil {
+				d = time.Duration(i) * time.Millisecond
+			}
+		case string:
+			var ok bool
+			if d, ok = calendarIntervals[t]; !ok {
+				d, err = parseFixedInterval(t)
+			}
+		default:
+			return 0, errors.ErrStepParse
+		}
+	} else {
+		return 0, errors.ErrStepParse
+	}
+	if err != nil || d <= 0 {
+		return 0, errors.ErrStepParse
+	}
+	return d, nil
+}
+
+// parseFixedInterval parses a fixed interval, excluding weeks and years, which
+// Elasticsearch only supports as calendar intervals
+func parseFixedInterval(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "w") || strings.HasSuffix(s, "y") {
+		return 0, errors.ErrStepParse
+	}
+	return timeconv.ParseDuration(s)
+}
+
+func isUTC(tz string) bool {
+	switch strings.ToUpper(tz) {
+	case "", "UTC", "Z", "GMT", "ETC/UTC", "+00:00", "-00:00", "+0000":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package elasticsearch
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	modeles "github.com/trickstercache/trickster/pkg/backends/elasticsearch/model"
+	"github.com/trickstercache/trickster/pkg/proxy/errors"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+)
+
+// testSearch is shaped like the searches issued by Grafana's Elasticsearch data source
+const testSearch = `{"size":0,"query":{"bool":{"filter":[{"range":{"@timestamp":` +
+	`{"gte":1609459200000,"lte":1609462800000,"format":"epoch_millis"}}},` +
+	`{"query_string":{"analyze_wildcard":true,"query":"*"}}]}},` +
+	`"aggs":{"2":{"date_histogram":{"interval":"1m","field":"@timestamp","min_doc_count":0,` +
+	`"extended_bounds":{"min":1609459200000,"max":1609462800000},"format":"epoch_millis"},"aggs":{}}}}`
+
+const testSearchHeader = `{"search_type":"query_then_fetch","ignore_unavailable":true,"index":"logs-*"}`
+
+var testNow = time.Unix(1609466400, 0)
+
+func TestParse(t *testing.T) {
+
+	trq, rlo, err := parse("/logs/_search", []byte(testSearch), testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rlo.OutputFormat != modeles.OutputFormatSearch {
+		t.Errorf("expected %d got %d", modeles.OutputFormatSearch, rlo.OutputFormat)
+	}
+	if trq.Step != time.Minute {
+		t.Errorf("expected %s got %s", time.Minute, trq.Step)
+	}
+	if !trq.Extent.Start.Equal(time.Unix(1609459200, 0)) ||
+		!trq.Extent.End.Equal(time.Unix(1609462800, 0)) {
+		t.Errorf("unexpected extent %s", trq.Extent.String())
+	}
+	for _, tk := range []string{tkStart, tkEnd, tkRangeEnd} {
+		if !strings.Contains(trq.Statement, strconv.Quote(tk)) {
+			t.Errorf("expected %s in %s", tk, trq.Statement)
+		}
+	}
+	if strings.Contains(trq.Statement, "1609459200000") {
+		t.Errorf("expected tokenized statement got %s", trq.Statement)
+	}
+	if _, err = modeles.ParseStatement(trq.Statement); err != nil {
+		t.Error(err)
+	}
+
+	// multi-searches must agree on their time range
+	body := testSearchHeader + "\n" + testSearch + "\n" + testSearchHeader + "\n" + testSearch + "\n"
+	trq, rlo, err = parse("/_msearch", []byte(body), testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rlo.OutputFormat != modeles.OutputFormatMultiSearch {
+		t.Errorf("expected %d got %d", modeles.OutputFormatMultiSearch, rlo.OutputFormat)
+	}
+	if !strings.HasPrefix(trq.Statement, testSearchHeader+"\n") ||
+		strings.Count(trq.Statement, "\n") != 4 {
+		t.Errorf("unexpected statement %s", trq.Statement)
+	}
+	body += testSearchHeader + "\n" + strings.Replace(testSearch, "1609459200000", "1609459260000", -1)
+	if _, _, err = parse("/_msearch", []byte(body), testNow); err != errors.ErrNotTimeRangeQuery {
+		t.Errorf("expected %v got %v", errors.ErrNotTimeRangeQuery, err)
+	}
+}
+
+func TestParseUnsupported(t *testing.T) {
+
+	tests := []struct {
+		from, to string
+		expected error
+	}{
+		{`"size":0`, `"size":10`, errors.ErrNotTimeRangeQuery},
+		{`"interval":"1m"`, `"interval":"1M"`, errors.ErrStepParse},
+		{`"interval":"1m"`, `"calendar_interval":"1w"`, errors.ErrStepParse},
+		{`"interval":"1m"`, `"interval":"1m","offset":"+6h"`, errors.ErrStepParse},
+		{`"interval":"1m"`, `"interval":"1h","time_zone":"America/New_York"`, errors.ErrStepParse},
+		{`"range"`, `"match"`, errors.ErrNotTimeRangeQuery},
+		{`"date_histogram"`, `"histogram"`, errors.ErrNotTimeRangeQuery},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, _, err := parse("/_search", []byte(strings.Replace(testSearch, test.from, test.to, 1)), testNow)
+			if err != test.expected {
+				t.Errorf("expected %v got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+
+	tests := []struct {
+		v        interface{}
+		format   string
+		expected time.Time
+	}{
+		{"1609459200000", epochMillis, time.Unix(1609459200, 0)},
+		{"1609459200", "epoch_second", time.Unix(1609459200, 0)},
+		{"now", "", testNow},
+		{"now-6h", "", testNow.Add(-6 * time.Hour)},
+		{"now-90m/h", "", testNow.Add(-2 * time.Hour)},
+		{"2021-01-01T00:00:00Z", "", time.Unix(1609459200, 0)},
+		{"2021-01-01", "", time.Unix(1609459200, 0)},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			tm, err := parseTime(test.v, test.format, testNow)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tm.Equal(test.expected) {
+				t.Errorf("expected %s got %s", test.expected, tm)
+			}
+		})
+	}
+
+	if _, err := parseTime("now-1M", "", testNow); err != errors.ErrNotTimeRangeQuery {
+		t.Errorf("expected %v got %v", errors.ErrNotTimeRangeQuery, err)
+	}
+}
+
+func TestParseTimeRangeQuery(t *testing.T) {
+
+	client := &Client{}
+	r, _ := http.NewRequest(http.MethodPost, "http://0/logs/_search?ignore_unavailable=true",
+		bytes.NewReader([]byte(testSearch)))
+	trq, _, canOPC, err := client.ParseTimeRangeQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canOPC {
+		t.Error("expected canOPC to be false")
+	}
+	qt := trq.TemplateURL.Query()
+	if qt.Get(upBody) != trq.Statement || qt.Get("ignore_unavailable") != "true" {
+		t.Errorf("unexpected template url %s", trq.TemplateURL.String())
+	}
+
+	// the body must remain readable for proxying
+	if string(request.GetBody(r)) != testSearch {
+		t.Error("expected request body to be preserved")
+	}
+
+	r, _ = http.NewRequest(http.MethodPost, "http://0/logs/_search?typed_keys",
+		bytes.NewReader([]byte(testSearch)))
+	if _, _, _, err = client.ParseTimeRangeQuery(r); err != errors.ErrNotTimeRangeQuery {
+		t.Errorf("expected %v got %v", errors.ErrNotTimeRangeQuery, err)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "http://0/_cat/indices", nil)
+	if _, _, _, err = client.ParseTimeRangeQuery(r); err != errors.ErrNotTimeRangeQuery {
+		t.Errorf("expected %v got %v", errors.ErrNotTimeRangeQuery, err)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package elasticsearch
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+)
+
+// Elasticsearch Endpoints
+const (
+	epSearch      = "_search"
+	epMultiSearch = "_msearch"
+	epHealth      = "_cluster/health"
+)
+
+// Common URL Parameter Names
+const (
+	upBody      = "body"
+	upTypedKeys = "typed_keys"
+)
+
+// SetExtent will change the upstream request body to use the provided Extent
+func (c *Client) SetExtent(r *http.Request, trq *timeseries.TimeRangeQuery, extent *timeseries.Extent) {
+
+	if extent == nil || r == nil || trq == nil {
+		return
+	}
+
+	start := extent.Start.UnixNano() / int64(time.Millisecond)
+	end := extent.End.UnixNano() / int64(time.Millisecond)
+	// the range includes each document in the final bucket
+	rangeEnd := extent.End.Add(trq.Step).UnixNano()/int64(time.Millisecond) - 1
+
+	body := strings.NewReplacer(
+		strconv.Quote(tkStart), strconv.FormatInt(start, 10),
+		strconv.Quote(tkEnd), strconv.FormatInt(end, 10),
+		strconv.Quote(tkRangeEnd), strconv.FormatInt(rangeEnd, 10),
+	).Replace(trq.Statement)
+
+	if isMultiSearch(r.URL.Path) {
+		r.Header.Set(headers.NameContentType, headers.ValueApplicationNDJSON)
+	} else {
+		// a _search request only sends the body that follows its empty search header
+		if i := strings.IndexByte(body, '\n'); i >= 0 {
+			body = strings.TrimSpace(body[i+1:])
+		}
+		r.Header.Set(headers.NameContentType, headers.ValueApplicationJSON)
+	}
+	*r = *request.SetBody(r, []byte(body))
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package elasticsearch
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+)
+
+func TestSetExtent(t *testing.T) {
+
+	client := &Client{}
+	e := &timeseries.Extent{Start: time.Unix(1609462800, 0), End: time.Unix(1609466400, 0)}
+
+	trq, _, err := parse("/logs/_search", []byte(testSearch), testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := http.NewRequest(http.MethodPost, "http://0/logs/_search", bytes.NewReader([]byte(testSearch)))
+	client.SetExtent(r, trq, e)
+	if r.Header.Get(headers.NameContentType) != headers.ValueApplicationJSON {
+		t.Errorf("expected %s got %s", headers.ValueApplicationJSON,
+			r.Header.Get(headers.NameContentType))
+	}
+	b, _ := io.ReadAll(r.Body)
+	for _, expected := range []string{
+		`"gte":1609462800000`,
+		`"lte":1609466459999`,
+		`"extended_bounds":{"max":1609466400000,"min":1609462800000}`,
+	} {
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("expected %s in %s", expected, string(b))
+		}
+	}
+	if strings.Contains(string(b), "\n") {
+		t.Errorf("expected a single search body got %s", string(b))
+	}
+	if r.ContentLength != int64(len(b)) {
+		t.Errorf("expected %d got %d", len(b), r.ContentLength)
+	}
+
+	body := testSearchHeader + "\n" + testSearch + "\n"
+	trq, _, err = parse("/_msearch", []byte(body), testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ = http.NewRequest(http.MethodPost, "http://0/_msearch", bytes.NewReader([]byte(body)))
+	client.SetExtent(r, trq, e)
+	if r.Header.Get(headers.NameContentType) != headers.ValueApplicationNDJSON {
+		t.Errorf("expected %s got %s", headers.ValueApplicationNDJSON,
+			r.Header.Get(headers.NameContentType))
+	}
+	b, _ = io.ReadAll(r.Body)
+	if !strings.HasPrefix(string(b), testSearchHeader+"\n") || !strings.HasSuffix(string(b), "\n") {
+		t.Errorf("unexpected multi-search body %s", string(b))
+	}
+}
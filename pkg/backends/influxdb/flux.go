@@ -0,0 +1,312 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package influxdb
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tpe "github.com/trickstercache/trickster/pkg/proxy/errors"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/util/timeconv"
+)
+
+// tokens that replace the range() arguments in the templatized Flux statement
+const (
+	fluxStartToken = "<$START$>"
+	fluxStopToken  = "<$STOP$>"
+)
+
+var fluxRangeCall = regexp.MustCompile(`\|>\s*range\s*\(`)
+var fluxWindowCall = regexp.MustCompile(`\baggregateWindow\s*\(`)
+
+var errFluxUnbalanced = errors.New("unbalanced parentheses or quotes in flux query")
+var errFluxTime = errors.New("unable to parse flux time value")
+
+// fluxRequest is the JSON body of an InfluxDB 2.x /api/v2/query request
+type fluxRequest struct {
+	Query   string          `json:"query"`
+	Type    string          `json:"type,omitempty"`
+	Dialect *fluxDialect    `json:"dialect,omitempty"`
+	Now     string          `json:"now,omitempty"`
+	Extern  json.RawMessage `json:"extern,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// fluxDialect describes the requested annotated CSV output format
+type fluxDialect struct {
+	Header         *bool    `json:"header,omitempty"`
+	Delimiter      string   `json:"delimiter,omitempty"`
+	Annotations    []string `json:"annotations,omitempty"`
+	CommentPrefix  string   `json:"commentPrefix,omitempty"`
+	DateTimeFormat string   `json:"dateTimeFormat,omitempty"`
+}
+
+// fluxQuery is the parsed form of a Flux request, which is stored as the
+// TimeRangeQuery's ParsedQuery and used to craft each upstream request
+type fluxQuery struct {
+	// statement is the Flux script with its range() arguments tokenized
+	statement string
+	// timeSrcStart is true when aggregateWindow() stamps each point with the
+	// start of its window rather than the default of its stop
+	timeSrcStart bool
+	request      *fluxRequest
+}
+
+// fluxUpstreamDialect is the dialect requested from the upstream, which includes every
+// annotation so the response can be fully modeled regardless of the client's dialect
+var fluxUpstreamDialect = &fluxDialect{
+	Annotations: []string{"datatype", "group", "default"},
+	Delimiter:   ",",
+}
+
+// body returns the upstream request body for the query with its range set to the extent
+func (fq *fluxQuery) body(e *timeseries.Extent, step time.Duration) ([]byte, error) {
+	start, stop := e.Start, e.End
+	// range() is start-inclusive and stop-exclusive, and aggregateWindow() stamps each
+	// point with its window's stop time unless timeSrc is "_start", so the range is
+	// shifted such that the points bounding the extent are included in the results
+	if fq.timeSrcStart {
+		stop = stop.Add(step)
+	} else {
+		start = start.Add(-step)
+	}
+	fr := *fq.request
+	fr.Query = strings.NewReplacer(
+		fluxStartToken, start.UTC().Format(time.RFC3339Nano),
+		fluxStopToken, stop.UTC().Format(time.RFC3339Nano),
+	).Replace(fq.statement)
+	fr.Type = "flux"
+	fr.Dialect = fluxUpstreamDialect
+	return json.Marshal(fr)
+}
+
+// parseFluxQuery parses the time range, step and tokenized statement from the Flux script
+func parseFluxQuery(fr *fluxRequest, now time.Time) (*fluxQuery, timeseries.Extent,
+	time.Duration, error) {
+
+	fq := &fluxQuery{request: fr}
+	var ex timeseries.Extent
+	var step time.Duration
+
+	if fr.Now != "" {
+		t, err := time.Parse(time.RFC3339Nano, fr.Now)
+		if err != nil {
+			return nil, ex, 0, err
+		}
+		now = t
+	}
+
+	q := fr.Query
+	sb := strings.Builder{}
+	var found bool
+	var pos int
+	for _, loc := range fluxRangeCall.FindAllStringIndex(q, -1) {
+		if loc[0] < pos {
+			continue
+		}
+		args, end, err := parseFluxArgs(q, loc[1])
+		if err != nil {
+			return nil, ex, 0, err
+		}
+		start, err := parseFluxTime(args["start"], now)
+		if err != nil {
+			return nil, ex, 0, err
+		}
+		stop := now
+		if v, ok := args["stop"]; ok {
+			if stop, err = parseFluxTime(v, now); err != nil {
+				return nil, ex, 0, err
+			}
+		}
+		e := timeseries.Extent{Start: start, End: stop}
+		if found && e != ex {
+			// multiple range() calls in the script must request identical ranges
+			return nil, ex, 0, tpe.ErrNotTimeRangeQuery
+		}
+		ex = e
+		found = true
+		sb.WriteString(q[pos:loc[1]])
+		sb.WriteString("start: " + fluxStartToken + ", stop: " + fluxStopToken)
+		pos = end - 1 // retains the closing paren
+	}
+	if !found {
+		return nil, ex, 0, tpe.ErrNotTimeRangeQuery
+	}
+	sb.WriteString(q[pos:])
+	fq.statement = sb.String()
+
+	for i, loc := range fluxWindowCall.FindAllStringIndex(q, -1) {
+		args, _, err := parseFluxArgs(q, loc[1])
+		if err != nil {
+			return nil, ex, 0, err
+		}
+		if _, ok := args["offset"]; ok {
+			// offset windows do not align to step boundaries
+			return nil, ex, 0, tpe.ErrStepParse
+		}
+		d, err := parseFluxDuration(args["every"])
+		if err != nil || d <= 0 {
+			return nil, ex, 0, tpe.ErrStepParse
+		}
+		timeSrcStart := strings.Trim(args["timeSrc"], `"`) == "_start"
+		if i > 0 && (d != step || timeSrcStart != fq.timeSrcStart) {
+			return nil, ex, 0, tpe.ErrStepParse
+		}
+		step = d
+		fq.timeSrcStart = timeSrcStart
+	}
+	if step == 0 {
+		return nil, ex, 0, tpe.ErrStepParse
+	}
+
+	return fq, ex, step, nil
+}
+
+// parseFluxArgs parses the named arguments of the Flux function call whose argument
+// list begins at the provided index, and returns the index following the closing paren
+func parseFluxArgs(q string, i int) (map[string]string, int, error) {
+	args := make(map[string]string)
+	var depth int
+	var inQuote bool
+	argStart := i
+	addArg := func(s string) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return
+		}
+		parts := strings.SplitN(s, ":", 2)
+		if len(parts) != 2 {
+			return
+		}
+		args[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	for ; i < len(q); i++ {
+		switch q[i] {
+		case '\\':
+			if inQuote {
+				i++
+			}
+		case '"':
+			inQuote = !inQuote
+		case '(', '[', '{':
+			if !inQuote {
+				depth++
+			}
+		case ']', '}':
+			if !inQuote {
+				depth--
+			}
+		case ')':
+			if inQuote {
+				continue
+			}
+			if depth == 0 {
+				addArg(q[argStart:i])
+				return args, i + 1, nil
+			}
+			depth--
+		case ',':
+			if !inQuote && depth == 0 {
+				addArg(q[argStart:i])
+				argStart = i + 1
+			}
+		}
+	}
+	return nil, 0, errFluxUnbalanced
+}
+
+// parseFluxTime parses a Flux time expression, which may be an RFC3339 timestamp, a
+// duration relative to now, now(), a time() conversion, or an integer Unix timestamp
+func parseFluxTime(v string, now time.Time) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	switch {
+	case v == "":
+		return time.Time{}, errFluxTime
+	case v == "now()":
+		return now, nil
+	case strings.HasPrefix(v, "time(") && strings.HasSuffix(v, ")"):
+		args, _, err := parseFluxArgs(v, 5)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return parseFluxTime(strings.Trim(args["v"], `"`), now)
+	}
+	if d, err := parseFluxDuration(v); err == nil {
+		return now.Add(d), nil
+	}
+	if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(i, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errFluxTime
+}
+
+// parseFluxDuration parses a Flux duration literal, which may be signed and consist of
+// multiple magnitude and unit pairs (e.g., -1h30m). Calendar units (mo, y) are unsupported.
+func parseFluxDuration(v string) (time.Duration, error) {
+	v = strings.TrimSpace(v)
+	var neg bool
+	if strings.HasPrefix(v, "-") {
+		neg = true
+		v = v[1:]
+	}
+	if v == "" {
+		return 0, errFluxTime
+	}
+	var d time.Duration
+	for len(v) > 0 {
+		i := 0
+		for i < len(v) && v[i] >= '0' && v[i] <= '9' {
+			i++
+		}
+		j := i
+		for j < len(v) && (v[j] < '0' || v[j] > '9') {
+			j++
+		}
+		if i == 0 || i == j {
+			return 0, errFluxTime
+		}
+		n, err := strconv.ParseInt(v[:i], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		unit := v[i:j]
+		if unit == "y" {
+			return 0, errFluxTime
+		}
+		p, err := timeconv.ParseDurationParts(n, unit)
+		if err != nil {
+			return 0, err
+		}
+		d += p
+		v = v[j:]
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package influxdb
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/proxy/errors"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+)
+
+const testFluxQuery = `from(bucket: "test")
+  |> range(start: -1h, stop: now())
+  |> filter(fn: (r) => r._measurement == "cpu" and r.host =~ /a(b|c)/)
+  |> aggregateWindow(every: 1m, fn: mean, createEmpty: false)`
+
+func TestParseFluxDuration(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected time.Duration
+		err      bool
+	}{
+		{"1m", time.Minute, false},
+		{"-1h30m", -90 * time.Minute, false},
+		{"2d", 48 * time.Hour, false},
+		{"500ms", 500 * time.Millisecond, false},
+		{"1mo", 0, true},
+		{"1y", 0, true},
+		{"", 0, true},
+		{"v.windowPeriod", 0, true},
+		{"2021-01-01T00:00:00Z", 0, true},
+	}
+	for _, test := range tests {
+		d, err := parseFluxDuration(test.in)
+		if test.err != (err != nil) {
+			t.Errorf("%s: unexpected error result %v", test.in, err)
+		}
+		if d != test.expected {
+			t.Errorf("%s: expected %s got %s", test.in, test.expected, d)
+		}
+	}
+}
+
+func TestParseFluxTime(t *testing.T) {
+	now := time.Unix(1609502400, 0)
+	tests := []struct {
+		in       string
+		expected time.Time
+		err      bool
+	}{
+		{"now()", now, false},
+		{"-1h", now.Add(-time.Hour), false},
+		{"2021-01-01T00:00:00Z", time.Unix(1609459200, 0), false},
+		{`time(v: "2021-01-01T00:00:00Z")`, time.Unix(1609459200, 0), false},
+		{"1609459200", time.Unix(1609459200, 0), false},
+		{"v.timeRangeStart", time.Time{}, true},
+	}
+	for _, test := range tests {
+		tm, err := parseFluxTime(test.in, now)
+		if test.err != (err != nil) {
+			t.Errorf("%s: unexpected error result %v", test.in, err)
+		}
+		if !tm.Equal(test.expected) {
+			t.Errorf("%s: expected %s got %s", test.in, test.expected, tm)
+		}
+	}
+}
+
+func TestParseFluxQuery(t *testing.T) {
+	now := time.Unix(1609502400, 0)
+	fq, ex, step, err := parseFluxQuery(&fluxRequest{Query: testFluxQuery}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step != time.Minute {
+		t.Errorf("expected %s got %s", time.Minute, step)
+	}
+	if !ex.Start.Equal(now.Add(-time.Hour)) || !ex.End.Equal(now) {
+		t.Errorf("unexpected extent %s", ex.String())
+	}
+	if !strings.Contains(fq.statement, "range(start: "+fluxStartToken+", stop: "+fluxStopToken+")") {
+		t.Errorf("expected tokenized range, got %s", fq.statement)
+	}
+	if fq.timeSrcStart {
+		t.Error("expected timeSrc to default to _stop")
+	}
+
+	// the client's now is used to resolve relative times
+	_, ex, _, err = parseFluxQuery(&fluxRequest{Query: testFluxQuery,
+		Now: "2021-01-01T00:00:00Z"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ex.End.Equal(time.Unix(1609459200, 0)) {
+		t.Errorf("unexpected extent %s", ex.String())
+	}
+
+	_, _, _, err = parseFluxQuery(&fluxRequest{
+		Query: `from(bucket: "test") |> range(start: -1h) |> mean()`}, now)
+	if err != errors.ErrStepParse {
+		t.Errorf("expected %v got %v", errors.ErrStepParse, err)
+	}
+
+	_, _, _, err = parseFluxQuery(&fluxRequest{
+		Query: `from(bucket: "test") |> aggregateWindow(every: 1m, fn: mean)`}, now)
+	if err != errors.ErrNotTimeRangeQuery {
+		t.Errorf("expected %v got %v", errors.ErrNotTimeRangeQuery, err)
+	}
+
+	_, _, _, err = parseFluxQuery(&fluxRequest{
+		Query: `a = from(bucket: "a") |> range(start: -1h) |> aggregateWindow(every: 1m, fn: mean)
+b = from(bucket: "b") |> range(start: -2h) |> aggregateWindow(every: 1m, fn: mean)`}, now)
+	if err != errors.ErrNotTimeRangeQuery {
+		t.Errorf("expected %v got %v", errors.ErrNotTimeRangeQuery, err)
+	}
+
+	_, _, _, err = parseFluxQuery(&fluxRequest{
+		Query: `from(bucket: "test") |> range(start: -1h |> aggregateWindow(every: 1m, fn: mean)`}, now)
+	if err != errFluxUnbalanced {
+		t.Errorf("expected %v got %v", errFluxUnbalanced, err)
+	}
+}
+
+func TestFluxQueryBody(t *testing.T) {
+	now := time.Unix(1609502400, 0)
+	fq, _, step, err := parseFluxQuery(&fluxRequest{Query: testFluxQuery,
+		Dialect: &fluxDialect{Annotations: []string{"group"}}}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &timeseries.Extent{Start: time.Unix(1609459200, 0), End: time.Unix(1609462800, 0)}
+	b, err := fq.body(e, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fr := &fluxRequest{}
+	if err = json.Unmarshal(b, fr); err != nil {
+		t.Fatal(err)
+	}
+	// the range is shifted back one step since points are stamped with their window's stop
+	const expected = "range(start: 2020-12-31T23:59:00Z, stop: 2021-01-01T01:00:00Z)"
+	if !strings.Contains(fr.Query, expected) {
+		t.Errorf("expected %s in %s", expected, fr.Query)
+	}
+	if fr.Dialect == nil || len(fr.Dialect.Annotations) != 3 {
+		t.Errorf("expected all annotations in upstream dialect, got %v", fr.Dialect)
+	}
+
+	fq.timeSrcStart = true
+	b, _ = fq.body(e, step)
+	json.Unmarshal(b, fr)
+	const expected2 = "range(start: 2021-01-01T00:00:00Z, stop: 2021-01-01T01:01:00Z)"
+	if !strings.Contains(fr.Query, expected2) {
+		t.Errorf("expected %s in %s", expected2, fr.Query)
+	}
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package influxdb
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	modelflux "github.com/trickstercache/trickster/pkg/backends/influxdb/model"
+	"github.com/trickstercache/trickster/pkg/proxy/engines"
+	"github.com/trickstercache/trickster/pkg/proxy/errors"
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+	"github.com/trickstercache/trickster/pkg/proxy/response/merge"
+	"github.com/trickstercache/trickster/pkg/proxy/urls"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+)
+
+// FluxHandler handles InfluxDB 2.x Flux queries and processes them through the delta proxy cache
+func (c *Client) FluxHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		c.ProxyHandler(w, r)
+		return
+	}
+	// if this request is part of a scatter/gather, provide a reconstitution function
+	if rsc := request.GetResources(r); rsc != nil && rsc.IsMergeMember {
+		rsc.ResponseMergeFunc = merge.Timeseries
+	}
+	r.URL = urls.BuildUpstreamURL(r, c.BaseUpstreamURL())
+	engines.DeltaProxyCacheRequest(w, r, c.Modeler())
+}
+
+func isFluxRequest(r *http.Request) bool {
+	return r != nil && r.URL != nil && strings.HasSuffix(r.URL.Path, "/"+mnFlux)
+}
+
+// parseFluxTimeRangeQuery parses the key parts of a TimeRangeQuery from a Flux request.
+// Flux requests that cannot be accelerated are proxied, so the returned canOPC is always false.
+func parseFluxTimeRangeQuery(r *http.Request) (*timeseries.TimeRangeQuery,
+	*timeseries.RequestOptions, bool, error) {
+
+	fr := &fluxRequest{}
+	b := request.GetBody(r)
+	ct := r.Header.Get(headers.NameContentType)
+	if strings.HasPrefix(ct, headers.ValueApplicationFlux) {
+		fr.Query = string(b)
+	} else if err := json.Unmarshal(b, fr); err != nil {
+		return nil, nil, false, errors.ParseRequestBody(err)
+	}
+	if fr.Query == "" {
+		return nil, nil, false, errors.MissingRequestParam(upFluxQuery)
+	}
+	if fr.Type != "" && fr.Type != "flux" {
+		return nil, nil, false, errors.ErrNotTimeRangeQuery
+	}
+
+	rlo := &timeseries.RequestOptions{OutputFormat: modelflux.FluxOutputFormat}
+	if fr.Dialect != nil {
+		// the model always writes a header row with comma delimiters
+		if (fr.Dialect.Header != nil && !*fr.Dialect.Header) ||
+			(fr.Dialect.Delimiter != "" && fr.Dialect.Delimiter != ",") {
+			return nil, nil, false, errors.ErrNotTimeRangeQuery
+		}
+		for _, a := range fr.Dialect.Annotations {
+			switch a {
+			case "datatype":
+				rlo.OutputFormat |= modelflux.FluxAnnotationDatatype
+			case "group":
+				rlo.OutputFormat |= modelflux.FluxAnnotationGroup
+			case "default":
+				rlo.OutputFormat |= modelflux.FluxAnnotationDefault
+			}
+		}
+	}
+
+	fq, ex, step, err := parseFluxQuery(fr, time.Now())
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	trq := &timeseries.TimeRangeQuery{
+		Statement:   fq.statement,
+		Extent:      ex,
+		Step:        step,
+		ParsedQuery: fq,
+	}
+
+	// the templatized statement and any query parameters are used in the cache key
+	trq.TemplateURL = urls.Clone(r.URL)
+	qt := trq.TemplateURL.Query()
+	qt.Set(upFluxQuery, trq.Statement)
+	if len(fr.Extern) > 0 {
+		qt.Set(upExtern, string(fr.Extern))
+	}
+	if len(fr.Params) > 0 {
+		qt.Set(upParams, string(fr.Params))
+	}
+	trq.TemplateURL.RawQuery = qt.Encode()
+
+	return trq, rlo, false, nil
+}
+
+// setFluxExtent will change the upstream Flux request body to use the provided Extent
+func setFluxExtent(r *http.Request, trq *timeseries.TimeRangeQuery, extent *timeseries.Extent) {
+	fq, ok := trq.ParsedQuery.(*fluxQuery)
+	if !ok {
+		return
+	}
+	b, err := fq.body(extent, trq.Step)
+	if err != nil {
+		return
+	}
+	r.Header.Set(headers.NameContentType, headers.ValueApplicationJSON)
+	r.Header.Set(headers.NameAccept, headers.ValueApplicationCSV)
+	*r = *request.SetBody(r, b)
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package influxdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	modelflux "github.com/trickstercache/trickster/pkg/backends/influxdb/model"
+	"github.com/trickstercache/trickster/pkg/cache/evictionmethods"
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	tu "github.com/trickstercache/trickster/pkg/util/testing"
+)
+
+const testFluxResponse = "#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,double,string,string\r\n" +
+	"#group,false,false,true,true,false,false,true,true\r\n" +
+	"#default,_result,,,,,,,\r\n" +
+	",result,table,_start,_stop,_time,_value,_field,_measurement\r\n" +
+	",,0,2020-12-31T23:59:00Z,2021-01-01T00:02:00Z,2021-01-01T00:00:00Z,1,usage,cpu\r\n" +
+	",,0,2020-12-31T23:59:00Z,2021-01-01T00:02:00Z,2021-01-01T00:01:00Z,2,usage,cpu\r\n" +
+	"\r\n"
+
+func TestFluxHandler(t *testing.T) {
+
+	backendClient, err := NewClient("test", nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	ts, w, r, _, err := tu.NewTestInstance("", backendClient.DefaultPathConfigs, 200,
+		testFluxResponse, nil, "influxdb", "/"+mnFlux, "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	rsc := request.GetResources(r)
+	rsc.BackendOptions.TimeseriesEvictionMethod = evictionmethods.EvictionMethodLRU
+	backendClient, err = NewClient("test", rsc.BackendOptions, nil, nil, nil, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	client := backendClient.(*Client)
+	rsc.BackendClient = client
+	rsc.BackendOptions.HTTPClient = backendClient.HTTPClient()
+
+	const q = `from(bucket: "test") |> range(start: 2021-01-01T00:00:00Z, stop: 2021-01-01T00:02:00Z)` +
+		` |> aggregateWindow(every: 1m, fn: mean)`
+	b, _ := json.Marshal(&fluxRequest{Query: q,
+		Dialect: &fluxDialect{Annotations: []string{"datatype", "group", "default"}}})
+	r2, _ := http.NewRequest(http.MethodPost, r.URL.String(), bytes.NewReader(b))
+	r2 = r2.WithContext(r.Context())
+	r2.Header.Set(headers.NameContentType, headers.ValueApplicationJSON)
+
+	client.FluxHandler(w, r2)
+	resp := w.Result()
+	if resp.StatusCode != 200 {
+		t.Errorf("expected 200 got %d.", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	const expected = ",,0,2021-01-01T00:00:00Z,2021-01-01T00:01:00Z,2021-01-01T00:01:00Z,2,usage,cpu\r\n"
+	if !strings.Contains(string(body), expected) {
+		t.Errorf("expected %s in\n%s", expected, string(body))
+	}
+}
+
+func TestParseFluxTimeRangeQuery(t *testing.T) {
+
+	client := &Client{}
+	b, _ := json.Marshal(&fluxRequest{Query: testFluxQuery,
+		Dialect: &fluxDialect{Annotations: []string{"datatype", "group"}}})
+	r, _ := http.NewRequest(http.MethodPost, "http://0/api/v2/query?org=test",
+		bytes.NewReader(b))
+	r.Header.Set(headers.NameContentType, headers.ValueApplicationJSON)
+
+	trq, rlo, canOPC, err := client.ParseTimeRangeQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canOPC {
+		t.Error("expected canOPC to be false")
+	}
+	if trq.Step != time.Minute {
+		t.Errorf("expected %s got %s", time.Minute, trq.Step)
+	}
+	if rlo.OutputFormat != modelflux.FluxOutputFormat|modelflux.FluxAnnotationDatatype|
+		modelflux.FluxAnnotationGroup {
+		t.Errorf("unexpected output format %d", rlo.OutputFormat)
+	}
+	qt := trq.TemplateURL.Query()
+	if qt.Get(upFluxQuery) != trq.Statement || qt.Get(upOrg) != "test" {
+		t.Errorf("unexpected template url %s", trq.TemplateURL.String())
+	}
+
+	// the body must remain readable for proxying
+	if string(request.GetBody(r)) != string(b) {
+		t.Error("expected request body to be preserved")
+	}
+
+	// raw flux scripts are also supported
+	r, _ = http.NewRequest(http.MethodPost, "http://0/api/v2/query?org=test",
+		strings.NewReader(testFluxQuery))
+	r.Header.Set(headers.NameContentType, headers.ValueApplicationFlux)
+	trq2, rlo, _, err := client.ParseTimeRangeQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trq2.Statement != trq.Statement {
+		t.Errorf("expected %s got %s", trq.Statement, trq2.Statement)
+	}
+	if rlo.OutputFormat != modelflux.FluxOutputFormat {
+		t.Errorf("unexpected output format %d", rlo.OutputFormat)
+	}
+
+	// dialects that the model cannot write are not accelerated
+	f := false
+	b, _ = json.Marshal(&fluxRequest{Query: testFluxQuery, Dialect: &fluxDialect{Header: &f}})
+	r, _ = http.NewRequest(http.MethodPost, "http://0/api/v2/query?org=test", bytes.NewReader(b))
+	if _, _, _, err = client.ParseTimeRangeQuery(r); err == nil {
+		t.Error("expected error for headerless dialect")
+	}
+}
+
+func TestSetFluxExtent(t *testing.T) {
+
+	client := &Client{}
+	r, _ := http.NewRequest(http.MethodPost, "http://0/api/v2/query?org=test",
+		strings.NewReader(testFluxQuery))
+	r.Header.Set(headers.NameContentType, headers.ValueApplicationFlux)
+
+	trq := &timeseries.TimeRangeQuery{Step: time.Minute}
+	e := &timeseries.Extent{Start: time.Unix(1609459200, 0), End: time.Unix(1609462800, 0)}
+	client.SetExtent(r, trq, e)
+
+	if r.Header.Get(headers.NameContentType) != headers.ValueApplicationJSON {
+		t.Errorf("expected %s got %s", headers.ValueApplicationJSON,
+			r.Header.Get(headers.NameContentType))
+	}
+	b, _ := io.ReadAll(r.Body)
+	fr := &fluxRequest{}
+	if err := json.Unmarshal(b, fr); err != nil {
+		t.Fatal(err)
+	}
+	const expected = "range(start: 2020-12-31T23:59:00Z, stop: 2021-01-01T01:00:00Z)"
+	if !strings.Contains(fr.Query, expected) {
+		t.Errorf("expected %s in %s", expected, fr.Query)
+	}
+	if r.ContentLength != int64(len(b)) {
+		t.Errorf("expected %d got %d", len(b), r.ContentLength)
+	}
+}
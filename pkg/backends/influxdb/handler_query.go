@@ -26,6 +26,8 @@ import (
 	"github.com/trickstercache/trickster/pkg/proxy/errors"
 	"github.com/trickstercache/trickster/pkg/proxy/headers"
 	"github.com/trickstercache/trickster/pkg/proxy/params"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+	"github.com/trickstercache/trickster/pkg/proxy/response/merge"
 	"github.com/trickstercache/trickster/pkg/proxy/urls"
 	"github.com/trickstercache/trickster/pkg/timeseries"
 
@@ -48,6 +50,10 @@ func (c *Client) QueryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// if this request is part of a scatter/gather, provide a reconstitution function
+	if rsc := request.GetResources(r); rsc != nil && rsc.IsMergeMember {
+		rsc.ResponseMergeFunc = merge.Timeseries
+	}
 	r.URL = urls.BuildUpstreamURL(r, c.BaseUpstreamURL())
 	engines.DeltaProxyCacheRequest(w, r, c.Modeler())
 }
@@ -65,6 +71,10 @@ var epochToFlag = map[string]byte{
 func (c *Client) ParseTimeRangeQuery(r *http.Request) (*timeseries.TimeRangeQuery,
 	*timeseries.RequestOptions, bool, error) {
 
+	if isFluxRequest(r) {
+		return parseFluxTimeRangeQuery(r)
+	}
+
 	trq := &timeseries.TimeRangeQuery{Extent: timeseries.Extent{}}
 	rlo := &timeseries.RequestOptions{}
 
@@ -28,6 +28,7 @@ import (
 )
 
 var _ backends.TimeseriesBackend = (*Client)(nil)
+var _ backends.MergeableTimeseriesBackend = (*Client)(nil)
 
 // Client Implements the Proxy Client Interface
 type Client struct {
@@ -0,0 +1,421 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+	"github.com/trickstercache/trickster/pkg/timeseries/epoch"
+)
+
+// Flux Output Formats. When the FluxOutputFormat bit is set in a RequestOptions'
+// OutputFormat, the DataSet is marshaled as Flux Annotated CSV, and the lower bits
+// indicate which annotations the client requested.
+const (
+	FluxAnnotationDatatype byte = 1
+	FluxAnnotationGroup    byte = 1 << 1
+	FluxAnnotationDefault  byte = 1 << 2
+	FluxOutputFormat       byte = 1 << 3
+)
+
+// Flux Annotated CSV reserved column names
+const (
+	fluxColResult = "result"
+	fluxColTable  = "table"
+	fluxColStart  = "_start"
+	fluxColStop   = "_stop"
+	fluxColTime   = "_time"
+	fluxColError  = "error"
+)
+
+// fluxGroupKey is set as a FieldDefinition's ProviderData1 when the column is part of
+// the Flux table's group key
+const fluxGroupKey = 1
+
+// fluxTable describes the schema of a section of a Flux Annotated CSV document
+type fluxTable struct {
+	header    []string
+	datatypes []string
+	groups    []string
+	defaults  []string
+	// fields maps the FieldsList index to the CSV column index
+	fields    []int
+	fieldDefs []timeseries.FieldDefinition
+	tags      []int
+	resultIdx int
+	tableIdx  int
+	timeIdx   int
+	timeAt    int
+}
+
+func (ft *fluxTable) value(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	if row[i] == "" && i < len(ft.defaults) {
+		return ft.defaults[i]
+	}
+	return row[i]
+}
+
+func (ft *fluxTable) init() error {
+	ft.resultIdx, ft.tableIdx, ft.timeIdx = -1, -1, -1
+	for i, name := range ft.header {
+		switch name {
+		case "":
+			continue
+		case fluxColResult:
+			ft.resultIdx = i
+			continue
+		case fluxColTable:
+			ft.tableIdx = i
+			continue
+		case fluxColTime:
+			ft.timeIdx = i
+			ft.timeAt = len(ft.fields)
+			continue
+		}
+		fd := timeseries.FieldDefinition{
+			Name:           name,
+			OutputPosition: len(ft.fields),
+		}
+		if i < len(ft.datatypes) {
+			fd.SDataType = ft.datatypes[i]
+		}
+		switch fd.SDataType {
+		case "double":
+			fd.DataType = timeseries.Float64
+		case "long":
+			fd.DataType = timeseries.Int64
+		case "boolean":
+			fd.DataType = timeseries.Bool
+		default:
+			fd.DataType = timeseries.String
+		}
+		if i < len(ft.groups) && ft.groups[i] == "true" {
+			fd.ProviderData1 = fluxGroupKey
+			if name != fluxColStart && name != fluxColStop {
+				ft.tags = append(ft.tags, i)
+			}
+		}
+		ft.fields = append(ft.fields, i)
+		ft.fieldDefs = append(ft.fieldDefs, fd)
+	}
+	if ft.timeIdx < 0 {
+		return timeseries.ErrInvalidBody
+	}
+	return nil
+}
+
+func parseFluxValue(fd timeseries.FieldDefinition, v string) (interface{}, int, error) {
+	if v == "" {
+		return nil, 0, nil
+	}
+	switch fd.DataType {
+	case timeseries.Float64:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, 8, err
+	case timeseries.Int64:
+		i, err := strconv.ParseInt(v, 10, 64)
+		return i, 8, err
+	case timeseries.Bool:
+		b, err := strconv.ParseBool(v)
+		return b, 1, err
+	}
+	return v, len(v), nil
+}
+
+// unmarshalFluxCSV converts a Flux Annotated CSV document into a DataSet. Each Flux table
+// becomes a Series named for its result, tagged with its group key. The _start and _stop
+// columns are retained in the schema but not their values, since they describe the range
+// of the upstream request rather than the data.
+func unmarshalFluxCSV(reader io.Reader, trq *timeseries.TimeRangeQuery) (timeseries.Timeseries, error) {
+	cr := csv.NewReader(reader)
+	cr.FieldsPerRecord = -1
+
+	ds := &dataset.DataSet{
+		TimeRangeQuery: trq,
+		ExtentList:     timeseries.ExtentList{trq.Extent},
+		Results:        []*dataset.Result{{}},
+	}
+	lookup := make(map[string]*dataset.Series)
+
+	var ft *fluxTable
+	var inAnnotations bool
+	var section int
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(row) == 0 {
+			continue
+		}
+		if strings.HasPrefix(row[0], "#") {
+			if !inAnnotations {
+				ft = &fluxTable{}
+				inAnnotations = true
+				section++
+			}
+			switch row[0] {
+			case "#datatype":
+				ft.datatypes = row
+			case "#group":
+				ft.groups = row
+			case "#default":
+				ft.defaults = row
+			}
+			continue
+		}
+		inAnnotations = false
+		if ft == nil || ft.header == nil ||
+			(len(row) > 2 && row[1] == fluxColResult && row[2] == fluxColTable) {
+			if ft == nil || ft.header != nil {
+				ft = &fluxTable{}
+				section++
+			}
+			ft.header = row
+			if len(row) > 1 && row[1] == fluxColError {
+				// the next row describes the error encountered by the query
+				row, _ = cr.Read()
+				if len(row) > 1 {
+					return nil, fmt.Errorf("flux query error: %s", row[1])
+				}
+				return nil, timeseries.ErrInvalidBody
+			}
+			if err = ft.init(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, ft.value(row, ft.timeIdx))
+		if err != nil {
+			return nil, timeseries.ErrInvalidTimeFormat
+		}
+		key := fmt.Sprintf("%s.%d.%s", ft.value(row, ft.resultIdx), section, ft.value(row, ft.tableIdx))
+		s, ok := lookup[key]
+		if !ok {
+			sh := dataset.SeriesHeader{
+				Name:           ft.value(row, ft.resultIdx),
+				Tags:           make(dataset.Tags),
+				FieldsList:     make([]timeseries.FieldDefinition, len(ft.fieldDefs)),
+				TimestampIndex: ft.timeAt,
+				QueryStatement: trq.Statement,
+			}
+			copy(sh.FieldsList, ft.fieldDefs)
+			for _, i := range ft.tags {
+				sh.Tags[ft.header[i]] = ft.value(row, i)
+			}
+			sh.CalculateSize()
+			s = &dataset.Series{Header: sh, Points: make(dataset.Points, 0, 64)}
+			lookup[key] = s
+			ds.Results[0].SeriesList = append(ds.Results[0].SeriesList, s)
+		}
+
+		p := dataset.Point{
+			Epoch:  epoch.Epoch(t.UnixNano()),
+			Size:   12,
+			Values: make([]interface{}, len(ft.fields)),
+		}
+		for i, ci := range ft.fields {
+			fd := ft.fieldDefs[i]
+			if fd.Name == fluxColStart || fd.Name == fluxColStop {
+				continue
+			}
+			v, sz, err := parseFluxValue(fd, ft.value(row, ci))
+			if err != nil {
+				return nil, err
+			}
+			p.Values[i] = v
+			p.Size += sz
+		}
+		s.Points = append(s.Points, p)
+		s.PointSize += int64(p.Size)
+	}
+
+	for _, s := range ds.Results[0].SeriesList {
+		sort.Sort(s.Points)
+	}
+	return ds, nil
+}
+
+func formatFluxValue(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case bool:
+		return strconv.FormatBool(t)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case int:
+		return strconv.Itoa(t)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return ""
+}
+
+// fluxSchema returns a string uniquely identifying the CSV schema of the Series,
+// which determines when a new annotated section must be written
+func fluxSchema(s *dataset.Series) string {
+	sb := strings.Builder{}
+	for i, fd := range s.Header.FieldsList {
+		if i == s.Header.TimestampIndex {
+			sb.WriteString(fluxColTime + ",")
+		}
+		sb.WriteString(fmt.Sprintf("%s:%s:%d,", fd.Name, fd.SDataType, fd.ProviderData1))
+	}
+	return sb.String()
+}
+
+// marshalTimeseriesFluxCSV writes the DataSet as Flux Annotated CSV. Tables are
+// renumbered per result, and the _start and _stop columns are set to the time range
+// of the DataSet's points.
+func marshalTimeseriesFluxCSV(ds *dataset.DataSet, rlo *timeseries.RequestOptions,
+	status int, w io.Writer) error {
+	if ds == nil {
+		return nil
+	}
+	if rw, ok := w.(http.ResponseWriter); ok {
+		h := rw.Header()
+		h.Set(headers.NameContentType, "text/csv; charset=utf-8")
+		rw.WriteHeader(status)
+	}
+
+	var of byte
+	if rlo != nil {
+		of = rlo.OutputFormat
+	}
+
+	var min, max epoch.Epoch
+	for _, r := range ds.Results {
+		for _, s := range r.SeriesList {
+			if s == nil || len(s.Points) == 0 {
+				continue
+			}
+			if min == 0 || s.Points[0].Epoch < min {
+				min = s.Points[0].Epoch
+			}
+			if e := s.Points[len(s.Points)-1].Epoch; e > max {
+				max = e
+			}
+		}
+	}
+	start := time.Unix(0, int64(min)).UTC().Format(time.RFC3339Nano)
+	stop := time.Unix(0, int64(max)).UTC().Format(time.RFC3339Nano)
+
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	tables := make(map[string]int)
+	var schema string
+	for _, r := range ds.Results {
+		for _, s := range r.SeriesList {
+			if s == nil || len(s.Points) == 0 {
+				continue
+			}
+			fl := len(s.Header.FieldsList)
+			cols := fl + 4
+			defaultResult := of&FluxAnnotationDefault != 0
+			if sc := fluxSchema(s); sc != schema {
+				if schema != "" {
+					cw.Flush()
+					w.Write([]byte("\r\n"))
+				}
+				schema = sc
+				dt := append(make([]string, 0, cols), "#datatype", "string", "long")
+				gr := append(make([]string, 0, cols), "#group", "false", "false")
+				df := append(make([]string, 0, cols), "#default", s.Header.Name, "")
+				hd := append(make([]string, 0, cols), "", fluxColResult, fluxColTable)
+				for i, fd := range s.Header.FieldsList {
+					if i == s.Header.TimestampIndex {
+						dt = append(dt, "dateTime:RFC3339")
+						gr = append(gr, "false")
+						df = append(df, "")
+						hd = append(hd, fluxColTime)
+					}
+					dt = append(dt, fd.SDataType)
+					gr = append(gr, strconv.FormatBool(fd.ProviderData1 == fluxGroupKey))
+					df = append(df, "")
+					hd = append(hd, fd.Name)
+				}
+				if s.Header.TimestampIndex >= fl {
+					dt = append(dt, "dateTime:RFC3339")
+					gr = append(gr, "false")
+					df = append(df, "")
+					hd = append(hd, fluxColTime)
+				}
+				if of&FluxAnnotationDatatype != 0 {
+					cw.Write(dt)
+				}
+				if of&FluxAnnotationGroup != 0 {
+					cw.Write(gr)
+				}
+				if defaultResult {
+					cw.Write(df)
+				}
+				cw.Write(hd)
+			}
+
+			table := strconv.Itoa(tables[s.Header.Name])
+			tables[s.Header.Name]++
+			result := s.Header.Name
+			if defaultResult {
+				result = ""
+			}
+			row := make([]string, 0, cols)
+			for _, p := range s.Points {
+				row = append(row[:0], "", result, table)
+				for i, fd := range s.Header.FieldsList {
+					if i == s.Header.TimestampIndex {
+						row = append(row, time.Unix(0, int64(p.Epoch)).UTC().Format(time.RFC3339Nano))
+					}
+					switch {
+					case fd.Name == fluxColStart:
+						row = append(row, start)
+					case fd.Name == fluxColStop:
+						row = append(row, stop)
+					case i < len(p.Values):
+						row = append(row, formatFluxValue(p.Values[i]))
+					default:
+						row = append(row, "")
+					}
+				}
+				if s.Header.TimestampIndex >= fl {
+					row = append(row, time.Unix(0, int64(p.Epoch)).UTC().Format(time.RFC3339Nano))
+				}
+				cw.Write(row)
+			}
+		}
+	}
+	cw.Flush()
+	w.Write([]byte("\r\n"))
+	return cw.Error()
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+)
+
+const testFluxDoc01 = "#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,double,string,string,string\r\n" +
+	"#group,false,false,true,true,false,false,true,true,true\r\n" +
+	"#default,_result,,,,,,,,\r\n" +
+	",result,table,_start,_stop,_time,_value,_field,_measurement,host\r\n" +
+	",,0,2020-12-31T23:59:00Z,2021-01-01T00:02:00Z,2021-01-01T00:01:00Z,1.5,usage,cpu,a\r\n" +
+	",,0,2020-12-31T23:59:00Z,2021-01-01T00:02:00Z,2021-01-01T00:00:00Z,1,usage,cpu,a\r\n" +
+	",,1,2020-12-31T23:59:00Z,2021-01-01T00:02:00Z,2021-01-01T00:00:00Z,2,usage,cpu,b\r\n" +
+	"\r\n" +
+	"#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,long,string,string\r\n" +
+	"#group,false,false,true,true,false,false,true,true\r\n" +
+	"#default,_result,,,,,,,\r\n" +
+	",result,table,_start,_stop,_time,_value,_field,_measurement\r\n" +
+	",,2,2020-12-31T23:59:00Z,2021-01-01T00:02:00Z,2021-01-01T00:01:00Z,7,count,mem\r\n" +
+	"\r\n"
+
+const expectedFluxDoc01 = "#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,double,string,string,string\r\n" +
+	"#group,false,false,true,true,false,false,true,true,true\r\n" +
+	"#default,_result,,,,,,,,\r\n" +
+	",result,table,_start,_stop,_time,_value,_field,_measurement,host\r\n" +
+	",,0,2021-01-01T00:00:00Z,2021-01-01T00:01:00Z,2021-01-01T00:00:00Z,1,usage,cpu,a\r\n" +
+	",,0,2021-01-01T00:00:00Z,2021-01-01T00:01:00Z,2021-01-01T00:01:00Z,1.5,usage,cpu,a\r\n" +
+	",,1,2021-01-01T00:00:00Z,2021-01-01T00:01:00Z,2021-01-01T00:00:00Z,2,usage,cpu,b\r\n" +
+	"\r\n" +
+	"#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,long,string,string\r\n" +
+	"#group,false,false,true,true,false,false,true,true\r\n" +
+	"#default,_result,,,,,,,\r\n" +
+	",result,table,_start,_stop,_time,_value,_field,_measurement\r\n" +
+	",,2,2021-01-01T00:00:00Z,2021-01-01T00:01:00Z,2021-01-01T00:01:00Z,7,count,mem\r\n" +
+	"\r\n"
+
+const testFluxErrorDoc = "#datatype,string,string\r\n" +
+	"#group,true,true\r\n" +
+	"#default,,\r\n" +
+	",error,reference\r\n" +
+	",failed to execute query,897\r\n"
+
+func testFluxTRQ() *timeseries.TimeRangeQuery {
+	return &timeseries.TimeRangeQuery{
+		Statement: "flux",
+		Step:      time.Minute,
+		Extent: timeseries.Extent{Start: time.Unix(1609459200, 0),
+			End: time.Unix(1609459260, 0)},
+	}
+}
+
+func TestUnmarshalFluxCSV(t *testing.T) {
+
+	ts, err := UnmarshalTimeseries([]byte(testFluxDoc01), testFluxTRQ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := ts.(*dataset.DataSet)
+	if len(ds.Results) != 1 || len(ds.Results[0].SeriesList) != 3 {
+		t.Fatalf("unexpected results %v", ds.Results)
+	}
+	s := ds.Results[0].SeriesList[0]
+	if s.Header.Name != "_result" {
+		t.Errorf("expected %s got %s", "_result", s.Header.Name)
+	}
+	if s.Header.Tags["host"] != "a" || s.Header.Tags["_field"] != "usage" ||
+		len(s.Header.Tags) != 3 {
+		t.Errorf("unexpected tags %s", s.Header.Tags.String())
+	}
+	if s.Header.TimestampIndex != 2 {
+		t.Errorf("expected %d got %d", 2, s.Header.TimestampIndex)
+	}
+	if len(s.Points) != 2 || s.Points[0].Epoch >= s.Points[1].Epoch {
+		t.Errorf("expected 2 sorted points got %v", s.Points)
+	}
+	if v, ok := s.Points[1].Values[2].(float64); !ok || v != 1.5 {
+		t.Errorf("expected %f got %v", 1.5, s.Points[1].Values[2])
+	}
+	if v, ok := ds.Results[0].SeriesList[2].Points[0].Values[2].(int64); !ok || v != 7 {
+		t.Errorf("expected %d got %v", 7, ds.Results[0].SeriesList[2].Points[0].Values[2])
+	}
+
+	_, err = UnmarshalTimeseries([]byte(testFluxErrorDoc), testFluxTRQ())
+	if err == nil || !strings.Contains(err.Error(), "failed to execute query") {
+		t.Errorf("expected flux query error got %v", err)
+	}
+
+	_, err = UnmarshalTimeseries([]byte(",result,table,_value\r\n,_result,0,1\r\n"), testFluxTRQ())
+	if err != timeseries.ErrInvalidBody {
+		t.Errorf("expected %v got %v", timeseries.ErrInvalidBody, err)
+	}
+}
+
+func TestMarshalFluxCSV(t *testing.T) {
+
+	ts, err := UnmarshalTimeseries([]byte(testFluxDoc01), testFluxTRQ())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rlo := &timeseries.RequestOptions{OutputFormat: FluxOutputFormat |
+		FluxAnnotationDatatype | FluxAnnotationGroup | FluxAnnotationDefault}
+	b, err := MarshalTimeseries(ts, rlo, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != expectedFluxDoc01 {
+		t.Errorf("expected\n%s\ngot\n%s", expectedFluxDoc01, string(b))
+	}
+
+	// without annotations, each row carries its result name
+	rlo.OutputFormat = FluxOutputFormat
+	b, err = MarshalTimeseries(ts, rlo, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = ",result,table,_start,_stop,_time,_value,_field,_measurement,host\r\n" +
+		",_result,0,2021-01-01T00:00:00Z,2021-01-01T00:01:00Z,2021-01-01T00:00:00Z,1,usage,cpu,a\r\n"
+	if !strings.HasPrefix(string(b), expected) {
+		t.Errorf("expected prefix\n%s\ngot\n%s", expected, string(b))
+	}
+
+	// merged datasets remain a single table per group key
+	ts2, _ := UnmarshalTimeseries([]byte(testFluxDoc01), testFluxTRQ())
+	ts.Merge(true, ts2)
+	if ts.SeriesCount() != 3 {
+		t.Errorf("expected %d got %d", 3, ts.SeriesCount())
+	}
+}
@@ -57,6 +57,9 @@ func MarshalTimeseriesWriter(ts timeseries.Timeseries, rlo *timeseries.RequestOp
 	if rlo != nil {
 		of = rlo.OutputFormat
 	}
+	if of&FluxOutputFormat != 0 {
+		return marshalTimeseriesFluxCSV(ds, rlo, status, w)
+	}
 	marshaler, ok := marshalers[of]
 	if !ok {
 		return timeseries.ErrUnknownFormat
@@ -17,6 +17,7 @@
 package model
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"io"
@@ -42,8 +43,13 @@ func UnmarshalTimeseriesReader(reader io.Reader, trq *timeseries.TimeRangeQuery)
 	if trq == nil {
 		return nil, timeseries.ErrNoTimerangeQuery
 	}
+	// Flux responses are Annotated CSV, which begin with an annotation or header row
+	br := bufio.NewReader(reader)
+	if b, err := br.Peek(1); err == nil && (b[0] == '#' || b[0] == ',') {
+		return unmarshalFluxCSV(br, trq)
+	}
 	wfd := &WFDocument{}
-	d := json.NewDecoder(reader)
+	d := json.NewDecoder(br)
 	err := d.Decode(wfd)
 	if err != nil {
 		return nil, err
@@ -28,6 +28,7 @@ import (
 // Upstream Endpoints
 const (
 	mnQuery = "query"
+	mnFlux  = "api/v2/query"
 )
 
 // Common URL Parameter Names
@@ -39,9 +40,30 @@ const (
 	upChunked = "chunked"
 )
 
+// Flux URL Parameter Names and Request Body Fields
+const (
+	upOrg       = "org"
+	upOrgID     = "orgID"
+	upFluxQuery = "query"
+	upExtern    = "extern"
+	upParams    = "params"
+)
+
 // SetExtent will change the upstream request query to use the provided Extent
 func (c *Client) SetExtent(r *http.Request, trq *timeseries.TimeRangeQuery, extent *timeseries.Extent) {
 
+	if isFluxRequest(r) {
+		if trq.ParsedQuery == nil {
+			t2, _, _, err := parseFluxTimeRangeQuery(r)
+			if err != nil {
+				return
+			}
+			trq.ParsedQuery = t2.ParsedQuery
+		}
+		setFluxExtent(r, trq, extent)
+		return
+	}
+
 	v, _, _ := params.GetRequestValues(r)
 	if trq.ParsedQuery == nil {
 		t2, _, _, err := c.ParseTimeRangeQuery(r)
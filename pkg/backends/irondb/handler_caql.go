@@ -34,6 +34,7 @@ import (
 // CAQLHandler handles CAQL requests for timeseries data and processes them
 // through the delta proxy cache.
 func (c *Client) CAQLHandler(w http.ResponseWriter, r *http.Request) {
+	setMergeFunc(r)
 	r.URL = urls.BuildUpstreamURL(r, c.BaseUpstreamURL())
 	engines.DeltaProxyCacheRequest(w, r, c.Modeler())
 }
@@ -35,6 +35,7 @@ import (
 // FetchHandler handles requests for numeric timeseries data with specified
 // spans and processes them through the delta proxy cache.
 func (c *Client) FetchHandler(w http.ResponseWriter, r *http.Request) {
+	setMergeFunc(r)
 	r.URL = urls.BuildUpstreamURL(r, c.BaseUpstreamURL())
 	engines.DeltaProxyCacheRequest(w, r, c.Modeler())
 }
@@ -37,6 +37,7 @@ import (
 // HistogramHandler handles requests for historgam timeseries data and processes
 // them through the delta proxy cache.
 func (c *Client) HistogramHandler(w http.ResponseWriter, r *http.Request) {
+	setMergeFunc(r)
 	r.URL = urls.BuildUpstreamURL(r, c.BaseUpstreamURL())
 	engines.DeltaProxyCacheRequest(w, r, c.Modeler())
 }
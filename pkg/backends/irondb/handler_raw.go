@@ -29,6 +29,7 @@ import (
 // RawHandler handles requests for raw numeric timeseries data and processes
 // them through the delta proxy cache.
 func (c *Client) RawHandler(w http.ResponseWriter, r *http.Request) {
+	setMergeFunc(r)
 	r.URL = urls.BuildUpstreamURL(r, c.BaseUpstreamURL())
 	engines.DeltaProxyCacheRequest(w, r, c.Modeler())
 }
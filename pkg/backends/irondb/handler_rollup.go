@@ -33,6 +33,7 @@ import (
 // RollupHandler handles requests for numeric timeseries data with specified
 // spans and processes them through the delta proxy cache.
 func (c *Client) RollupHandler(w http.ResponseWriter, r *http.Request) {
+	setMergeFunc(r)
 	r.URL = urls.BuildUpstreamURL(r, c.BaseUpstreamURL())
 	engines.DeltaProxyCacheRequest(w, r, c.Modeler())
 }
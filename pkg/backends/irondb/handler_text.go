@@ -34,6 +34,7 @@ import (
 // TextHandler handles requests for text timeseries data and processes them
 // through the delta proxy cache.
 func (c *Client) TextHandler(w http.ResponseWriter, r *http.Request) {
+	setMergeFunc(r)
 	r.URL = urls.BuildUpstreamURL(r, c.BaseUpstreamURL())
 	engines.DeltaProxyCacheRequest(w, r, c.Modeler())
 }
@@ -25,10 +25,13 @@ import (
 	bo "github.com/trickstercache/trickster/pkg/backends/options"
 	"github.com/trickstercache/trickster/pkg/backends/providers/registration/types"
 	"github.com/trickstercache/trickster/pkg/cache"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+	"github.com/trickstercache/trickster/pkg/proxy/response/merge"
 	"github.com/trickstercache/trickster/pkg/timeseries"
 )
 
 var _ backends.TimeseriesBackend = (*Client)(nil)
+var _ backends.MergeableTimeseriesBackend = (*Client)(nil)
 
 // IRONdb API path segments.
 const (
@@ -112,3 +115,10 @@ func (c *Client) makeExtentSetters() {
 		"CAQLHandler":      c.caqlHandlerSetExtent,
 	}
 }
+
+// setMergeFunc provides a reconstitution function if the request is part of a scatter/gather
+func setMergeFunc(r *http.Request) {
+	if rsc := request.GetResources(r); rsc != nil && rsc.IsMergeMember {
+		rsc.ResponseMergeFunc = merge.Timeseries
+	}
+}
@@ -61,6 +61,8 @@ const (
 	DefaultTimeseriesShardSize = 0
 	// DefaultTimeseriesShardStep defines the default shard step of 0 (no sharding)
 	DefaultTimeseriesShardStep = 0
+	// DefaultTimeseriesShardConcurrency defines the default shard concurrency of 0 (unlimited)
+	DefaultTimeseriesShardConcurrency = 0
 )
 
 // DefaultCompressibleTypes returns a list of types that Trickster should compress before caching
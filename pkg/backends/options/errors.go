@@ -34,6 +34,19 @@ var ErrInvalidMaxShardSizeMS = errors.New(
 var ErrInvalidMaxShardSize = errors.New(
 	"'shard_max_size_ms' and 'shard_max_size_points' cannot both be non-zero")
 
+// ErrInvalidMaxShardConcurrency is an error for when 'shard_max_concurrency' is negative
+var ErrInvalidMaxShardConcurrency = errors.New("'shard_max_concurrency' must be >= 0")
+
+// ErrInvalidCanonicalSteps is an error for when 'canonical_steps_ms' includes a value <= 0
+var ErrInvalidCanonicalSteps = errors.New("'canonical_steps_ms' values must be > 0")
+
+// ErrInvalidTimeseriesChunkFactor is an error for when 'timeseries_chunk_factor' is negative
+var ErrInvalidTimeseriesChunkFactor = errors.New("'timeseries_chunk_factor' must be >= 0")
+
+// ErrInvalidTimeseriesCacheCodec is an error for when 'timeseries_cache_codec' is unsupported
+var ErrInvalidTimeseriesCacheCodec = errors.New(
+	"'timeseries_cache_codec' must be 'msgpack' or 'gorilla'")
+
 // ErrMissingProvider is an error type for missing provider
 type ErrMissingProvider struct {
 	error
@@ -26,13 +26,17 @@ import (
 	ho "github.com/trickstercache/trickster/pkg/backends/healthcheck/options"
 	prop "github.com/trickstercache/trickster/pkg/backends/prometheus/options"
 	ro "github.com/trickstercache/trickster/pkg/backends/rule/options"
+	wo "github.com/trickstercache/trickster/pkg/backends/warmer/options"
 	"github.com/trickstercache/trickster/pkg/cache/evictionmethods"
 	"github.com/trickstercache/trickster/pkg/cache/negative"
 	co "github.com/trickstercache/trickster/pkg/cache/options"
+	tgo "github.com/trickstercache/trickster/pkg/cache/tags/options"
 	"github.com/trickstercache/trickster/pkg/proxy/headers"
 	po "github.com/trickstercache/trickster/pkg/proxy/paths/options"
 	"github.com/trickstercache/trickster/pkg/proxy/request/rewriter"
 	to "github.com/trickstercache/trickster/pkg/proxy/tls/options"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+	tfo "github.com/trickstercache/trickster/pkg/timeseries/transformations/options"
 	"github.com/trickstercache/trickster/pkg/util/copiers"
 	"github.com/trickstercache/trickster/pkg/util/yamlx"
 
@@ -97,6 +101,14 @@ type Options struct {
 	// RevalidationFactor specifies how many times to multiply the object freshness lifetime
 	// by to calculate an absolute cache TTL
 	RevalidationFactor float64 `yaml:"revalidation_factor,omitempty"`
+	// StaleWhileRevalidateMS overrides the stale-while-revalidate window of objects cached by the
+	// Object Proxy Cache. When 0, the upstream Cache-Control directive is used. When < 0, objects are
+	// never served stale while being revalidated
+	StaleWhileRevalidateMS int `yaml:"stale_while_revalidate_ms,omitempty"`
+	// StaleIfErrorMS overrides the stale-if-error window of objects cached by the Object Proxy Cache.
+	// When 0, the upstream Cache-Control directive is used. When < 0, objects are never served
+	// stale when the upstream responds with an error
+	StaleIfErrorMS int `yaml:"stale_if_error_ms,omitempty"`
 	// MaxObjectSizeBytes specifies the max objectsize to be accepted for any given cache object
 	MaxObjectSizeBytes int `yaml:"max_object_size_bytes,omitempty"`
 	// CompressibleTypeList specifies the HTTP Object Content Types that will be compressed internally
@@ -122,11 +134,37 @@ type Options struct {
 	// shards are not aligned to the epoch at a specific step. MaxShardSizeMS must be perfectly
 	// divisible by ShardStepMS when both are > 0, or the configuration is invalid
 	ShardStepMS int `yaml:"shard_step_ms,omitempty"`
+	// MaxShardConcurrency defines the maximum number of sharded requests (or missing time ranges)
+	// for a single client request that are fetched from the origin concurrently. When set to 0,
+	// all shards are fetched concurrently
+	MaxShardConcurrency int `yaml:"shard_max_concurrency,omitempty"`
+	// CanonicalStepsMS is an opt-in list of canonical query steps in milliseconds. When set,
+	// the step of each time series query is normalized to the largest canonical step that is
+	// less than or equal to the requested step, so that queries with similar steps share
+	// a single cached object, which is resampled to the requested step when served
+	CanonicalStepsMS []int `yaml:"canonical_steps_ms,omitempty"`
+	// TimeseriesChunkFactor, when > 0, opts into chunked storage of cached time series, where
+	// each query's data is stored in epoch-aligned chunks spanning this many steps, so that
+	// only the chunks overlapping a request are read, and only changed chunks are written
+	TimeseriesChunkFactor int `yaml:"timeseries_chunk_factor,omitempty"`
+	// TimeseriesCacheCodec selects how cached time series are encoded for non-memory caches.
+	// Options are 'msgpack' (default) and 'gorilla', which compresses each series using
+	// delta-of-delta timestamps and XOR float values. Either encoding is always readable
+	TimeseriesCacheCodec string `yaml:"timeseries_cache_codec,omitempty"`
 
 	// ALBOptions holds the options for ALBs
 	ALBOptions *ao.Options `yaml:"alb,omitempty"`
 	// Prometheus holds options specific to prometheus backends
 	Prometheus *prop.Options `yaml:"prometheus,omitempty"`
+	// Warmer holds the options for warming frequently requested time series queries.
+	// When nil, the Query Warmer is disabled for this backend
+	Warmer *wo.Options `yaml:"warmer,omitempty"`
+	// Transformations is the ordered list of transformations applied to time series
+	// query results after they are retrieved from the cache, and before they are returned
+	Transformations tfo.List `yaml:"transformations,omitempty"`
+	// CacheTags holds the rules for tagging cached objects, so they can be purged by tag.
+	// When nil, objects are only tagged by the origin's Surrogate-Key response header
+	CacheTags *tgo.Options `yaml:"cache_tags,omitempty"`
 
 	// TLS is the TLS Configuration for the Frontend and Backend
 	TLS *to.Options `yaml:"tls,omitempty"`
@@ -138,6 +176,9 @@ type Options struct {
 	IsDefault bool `yaml:"is_default,omitempty"`
 	// FastForwardDisable indicates whether the FastForward feature should be disabled for this backend
 	FastForwardDisable bool `yaml:"fast_forward_disable,omitempty"`
+	// ServeStaleOnError, when true, indicates that when the upstream requests for the uncached portions of a
+	// time series request fail, the cached portion is returned to the client in place of the upstream error
+	ServeStaleOnError bool `yaml:"serve_stale_on_error,omitempty"`
 	// PathRoutingDisabled, when true, will bypass /backendName/path route registrations
 	PathRoutingDisabled bool `yaml:"path_routing_disabled,omitempty"`
 	// RequireTLS, when true, indicates this Backend Config's paths must only be registered with the TLS Router
@@ -202,6 +243,8 @@ type Options struct {
 	MaxShardSize time.Duration `yaml:"-"`
 	// ShardStep is the parsed version of ShardStepMS
 	ShardStep time.Duration `yaml:"-"`
+	// CanonicalSteps is the parsed version of CanonicalStepsMS
+	CanonicalSteps []time.Duration `yaml:"-"`
 
 	//
 	md yamlx.KeyLookup `yaml:"-"`
@@ -229,6 +272,7 @@ func New() *Options {
 		NegativeCacheName:            DefaultBackendNegativeCacheName,
 		Paths:                        make(map[string]*po.Options),
 		RevalidationFactor:           DefaultRevalidationFactor,
+		MaxShardConcurrency:          DefaultTimeseriesShardConcurrency,
 		MaxShardSizePoints:           DefaultTimeseriesShardSize,
 		MaxShardSizeMS:               DefaultTimeseriesShardSize,
 		MaxShardSize:                 time.Duration(DefaultTimeseriesShardSize) * time.Millisecond,
@@ -259,6 +303,7 @@ func (o *Options) Clone() *Options {
 	no.CacheKeyPrefix = o.CacheKeyPrefix
 	no.DoesShard = o.DoesShard
 	no.FastForwardDisable = o.FastForwardDisable
+	no.ServeStaleOnError = o.ServeStaleOnError
 	no.FastForwardTTL = o.FastForwardTTL
 	no.FastForwardTTLMS = o.FastForwardTTLMS
 	no.ForwardedHeaders = o.ForwardedHeaders
@@ -269,6 +314,8 @@ func (o *Options) Clone() *Options {
 	no.MaxIdleConns = o.MaxIdleConns
 	no.MaxTTLMS = o.MaxTTLMS
 	no.MaxTTL = o.MaxTTL
+	no.StaleWhileRevalidateMS = o.StaleWhileRevalidateMS
+	no.StaleIfErrorMS = o.StaleIfErrorMS
 	no.MaxObjectSizeBytes = o.MaxObjectSizeBytes
 	no.MultipartRangesDisabled = o.MultipartRangesDisabled
 	no.Provider = o.Provider
@@ -278,11 +325,22 @@ func (o *Options) Clone() *Options {
 	no.RevalidationFactor = o.RevalidationFactor
 	no.RuleName = o.RuleName
 	no.Scheme = o.Scheme
+	no.MaxShardConcurrency = o.MaxShardConcurrency
 	no.MaxShardSize = o.MaxShardSize
 	no.MaxShardSizeMS = o.MaxShardSizeMS
 	no.MaxShardSizePoints = o.MaxShardSizePoints
 	no.ShardStep = o.ShardStep
 	no.ShardStepMS = o.ShardStepMS
+	no.TimeseriesChunkFactor = o.TimeseriesChunkFactor
+	no.TimeseriesCacheCodec = o.TimeseriesCacheCodec
+	if o.CanonicalStepsMS != nil {
+		no.CanonicalStepsMS = make([]int, len(o.CanonicalStepsMS))
+		copy(no.CanonicalStepsMS, o.CanonicalStepsMS)
+	}
+	if o.CanonicalSteps != nil {
+		no.CanonicalSteps = make([]time.Duration, len(o.CanonicalSteps))
+		copy(no.CanonicalSteps, o.CanonicalSteps)
+	}
 	no.Timeout = o.Timeout
 	no.TimeoutMS = o.TimeoutMS
 	no.TimeseriesRetention = o.TimeseriesRetention
@@ -344,6 +402,16 @@ func (o *Options) Clone() *Options {
 		no.Prometheus = o.Prometheus.Clone()
 	}
 
+	if o.Warmer != nil {
+		no.Warmer = o.Warmer.Clone()
+	}
+
+	no.Transformations = o.Transformations.Clone()
+
+	if o.CacheTags != nil {
+		no.CacheTags = o.CacheTags.Clone()
+	}
+
 	return no
 }
 
@@ -389,6 +457,31 @@ func (l Lookup) Validate(ncl negative.Lookups) error {
 			return ErrInvalidMaxShardSizeMS
 		}
 
+		if o.MaxShardConcurrency < 0 {
+			return ErrInvalidMaxShardConcurrency
+		}
+
+		if o.TimeseriesChunkFactor < 0 {
+			return ErrInvalidTimeseriesChunkFactor
+		}
+
+		switch o.TimeseriesCacheCodec {
+		case "", dataset.CacheCodecMsgpack, dataset.CacheCodecGorilla:
+		default:
+			return ErrInvalidTimeseriesCacheCodec
+		}
+
+		o.CanonicalSteps = nil
+		if len(o.CanonicalStepsMS) > 0 {
+			o.CanonicalSteps = make([]time.Duration, len(o.CanonicalStepsMS))
+			for i, ms := range o.CanonicalStepsMS {
+				if ms <= 0 {
+					return ErrInvalidCanonicalSteps
+				}
+				o.CanonicalSteps[i] = time.Duration(ms) * time.Millisecond
+			}
+		}
+
 		if o.CompressibleTypeList != nil {
 			o.CompressibleTypes = make(map[string]interface{})
 			for _, v := range o.CompressibleTypeList {
@@ -581,6 +674,22 @@ func SetDefaults(
 		no.ShardStepMS = o.ShardStepMS
 	}
 
+	if metadata.IsDefined("backends", name, "shard_max_concurrency") {
+		no.MaxShardConcurrency = o.MaxShardConcurrency
+	}
+
+	if metadata.IsDefined("backends", name, "canonical_steps_ms") {
+		no.CanonicalStepsMS = o.CanonicalStepsMS
+	}
+
+	if metadata.IsDefined("backends", name, "timeseries_chunk_factor") {
+		no.TimeseriesChunkFactor = o.TimeseriesChunkFactor
+	}
+
+	if metadata.IsDefined("backends", name, "timeseries_cache_codec") {
+		no.TimeseriesCacheCodec = o.TimeseriesCacheCodec
+	}
+
 	if metadata.IsDefined("backends", name, "timeseries_retention_factor") {
 		no.TimeseriesRetentionFactor = o.TimeseriesRetentionFactor
 	}
@@ -604,10 +713,22 @@ func SetDefaults(
 		no.FastForwardTTLMS = o.FastForwardTTLMS
 	}
 
+	if metadata.IsDefined("backends", name, "stale_while_revalidate_ms") {
+		no.StaleWhileRevalidateMS = o.StaleWhileRevalidateMS
+	}
+
+	if metadata.IsDefined("backends", name, "stale_if_error_ms") {
+		no.StaleIfErrorMS = o.StaleIfErrorMS
+	}
+
 	if metadata.IsDefined("backends", name, "fast_forward_disable") {
 		no.FastForwardDisable = o.FastForwardDisable
 	}
 
+	if metadata.IsDefined("backends", name, "serve_stale_on_error") {
+		no.ServeStaleOnError = o.ServeStaleOnError
+	}
+
 	if metadata.IsDefined("backends", name, "backfill_tolerance_ms") {
 		no.BackfillToleranceMS = o.BackfillToleranceMS
 	}
@@ -631,6 +752,29 @@ func SetDefaults(
 		no.ALBOptions = opts
 	}
 
+	if metadata.IsDefined("backends", name, "warmer") {
+		opts, err := wo.SetDefaults(name, o.Warmer, metadata)
+		if err != nil {
+			return nil, err
+		}
+		no.Warmer = opts
+	}
+
+	if metadata.IsDefined("backends", name, "transformations") {
+		if err := o.Transformations.Validate(); err != nil {
+			return nil, err
+		}
+		no.Transformations = o.Transformations.Clone()
+	}
+
+	if metadata.IsDefined("backends", name, "cache_tags") {
+		opts, err := tgo.SetDefaults(name, o.CacheTags, metadata)
+		if err != nil {
+			return nil, err
+		}
+		no.CacheTags = opts
+	}
+
 	if metadata.IsDefined("backends", name, "negative_cache_name") {
 		no.NegativeCacheName = o.NegativeCacheName
 	}
@@ -51,6 +51,7 @@ backends:
     timeseries_retention_factor: 666
     timeseries_eviction_method: lru
     fast_forward_disable: true
+    serve_stale_on_error: true
     backfill_tolerance_ms: 301000
     backfill_tolerance_points: 2
     timeout_ms: 37000
@@ -60,6 +61,8 @@ backends:
     health_check_query: query=1234
     timeseries_ttl_ms: 8666000
     max_ttl_ms: 300000
+    stale_while_revalidate_ms: 10000
+    stale_if_error_ms: 60000
     fastforward_ttl_ms: 382000
     require_tls: true
     max_object_size_bytes: 999
@@ -71,6 +74,10 @@ backends:
     shard_max_size_ms: 0
     shard_max_size_points: 0
     shard_step_ms: 0
+    shard_max_concurrency: 0
+    canonical_steps_ms: [ 15000, 60000 ]
+    timeseries_chunk_factor: 240
+    timeseries_cache_codec: gorilla
     healthcheck:
       headers:
         Authorization: Basic SomeHash
@@ -94,6 +101,19 @@ backends:
     alb:
       methodology: rr
       pool: [ test ]
+    warmer:
+      top_n: 5
+      interval_ms: 15000
+    transformations:
+      - type: drop_series
+        label: job
+        regex: 'test.*'
+      - type: downsample
+        max_points: 100
+    cache_tags:
+      params: [ job ]
+      tenant_header: X-Scope-OrgID
+      metric_names: true
     tls:
       full_chain_cert_path: file.that.should.not.exist.ever.pem
       private_key_path: file.that.should.not.exist.ever.pem
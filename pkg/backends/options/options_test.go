@@ -322,6 +322,16 @@ func TestValidate(t *testing.T) {
 			},
 			expected: ErrInvalidMaxShardSizeMS,
 		},
+		{ // case 3 - MaxShardConcurrency must not be negative
+			to: to,
+			sw: []intSwapper{
+				{
+					location:  &o.MaxShardConcurrency,
+					testValue: -1,
+				},
+			},
+			expected: ErrInvalidMaxShardConcurrency,
+		},
 	}
 
 	for i, test := range tests2 {
@@ -345,6 +355,32 @@ func TestValidate(t *testing.T) {
 		})
 	}
 
+	err = Lookup(to.Backends).Validate(to.ncl)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(o.CanonicalSteps) != 2 || o.CanonicalSteps[1] != time.Minute {
+		t.Errorf("unexpected canonical steps %v", o.CanonicalSteps)
+	}
+	o.CanonicalStepsMS[0] = 0
+	err = Lookup(to.Backends).Validate(to.ncl)
+	o.CanonicalStepsMS[0] = 15000
+	if err != ErrInvalidCanonicalSteps {
+		t.Errorf("expected [%s] got [%v]", ErrInvalidCanonicalSteps, err)
+	}
+	o.TimeseriesChunkFactor = -1
+	err = Lookup(to.Backends).Validate(to.ncl)
+	o.TimeseriesChunkFactor = 240
+	if err != ErrInvalidTimeseriesChunkFactor {
+		t.Errorf("expected [%s] got [%v]", ErrInvalidTimeseriesChunkFactor, err)
+	}
+	o.TimeseriesCacheCodec = "invalid"
+	err = Lookup(to.Backends).Validate(to.ncl)
+	o.TimeseriesCacheCodec = "gorilla"
+	if err != ErrInvalidTimeseriesCacheCodec {
+		t.Errorf("expected [%s] got [%v]", ErrInvalidTimeseriesCacheCodec, err)
+	}
+
 }
 
 func TestSetDefaults(t *testing.T) {
@@ -356,16 +392,44 @@ func TestSetDefaults(t *testing.T) {
 
 	backends := Lookup{o.Name: o}
 
-	_, err = SetDefaults("test", o, o.md, nil, backends, map[string]interface{}{})
+	no, err := SetDefaults("test", o, o.md, nil, backends, map[string]interface{}{})
 	if err != nil {
 		t.Error(err)
 	}
+	if no.Warmer == nil || no.Warmer.TopN != 5 || no.Warmer.Interval != 15*time.Second {
+		t.Error("expected warmer options to be set")
+	}
+	if len(no.Transformations) != 2 || no.Transformations[0].CompiledRegex == nil {
+		t.Error("expected transformations to be set")
+	}
+	if len(no.CanonicalStepsMS) != 2 {
+		t.Error("expected canonical steps to be set")
+	}
+	if no.TimeseriesChunkFactor != 240 {
+		t.Error("expected timeseries chunk factor to be set")
+	}
+	if no.TimeseriesCacheCodec != "gorilla" {
+		t.Error("expected timeseries cache codec to be set")
+	}
+	if no.CacheTags == nil || len(no.CacheTags.Params) != 1 || !no.CacheTags.MetricNames ||
+		no.CacheTags.TenantHeader != "X-Scope-OrgID" {
+		t.Error("expected cache tag options to be set")
+	}
 
 	_, err = SetDefaults("test", o, nil, nil, backends, map[string]interface{}{})
 	if err != ErrInvalidMetadata {
 		t.Error("expected invalid metadata, got", err)
 	}
 
+	o3, err := fromYAML(strings.Replace(testYAML, "max_points: 100", "max_points: 0", 1))
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = SetDefaults("test", o3, o3.md, nil, backends, map[string]interface{}{})
+	if err == nil {
+		t.Error("expected error for invalid transformation")
+	}
+
 	o2, err := fromTestYAMLWithDefault()
 	if err != nil {
 		t.Error(err)
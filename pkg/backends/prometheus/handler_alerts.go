@@ -27,6 +27,9 @@ import (
 
 // AlertsHandler proxies requests for path /alerts to the origin by way of the object proxy cache
 func (c *Client) AlertsHandler(w http.ResponseWriter, r *http.Request) {
+	if c.rejectUnenforced(w) {
+		return
+	}
 
 	rsc := request.GetResources(r)
 	r.URL = urls.BuildUpstreamURL(r, c.BaseUpstreamURL())
@@ -0,0 +1,53 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"net/http"
+
+	"github.com/trickstercache/trickster/pkg/backends/prometheus/model"
+	"github.com/trickstercache/trickster/pkg/proxy/engines"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+	"github.com/trickstercache/trickster/pkg/proxy/urls"
+)
+
+// MetadataHandler proxies requests for path /metadata to the origin by way of the object proxy cache
+func (c *Client) MetadataHandler(w http.ResponseWriter, r *http.Request) {
+	if c.rejectUnenforced(w) {
+		return
+	}
+	rsc := request.GetResources(r)
+	if rsc.IsMergeMember {
+		rsc.ResponseMergeFunc = model.MergeAndWriteMetadata
+	}
+	r.URL = urls.BuildUpstreamURL(r, c.BaseUpstreamURL())
+	engines.ObjectProxyCacheRequest(w, r)
+}
+
+// TargetsMetadataHandler proxies requests for path /targets/metadata to the origin
+// by way of the object proxy cache
+func (c *Client) TargetsMetadataHandler(w http.ResponseWriter, r *http.Request) {
+	if c.rejectUnenforced(w) {
+		return
+	}
+	rsc := request.GetResources(r)
+	if rsc.IsMergeMember {
+		rsc.ResponseMergeFunc = model.MergeAndWriteTargetsMetadata
+	}
+	r.URL = urls.BuildUpstreamURL(r, c.BaseUpstreamURL())
+	engines.ObjectProxyCacheRequest(w, r)
+}
@@ -25,6 +25,9 @@ import (
 
 // ObjectProxyCacheHandler handles calls to /query (for instantaneous values)
 func (c *Client) ObjectProxyCacheHandler(w http.ResponseWriter, r *http.Request) {
+	if c.rejectUnenforced(w) {
+		return
+	}
 	r.URL = urls.BuildUpstreamURL(r, c.BaseUpstreamURL())
 	engines.ObjectProxyCacheRequest(w, r)
 }
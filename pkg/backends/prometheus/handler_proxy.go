@@ -26,6 +26,9 @@ import (
 // ProxyHandler sends a request through the basic reverse proxy to the origin,
 // and services non-cacheable Prometheus API calls.
 func (c *Client) ProxyHandler(w http.ResponseWriter, r *http.Request) {
+	if c.rejectUnenforced(w) {
+		return
+	}
 	r.URL = urls.BuildUpstreamURL(r, c.BaseUpstreamURL())
 	engines.DoProxy(w, r, true)
 }
@@ -36,6 +36,10 @@ func indicateTransoformations(timeseries.Timeseries) {}
 // QueryHandler handles calls to /query (for instantaneous values)
 func (c *Client) QueryHandler(w http.ResponseWriter, r *http.Request) {
 
+	if !c.enforceLabels(w, r, false) {
+		return
+	}
+
 	var err error
 	rsc := request.GetResources(r)
 
@@ -29,6 +29,10 @@ import (
 // Prometheus and processes them through the delta proxy cache
 func (c *Client) QueryRangeHandler(w http.ResponseWriter, r *http.Request) {
 
+	if !c.enforceLabels(w, r, false) {
+		return
+	}
+
 	// if this request is part of a scatter/gather, provide a reconstitution function
 	rsc := request.GetResources(r)
 	if rsc != nil {
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"net/http"
+
+	"github.com/trickstercache/trickster/pkg/backends/prometheus/model"
+	"github.com/trickstercache/trickster/pkg/encoding/profile"
+	"github.com/trickstercache/trickster/pkg/encoding/providers"
+	"github.com/trickstercache/trickster/pkg/proxy/engines"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+	"github.com/trickstercache/trickster/pkg/proxy/urls"
+)
+
+// RemoteReadHandler handles remote read requests and processes them through the delta proxy cache
+func (c *Client) RemoteReadHandler(w http.ResponseWriter, r *http.Request) {
+
+	// remote read responses are always snappy-encoded, and must be served to the client as-is
+	if ep := profile.FromContext(r.Context()); ep != nil {
+		ep.Supported |= providers.Snappy
+	}
+
+	if r.Method != http.MethodPost && !c.hasEnforcedLabels {
+		c.ProxyHandler(w, r)
+		return
+	}
+
+	rr, err := model.DecodeReadRequest(request.GetBody(r))
+	if c.hasEnforcedLabels {
+		// requests that cannot be decoded are never proxied unenforced
+		if err == nil {
+			err = c.enforceReadRequestLabels(r, rr)
+		}
+		var b []byte
+		if err == nil {
+			b, err = model.EncodeReadRequest(rr)
+		}
+		if err != nil {
+			writeBadData(w, err)
+			return
+		}
+		r = request.SetBody(r, b)
+	}
+	if err != nil || len(rr.Queries) == 0 {
+		c.ProxyHandler(w, r)
+		return
+	}
+
+	r.URL = urls.BuildUpstreamURL(r, c.BaseUpstreamURL())
+	engines.DeltaProxyCacheRequest(w, r, model.NewRemoteReadModeler(rr.Queries[0].StartTimestampMs,
+		rr.Queries[0].EndTimestampMs))
+}
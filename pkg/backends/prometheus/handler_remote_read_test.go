@@ -0,0 +1,146 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/backends/prometheus/model"
+	"github.com/trickstercache/trickster/pkg/encoding/providers"
+	"github.com/trickstercache/trickster/pkg/encoding/snappy"
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+	tu "github.com/trickstercache/trickster/pkg/util/testing"
+)
+
+func TestRemoteReadHandler(t *testing.T) {
+
+	end := time.Now().Add(-20 * time.Minute).Truncate(time.Minute)
+	start := end.Add(-10 * time.Minute)
+	startMs, endMs := timeToMS(start), timeToMS(end)
+
+	resp := &model.ReadResponse{Results: []*model.QueryResult{{}, {}}}
+	for i, qr := range resp.Results {
+		s := &model.TimeSeries{Labels: []model.Label{{Name: "__name__", Value: "up"}}}
+		// includes samples outside of the requested time range, which are not returned
+		for ts := startMs - 60000; ts <= endMs+30000; ts += 15000 {
+			s.Samples = append(s.Samples, model.Sample{Timestamp: ts, Value: float64(i)})
+		}
+		qr.Timeseries = []*model.TimeSeries{s}
+	}
+	rb, err := model.EncodeReadResponse(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backendClient, err := NewClient("test", nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	ts, w, r, _, err := tu.NewTestInstance("", backendClient.DefaultPathConfigs, 200, string(rb),
+		map[string]string{headers.NameContentEncoding: providers.SnappyValue},
+		"prometheus", "/api/v1/read", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	rsc := request.GetResources(r)
+	backendClient, err = NewClient("test", rsc.BackendOptions, nil, nil, nil, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	client := backendClient.(*Client)
+	rsc.BackendClient = client
+	rsc.BackendOptions.HTTPClient = backendClient.HTTPClient()
+
+	r2, _ := http.NewRequest(http.MethodPost, r.URL.String(),
+		bytes.NewReader(testRemoteReadBody(t, startMs, endMs)))
+	r2 = r2.WithContext(r.Context())
+
+	client.RemoteReadHandler(w, r2)
+	res := w.Result()
+	if res.StatusCode != 200 {
+		t.Errorf("expected 200 got %d.", res.StatusCode)
+	}
+	if res.Header.Get(headers.NameContentEncoding) != providers.SnappyValue {
+		t.Errorf("expected %s got %s", providers.SnappyValue, res.Header.Get(headers.NameContentEncoding))
+	}
+	b, _ := io.ReadAll(res.Body)
+	b, err = snappy.Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := &model.ReadResponse{}
+	if err = rr.Unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+	if len(rr.Results) != 2 {
+		t.Fatalf("expected %d got %d", 2, len(rr.Results))
+	}
+	for i, qr := range rr.Results {
+		if len(qr.Timeseries) != 1 {
+			t.Fatalf("expected %d got %d", 1, len(qr.Timeseries))
+		}
+		samples := qr.Timeseries[0].Samples
+		if len(samples) != 41 {
+			t.Errorf("expected %d got %d", 41, len(samples))
+		}
+		if samples[0].Timestamp != startMs || samples[len(samples)-1].Timestamp != endMs {
+			t.Errorf("unexpected samples range %d-%d", samples[0].Timestamp,
+				samples[len(samples)-1].Timestamp)
+		}
+		if samples[0].Value != float64(i) {
+			t.Errorf("expected %d got %f", i, samples[0].Value)
+		}
+	}
+}
+
+func TestRemoteReadHandlerProxy(t *testing.T) {
+
+	backendClient, err := NewClient("test", nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	ts, w, r, _, err := tu.NewTestInstance("", backendClient.DefaultPathConfigs, 200, "test",
+		nil, "prometheus", "/api/v1/read", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	rsc := request.GetResources(r)
+	backendClient, err = NewClient("test", rsc.BackendOptions, nil, nil, nil, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	client := backendClient.(*Client)
+	rsc.BackendClient = client
+	rsc.BackendOptions.HTTPClient = backendClient.HTTPClient()
+
+	client.RemoteReadHandler(w, r)
+	res := w.Result()
+	if res.StatusCode != 200 {
+		t.Errorf("expected 200 got %d.", res.StatusCode)
+	}
+	b, _ := io.ReadAll(res.Body)
+	if string(b) != "test" {
+		t.Errorf("expected 'test' got %s.", b)
+	}
+}
@@ -31,6 +31,10 @@ import (
 // SeriesHandler proxies requests for path /series to the origin by way of the object proxy cache
 func (c *Client) SeriesHandler(w http.ResponseWriter, r *http.Request) {
 
+	if !c.enforceLabels(w, r, true) {
+		return
+	}
+
 	// if this request is part of a scatter/gather, provide a reconstitution function
 	rsc := request.GetResources(r)
 	if rsc.IsMergeMember {
@@ -0,0 +1,169 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/trickstercache/trickster/pkg/backends/prometheus/model"
+	"github.com/trickstercache/trickster/pkg/proxy/params"
+)
+
+// validateLabelName returns an error if the provided name is not a valid Prometheus label name
+func validateLabelName(name string) error {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return fmt.Errorf("invalid enforced label name: %s", name)
+	}
+	for i := 0; i < len(name); i++ {
+		if name[i] == ':' || !isIdentChar(name[i]) {
+			return fmt.Errorf("invalid enforced label name: %s", name)
+		}
+	}
+	return nil
+}
+
+// enforcedLabelValues returns the enforced label names and values for the request, which are
+// sourced from the backend's configuration and the request headers
+func (c *Client) enforcedLabelValues(r *http.Request) (map[string]string, error) {
+	lv := make(map[string]string, len(c.enforcedLabels)+len(c.enforcedLabelHeaders))
+	for k, v := range c.enforcedLabels {
+		lv[k] = v
+	}
+	for k, h := range c.enforcedLabelHeaders {
+		v := r.Header.Get(h)
+		if v == "" {
+			return nil, fmt.Errorf("missing required header %s", h)
+		}
+		lv[k] = v
+	}
+	return lv, nil
+}
+
+// sortedLabelNames returns the sorted label names of the provided label values
+func sortedLabelNames(lv map[string]string) []string {
+	names := make([]string, 0, len(lv))
+	for k := range lv {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// matchersString returns the PromQL equality matchers for the provided label values
+func matchersString(lv map[string]string) string {
+	names := sortedLabelNames(lv)
+	for i, k := range names {
+		names[i] = k + "=" + strconv.Quote(lv[k])
+	}
+	return strings.Join(names, ",")
+}
+
+// enforceLabels injects the enforced label matchers into the PromQL statements of the request's
+// query and match[] parameters, before the request is cached or proxied. When addMatch is true,
+// a match[] parameter is added to requests without one. If the labels cannot be enforced, an
+// error response is written and false is returned.
+func (c *Client) enforceLabels(w http.ResponseWriter, r *http.Request, addMatch bool) bool {
+	if !c.hasEnforcedLabels {
+		return true
+	}
+	lv, err := c.enforcedLabelValues(r)
+	if err == nil {
+		m := matchersString(lv)
+		qp, _, _ := params.GetRequestValues(r)
+		if q, ok := qp[upQuery]; ok {
+			for i := range q {
+				if q[i], err = injectMatchers(q[i], m); err != nil {
+					break
+				}
+			}
+		}
+		if ms := qp[upMatch]; err == nil && len(ms) > 0 {
+			for i := range ms {
+				if ms[i], err = injectMatchers(ms[i], m); err != nil {
+					break
+				}
+			}
+		} else if err == nil && addMatch {
+			qp.Set(upMatch, "{"+m+"}")
+		}
+		if err == nil {
+			params.SetRequestValues(r, qp)
+			return true
+		}
+	}
+	writeBadData(w, err)
+	return false
+}
+
+// rejectUnenforced writes a 403 Forbidden response and returns true when the backend enforces
+// labels, so that endpoints whose requests cannot be enforced are never proxied to the origin
+func (c *Client) rejectUnenforced(w http.ResponseWriter) bool {
+	if !c.hasEnforcedLabels {
+		return false
+	}
+	e := &model.Envelope{
+		Status: "error",
+		Error:  "this endpoint is not available when labels are enforced",
+	}
+	e.StartMarshal(w, http.StatusForbidden)
+	w.Write([]byte("}"))
+	return true
+}
+
+// enforceReadRequestLabels adds the enforced label matchers to each query of the remote read
+// request, other than those the query already has
+func (c *Client) enforceReadRequestLabels(r *http.Request, rr *model.ReadRequest) error {
+	lv, err := c.enforcedLabelValues(r)
+	if err != nil {
+		return err
+	}
+	for _, q := range rr.Queries {
+		for _, k := range sortedLabelNames(lv) {
+			if hasMatcher(q.Matchers, k, lv[k]) {
+				continue
+			}
+			q.Matchers = append(q.Matchers,
+				&model.LabelMatcher{Type: model.MatchEqual, Name: k, Value: lv[k]})
+		}
+	}
+	return nil
+}
+
+// hasMatcher returns true if the matchers include an equality matcher for the label and value
+func hasMatcher(matchers []*model.LabelMatcher, name, value string) bool {
+	for _, m := range matchers {
+		if m != nil && m.Type == model.MatchEqual && m.Name == name && m.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// writeBadData writes a Prometheus bad_data error response for the provided error
+func writeBadData(w http.ResponseWriter, err error) {
+	e := &model.Envelope{
+		Status:    "error",
+		ErrorType: "bad_data",
+		Error:     err.Error(),
+	}
+	e.StartMarshal(w, http.StatusBadRequest)
+	w.Write([]byte("}"))
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	bo "github.com/trickstercache/trickster/pkg/backends/options"
+	"github.com/trickstercache/trickster/pkg/backends/prometheus/model"
+	po "github.com/trickstercache/trickster/pkg/backends/prometheus/options"
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+)
+
+func testEnforcingClient() *Client {
+	return &Client{
+		hasEnforcedLabels:    true,
+		enforcedLabels:       map[string]string{"namespace": "team-a"},
+		enforcedLabelHeaders: map[string]string{"tenant": headers.NameScopeOrgID},
+	}
+}
+
+func TestNewClientEnforcedLabels(t *testing.T) {
+	o := bo.New()
+	o.Prometheus = &po.Options{EnforcedLabels: map[string]string{"namespace": "team-a"}}
+	backendClient, err := NewClient("test", o, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !backendClient.(*Client).hasEnforcedLabels {
+		t.Error("expected true")
+	}
+
+	for _, name := range []string{"", "1a", "a-b", "a:b"} {
+		o.Prometheus = &po.Options{EnforcedLabelHeaders: map[string]string{name: "X-Test"}}
+		if _, err = NewClient("test", o, nil, nil, nil, nil); err == nil {
+			t.Errorf("expected error for label name %s", name)
+		}
+	}
+}
+
+func TestEnforceLabels(t *testing.T) {
+
+	c := testEnforcingClient()
+	const expected = `up{job="x",namespace="team-a",tenant="org\"1"}`
+
+	r, _ := http.NewRequest(http.MethodGet,
+		"http://0/api/v1/query?query="+url.QueryEscape(`up{job="x"}`), nil)
+	r.Header.Set(headers.NameScopeOrgID, `org"1`)
+	w := httptest.NewRecorder()
+	if !c.enforceLabels(w, r, false) {
+		t.Fatal("expected true")
+	}
+	if q := r.URL.Query().Get(upQuery); q != expected {
+		t.Errorf("expected %s got %s", expected, q)
+	}
+
+	// enforcing an enforced request, as when the warmer replays it, leaves it unchanged
+	if !c.enforceLabels(w, r, false) {
+		t.Fatal("expected true")
+	}
+	if q := r.URL.Query().Get(upQuery); q != expected {
+		t.Errorf("expected %s got %s", expected, q)
+	}
+
+	// form-encoded POST bodies are enforced
+	r, _ = http.NewRequest(http.MethodPost, "http://0/api/v1/query_range",
+		strings.NewReader("query="+url.QueryEscape(`up{job="x"}`)))
+	r.Header.Set(headers.NameContentType, headers.ValueXFormURLEncoded)
+	r.Header.Set(headers.NameScopeOrgID, `org"1`)
+	if !c.enforceLabels(w, r, false) {
+		t.Fatal("expected true")
+	}
+	r.PostForm = nil
+	r.ParseForm()
+	if q := r.PostForm.Get(upQuery); q != expected {
+		t.Errorf("expected %s got %s", expected, q)
+	}
+
+	// a match[] parameter is added when absent
+	r, _ = http.NewRequest(http.MethodGet, "http://0/api/v1/labels", nil)
+	r.Header.Set(headers.NameScopeOrgID, "org1")
+	if !c.enforceLabels(w, r, true) {
+		t.Fatal("expected true")
+	}
+	const expectedMatch = `{namespace="team-a",tenant="org1"}`
+	if m := r.URL.Query().Get(upMatch); m != expectedMatch {
+		t.Errorf("expected %s got %s", expectedMatch, m)
+	}
+	if !c.enforceLabels(w, r, true) {
+		t.Fatal("expected true")
+	}
+	if m := r.URL.Query()[upMatch]; len(m) != 1 || m[0] != expectedMatch {
+		t.Errorf("unexpected match[] values %v", m)
+	}
+
+	// each match[] parameter is enforced
+	r, _ = http.NewRequest(http.MethodGet, "http://0/api/v1/series?match[]=a&match[]=b", nil)
+	r.Header.Set(headers.NameScopeOrgID, "org1")
+	if !c.enforceLabels(w, r, true) {
+		t.Fatal("expected true")
+	}
+	if m := r.URL.Query()[upMatch]; len(m) != 2 || m[1] != "b"+expectedMatch {
+		t.Errorf("unexpected match[] values %v", m)
+	}
+
+	// requests missing the tenant header are rejected
+	r, _ = http.NewRequest(http.MethodGet, "http://0/api/v1/query?query=up", nil)
+	if c.enforceLabels(w, r, false) {
+		t.Error("expected false")
+	}
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d got %d", http.StatusBadRequest, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"errorType":"bad_data"`) {
+		t.Errorf("unexpected body %s", w.Body.String())
+	}
+
+	// as are queries that cannot be scanned
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest(http.MethodGet, "http://0/api/v1/query?query="+url.QueryEscape(`up{`), nil)
+	r.Header.Set(headers.NameScopeOrgID, "org1")
+	if c.enforceLabels(w, r, false) {
+		t.Error("expected false")
+	}
+
+	// clients without enforced labels are unaffected
+	r, _ = http.NewRequest(http.MethodGet, "http://0/api/v1/query?query=up", nil)
+	if !(&Client{}).enforceLabels(w, r, false) {
+		t.Error("expected true")
+	}
+	if q := r.URL.Query().Get(upQuery); q != "up" {
+		t.Errorf("expected %s got %s", "up", q)
+	}
+}
+
+func TestRemoteReadHandlerEnforcedLabels(t *testing.T) {
+
+	c := testEnforcingClient()
+	r, _ := http.NewRequest(http.MethodPost, "http://0/api/v1/read",
+		bytes.NewReader([]byte("invalid")))
+	r.Header.Set(headers.NameScopeOrgID, "org1")
+	w := httptest.NewRecorder()
+	c.RemoteReadHandler(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d got %d", http.StatusBadRequest, w.Code)
+	}
+
+	rr, _ := model.DecodeReadRequest(testRemoteReadBody(t, 0, 60000))
+	if err := c.enforceReadRequestLabels(r, rr); err != nil {
+		t.Fatal(err)
+	}
+	const expected = `{__name__="up",namespace="team-a",tenant="org1"}`
+	if s := rr.Queries[0].MatchersString(); s != expected {
+		t.Errorf("expected %s got %s", expected, s)
+	}
+	// enforcing an enforced request leaves it unchanged
+	if err := c.enforceReadRequestLabels(r, rr); err != nil {
+		t.Fatal(err)
+	}
+	if s := rr.Queries[0].MatchersString(); s != expected {
+		t.Errorf("expected %s got %s", expected, s)
+	}
+
+	r.Header.Del(headers.NameScopeOrgID)
+	if err := c.enforceReadRequestLabels(r, rr); err == nil {
+		t.Error("expected error for missing header")
+	}
+
+	// the enforced request body is what is parsed for caching
+	b, _ := model.EncodeReadRequest(rr)
+	r = request.SetBody(r, b)
+	trq, _, _, err := c.ParseTimeRangeQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(trq.Statement, expected) {
+		t.Errorf("expected %s in %s", expected, trq.Statement)
+	}
+}
+
+func TestRejectUnenforced(t *testing.T) {
+
+	c := testEnforcingClient()
+	handlers := map[string]http.HandlerFunc{
+		"/federate":                c.ProxyHandler,
+		"/api/v1/status/config":    c.ObjectProxyCacheHandler,
+		"/api/v1/metadata":         c.MetadataHandler,
+		"/api/v1/targets/metadata": c.TargetsMetadataHandler,
+		"/api/v1/rules":            c.RulesHandler,
+		"/api/v1/alerts":           c.AlertsHandler,
+	}
+	for path, h := range handlers {
+		t.Run(path, func(t *testing.T) {
+			r, _ := http.NewRequest(http.MethodGet, "http://0"+path, nil)
+			r.Header.Set(headers.NameScopeOrgID, "org1")
+			w := httptest.NewRecorder()
+			h(w, r)
+			if w.Code != http.StatusForbidden {
+				t.Errorf("expected %d got %d", http.StatusForbidden, w.Code)
+			}
+		})
+	}
+
+	if (&Client{}).rejectUnenforced(httptest.NewRecorder()) {
+		t.Error("expected false")
+	}
+}
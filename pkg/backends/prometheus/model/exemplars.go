@@ -0,0 +1,115 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/trickstercache/trickster/pkg/proxy/response/merge"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+)
+
+// WFExemplars is the Wire Format Document for the /query_exemplars endpoint
+type WFExemplars struct {
+	*Envelope
+	Data []WFExemplarSeries `json:"data"`
+}
+
+// WFExemplarSeries is the Wire Format Document for a series's exemplars in
+// /query_exemplars responses
+type WFExemplarSeries struct {
+	SeriesLabels map[string]string `json:"seriesLabels"`
+	Exemplars    []WFExemplar      `json:"exemplars"`
+}
+
+// WFExemplar is the Wire Format Document for the exemplar object in
+// /query_exemplars responses
+type WFExemplar struct {
+	Labels    map[string]string `json:"labels"`
+	Value     string            `json:"value"`
+	Timestamp json.Number       `json:"timestamp"`
+}
+
+func (e WFExemplar) key() string {
+	return dataset.Tags(e.Labels).String() + "|" + e.Value + "|" + e.Timestamp.String()
+}
+
+// Merge merges the passed WFExemplars into the subject WFExemplars; the exemplars
+// of series found in more than one document are de-duplicated and sorted by time
+func (e *WFExemplars) Merge(results ...*WFExemplars) {
+	m := make(map[string]int, len(e.Data))
+	for i, s := range e.Data {
+		m[dataset.Tags(s.SeriesLabels).String()] = i
+	}
+	for _, e2 := range results {
+		e.Envelope.Merge(e2.Envelope)
+		for _, s := range e2.Data {
+			k := dataset.Tags(s.SeriesLabels).String()
+			i, ok := m[k]
+			if !ok {
+				m[k] = len(e.Data)
+				e.Data = append(e.Data, s)
+				continue
+			}
+			s1 := &e.Data[i]
+			em := make(map[string]interface{}, len(s1.Exemplars))
+			for _, ex := range s1.Exemplars {
+				em[ex.key()] = nil
+			}
+			for _, ex := range s.Exemplars {
+				if _, ok := em[ex.key()]; !ok {
+					em[ex.key()] = nil
+					s1.Exemplars = append(s1.Exemplars, ex)
+				}
+			}
+			sort.SliceStable(s1.Exemplars, func(a, b int) bool {
+				ta, _ := s1.Exemplars[a].Timestamp.Float64()
+				tb, _ := s1.Exemplars[b].Timestamp.Float64()
+				return ta < tb
+			})
+		}
+	}
+}
+
+// MergeAndWriteExemplars merges the provided Responses into a single prometheus Exemplars data object,
+// and writes it to the provided ResponseWriter
+func MergeAndWriteExemplars(w http.ResponseWriter, r *http.Request, rgs merge.ResponseGates) {
+	var e *WFExemplars
+	statusCode, ok := mergeResponses(w, r, rgs, "exemplars", func(b []byte) error {
+		e1 := &WFExemplars{}
+		if err := json.Unmarshal(b, &e1); err != nil {
+			return err
+		}
+		if e == nil {
+			e = e1
+		} else {
+			e.Merge(e1)
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+	e.StartMarshal(w, statusCode)
+	if e.Data == nil {
+		e.Data = []WFExemplarSeries{}
+	}
+	writeData(w, e.Data)
+	w.Write([]byte("}")) // complete the envelope
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+	"github.com/trickstercache/trickster/pkg/proxy/response/merge"
+)
+
+// testBodyResponseGates returns a ResponseGate for each provided body, with a
+// status code of 200, or 400 for bodies prefixed with "!"
+func testBodyResponseGates(bodies ...string) merge.ResponseGates {
+	rgs := make(merge.ResponseGates, len(bodies))
+	for i, b := range bodies {
+		code := http.StatusOK
+		if strings.HasPrefix(b, "!") {
+			code = http.StatusBadRequest
+			b = b[1:]
+		}
+		rsc := request.NewResources(nil, nil, nil, nil, nil, nil, nil)
+		rsc.Response = &http.Response{
+			Body:       io.NopCloser(strings.NewReader(b)),
+			StatusCode: code,
+		}
+		rgs[i] = merge.NewResponseGate(nil, nil, rsc)
+		rgs[i].Write([]byte(b))
+	}
+	return rgs
+}
+
+const testExemplars1 = `{"status":"success","data":[{"seriesLabels":{"__name__":"a","job":"j1"},` +
+	`"exemplars":[{"labels":{"trace_id":"t2"},"value":"2","timestamp":1600000060.5}]}]}`
+
+const testExemplars2 = `{"status":"success","warnings":["w1"],"data":[` +
+	`{"seriesLabels":{"__name__":"a","job":"j1"},"exemplars":[` +
+	`{"labels":{"trace_id":"t1"},"value":"1","timestamp":1600000000.123},` +
+	`{"labels":{"trace_id":"t2"},"value":"2","timestamp":1600000060.5}]},` +
+	`{"seriesLabels":{"__name__":"b"},"exemplars":[]}]}`
+
+func TestMergeExemplars(t *testing.T) {
+	e1, e2 := &WFExemplars{}, &WFExemplars{}
+	json.Unmarshal([]byte(testExemplars1), &e1)
+	json.Unmarshal([]byte(testExemplars2), &e2)
+	e1.Merge(e2)
+	if len(e1.Data) != 2 {
+		t.Fatalf("expected %d got %d", 2, len(e1.Data))
+	}
+	if len(e1.Data[0].Exemplars) != 2 || e1.Data[0].Exemplars[0].Value != "1" {
+		t.Errorf("unexpected exemplars %v", e1.Data[0].Exemplars)
+	}
+	if len(e1.Warnings) != 1 {
+		t.Errorf("expected %d got %d", 1, len(e1.Warnings))
+	}
+}
+
+func TestMergeAndWriteExemplars(t *testing.T) {
+
+	tests := []struct {
+		rgs     merge.ResponseGates
+		expCode int
+		expBody string
+	}{
+		{nil, http.StatusBadGateway, ""},
+		{testBodyResponseGates(testExemplars1, `{"stat`, testExemplars2), http.StatusOK,
+			`"exemplars":[{"labels":{"trace_id":"t1"},"value":"1","timestamp":1600000000.123},`},
+		{testBodyResponseGates(`!{"status":"error"}`), http.StatusBadRequest, `{"status":"error"}`},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			MergeAndWriteExemplars(w, nil, test.rgs)
+			if w.Code != test.expCode {
+				t.Errorf("expected %d got %d", test.expCode, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), test.expBody) {
+				t.Errorf("expected %s in %s", test.expBody, w.Body.String())
+			}
+			if w.Code == http.StatusOK && !json.Valid(w.Body.Bytes()) {
+				t.Errorf("invalid json %s", w.Body.String())
+			}
+		})
+	}
+}
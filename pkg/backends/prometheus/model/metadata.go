@@ -0,0 +1,158 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/trickstercache/trickster/pkg/proxy/response/merge"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+)
+
+// WFMetadata is the Wire Format Document for the /metadata endpoint
+type WFMetadata struct {
+	*Envelope
+	Data map[string][]WFMetricMetadata `json:"data"`
+}
+
+// WFMetricMetadata is the Wire Format Document for a metric's metadata in
+// /metadata responses
+type WFMetricMetadata struct {
+	Type string `json:"type"`
+	Help string `json:"help"`
+	Unit string `json:"unit"`
+}
+
+// Merge merges the passed WFMetadata into the subject WFMetadata
+func (md *WFMetadata) Merge(results ...*WFMetadata) {
+	if md.Data == nil {
+		md.Data = make(map[string][]WFMetricMetadata)
+	}
+	for _, md2 := range results {
+		md.Envelope.Merge(md2.Envelope)
+		for metric, l := range md2.Data {
+			l1 := md.Data[metric]
+			for _, d := range l {
+				var found bool
+				for _, d1 := range l1 {
+					if d == d1 {
+						found = true
+						break
+					}
+				}
+				if !found {
+					l1 = append(l1, d)
+				}
+			}
+			md.Data[metric] = l1
+		}
+	}
+}
+
+// MergeAndWriteMetadata merges the provided Responses into a single prometheus Metadata data object,
+// and writes it to the provided ResponseWriter
+func MergeAndWriteMetadata(w http.ResponseWriter, r *http.Request, rgs merge.ResponseGates) {
+	var md *WFMetadata
+	statusCode, ok := mergeResponses(w, r, rgs, "metadata", func(b []byte) error {
+		md1 := &WFMetadata{}
+		if err := json.Unmarshal(b, &md1); err != nil {
+			return err
+		}
+		if md == nil {
+			md = md1
+		} else {
+			md.Merge(md1)
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+	md.StartMarshal(w, statusCode)
+	if md.Data == nil {
+		md.Data = map[string][]WFMetricMetadata{}
+	}
+	writeData(w, md.Data)
+	w.Write([]byte("}")) // complete the envelope
+}
+
+// WFTargetsMetadata is the Wire Format Document for the /targets/metadata endpoint
+type WFTargetsMetadata struct {
+	*Envelope
+	Data []WFTargetMetadata `json:"data"`
+}
+
+// WFTargetMetadata is the Wire Format Document for a target's metric metadata in
+// /targets/metadata responses
+type WFTargetMetadata struct {
+	Target map[string]string `json:"target"`
+	Metric string            `json:"metric,omitempty"`
+	Type   string            `json:"type"`
+	Help   string            `json:"help"`
+	Unit   string            `json:"unit"`
+}
+
+func (t WFTargetMetadata) key() string {
+	return dataset.Tags(t.Target).String() + "|" + t.Metric + "|" + t.Type + "|" +
+		t.Help + "|" + t.Unit
+}
+
+// Merge merges the passed WFTargetsMetadata into the subject WFTargetsMetadata
+func (tm *WFTargetsMetadata) Merge(results ...*WFTargetsMetadata) {
+	m := make(map[string]interface{}, len(tm.Data))
+	for _, d := range tm.Data {
+		m[d.key()] = nil
+	}
+	for _, tm2 := range results {
+		tm.Envelope.Merge(tm2.Envelope)
+		for _, d := range tm2.Data {
+			k := d.key()
+			if _, ok := m[k]; !ok {
+				m[k] = nil
+				tm.Data = append(tm.Data, d)
+			}
+		}
+	}
+}
+
+// MergeAndWriteTargetsMetadata merges the provided Responses into a single prometheus
+// Targets Metadata data object, and writes it to the provided ResponseWriter
+func MergeAndWriteTargetsMetadata(w http.ResponseWriter, r *http.Request, rgs merge.ResponseGates) {
+	var tm *WFTargetsMetadata
+	statusCode, ok := mergeResponses(w, r, rgs, "targets metadata", func(b []byte) error {
+		tm1 := &WFTargetsMetadata{}
+		if err := json.Unmarshal(b, &tm1); err != nil {
+			return err
+		}
+		if tm == nil {
+			tm = tm1
+		} else {
+			tm.Merge(tm1)
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+	tm.StartMarshal(w, statusCode)
+	if tm.Data == nil {
+		tm.Data = []WFTargetMetadata{}
+	}
+	writeData(w, tm.Data)
+	w.Write([]byte("}")) // complete the envelope
+}
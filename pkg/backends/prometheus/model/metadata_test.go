@@ -0,0 +1,83 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testMetadata1 = `{"status":"success","data":{"up":[{"type":"gauge","help":"up","unit":""}]}}`
+
+const testMetadata2 = `{"status":"success","data":{"up":[{"type":"gauge","help":"up","unit":""}],` +
+	`"go_goroutines":[{"type":"gauge","help":"goroutines","unit":""}]}}`
+
+func TestMergeAndWriteMetadata(t *testing.T) {
+	w := httptest.NewRecorder()
+	MergeAndWriteMetadata(w, nil, testBodyResponseGates(testMetadata1, testMetadata2))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, w.Code)
+	}
+	md := &WFMetadata{}
+	if err := json.Unmarshal(w.Body.Bytes(), &md); err != nil {
+		t.Fatal(err)
+	}
+	if len(md.Data) != 2 || len(md.Data["up"]) != 1 {
+		t.Errorf("unexpected metadata %v", md.Data)
+	}
+
+	w = httptest.NewRecorder()
+	MergeAndWriteMetadata(w, nil, nil)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected %d got %d", http.StatusBadGateway, w.Code)
+	}
+}
+
+const testTargetsMetadata1 = `{"status":"success","data":[{"target":{"instance":"i1","job":"j1"},` +
+	`"metric":"up","type":"gauge","help":"up","unit":""}]}`
+
+const testTargetsMetadata2 = `{"status":"success","data":[{"target":{"instance":"i1","job":"j1"},` +
+	`"metric":"up","type":"gauge","help":"up","unit":""},{"target":{"instance":"i2","job":"j1"},` +
+	`"metric":"up","type":"gauge","help":"up","unit":""}]}`
+
+func TestMergeAndWriteTargetsMetadata(t *testing.T) {
+	w := httptest.NewRecorder()
+	MergeAndWriteTargetsMetadata(w, nil,
+		testBodyResponseGates(testTargetsMetadata1, testTargetsMetadata2))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, w.Code)
+	}
+	tm := &WFTargetsMetadata{}
+	if err := json.Unmarshal(w.Body.Bytes(), &tm); err != nil {
+		t.Fatal(err)
+	}
+	if len(tm.Data) != 2 {
+		t.Errorf("expected %d got %d", 2, len(tm.Data))
+	}
+
+	w = httptest.NewRecorder()
+	MergeAndWriteTargetsMetadata(w, nil, testBodyResponseGates(`!{"status":"error"}`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d got %d", http.StatusBadRequest, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "error") {
+		t.Errorf("unexpected body %s", w.Body.String())
+	}
+}
@@ -17,12 +17,18 @@
 package model
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 
+	"github.com/trickstercache/trickster/pkg/observability/logging"
+	"github.com/trickstercache/trickster/pkg/proxy/handlers"
 	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/proxy/response/merge"
 )
 
 // Envelope represents a Proemtheus Response Envelope Root Type
@@ -80,3 +86,67 @@ func (e *Envelope) Merge(e2 *Envelope) {
 	}
 
 }
+
+// mergeResponses passes the body of each successful member of the provided ResponseGates
+// to the provided merge function, and returns the lowest status code of all members. When
+// no member was successfully merged, the best member response is written to the provided
+// ResponseWriter instead, and ok is returned as false.
+func mergeResponses(w http.ResponseWriter, r *http.Request, rgs merge.ResponseGates,
+	docType string, mergeFunc func([]byte) error) (statusCode int, ok bool) {
+
+	responses := make([]int, len(rgs))
+	var bestResp *http.Response
+
+	for i, rg := range rgs {
+		if rg == nil {
+			continue
+		}
+		if rg.Resources != nil && rg.Resources.Response != nil {
+			resp := rg.Resources.Response
+			responses[i] = resp.StatusCode
+
+			if resp.Body != nil {
+				defer resp.Body.Close()
+			}
+
+			if resp.StatusCode < 400 {
+				if err := mergeFunc(rg.Body()); err != nil {
+					logging.Error(rg.Resources.Logger, docType+" unmarshaling error",
+						logging.Pairs{"provider": "prometheus", "detail": err.Error()})
+				} else {
+					ok = true
+				}
+			}
+			if bestResp == nil || resp.StatusCode < bestResp.StatusCode {
+				bestResp = resp
+				resp.Body = io.NopCloser(bytes.NewReader(rg.Body()))
+			}
+		}
+	}
+
+	if !ok || len(responses) == 0 {
+		if bestResp != nil {
+			h := w.Header()
+			headers.Merge(h, bestResp.Header)
+			w.WriteHeader(bestResp.StatusCode)
+			io.Copy(w, bestResp.Body)
+		} else {
+			handlers.HandleBadGateway(w, r)
+		}
+		return 0, false
+	}
+
+	sort.Ints(responses)
+	return responses[0], true
+}
+
+// writeData writes the provided data as the data block of an envelope
+func writeData(w io.Writer, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	w.Write([]byte(`,"data":`))
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,223 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/trickstercache/trickster/pkg/encoding/providers"
+	"github.com/trickstercache/trickster/pkg/encoding/snappy"
+	"github.com/trickstercache/trickster/pkg/errors"
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+	"github.com/trickstercache/trickster/pkg/timeseries/epoch"
+)
+
+// DecodeReadRequest decodes the provided snappy-encoded remote read request body
+func DecodeReadRequest(b []byte) (*ReadRequest, error) {
+	b, err := snappy.Decode(b)
+	if err != nil {
+		return nil, err
+	}
+	rr := &ReadRequest{}
+	if err = rr.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return rr, nil
+}
+
+// EncodeReadRequest returns the snappy-encoded remote read request body for the provided ReadRequest
+func EncodeReadRequest(rr *ReadRequest) ([]byte, error) {
+	return snappy.Encode(rr.Marshal())
+}
+
+// NewRemoteReadModeler returns a collection of modeling functions for remote read
+// responses. Only samples between the provided start and end epoch milliseconds
+// (inclusive) are included in the marshaled responses.
+func NewRemoteReadModeler(startMs, endMs int64) *timeseries.Modeler {
+	mw := func(ts timeseries.Timeseries, rlo *timeseries.RequestOptions, status int, w io.Writer) error {
+		return marshalRemoteReadWriter(ts, status, w, startMs, endMs)
+	}
+	return &timeseries.Modeler{
+		WireUnmarshalerReader: UnmarshalRemoteReadReader,
+		WireUnmarshaler:       UnmarshalRemoteRead,
+		WireMarshalWriter:     mw,
+		WireMarshaler: func(ts timeseries.Timeseries, rlo *timeseries.RequestOptions,
+			status int) ([]byte, error) {
+			buf := bytes.NewBuffer(nil)
+			err := mw(ts, rlo, status, buf)
+			return buf.Bytes(), err
+		},
+		CacheMarshaler:   dataset.MarshalDataSet,
+		CacheUnmarshaler: dataset.UnmarshalDataSet,
+	}
+}
+
+// UnmarshalRemoteRead converts a remote read response into a Timeseries
+func UnmarshalRemoteRead(data []byte, trq *timeseries.TimeRangeQuery) (timeseries.Timeseries, error) {
+	return UnmarshalRemoteReadReader(bytes.NewReader(data), trq)
+}
+
+// UnmarshalRemoteReadReader converts a remote read response into a Timeseries via io.Reader.
+// The response may be either snappy-encoded or already decoded.
+func UnmarshalRemoteReadReader(reader io.Reader, trq *timeseries.TimeRangeQuery) (timeseries.Timeseries, error) {
+	if trq == nil {
+		return nil, timeseries.ErrNoTimerangeQuery
+	}
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	rr := &ReadResponse{}
+	if err = rr.Unmarshal(b); err != nil {
+		if b, err = snappy.Decode(b); err != nil {
+			return nil, timeseries.ErrInvalidBody
+		}
+		rr = &ReadResponse{}
+		if err = rr.Unmarshal(b); err != nil {
+			return nil, err
+		}
+	}
+
+	statements := strings.Split(trq.Statement, "\n")
+	ds := &dataset.DataSet{
+		Results:        make([]*dataset.Result, len(rr.Results)),
+		TimeRangeQuery: trq,
+		ExtentList:     timeseries.ExtentList{trq.Extent},
+	}
+	for i, qr := range rr.Results {
+		r := &dataset.Result{
+			StatementID: i,
+			SeriesList:  make([]*dataset.Series, len(qr.Timeseries)),
+		}
+		for j, ts := range qr.Timeseries {
+			sh := dataset.SeriesHeader{
+				Tags:       make(dataset.Tags, len(ts.Labels)),
+				FieldsList: []timeseries.FieldDefinition{{Name: "value", DataType: timeseries.Float64}},
+			}
+			if i < len(statements) {
+				sh.QueryStatement = statements[i]
+			}
+			for _, l := range ts.Labels {
+				sh.Tags[l.Name] = l.Value
+			}
+			sh.Name = sh.Tags["__name__"]
+			sh.CalculateSize()
+			pts := make(dataset.Points, len(ts.Samples))
+			for k, s := range ts.Samples {
+				pts[k] = dataset.Point{
+					Epoch:  epoch.Epoch(s.Timestamp * 1000000),
+					Size:   32, // 8 bytes for epoch, 8 bytes for size, 16 bytes for the value interface
+					Values: []interface{}{s.Value},
+				}
+			}
+			r.SeriesList[j] = &dataset.Series{
+				Header:    sh,
+				Points:    pts,
+				PointSize: pts.Size(),
+			}
+		}
+		ds.Results[i] = r
+	}
+	return ds, nil
+}
+
+// marshalRemoteReadWriter writes the Timeseries to the provided io.Writer as a
+// snappy-encoded remote read response
+func marshalRemoteReadWriter(ts timeseries.Timeseries, status int, w io.Writer,
+	startMs, endMs int64) error {
+
+	if w == nil {
+		return errors.ErrNilWriter
+	}
+	ds, ok := ts.(*dataset.DataSet)
+	if !ok || ds == nil {
+		return timeseries.ErrUnknownFormat
+	}
+
+	var l int
+	for _, r := range ds.Results {
+		if r != nil && r.StatementID >= l {
+			l = r.StatementID + 1
+		}
+	}
+	rr := &ReadResponse{Results: make([]*QueryResult, l)}
+	for i := range rr.Results {
+		rr.Results[i] = &QueryResult{}
+	}
+
+	start := epoch.Epoch(startMs * 1000000)
+	end := epoch.Epoch(endMs * 1000000)
+	for _, r := range ds.Results {
+		if r == nil {
+			continue
+		}
+		qr := rr.Results[r.StatementID]
+		for _, s := range r.SeriesList {
+			if s == nil {
+				continue
+			}
+			t := &TimeSeries{
+				Labels:  make([]Label, 0, len(s.Header.Tags)),
+				Samples: make([]Sample, 0, len(s.Points)),
+			}
+			for _, k := range s.Header.Tags.Keys() {
+				t.Labels = append(t.Labels, Label{Name: k, Value: s.Header.Tags[k]})
+			}
+			for _, p := range s.Points {
+				if p.Epoch < start || p.Epoch > end || len(p.Values) == 0 {
+					continue
+				}
+				if v, ok := p.Values[0].(float64); ok {
+					t.Samples = append(t.Samples, Sample{Timestamp: int64(p.Epoch) / 1000000, Value: v})
+				}
+			}
+			if len(t.Samples) == 0 {
+				continue
+			}
+			sort.Slice(t.Samples, func(a, b int) bool {
+				return t.Samples[a].Timestamp < t.Samples[b].Timestamp
+			})
+			qr.Timeseries = append(qr.Timeseries, t)
+		}
+	}
+
+	b, err := EncodeReadResponse(rr)
+	if err != nil {
+		return err
+	}
+	if rw, ok := w.(http.ResponseWriter); ok {
+		h := rw.Header()
+		h.Set(headers.NameContentType, headers.ValueApplicationProtobuf)
+		h.Set(headers.NameContentEncoding, providers.SnappyValue)
+		h.Del(headers.NameContentLength)
+		rw.WriteHeader(status)
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// EncodeReadResponse returns the snappy-encoded remote read response body for the
+// provided ReadResponse
+func EncodeReadResponse(rr *ReadResponse) ([]byte, error) {
+	return snappy.Encode(rr.Marshal())
+}
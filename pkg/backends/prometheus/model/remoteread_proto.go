@@ -0,0 +1,467 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// This file implements the subset of the Prometheus remote read protocol
+// (prompb) messages used by Trickster, as documented at
+// https://github.com/prometheus/prometheus/blob/main/prompb/remote.proto
+
+// ResponseTypeSamples is the remote read response type that returns raw samples,
+// and is the only response type supported by Trickster
+const ResponseTypeSamples = 0
+
+// Label Matcher Types
+const (
+	MatchEqual = iota
+	MatchNotEqual
+	MatchRegexp
+	MatchNotRegexp
+)
+
+var matchOperators = []string{"=", "!=", "=~", "!~"}
+
+// ReadRequest represents a remote read request
+type ReadRequest struct {
+	Queries               []*Query
+	AcceptedResponseTypes []int32
+}
+
+// Query represents a single query in a remote read request
+type Query struct {
+	StartTimestampMs int64
+	EndTimestampMs   int64
+	Matchers         []*LabelMatcher
+	Hints            *ReadHints
+}
+
+// LabelMatcher represents a label matcher of a remote read query
+type LabelMatcher struct {
+	Type  int32
+	Name  string
+	Value string
+}
+
+// ReadHints represents the hints of a remote read query
+type ReadHints struct {
+	StepMs   int64
+	Func     string
+	StartMs  int64
+	EndMs    int64
+	Grouping []string
+	By       bool
+	RangeMs  int64
+}
+
+// ReadResponse represents a remote read response
+type ReadResponse struct {
+	Results []*QueryResult
+}
+
+// QueryResult represents the results of a single query in a remote read response
+type QueryResult struct {
+	Timeseries []*TimeSeries
+}
+
+// TimeSeries represents a series in a remote read query result
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// Label represents a series label
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample represents a series sample
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// field is a single decoded protobuf field
+type field struct {
+	num protowire.Number
+	typ protowire.Type
+	v   uint64
+	b   []byte
+}
+
+// consumeFields decodes each field in the provided message and passes it to fn
+func consumeFields(b []byte, fn func(field) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		f := field{num: num, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.v, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			f.v, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			f.v = uint64(v)
+		case protowire.BytesType:
+			f.b, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendVarintField(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendStringField(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendMessageField(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+// Unmarshal decodes the provided protobuf message into the ReadRequest
+func (rr *ReadRequest) Unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			q := &Query{}
+			if err := q.Unmarshal(f.b); err != nil {
+				return err
+			}
+			rr.Queries = append(rr.Queries, q)
+		case 2:
+			if f.typ == protowire.VarintType {
+				rr.AcceptedResponseTypes = append(rr.AcceptedResponseTypes, int32(f.v))
+				return nil
+			}
+			// packed repeated enum
+			for b := f.b; len(b) > 0; {
+				v, n := protowire.ConsumeVarint(b)
+				if n < 0 {
+					return protowire.ParseError(n)
+				}
+				rr.AcceptedResponseTypes = append(rr.AcceptedResponseTypes, int32(v))
+				b = b[n:]
+			}
+		}
+		return nil
+	})
+}
+
+// Marshal encodes the ReadRequest into a protobuf message
+func (rr *ReadRequest) Marshal() []byte {
+	var b []byte
+	for _, q := range rr.Queries {
+		b = appendMessageField(b, 1, q.Marshal())
+	}
+	if len(rr.AcceptedResponseTypes) > 0 {
+		var p []byte
+		for _, t := range rr.AcceptedResponseTypes {
+			p = protowire.AppendVarint(p, uint64(t))
+		}
+		b = appendMessageField(b, 2, p)
+	}
+	return b
+}
+
+// Clone returns a copy of the ReadRequest whose queries and hints may be modified
+// without affecting the original
+func (rr *ReadRequest) Clone() *ReadRequest {
+	c := &ReadRequest{
+		Queries:               make([]*Query, len(rr.Queries)),
+		AcceptedResponseTypes: append([]int32(nil), rr.AcceptedResponseTypes...),
+	}
+	for i, q := range rr.Queries {
+		q2 := *q
+		if q.Hints != nil {
+			h := *q.Hints
+			q2.Hints = &h
+		}
+		c.Queries[i] = &q2
+	}
+	return c
+}
+
+// AcceptsSamples returns true if the request accepts the samples response type
+func (rr *ReadRequest) AcceptsSamples() bool {
+	if len(rr.AcceptedResponseTypes) == 0 {
+		return true
+	}
+	for _, t := range rr.AcceptedResponseTypes {
+		if t == ResponseTypeSamples {
+			return true
+		}
+	}
+	return false
+}
+
+// Unmarshal decodes the provided protobuf message into the Query
+func (q *Query) Unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			q.StartTimestampMs = int64(f.v)
+		case 2:
+			q.EndTimestampMs = int64(f.v)
+		case 3:
+			m := &LabelMatcher{}
+			if err := m.Unmarshal(f.b); err != nil {
+				return err
+			}
+			q.Matchers = append(q.Matchers, m)
+		case 4:
+			q.Hints = &ReadHints{}
+			return q.Hints.Unmarshal(f.b)
+		}
+		return nil
+	})
+}
+
+// Marshal encodes the Query into a protobuf message
+func (q *Query) Marshal() []byte {
+	var b []byte
+	b = appendVarintField(b, 1, uint64(q.StartTimestampMs))
+	b = appendVarintField(b, 2, uint64(q.EndTimestampMs))
+	for _, m := range q.Matchers {
+		b = appendMessageField(b, 3, m.Marshal())
+	}
+	if q.Hints != nil {
+		b = appendMessageField(b, 4, q.Hints.Marshal())
+	}
+	return b
+}
+
+// MatchersString returns the Query's label matchers in PromQL selector format,
+// sorted so that equivalent queries produce the same string
+func (q *Query) MatchersString() string {
+	l := make([]string, len(q.Matchers))
+	for i, m := range q.Matchers {
+		l[i] = m.String()
+	}
+	sort.Strings(l)
+	return "{" + strings.Join(l, ",") + "}"
+}
+
+// Unmarshal decodes the provided protobuf message into the LabelMatcher
+func (m *LabelMatcher) Unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.Type = int32(f.v)
+		case 2:
+			m.Name = string(f.b)
+		case 3:
+			m.Value = string(f.b)
+		}
+		return nil
+	})
+}
+
+// Marshal encodes the LabelMatcher into a protobuf message
+func (m *LabelMatcher) Marshal() []byte {
+	var b []byte
+	b = appendVarintField(b, 1, uint64(m.Type))
+	b = appendStringField(b, 2, m.Name)
+	return appendStringField(b, 3, m.Value)
+}
+
+func (m *LabelMatcher) String() string {
+	op := "?"
+	if m.Type >= 0 && int(m.Type) < len(matchOperators) {
+		op = matchOperators[m.Type]
+	}
+	return m.Name + op + strconv.Quote(m.Value)
+}
+
+// Unmarshal decodes the provided protobuf message into the ReadHints
+func (h *ReadHints) Unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			h.StepMs = int64(f.v)
+		case 2:
+			h.Func = string(f.b)
+		case 3:
+			h.StartMs = int64(f.v)
+		case 4:
+			h.EndMs = int64(f.v)
+		case 5:
+			h.Grouping = append(h.Grouping, string(f.b))
+		case 6:
+			h.By = f.v != 0
+		case 7:
+			h.RangeMs = int64(f.v)
+		}
+		return nil
+	})
+}
+
+// Marshal encodes the ReadHints into a protobuf message
+func (h *ReadHints) Marshal() []byte {
+	var b []byte
+	b = appendVarintField(b, 1, uint64(h.StepMs))
+	b = appendStringField(b, 2, h.Func)
+	b = appendVarintField(b, 3, uint64(h.StartMs))
+	b = appendVarintField(b, 4, uint64(h.EndMs))
+	for _, g := range h.Grouping {
+		b = protowire.AppendTag(b, 5, protowire.BytesType)
+		b = protowire.AppendString(b, g)
+	}
+	if h.By {
+		b = appendVarintField(b, 6, 1)
+	}
+	return appendVarintField(b, 7, uint64(h.RangeMs))
+}
+
+// Unmarshal decodes the provided protobuf message into the ReadResponse
+func (rr *ReadResponse) Unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		if f.num == 1 {
+			qr := &QueryResult{}
+			if err := qr.Unmarshal(f.b); err != nil {
+				return err
+			}
+			rr.Results = append(rr.Results, qr)
+		}
+		return nil
+	})
+}
+
+// Marshal encodes the ReadResponse into a protobuf message
+func (rr *ReadResponse) Marshal() []byte {
+	var b []byte
+	for _, qr := range rr.Results {
+		b = appendMessageField(b, 1, qr.Marshal())
+	}
+	return b
+}
+
+// Unmarshal decodes the provided protobuf message into the QueryResult
+func (qr *QueryResult) Unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		if f.num == 1 {
+			ts := &TimeSeries{}
+			if err := ts.Unmarshal(f.b); err != nil {
+				return err
+			}
+			qr.Timeseries = append(qr.Timeseries, ts)
+		}
+		return nil
+	})
+}
+
+// Marshal encodes the QueryResult into a protobuf message
+func (qr *QueryResult) Marshal() []byte {
+	var b []byte
+	for _, ts := range qr.Timeseries {
+		b = appendMessageField(b, 1, ts.Marshal())
+	}
+	return b
+}
+
+// Unmarshal decodes the provided protobuf message into the TimeSeries
+func (ts *TimeSeries) Unmarshal(b []byte) error {
+	return consumeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			var l Label
+			err := consumeFields(f.b, func(f2 field) error {
+				switch f2.num {
+				case 1:
+					l.Name = string(f2.b)
+				case 2:
+					l.Value = string(f2.b)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			ts.Labels = append(ts.Labels, l)
+		case 2:
+			var s Sample
+			err := consumeFields(f.b, func(f2 field) error {
+				switch f2.num {
+				case 1:
+					s.Value = math.Float64frombits(f2.v)
+				case 2:
+					s.Timestamp = int64(f2.v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			ts.Samples = append(ts.Samples, s)
+		}
+		return nil
+	})
+}
+
+// Marshal encodes the TimeSeries into a protobuf message
+func (ts *TimeSeries) Marshal() []byte {
+	var b []byte
+	for _, l := range ts.Labels {
+		var lb []byte
+		lb = appendStringField(lb, 1, l.Name)
+		lb = appendStringField(lb, 2, l.Value)
+		b = appendMessageField(b, 1, lb)
+	}
+	for _, s := range ts.Samples {
+		var sb []byte
+		if s.Value != 0 || math.Signbit(s.Value) {
+			sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+			sb = protowire.AppendFixed64(sb, math.Float64bits(s.Value))
+		}
+		sb = appendVarintField(sb, 2, uint64(s.Timestamp))
+		b = appendMessageField(b, 2, sb)
+	}
+	return b
+}
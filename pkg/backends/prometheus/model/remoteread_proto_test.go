@@ -0,0 +1,116 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadRequestRoundTrip(t *testing.T) {
+
+	rr := &ReadRequest{
+		Queries: []*Query{
+			{
+				StartTimestampMs: 1000,
+				EndTimestampMs:   61000,
+				Matchers: []*LabelMatcher{
+					{Type: MatchEqual, Name: "__name__", Value: "up"},
+					{Type: MatchRegexp, Name: "job", Value: "prom.*"},
+				},
+				Hints: &ReadHints{StepMs: 15000, Func: "rate", StartMs: 1000, EndMs: 61000,
+					Grouping: []string{"job"}, By: true, RangeMs: 60000},
+			},
+		},
+		AcceptedResponseTypes: []int32{ResponseTypeSamples, 1},
+	}
+
+	rr2 := &ReadRequest{}
+	if err := rr2.Unmarshal(rr.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rr, rr2) {
+		t.Errorf("expected %v got %v", rr, rr2)
+	}
+
+	if !rr2.AcceptsSamples() {
+		t.Error("expected true")
+	}
+
+	// modifying a clone does not modify the original
+	rr3 := rr2.Clone()
+	rr3.Queries[0].StartTimestampMs = 0
+	rr3.Queries[0].Hints.StartMs = 0
+	if !reflect.DeepEqual(rr, rr2) {
+		t.Errorf("expected %v got %v", rr, rr2)
+	}
+
+	rr2.AcceptedResponseTypes = []int32{1}
+	if rr2.AcceptsSamples() {
+		t.Error("expected false")
+	}
+	// an empty list of accepted response types implies samples
+	rr2.AcceptedResponseTypes = nil
+	if !rr2.AcceptsSamples() {
+		t.Error("expected true")
+	}
+
+	err := rr2.Unmarshal([]byte{0x0a, 0xff})
+	if err == nil {
+		t.Error("expected error for truncated message")
+	}
+}
+
+func TestReadResponseRoundTrip(t *testing.T) {
+
+	rr := &ReadResponse{
+		Results: []*QueryResult{
+			{
+				Timeseries: []*TimeSeries{
+					{
+						Labels:  []Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "a"}},
+						Samples: []Sample{{Timestamp: 1000, Value: 1}, {Timestamp: 16000, Value: 0.5}},
+					},
+				},
+			},
+			{},
+		},
+	}
+
+	rr2 := &ReadResponse{}
+	if err := rr2.Unmarshal(rr.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	if len(rr2.Results) != 2 || len(rr2.Results[0].Timeseries) != 1 {
+		t.Fatalf("unexpected response %v", rr2)
+	}
+	if !reflect.DeepEqual(rr.Results[0].Timeseries[0], rr2.Results[0].Timeseries[0]) {
+		t.Errorf("expected %v got %v", rr.Results[0].Timeseries[0], rr2.Results[0].Timeseries[0])
+	}
+}
+
+func TestMatchersString(t *testing.T) {
+	q := &Query{Matchers: []*LabelMatcher{
+		{Type: MatchNotRegexp, Name: "job", Value: "a|b"},
+		{Type: MatchEqual, Name: "__name__", Value: "up"},
+		{Type: MatchNotEqual, Name: "env", Value: `"x"`},
+	}}
+	const expected = `{__name__="up",env!="\"x\"",job!~"a|b"}`
+	if s := q.MatchersString(); s != expected {
+		t.Errorf("expected %s got %s", expected, s)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/encoding/providers"
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+)
+
+func testReadResponse() *ReadResponse {
+	return &ReadResponse{
+		Results: []*QueryResult{
+			{
+				Timeseries: []*TimeSeries{
+					{
+						Labels: []Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "a"}},
+						Samples: []Sample{{Timestamp: 60000, Value: 1}, {Timestamp: 75000, Value: 2},
+							{Timestamp: 90000, Value: 3}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestReadRequestEncoding(t *testing.T) {
+	rr := &ReadRequest{Queries: []*Query{{StartTimestampMs: 1, EndTimestampMs: 2}}}
+	b, err := EncodeReadRequest(rr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr2, err := DecodeReadRequest(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rr2.Queries) != 1 || rr2.Queries[0].EndTimestampMs != 2 {
+		t.Errorf("unexpected request %v", rr2)
+	}
+	if _, err = DecodeReadRequest([]byte("invalid")); err == nil {
+		t.Error("expected error for invalid body")
+	}
+}
+
+func TestUnmarshalRemoteRead(t *testing.T) {
+
+	trq := &timeseries.TimeRangeQuery{Statement: `{__name__="up"}`, Step: time.Minute,
+		Extent: timeseries.Extent{Start: time.Unix(60, 0), End: time.Unix(60, 0)}}
+
+	if _, err := UnmarshalRemoteRead(nil, nil); err != timeseries.ErrNoTimerangeQuery {
+		t.Errorf("expected %v got %v", timeseries.ErrNoTimerangeQuery, err)
+	}
+
+	b, _ := EncodeReadResponse(testReadResponse())
+	// snappy-encoded and raw bodies are both supported
+	for _, body := range [][]byte{b, testReadResponse().Marshal()} {
+		ts, err := UnmarshalRemoteRead(body, trq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ds := ts.(*dataset.DataSet)
+		if len(ds.Results) != 1 || len(ds.Results[0].SeriesList) != 1 {
+			t.Fatalf("unexpected dataset %v", ds)
+		}
+		s := ds.Results[0].SeriesList[0]
+		if s.Header.Name != "up" || s.Header.Tags["job"] != "a" {
+			t.Errorf("unexpected header %v", s.Header)
+		}
+		if s.Header.QueryStatement != trq.Statement {
+			t.Errorf("expected %s got %s", trq.Statement, s.Header.QueryStatement)
+		}
+		if len(s.Points) != 3 || s.Points[1].Epoch != 75000000000 {
+			t.Errorf("unexpected points %v", s.Points)
+		}
+	}
+
+	if _, err := UnmarshalRemoteRead([]byte("invalid"), trq); err != timeseries.ErrInvalidBody {
+		t.Errorf("expected %v got %v", timeseries.ErrInvalidBody, err)
+	}
+}
+
+func TestMarshalRemoteReadWriter(t *testing.T) {
+
+	trq := &timeseries.TimeRangeQuery{Statement: `{__name__="up"}`, Step: time.Minute,
+		Extent: timeseries.Extent{Start: time.Unix(60, 0), End: time.Unix(60, 0)}}
+	b, _ := EncodeReadResponse(testReadResponse())
+	ts, err := UnmarshalRemoteRead(b, trq)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only samples within the client's time range are written
+	m := NewRemoteReadModeler(70000, 80000)
+	w := httptest.NewRecorder()
+	if err = m.WireMarshalWriter(ts, nil, 200, w); err != nil {
+		t.Fatal(err)
+	}
+	if w.Header().Get(headers.NameContentEncoding) != providers.SnappyValue {
+		t.Errorf("expected %s got %s", providers.SnappyValue, w.Header().Get(headers.NameContentEncoding))
+	}
+	if w.Header().Get(headers.NameContentType) != headers.ValueApplicationProtobuf {
+		t.Errorf("expected %s got %s", headers.ValueApplicationProtobuf,
+			w.Header().Get(headers.NameContentType))
+	}
+	ts2, err := UnmarshalRemoteRead(w.Body.Bytes(), trq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := ts2.(*dataset.DataSet).Results[0].SeriesList[0]
+	if len(s.Points) != 1 || s.Points[0].Values[0].(float64) != 2 {
+		t.Errorf("unexpected points %v", s.Points)
+	}
+
+	b2, err := m.WireMarshaler(ts, nil, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(w.Body.Bytes(), b2) {
+		t.Error("expected identical marshaled responses")
+	}
+
+	// series without samples in the time range are omitted
+	m = NewRemoteReadModeler(0, 1000)
+	b2, _ = m.WireMarshaler(ts, nil, 200)
+	ts2, _ = UnmarshalRemoteRead(b2, trq)
+	if n := len(ts2.(*dataset.DataSet).Results[0].SeriesList); n != 0 {
+		t.Errorf("expected %d got %d", 0, n)
+	}
+
+	if err = m.WireMarshalWriter(ts, nil, 200, nil); err == nil {
+		t.Error("expected error for nil writer")
+	}
+	if err = m.WireMarshalWriter(nil, nil, 200, w); err != timeseries.ErrUnknownFormat {
+		t.Errorf("expected %v got %v", timeseries.ErrUnknownFormat, err)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/trickstercache/trickster/pkg/proxy/response/merge"
+)
+
+// WFRules is the Wire Format Document for the /rules endpoint
+type WFRules struct {
+	*Envelope
+	Data *WFRulesData `json:"data"`
+}
+
+// WFRulesData is the Wire Format Document for the rule groups list in /rules responses.
+// Each group is retained in its original form, so that it is written back unaltered.
+type WFRulesData struct {
+	Groups []json.RawMessage `json:"groups"`
+}
+
+// wfRuleGroupID holds the fields that identify a rule group in /rules responses
+type wfRuleGroupID struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+}
+
+func ruleGroupID(g json.RawMessage) wfRuleGroupID {
+	var id wfRuleGroupID
+	json.Unmarshal(g, &id)
+	return id
+}
+
+// Merge merges the passed WFRules into the subject WFRules; when more than one
+// document includes a rule group of the same name and file, the first one is kept
+func (ru *WFRules) Merge(results ...*WFRules) {
+	if ru.Data == nil {
+		ru.Data = &WFRulesData{}
+	}
+	m := make(map[wfRuleGroupID]interface{}, len(ru.Data.Groups))
+	for _, g := range ru.Data.Groups {
+		m[ruleGroupID(g)] = nil
+	}
+	for _, ru2 := range results {
+		ru.Envelope.Merge(ru2.Envelope)
+		if ru2.Data == nil {
+			continue
+		}
+		for _, g := range ru2.Data.Groups {
+			id := ruleGroupID(g)
+			if _, ok := m[id]; !ok {
+				m[id] = nil
+				ru.Data.Groups = append(ru.Data.Groups, g)
+			}
+		}
+	}
+}
+
+// MergeAndWriteRules merges the provided Responses into a single prometheus Rules data object,
+// and writes it to the provided ResponseWriter
+func MergeAndWriteRules(w http.ResponseWriter, r *http.Request, rgs merge.ResponseGates) {
+	var ru *WFRules
+	statusCode, ok := mergeResponses(w, r, rgs, "rules", func(b []byte) error {
+		ru1 := &WFRules{}
+		if err := json.Unmarshal(b, &ru1); err != nil {
+			return err
+		}
+		if ru == nil {
+			ru = ru1
+		} else {
+			ru.Merge(ru1)
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+	ru.StartMarshal(w, statusCode)
+	if ru.Data == nil {
+		ru.Data = &WFRulesData{}
+	}
+	if ru.Data.Groups == nil {
+		ru.Data.Groups = []json.RawMessage{}
+	}
+	writeData(w, ru.Data)
+	w.Write([]byte("}")) // complete the envelope
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testRules1 = `{"status":"success","data":{"groups":[{"name":"g1","file":"f1",` +
+	`"rules":[{"name":"r1","query":"up == 0","type":"alerting","health":"ok"}],"interval":60}]}}`
+
+const testRules2 = `{"status":"success","data":{"groups":[{"name":"g1","file":"f1",` +
+	`"rules":[{"name":"r1","query":"up == 0","type":"alerting","health":"ok"}],"interval":60},` +
+	`{"name":"g2","file":"f1","rules":[],"interval":30}]}}`
+
+func TestMergeAndWriteRules(t *testing.T) {
+	w := httptest.NewRecorder()
+	MergeAndWriteRules(w, nil, testBodyResponseGates(testRules1, testRules2))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, w.Code)
+	}
+	ru := &WFRules{}
+	if err := json.Unmarshal(w.Body.Bytes(), &ru); err != nil {
+		t.Fatal(err)
+	}
+	if ru.Data == nil || len(ru.Data.Groups) != 2 {
+		t.Fatalf("unexpected rules %s", w.Body.String())
+	}
+	// groups are written back in their original form
+	if !strings.Contains(w.Body.String(), `"query":"up == 0"`) {
+		t.Errorf("expected rule query in %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	MergeAndWriteRules(w, nil, nil)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected %d got %d", http.StatusBadGateway, w.Code)
+	}
+}
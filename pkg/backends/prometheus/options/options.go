@@ -22,11 +22,19 @@ import "github.com/trickstercache/trickster/pkg/util/copiers"
 type Options struct {
 	Labels         map[string]string `yaml:"labels,omitempty"`
 	InstantRoundMS int               `yaml:"instant_round_ms,omitempty"`
+	// EnforcedLabels is a map of label names and values that are injected as matchers
+	// into every selector of the PromQL statements sent to the backend
+	EnforcedLabels map[string]string `yaml:"enforced_labels,omitempty"`
+	// EnforcedLabelHeaders is a map of label names and the request headers providing the
+	// values that are injected as matchers into every selector of the PromQL statements
+	EnforcedLabelHeaders map[string]string `yaml:"enforced_label_headers,omitempty"`
 }
 
 func (o *Options) Clone() *Options {
 	return &Options{
-		InstantRoundMS: o.InstantRoundMS,
-		Labels:         copiers.CopyStringLookup(o.Labels),
+		InstantRoundMS:       o.InstantRoundMS,
+		Labels:               copiers.CopyStringLookup(o.Labels),
+		EnforcedLabels:       copiers.CopyStringLookup(o.EnforcedLabels),
+		EnforcedLabelHeaders: copiers.CopyStringLookup(o.EnforcedLabelHeaders),
 	}
 }
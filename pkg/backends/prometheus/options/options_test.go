@@ -24,8 +24,10 @@ func TestClone(t *testing.T) {
 	const expectedLen = 1
 
 	o := &Options{
-		InstantRoundMS: expectedMS,
-		Labels:         map[string]string{"test": "trickster"},
+		InstantRoundMS:       expectedMS,
+		Labels:               map[string]string{"test": "trickster"},
+		EnforcedLabels:       map[string]string{"namespace": "team-a"},
+		EnforcedLabelHeaders: map[string]string{"tenant": "X-Scope-OrgID"},
 	}
 
 	o2 := o.Clone()
@@ -35,5 +37,11 @@ func TestClone(t *testing.T) {
 	if len(o2.Labels) != expectedLen {
 		t.Errorf("expected %d got %d", expectedLen, len(o2.Labels))
 	}
+	if o2.EnforcedLabels["namespace"] != "team-a" {
+		t.Errorf("expected %s got %s", "team-a", o2.EnforcedLabels["namespace"])
+	}
+	if o2.EnforcedLabelHeaders["tenant"] != "X-Scope-OrgID" {
+		t.Errorf("expected %s got %s", "X-Scope-OrgID", o2.EnforcedLabelHeaders["tenant"])
+	}
 
 }
@@ -34,6 +34,7 @@ import (
 	"github.com/trickstercache/trickster/pkg/proxy/errors"
 	"github.com/trickstercache/trickster/pkg/proxy/params"
 	"github.com/trickstercache/trickster/pkg/timeseries"
+	tfo "github.com/trickstercache/trickster/pkg/timeseries/transformations/options"
 	tt "github.com/trickstercache/trickster/pkg/util/timeconv"
 )
 
@@ -42,18 +43,21 @@ var _ backends.MergeableTimeseriesBackend = (*Client)(nil)
 
 // Prometheus API
 const (
-	APIPath         = "/api/v1/"
-	mnQueryRange    = "query_range"
-	mnQuery         = "query"
-	mnLabels        = "labels"
-	mnLabel         = "label"
-	mnSeries        = "series"
-	mnTargets       = "targets"
-	mnTargetsMeta   = "targets/metadata"
-	mnRules         = "rules"
-	mnAlerts        = "alerts"
-	mnAlertManagers = "alertmanagers"
-	mnStatus        = "status"
+	APIPath          = "/api/v1/"
+	mnQueryRange     = "query_range"
+	mnQuery          = "query"
+	mnQueryExemplars = "query_exemplars"
+	mnMetadata       = "metadata"
+	mnRead           = "read"
+	mnLabels         = "labels"
+	mnLabel          = "label"
+	mnSeries         = "series"
+	mnTargets        = "targets"
+	mnTargetsMeta    = "targets/metadata"
+	mnRules          = "rules"
+	mnAlerts         = "alerts"
+	mnAlertManagers  = "alertmanagers"
+	mnStatus         = "status"
 )
 
 // Common URL Parameter Names
@@ -66,12 +70,29 @@ const (
 	upMatch = "match[]"
 )
 
+// Thanos URL Parameter Names
+const (
+	upDedup               = "dedup"
+	upPartialResponse     = "partial_response"
+	upMaxSourceResolution = "max_source_resolution"
+	upReplicaLabels       = "replicaLabels[]"
+)
+
+// thanosParams are the Thanos-specific parameters that alter query results,
+// and must therefore be included in cache keys
+var thanosParams = []string{upDedup, upPartialResponse, upMaxSourceResolution, upReplicaLabels}
+
 // Client Implements Proxy Client Interface
 type Client struct {
 	backends.TimeseriesBackend
 	instantRounder     time.Duration
 	hasTransformations bool
 	injectLabels       map[string]string
+	transformations    tfo.List
+
+	hasEnforcedLabels    bool
+	enforcedLabels       map[string]string
+	enforcedLabelHeaders map[string]string
 }
 
 var _ types.NewBackendClientFunc = NewClient
@@ -94,8 +115,22 @@ func NewClient(name string, o *bo.Options, router http.Handler,
 			rounder = time.Duration(o.Prometheus.InstantRoundMS) * time.Millisecond
 			c.injectLabels = o.Prometheus.Labels
 			c.hasTransformations = len(c.injectLabels) > 0
+			c.enforcedLabels = o.Prometheus.EnforcedLabels
+			c.enforcedLabelHeaders = o.Prometheus.EnforcedLabelHeaders
+			c.hasEnforcedLabels = len(c.enforcedLabels) > 0 || len(c.enforcedLabelHeaders) > 0
+			for _, lm := range []map[string]string{c.enforcedLabels, c.enforcedLabelHeaders} {
+				for k := range lm {
+					if verr := validateLabelName(k); verr != nil {
+						return nil, verr
+					}
+				}
+			}
 		}
 	}
+	if o != nil && len(o.Transformations) > 0 {
+		c.transformations = o.Transformations
+		c.hasTransformations = true
+	}
 	c.instantRounder = rounder
 
 	return c, err
@@ -130,6 +165,10 @@ func parseDuration(input string) (time.Duration, error) {
 func (c *Client) ParseTimeRangeQuery(r *http.Request) (*timeseries.TimeRangeQuery,
 	*timeseries.RequestOptions, bool, error) {
 
+	if isRemoteRead(r) {
+		return parseRemoteReadQuery(r)
+	}
+
 	trq := &timeseries.TimeRangeQuery{Extent: timeseries.Extent{}}
 	rlo := &timeseries.RequestOptions{}
 	qp, _, _ := params.GetRequestValues(r)
@@ -0,0 +1,486 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// errors returned when a PromQL statement cannot be scanned for selectors
+var (
+	errUnterminatedString = errors.New("unterminated string in query")
+	errUnterminatedBraces = errors.New("unterminated braces in query")
+	errUnterminatedGroup  = errors.New("unterminated brackets or parentheses in query")
+)
+
+// promqlKeywords are the case-insensitive PromQL keywords. Other than the
+// promqlReservedKeywords, they are also valid metric names where an operand is expected
+var promqlKeywords = map[string]bool{
+	"and": true, "or": true, "unless": true, "atan2": true, "bool": true, "offset": true,
+	"by": true, "without": true, "on": true, "ignoring": true,
+	"group_left": true, "group_right": true, "inf": true, "nan": true,
+	"sum": true, "avg": true, "count": true, "min": true, "max": true, "group": true,
+	"stddev": true, "stdvar": true, "topk": true, "bottomk": true, "count_values": true,
+	"quantile": true, "limitk": true, "limit_ratio": true,
+}
+
+// promqlReservedKeywords are the keywords that are never metric names
+var promqlReservedKeywords = map[string]bool{
+	"atan2": true, "bool": true, "on": true, "ignoring": true,
+	"group_left": true, "group_right": true, "inf": true, "nan": true,
+}
+
+// promqlAggregators are the aggregation operators, which may be followed by a
+// grouping clause before their parenthesized parameters
+var promqlAggregators = map[string]bool{
+	"sum": true, "avg": true, "count": true, "min": true, "max": true, "group": true,
+	"stddev": true, "stdvar": true, "topk": true, "bottomk": true, "count_values": true,
+	"quantile": true, "limitk": true, "limit_ratio": true,
+}
+
+// promqlGroupingKeywords are the keywords followed by a parenthesized list of
+// label names, rather than by expressions
+var promqlGroupingKeywords = map[string]bool{
+	"by": true, "without": true, "on": true, "ignoring": true,
+	"group_left": true, "group_right": true,
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// skipSpace returns the index of the first non-whitespace byte of q at or after i
+func skipSpace(q string, i int) int {
+	for i < len(q) && (q[i] == ' ' || q[i] == '\t' || q[i] == '\n' || q[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// stringEnd returns the index following the end of the string literal that starts at i
+func stringEnd(q string, i int) (int, error) {
+	quote := q[i]
+	for j := i + 1; j < len(q); j++ {
+		switch q[j] {
+		case '\\':
+			if quote != '`' {
+				j++
+			}
+		case quote:
+			return j + 1, nil
+		}
+	}
+	return 0, errUnterminatedString
+}
+
+// groupEnd returns the index following the closing delimiter of the group that starts at i,
+// skipping any string literals within the group
+func groupEnd(q string, i int, closer byte, err error) (int, error) {
+	for j := i + 1; j < len(q); j++ {
+		switch q[j] {
+		case '"', '\'', '`':
+			k, err := stringEnd(q, j)
+			if err != nil {
+				return 0, err
+			}
+			j = k - 1
+		case closer:
+			return j + 1, nil
+		}
+	}
+	return 0, err
+}
+
+// identifier kinds returned by classifyIdent
+const (
+	identKeyword  = iota // keywords and function names
+	identGrouping        // grouping keywords followed by a list of label names
+	identMetric          // metric names
+)
+
+// classifyIdent returns the kind of the identifier spanning q[i:j], where k is the index of
+// the first non-whitespace byte following it and operand is true when an operand is expected
+// at i. It also returns whether an operand is expected after the identifier.
+func classifyIdent(q string, i, j, k int, operand bool) (int, bool) {
+	lower := strings.ToLower(q[i:j])
+	var next byte
+	if k < len(q) {
+		next = q[k]
+	}
+	switch {
+	case promqlGroupingKeywords[lower] && next == '(':
+		// an aggregation's by or without clause may end the expression
+		return identGrouping, lower != "by" && lower != "without"
+	case next == '(':
+		return identKeyword, true
+	case lower == "inf" || lower == "nan":
+		return identKeyword, false
+	case promqlReservedKeywords[lower]:
+		return identKeyword, true
+	case promqlAggregators[lower] && operand && isGroupingClause(q, k):
+		// an aggregation like sum by (job) (...)
+		return identKeyword, true
+	case promqlKeywords[lower] && !operand:
+		return identKeyword, true
+	}
+	return identMetric, false
+}
+
+// isGroupingClause returns true if q has a by or without keyword at i
+func isGroupingClause(q string, i int) bool {
+	j := i
+	for j < len(q) && isIdentChar(q[j]) {
+		j++
+	}
+	w := strings.ToLower(q[i:j])
+	return w == "by" || w == "without"
+}
+
+// nextOperand returns whether an operand is expected after the punctuation or operator c
+func nextOperand(c byte, operand bool) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r':
+		return operand
+	case ')':
+		return false
+	}
+	return true
+}
+
+// splitMatchers returns the comma-separated label matchers of the selector contents s, with
+// whitespace outside of string literals removed and string literals requoted, so that
+// equivalent matchers compare as equal
+func splitMatchers(s string) []string {
+	var out []string
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch c {
+		case '"', '\'', '`':
+			j, err := stringEnd(s, i)
+			if err != nil {
+				j = len(s)
+			}
+			lit := s[i:j]
+			if v, err := strconv.Unquote(lit); err == nil {
+				lit = strconv.Quote(v)
+			}
+			sb.WriteString(lit)
+			i = j
+			continue
+		case ',':
+			if sb.Len() > 0 {
+				out = append(out, sb.String())
+				sb.Reset()
+			}
+		case ' ', '\t', '\n', '\r':
+		default:
+			sb.WriteByte(c)
+		}
+		i++
+	}
+	if sb.Len() > 0 {
+		out = append(out, sb.String())
+	}
+	return out
+}
+
+// injectMatchers returns the PromQL statement with the provided label matchers
+// (e.g., `namespace="team-a"`) added to each of its vector selectors. Matchers that a
+// selector already has are not added again, so injecting into an injected statement
+// returns it unchanged.
+func injectMatchers(q string, matchers string) (string, error) {
+	if matchers == "" {
+		return q, nil
+	}
+	var sb strings.Builder
+	sb.Grow(len(q) + len(matchers)*2)
+	injected := splitMatchers(matchers)
+
+	// writeMatchers writes the selector braces starting at i with the injected matchers
+	writeMatchers := func(i int) (int, error) {
+		j, err := groupEnd(q, i, '}', errUnterminatedBraces)
+		if err != nil {
+			return 0, err
+		}
+		existing := strings.TrimRight(strings.TrimSpace(q[i+1:j-1]), ",")
+		has := make(map[string]bool)
+		for _, m := range splitMatchers(existing) {
+			has[m] = true
+		}
+		sb.WriteByte('{')
+		sb.WriteString(existing)
+		sep := existing != ""
+		for _, m := range injected {
+			if has[m] {
+				continue
+			}
+			if sep {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(m)
+			sep = true
+		}
+		sb.WriteByte('}')
+		return j, nil
+	}
+
+	// operand is true where the scanner expects an operand, rather than an operator
+	operand := true
+	for i := 0; i < len(q); {
+		c := q[i]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			j, err := stringEnd(q, i)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(q[i:j])
+			i = j
+			operand = false
+		case c == '#':
+			// comments run through the end of the line
+			j := strings.IndexByte(q[i:], '\n')
+			if j < 0 {
+				j = len(q) - i
+			}
+			sb.WriteString(q[i : i+j])
+			i += j
+		case c == '[':
+			// ranges and subqueries only contain durations
+			j, err := groupEnd(q, i, ']', errUnterminatedGroup)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(q[i:j])
+			i = j
+			operand = false
+		case c == '{':
+			// a selector without a metric name
+			j, err := writeMatchers(i)
+			if err != nil {
+				return "", err
+			}
+			i = j
+			operand = false
+		case isDigit(c) || (c == '.' && i+1 < len(q) && isDigit(q[i+1])):
+			// numbers and durations, including exponents like 1e+3
+			j := i + 1
+			for j < len(q) && (isIdentChar(q[j]) || q[j] == '.' ||
+				((q[j] == '+' || q[j] == '-') && (q[j-1] == 'e' || q[j-1] == 'E') &&
+					!strings.HasPrefix(strings.ToLower(q[i:]), "0x"))) {
+				j++
+			}
+			sb.WriteString(q[i:j])
+			i = j
+			operand = false
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(q) && isIdentChar(q[j]) {
+				j++
+			}
+			sb.WriteString(q[i:j])
+			k := skipSpace(q, j)
+			var kind int
+			kind, operand = classifyIdent(q, i, j, k, operand)
+			switch {
+			case kind == identGrouping:
+				// label name lists are copied as-is
+				e, err := groupEnd(q, k, ')', errUnterminatedGroup)
+				if err != nil {
+					return "", err
+				}
+				sb.WriteString(q[j:e])
+				i = e
+			case kind == identKeyword:
+				i = j
+			case k < len(q) && q[k] == '{':
+				sb.WriteString(q[j:k])
+				e, err := writeMatchers(k)
+				if err != nil {
+					return "", err
+				}
+				i = e
+			default:
+				// a metric name without label matchers
+				sb.WriteByte('{')
+				sb.WriteString(matchers)
+				sb.WriteByte('}')
+				i = j
+			}
+		default:
+			sb.WriteByte(c)
+			operand = nextOperand(c, operand)
+			i++
+		}
+	}
+	return sb.String(), nil
+}
+
+// metricNames returns the sorted, distinct metric names referenced by the vector selectors
+// of the PromQL statement, either by name or by an equality matcher on the __name__ label
+func metricNames(q string) ([]string, error) {
+	names := make(map[string]bool)
+
+	// selectorName adds the metric name of any __name__ equality matcher in the selector
+	// braces starting at i, and returns the index following the braces
+	selectorName := func(i int) (int, error) {
+		j, err := groupEnd(q, i, '}', errUnterminatedBraces)
+		if err != nil {
+			return 0, err
+		}
+		for k := i + 1; k < j-1; {
+			c := q[k]
+			switch {
+			case c == '"' || c == '\'' || c == '`':
+				e, err := stringEnd(q, k)
+				if err != nil {
+					return 0, err
+				}
+				k = e
+			case isIdentStart(c):
+				e := k + 1
+				for e < j && isIdentChar(q[e]) {
+					e++
+				}
+				ident := q[k:e]
+				k = skipSpace(q, e)
+				if ident != "__name__" || q[k] != '=' || q[k+1] == '~' {
+					continue
+				}
+				s := skipSpace(q, k+1)
+				if q[s] != '"' && q[s] != '\'' && q[s] != '`' {
+					continue
+				}
+				e, err := stringEnd(q, s)
+				if err != nil {
+					return 0, err
+				}
+				// metric names never require escaping
+				if v := q[s+1 : e-1]; v != "" && !strings.Contains(v, `\`) {
+					names[v] = true
+				}
+				k = e
+			default:
+				k++
+			}
+		}
+		return j, nil
+	}
+
+	// operand is true where the scanner expects an operand, rather than an operator
+	operand := true
+	for i := 0; i < len(q); {
+		c := q[i]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			j, err := stringEnd(q, i)
+			if err != nil {
+				return nil, err
+			}
+			i = j
+			operand = false
+		case c == '#':
+			// comments run through the end of the line
+			j := strings.IndexByte(q[i:], '\n')
+			if j < 0 {
+				j = len(q) - i
+			}
+			i += j
+		case c == '[':
+			// ranges and subqueries only contain durations
+			j, err := groupEnd(q, i, ']', errUnterminatedGroup)
+			if err != nil {
+				return nil, err
+			}
+			i = j
+			operand = false
+		case c == '{':
+			// a selector without a metric name
+			j, err := selectorName(i)
+			if err != nil {
+				return nil, err
+			}
+			i = j
+			operand = false
+		case isDigit(c) || (c == '.' && i+1 < len(q) && isDigit(q[i+1])):
+			// numbers and durations, including exponents like 1e+3
+			j := i + 1
+			for j < len(q) && (isIdentChar(q[j]) || q[j] == '.' ||
+				((q[j] == '+' || q[j] == '-') && (q[j-1] == 'e' || q[j-1] == 'E') &&
+					!strings.HasPrefix(strings.ToLower(q[i:]), "0x"))) {
+				j++
+			}
+			i = j
+			operand = false
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(q) && isIdentChar(q[j]) {
+				j++
+			}
+			ident := q[i:j]
+			k := skipSpace(q, j)
+			var kind int
+			kind, operand = classifyIdent(q, i, j, k, operand)
+			switch {
+			case kind == identGrouping:
+				// label name lists are skipped
+				e, err := groupEnd(q, k, ')', errUnterminatedGroup)
+				if err != nil {
+					return nil, err
+				}
+				i = e
+			case kind == identKeyword:
+				i = j
+			case k < len(q) && q[k] == '{':
+				names[ident] = true
+				e, err := selectorName(k)
+				if err != nil {
+					return nil, err
+				}
+				i = e
+			default:
+				names[ident] = true
+				i = j
+			}
+		default:
+			operand = nextOperand(c, operand)
+			i++
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+	out := make([]string, 0, len(names))
+	for n := range names {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out, nil
+}
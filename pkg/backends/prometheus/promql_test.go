@@ -0,0 +1,252 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestInjectMatchers(t *testing.T) {
+
+	const m = `ns="a"`
+
+	tests := []struct {
+		query, expected string
+	}{
+		{`up`, `up{ns="a"}`},
+		{`up{job="x"}`, `up{job="x",ns="a"}`},
+		{`up {job="x",}`, `up {job="x",ns="a"}`},
+		{`{__name__="up"}`, `{__name__="up",ns="a"}`},
+		{`up{}`, `up{ns="a"}`},
+		{`rate(http_requests_total{code=~"5.."}[5m] offset 1h)`,
+			`rate(http_requests_total{code=~"5..",ns="a"}[5m] offset 1h)`},
+		{`sum by (job, instance) (rate(a[1m:30s]))`,
+			`sum by (job, instance) (rate(a{ns="a"}[1m:30s]))`},
+		{`sum(rate(a[5m])) without(le)`, `sum(rate(a{ns="a"}[5m])) without(le)`},
+		{`a / on(job) group_left(env) b`, `a{ns="a"} / on(job) group_left(env) b{ns="a"}`},
+		{`a * ignoring(x) group_right c`, `a{ns="a"} * ignoring(x) group_right c{ns="a"}`},
+		{`a > bool 2.5e+3 and b or c unless d`,
+			`a{ns="a"} > bool 2.5e+3 and b{ns="a"} or c{ns="a"} unless d{ns="a"}`},
+		{`topk(5, a) AND ON(x) b`, `topk(5, a{ns="a"}) AND ON(x) b{ns="a"}`},
+		{`label_replace(a, "dst", "$1", "src", "(.*)")`,
+			`label_replace(a{ns="a"}, "dst", "$1", "src", "(.*)")`},
+		{`count_values("v", a{b="}"})`, `count_values("v", a{b="}",ns="a"})`},
+		{`a @ start() - a @ 1609746000`, `a{ns="a"} @ start() - a{ns="a"} @ 1609746000`},
+		{"a # b\n+ c", "a{ns=\"a\"} # b\n+ c{ns=\"a\"}"},
+		{`vector(1) + Inf - NaN`, `vector(1) + Inf - NaN`},
+		{`job:http_requests:rate5m`, `job:http_requests:rate5m{ns="a"}`},
+		{`a{b='c\'d'}`, `a{b='c\'d',ns="a"}`},
+		{`sum`, `sum{ns="a"}`},
+		{`sum{job="x"} + count`, `sum{job="x",ns="a"} + count{ns="a"}`},
+		{`and and and offset 5m + offset`, `and{ns="a"} and and{ns="a"} offset 5m + offset{ns="a"}`},
+		{`rate(offset[5m]) / by`, `rate(offset{ns="a"}[5m]) / by{ns="a"}`},
+		{`sum without (le) (a) or group`, `sum without (le) (a{ns="a"}) or group{ns="a"}`},
+		{`sum(a) by (job) unless b`, `sum(a{ns="a"}) by (job) unless b{ns="a"}`},
+		{`0x1F * a + 0X1e-3`, `0x1F * a{ns="a"} + 0X1e-3`},
+		{`a @ end() offset -5m`, `a{ns="a"} @ end() offset -5m`},
+		{`max_over_time(rate(a[5m])[1h:5m] @ 100)[2h:]`,
+			`max_over_time(rate(a{ns="a"}[5m])[1h:5m] @ 100)[2h:]`},
+		{`up{ns="a"}`, `up{ns="a"}`},
+		{`up{ns = 'a', job="x"} + b{ns="a",}`, `up{ns = 'a', job="x"} + b{ns="a"}`},
+		{`up{ns="b"}`, `up{ns="b",ns="a"}`},
+		{`up{ns=~"a"}`, `up{ns=~"a",ns="a"}`},
+		{`up{ns="a,b"}`, `up{ns="a,b",ns="a"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			out, err := injectMatchers(test.query, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != test.expected {
+				t.Errorf("expected %s got %s", test.expected, out)
+			}
+			// injecting into an injected statement leaves it unchanged
+			if out2, _ := injectMatchers(out, m); out2 != out {
+				t.Errorf("expected %s got %s", out, out2)
+			}
+		})
+	}
+
+	for _, q := range []string{`a{b="c}`, `a{b="c"`, `rate(a[5m)`, `sum by (a`} {
+		if _, err := injectMatchers(q, m); err == nil {
+			t.Errorf("expected error for %s", q)
+		}
+	}
+
+	if out, _ := injectMatchers("up", ""); out != "up" {
+		t.Errorf("expected %s got %s", "up", out)
+	}
+}
+
+func TestMetricNames(t *testing.T) {
+
+	tests := []struct {
+		query, expected string
+	}{
+		{`up`, `up`},
+		{`up{job="x"}`, `up`},
+		{`{__name__="up",job="x"}`, `up`},
+		{`{__name__ = 'up'}`, `up`},
+		{`{__name__=~"up|down"}`, ``},
+		{`{__name__!="up"}`, ``},
+		{`rate(http_requests_total{code=~"5.."}[5m] offset 1h)`, `http_requests_total`},
+		{`sum by (job, instance) (rate(b[1m:30s])) / a`, `a,b`},
+		{`a / on(job) group_left(env) b`, `a,b`},
+		{`a > bool 2.5e+3 and b or c unless a`, `a,b,c`},
+		{`label_replace(a, "dst", "$1", "src", "(.*)")`, `a`},
+		{`a @ start() - a @ 1609746000`, `a`},
+		{"a # b\n+ c", `a,c`},
+		{`vector(1) + Inf - NaN`, ``},
+		{`job:http_requests:rate5m`, `job:http_requests:rate5m`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			names, err := metricNames(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out := strings.Join(names, ","); out != test.expected {
+				t.Errorf("expected %s got %s", test.expected, out)
+			}
+		})
+	}
+
+	for _, q := range []string{`a{b="c}`, `{__name__="up"`, `rate(a[5m)`, `sum by (a`} {
+		if _, err := metricNames(q); err == nil {
+			t.Errorf("expected error for %s", q)
+		}
+	}
+}
+
+// promqlGen generates random PromQL statements, along with their expected
+// outputs from injectMatchers and metricNames
+type promqlGen struct {
+	rnd   *rand.Rand
+	names map[string]bool
+}
+
+// metric names include keywords, which are valid metric names where an operand is expected
+var genMetricNames = []string{"up", "job:rate5m", "sum", "count", "topk", "and", "or",
+	"unless", "offset", "by", "without", "start", "end", "boolean"}
+
+var genNumbers = []string{"1", "2.5", ".5", "0x1F", "0X1e", "1e+3", "2E-2", "Inf", "NaN"}
+
+var genBinaryOps = []string{" + ", "-", " / ", " > bool ", " == ", " and ", " OR ",
+	" unless ", " / on(job) group_left(env) ", " * ignoring (x) group_right ", " atan2 "}
+
+var genAggregators = []string{"sum", "avg", "count", "max", "group", "stddev"}
+
+func (g *promqlGen) pick(s []string) string {
+	return s[g.rnd.Intn(len(s))]
+}
+
+// selector returns a vector selector and its expected injected form
+func (g *promqlGen) selector(m string) (string, string) {
+	name := g.pick(genMetricNames)
+	g.names[name] = true
+	switch g.rnd.Intn(4) {
+	case 0:
+		return name + `{a="}"}`, name + `{a="}",` + m + "}"
+	case 1:
+		return `{__name__="` + name + `"}`, `{__name__="` + name + `",` + m + "}"
+	}
+	return name, name + "{" + m + "}"
+}
+
+// expr returns an expression and its expected injected form
+func (g *promqlGen) expr(m string, depth int) (string, string) {
+	n := 7
+	if depth <= 0 {
+		n = 2
+	}
+	switch g.rnd.Intn(n) {
+	case 0:
+		s := g.pick(genNumbers)
+		return s, s
+	case 1:
+		q, e := g.selector(m)
+		switch g.rnd.Intn(4) {
+		case 0:
+			return q + " offset 5m", e + " offset 5m"
+		case 1:
+			return q + " @ 1609746000", e + " @ 1609746000"
+		case 2:
+			return q + " @ start()", e + " @ start()"
+		}
+		return q, e
+	case 2:
+		q, e := g.selector(m)
+		return "rate(" + q + "[5m])", "rate(" + e + "[5m])"
+	case 3:
+		q, e := g.expr(m, depth-1)
+		return "max_over_time((" + q + ")[1h:5m] offset 1h)",
+			"max_over_time((" + e + ")[1h:5m] offset 1h)"
+	case 4:
+		agg := g.pick(genAggregators)
+		q, e := g.expr(m, depth-1)
+		if g.rnd.Intn(2) == 0 {
+			return agg + " by (job) (" + q + ")", agg + " by (job) (" + e + ")"
+		}
+		return agg + "(" + q + ") without (le)", agg + "(" + e + ") without (le)"
+	case 5:
+		q, e := g.expr(m, depth-1)
+		return "-(" + q + ")", "-(" + e + ")"
+	}
+	q1, e1 := g.expr(m, depth-1)
+	q2, e2 := g.expr(m, depth-1)
+	op := g.pick(genBinaryOps)
+	return q1 + op + q2, e1 + op + e2
+}
+
+func TestInjectMatchersGenerated(t *testing.T) {
+
+	const m = `ns="a"`
+	g := &promqlGen{rnd: rand.New(rand.NewSource(1))}
+
+	for i := 0; i < 5000; i++ {
+		g.names = make(map[string]bool)
+		q, expected := g.expr(m, 4)
+		out, err := injectMatchers(q, m)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", q, err)
+		}
+		if out != expected {
+			t.Fatalf("for %s\nexpected %s\ngot      %s", q, expected, out)
+		}
+		if out2, _ := injectMatchers(out, m); out2 != out {
+			t.Fatalf("for %s\nexpected %s\ngot      %s", out, out, out2)
+		}
+		names, err := metricNames(q)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", q, err)
+		}
+		en := make([]string, 0, len(g.names))
+		for n := range g.names {
+			en = append(en, n)
+		}
+		sort.Strings(en)
+		if strings.Join(names, ",") != strings.Join(en, ",") {
+			t.Fatalf("for %s expected names %v got %v", q, en, names)
+		}
+	}
+}
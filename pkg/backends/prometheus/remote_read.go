@@ -0,0 +1,122 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/backends/prometheus/model"
+	"github.com/trickstercache/trickster/pkg/encoding/providers"
+	"github.com/trickstercache/trickster/pkg/proxy/errors"
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+	"github.com/trickstercache/trickster/pkg/proxy/urls"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+)
+
+// remoteReadStep is the width of the time buckets by which the raw samples of remote
+// read responses are cached. Each upstream request includes every sample through the
+// end of its final bucket.
+const remoteReadStep = time.Minute
+
+func isRemoteRead(r *http.Request) bool {
+	return strings.HasSuffix(r.URL.Path, "/"+mnRead)
+}
+
+func msToTime(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+func timeToMS(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// parseRemoteReadQuery parses the key parts of a TimeRangeQuery from the inbound remote
+// read request. Only requests whose queries all share the same time range, and that
+// accept the samples response type, can be accelerated.
+func parseRemoteReadQuery(r *http.Request) (*timeseries.TimeRangeQuery,
+	*timeseries.RequestOptions, bool, error) {
+
+	if r.Method != http.MethodPost {
+		return nil, nil, false, errors.ErrNotTimeRangeQuery
+	}
+	rr, err := model.DecodeReadRequest(request.GetBody(r))
+	if err != nil || len(rr.Queries) == 0 || !rr.AcceptsSamples() {
+		return nil, nil, false, errors.ErrNotTimeRangeQuery
+	}
+
+	start, end := rr.Queries[0].StartTimestampMs, rr.Queries[0].EndTimestampMs
+	statements := make([]string, len(rr.Queries))
+	for i, q := range rr.Queries {
+		if q.StartTimestampMs != start || q.EndTimestampMs != end {
+			return nil, nil, false, errors.ErrNotTimeRangeQuery
+		}
+		statements[i] = q.MatchersString()
+	}
+
+	trq := &timeseries.TimeRangeQuery{
+		Statement: strings.Join(statements, "\n"),
+		Step:      remoteReadStep,
+		// the most recent bucket is still receiving samples, so it is always refetched
+		BackfillTolerance: remoteReadStep,
+		ParsedQuery:       rr,
+		// the end is extended so that its normalized bucket includes the requested end
+		Extent: timeseries.Extent{Start: msToTime(start),
+			End: msToTime(end).Add(remoteReadStep - time.Millisecond)},
+	}
+
+	// the queries' matchers are used in the cache key
+	trq.TemplateURL = urls.Clone(r.URL)
+	qi := trq.TemplateURL.Query()
+	qi.Set(upQuery, trq.Statement)
+	trq.TemplateURL.RawQuery = qi.Encode()
+
+	return trq, &timeseries.RequestOptions{FastForwardDisable: true}, false, nil
+}
+
+// setRemoteReadExtent changes the upstream remote read request body to use the provided Extent
+func setRemoteReadExtent(r *http.Request, trq *timeseries.TimeRangeQuery, extent *timeseries.Extent) {
+	if trq == nil || extent == nil {
+		return
+	}
+	prr, ok := trq.ParsedQuery.(*model.ReadRequest)
+	if !ok {
+		return
+	}
+	rr := prr.Clone()
+	start := timeToMS(extent.Start)
+	// the range includes each sample in the final bucket
+	end := timeToMS(extent.End.Add(trq.Step)) - 1
+	for _, q := range rr.Queries {
+		q.StartTimestampMs = start
+		q.EndTimestampMs = end
+		if q.Hints != nil {
+			q.Hints.StartMs = start
+			q.Hints.EndMs = end
+		}
+	}
+	rr.AcceptedResponseTypes = []int32{model.ResponseTypeSamples}
+	b, err := model.EncodeReadRequest(rr)
+	if err != nil {
+		return
+	}
+	r.Header.Set(headers.NameContentType, headers.ValueApplicationProtobuf)
+	r.Header.Set(headers.NameContentEncoding, providers.SnappyValue)
+	*r = *request.SetBody(r, b)
+}
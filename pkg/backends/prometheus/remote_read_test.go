@@ -0,0 +1,140 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/backends/prometheus/model"
+	"github.com/trickstercache/trickster/pkg/proxy/errors"
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+)
+
+func testRemoteReadBody(t *testing.T, start, end int64) []byte {
+	rr := &model.ReadRequest{}
+	for _, name := range []string{"up", "go_goroutines"} {
+		rr.Queries = append(rr.Queries, &model.Query{
+			StartTimestampMs: start,
+			EndTimestampMs:   end,
+			Matchers: []*model.LabelMatcher{
+				{Type: model.MatchEqual, Name: "__name__", Value: name},
+			},
+			Hints: &model.ReadHints{StartMs: start, EndMs: end},
+		})
+	}
+	b, err := model.EncodeReadRequest(rr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParseRemoteReadQuery(t *testing.T) {
+
+	client := &Client{}
+	b := testRemoteReadBody(t, 90000, 150000)
+	r, _ := http.NewRequest(http.MethodPost, "http://0/api/v1/read", bytes.NewReader(b))
+	trq, rlo, _, err := client.ParseTimeRangeQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trq.Step != remoteReadStep {
+		t.Errorf("expected %s got %s", remoteReadStep, trq.Step)
+	}
+	const expected = "{__name__=\"up\"}\n{__name__=\"go_goroutines\"}"
+	if trq.Statement != expected {
+		t.Errorf("expected %s got %s", expected, trq.Statement)
+	}
+	if trq.TemplateURL.Query().Get(upQuery) != expected {
+		t.Errorf("expected %s got %s", expected, trq.TemplateURL.Query().Get(upQuery))
+	}
+	trq.NormalizeExtent()
+	if trq.Extent.Start.Unix() != 60 || trq.Extent.End.Unix() != 180 {
+		t.Errorf("unexpected extent %s", trq.Extent.String())
+	}
+	if !rlo.FastForwardDisable {
+		t.Error("expected fast forward to be disabled")
+	}
+
+	// queries with differing time ranges cannot be cached
+	rr, _ := model.DecodeReadRequest(b)
+	rr.Queries[1].EndTimestampMs = 160000
+	b, _ = model.EncodeReadRequest(rr)
+	r, _ = http.NewRequest(http.MethodPost, "http://0/api/v1/read", bytes.NewReader(b))
+	if _, _, _, err = client.ParseTimeRangeQuery(r); err != errors.ErrNotTimeRangeQuery {
+		t.Errorf("expected %v got %v", errors.ErrNotTimeRangeQuery, err)
+	}
+
+	// nor can queries that only accept streamed chunks
+	rr.Queries = rr.Queries[:1]
+	rr.AcceptedResponseTypes = []int32{1}
+	b, _ = model.EncodeReadRequest(rr)
+	r, _ = http.NewRequest(http.MethodPost, "http://0/api/v1/read", bytes.NewReader(b))
+	if _, _, _, err = client.ParseTimeRangeQuery(r); err != errors.ErrNotTimeRangeQuery {
+		t.Errorf("expected %v got %v", errors.ErrNotTimeRangeQuery, err)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "http://0/api/v1/read", nil)
+	if _, _, _, err = client.ParseTimeRangeQuery(r); err != errors.ErrNotTimeRangeQuery {
+		t.Errorf("expected %v got %v", errors.ErrNotTimeRangeQuery, err)
+	}
+}
+
+func TestSetRemoteReadExtent(t *testing.T) {
+
+	client := &Client{}
+	r, _ := http.NewRequest(http.MethodPost, "http://0/api/v1/read",
+		bytes.NewReader(testRemoteReadBody(t, 90000, 150000)))
+	trq, _, _, err := client.ParseTimeRangeQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetExtent(r, trq, &timeseries.Extent{Start: time.Unix(120, 0), End: time.Unix(180, 0)})
+
+	if r.Header.Get(headers.NameContentType) != headers.ValueApplicationProtobuf {
+		t.Errorf("expected %s got %s", headers.ValueApplicationProtobuf,
+			r.Header.Get(headers.NameContentType))
+	}
+	b, _ := io.ReadAll(r.Body)
+	if r.ContentLength != int64(len(b)) {
+		t.Errorf("expected %d got %d", len(b), r.ContentLength)
+	}
+	rr, err := model.DecodeReadRequest(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the parsed request is not modified
+	if q := trq.ParsedQuery.(*model.ReadRequest).Queries[0]; q.StartTimestampMs != 90000 {
+		t.Errorf("expected %d got %d", 90000, q.StartTimestampMs)
+	}
+	for _, q := range rr.Queries {
+		if q.StartTimestampMs != 120000 || q.EndTimestampMs != 239999 {
+			t.Errorf("unexpected range %d-%d", q.StartTimestampMs, q.EndTimestampMs)
+		}
+		if q.Hints.StartMs != 120000 || q.Hints.EndMs != 239999 {
+			t.Errorf("unexpected hints range %d-%d", q.Hints.StartMs, q.Hints.EndMs)
+		}
+	}
+	if !rr.AcceptsSamples() || len(rr.AcceptedResponseTypes) != 1 {
+		t.Errorf("unexpected accepted response types %v", rr.AcceptedResponseTypes)
+	}
+}
@@ -30,15 +30,20 @@ import (
 func (c *Client) RegisterHandlers(map[string]http.Handler) {
 	c.TimeseriesBackend.RegisterHandlers(
 		map[string]http.Handler{
-			"health":      http.HandlerFunc(c.HealthHandler),
-			"query_range": http.HandlerFunc(c.QueryRangeHandler),
-			"query":       http.HandlerFunc(c.QueryHandler),
-			"series":      http.HandlerFunc(c.SeriesHandler),
-			"proxycache":  http.HandlerFunc(c.ObjectProxyCacheHandler),
-			"proxy":       http.HandlerFunc(c.ProxyHandler),
-			"labels":      http.HandlerFunc(c.LabelsHandler),
-			"alerts":      http.HandlerFunc(c.AlertsHandler),
-			"admin":       http.HandlerFunc(c.UnsupportedHandler),
+			"health":           http.HandlerFunc(c.HealthHandler),
+			"query_range":      http.HandlerFunc(c.QueryRangeHandler),
+			"query":            http.HandlerFunc(c.QueryHandler),
+			"series":           http.HandlerFunc(c.SeriesHandler),
+			"query_exemplars":  http.HandlerFunc(c.QueryExemplarsHandler),
+			"metadata":         http.HandlerFunc(c.MetadataHandler),
+			"targets_metadata": http.HandlerFunc(c.TargetsMetadataHandler),
+			"rules":            http.HandlerFunc(c.RulesHandler),
+			"remote_read":      http.HandlerFunc(c.RemoteReadHandler),
+			"proxycache":       http.HandlerFunc(c.ObjectProxyCacheHandler),
+			"proxy":            http.HandlerFunc(c.ProxyHandler),
+			"labels":           http.HandlerFunc(c.LabelsHandler),
+			"alerts":           http.HandlerFunc(c.AlertsHandler),
+			"admin":            http.HandlerFunc(c.UnsupportedHandler),
 		},
 	)
 }
@@ -53,6 +58,10 @@ func MergeablePaths() []string {
 		"/api/v1/series",
 		"/api/v1/labels",
 		"/api/v1/label/",
+		"/api/v1/query_exemplars",
+		"/api/v1/metadata",
+		"/api/v1/targets/metadata",
+		"/api/v1/rules",
 	}
 }
 
@@ -79,8 +88,8 @@ func (c *Client) DefaultPathConfigs(o *bo.Options) map[string]*po.Options {
 			Path:            APIPath + mnQueryRange,
 			HandlerName:     mnQueryRange,
 			Methods:         methods.GetAndPost(),
-			CacheKeyParams:  []string{upQuery, upStep},
-			CacheKeyHeaders: []string{},
+			CacheKeyParams:  append([]string{upQuery, upStep}, thanosParams...),
+			CacheKeyHeaders: []string{headers.NameScopeOrgID},
 			ResponseHeaders: rhts,
 			MatchTypeName:   "exact",
 			MatchType:       matching.PathMatchTypeExact,
@@ -90,8 +99,8 @@ func (c *Client) DefaultPathConfigs(o *bo.Options) map[string]*po.Options {
 			Path:            APIPath + mnQuery,
 			HandlerName:     mnQuery,
 			Methods:         methods.GetAndPost(),
-			CacheKeyParams:  []string{upQuery, upTime},
-			CacheKeyHeaders: []string{},
+			CacheKeyParams:  append([]string{upQuery, upTime}, thanosParams...),
+			CacheKeyHeaders: []string{headers.NameScopeOrgID},
 			ResponseHeaders: rhinst,
 			MatchTypeName:   "exact",
 			MatchType:       matching.PathMatchTypeExact,
@@ -101,19 +110,51 @@ func (c *Client) DefaultPathConfigs(o *bo.Options) map[string]*po.Options {
 			Path:            APIPath + mnSeries,
 			HandlerName:     mnSeries,
 			Methods:         methods.GetAndPost(),
-			CacheKeyParams:  []string{upMatch, upStart, upEnd},
-			CacheKeyHeaders: []string{},
+			CacheKeyParams:  append([]string{upMatch, upStart, upEnd}, thanosParams...),
+			CacheKeyHeaders: []string{headers.NameScopeOrgID},
+			ResponseHeaders: rhinst,
+			MatchTypeName:   "exact",
+			MatchType:       matching.PathMatchTypeExact,
+		},
+
+		APIPath + mnQueryExemplars: {
+			Path:            APIPath + mnQueryExemplars,
+			HandlerName:     mnQueryExemplars,
+			Methods:         methods.GetAndPost(),
+			CacheKeyParams:  []string{upQuery, upStart, upEnd},
+			CacheKeyHeaders: []string{headers.NameScopeOrgID},
+			ResponseHeaders: rhinst,
+			MatchTypeName:   "exact",
+			MatchType:       matching.PathMatchTypeExact,
+		},
+
+		APIPath + mnMetadata: {
+			Path:            APIPath + mnMetadata,
+			HandlerName:     mnMetadata,
+			Methods:         []string{http.MethodGet},
+			CacheKeyParams:  []string{"metric", "limit", "limit_per_metric"},
+			CacheKeyHeaders: []string{headers.NameScopeOrgID},
 			ResponseHeaders: rhinst,
 			MatchTypeName:   "exact",
 			MatchType:       matching.PathMatchTypeExact,
 		},
 
+		APIPath + mnRead: {
+			Path:            APIPath + mnRead,
+			HandlerName:     "remote_read",
+			Methods:         []string{http.MethodPost},
+			CacheKeyParams:  []string{upQuery},
+			CacheKeyHeaders: []string{headers.NameScopeOrgID},
+			MatchTypeName:   "exact",
+			MatchType:       matching.PathMatchTypeExact,
+		},
+
 		APIPath + mnLabels: {
 			Path:            APIPath + mnLabels,
 			HandlerName:     "labels",
 			Methods:         methods.GetAndPost(),
-			CacheKeyParams:  []string{},
-			CacheKeyHeaders: []string{},
+			CacheKeyParams:  append([]string{upMatch, upStart, upEnd}, thanosParams...),
+			CacheKeyHeaders: []string{headers.NameScopeOrgID},
 			ResponseHeaders: rhinst,
 			MatchTypeName:   "exact",
 			MatchType:       matching.PathMatchTypeExact,
@@ -123,8 +164,8 @@ func (c *Client) DefaultPathConfigs(o *bo.Options) map[string]*po.Options {
 			Path:            APIPath + mnLabel + "/",
 			HandlerName:     "labels",
 			Methods:         []string{http.MethodGet},
-			CacheKeyParams:  []string{},
-			CacheKeyHeaders: []string{},
+			CacheKeyParams:  append([]string{upMatch, upStart, upEnd}, thanosParams...),
+			CacheKeyHeaders: []string{headers.NameScopeOrgID},
 			MatchTypeName:   "prefix",
 			MatchType:       matching.PathMatchTypePrefix,
 			ResponseHeaders: rhinst,
@@ -143,10 +184,10 @@ func (c *Client) DefaultPathConfigs(o *bo.Options) map[string]*po.Options {
 
 		APIPath + mnTargetsMeta: {
 			Path:            APIPath + mnTargetsMeta,
-			HandlerName:     "proxycache",
+			HandlerName:     "targets_metadata",
 			Methods:         []string{http.MethodGet},
 			CacheKeyParams:  []string{"match_target", "metric", "limit"},
-			CacheKeyHeaders: []string{},
+			CacheKeyHeaders: []string{headers.NameScopeOrgID},
 			ResponseHeaders: rhinst,
 			MatchTypeName:   "exact",
 			MatchType:       matching.PathMatchTypeExact,
@@ -154,10 +195,10 @@ func (c *Client) DefaultPathConfigs(o *bo.Options) map[string]*po.Options {
 
 		APIPath + mnRules: {
 			Path:            APIPath + mnRules,
-			HandlerName:     "proxycache",
+			HandlerName:     mnRules,
 			Methods:         []string{http.MethodGet},
-			CacheKeyParams:  []string{},
-			CacheKeyHeaders: []string{},
+			CacheKeyParams:  []string{"type", "rule_name[]", "rule_group[]", "file[]"},
+			CacheKeyHeaders: []string{headers.NameScopeOrgID},
 			ResponseHeaders: rhinst,
 			MatchTypeName:   "exact",
 			MatchType:       matching.PathMatchTypeExact,
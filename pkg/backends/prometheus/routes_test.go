@@ -19,6 +19,7 @@ package prometheus
 import (
 	"testing"
 
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
 	"github.com/trickstercache/trickster/pkg/proxy/request"
 	tu "github.com/trickstercache/trickster/pkg/util/testing"
 )
@@ -62,15 +63,32 @@ func TestDefaultPathConfigs(t *testing.T) {
 		t.Errorf("expected to find path named: %s", "/")
 	}
 
-	const expectedLen = 14
+	const expectedLen = 17
 	if len(dpc) != expectedLen {
 		t.Errorf("expected ordered length to be: %d got %d", expectedLen, len(dpc))
 	}
 
+	pc := dpc[APIPath+mnQueryRange]
+	for _, p := range thanosParams {
+		var found bool
+		for _, p2 := range pc.CacheKeyParams {
+			if p == p2 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("expected cache key param %s", p)
+		}
+	}
+	if len(pc.CacheKeyHeaders) != 1 || pc.CacheKeyHeaders[0] != headers.NameScopeOrgID {
+		t.Errorf("expected cache key headers %v", pc.CacheKeyHeaders)
+	}
+
 }
 
 func TestMergeablePaths(t *testing.T) {
-	if len(MergeablePaths()) != 6 {
-		t.Errorf("expected %d got %d", 6, len(MergeablePaths()))
+	if len(MergeablePaths()) != 10 {
+		t.Errorf("expected %d got %d", 10, len(MergeablePaths()))
 	}
 }
@@ -25,6 +25,7 @@ import (
 	"github.com/trickstercache/trickster/pkg/proxy/response/merge"
 	"github.com/trickstercache/trickster/pkg/timeseries"
 	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+	"github.com/trickstercache/trickster/pkg/timeseries/transformations"
 )
 
 func (c *Client) ProcessTransformations(ts timeseries.Timeseries) {
@@ -32,7 +33,7 @@ func (c *Client) ProcessTransformations(ts timeseries.Timeseries) {
 		return
 	}
 	ds, ok := ts.(*dataset.DataSet)
-	if !ok {
+	if !ok || len(c.injectLabels) == 0 {
 		return
 	}
 	ds.InjectTags(c.injectLabels)
@@ -54,7 +55,12 @@ func (c *Client) processVectorTransformations(w http.ResponseWriter, rg *merge.R
 		return
 	}
 	ds := t2.(*dataset.DataSet) // failure of this type assertion should be impossible
-	ds.InjectTags(c.injectLabels)
+	if len(c.injectLabels) > 0 {
+		ds.InjectTags(c.injectLabels)
+	}
+	// the delta proxy cache applies the backend's transformations to timeseries, but
+	// vectors are processed here
+	transformations.Process(ds, c.transformations)
 	model.MarshalTSOrVectorWriter(ds, rg.Resources.TSReqestOptions, rg.Response.StatusCode, w, true)
 }
 
@@ -19,11 +19,14 @@ package prometheus
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/trickstercache/trickster/pkg/proxy/request"
 	"github.com/trickstercache/trickster/pkg/proxy/response/merge"
+	"github.com/trickstercache/trickster/pkg/timeseries"
 	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+	tfo "github.com/trickstercache/trickster/pkg/timeseries/transformations/options"
 )
 
 func TestProcessTransformations(t *testing.T) {
@@ -60,3 +63,25 @@ func TestProcessVectorTransformations(t *testing.T) {
 	}
 
 }
+
+func TestProcessVectorTransformationsPipeline(t *testing.T) {
+
+	l := tfo.List{{Type: tfo.TypeScale, Factor: 10}, {Type: tfo.TypeDropLabel, Label: "job"}}
+	if err := l.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{transformations: l}
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+
+	rsc := &request.Resources{TimeRangeQuery: &timeseries.TimeRangeQuery{}}
+	rg := merge.NewResponseGate(w, r, rsc)
+	rg.Response = &http.Response{StatusCode: 200}
+	rg.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[` +
+		`{"metric":{"__name__":"up","job":"a"},"value":[1,"2"]}]}}`))
+	c.processVectorTransformations(w, rg)
+	body := w.Body.String()
+	if !strings.Contains(body, `"20"`) || strings.Contains(body, `"job"`) {
+		t.Errorf("unexpected body %s", body)
+	}
+}
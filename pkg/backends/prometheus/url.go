@@ -21,6 +21,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/trickstercache/trickster/pkg/proxy/params"
 	"github.com/trickstercache/trickster/pkg/timeseries"
@@ -28,12 +29,48 @@ import (
 
 // SetExtent will change the upstream request query to use the provided Extent
 func (c *Client) SetExtent(r *http.Request, trq *timeseries.TimeRangeQuery, extent *timeseries.Extent) {
+	if isRemoteRead(r) {
+		setRemoteReadExtent(r, trq, extent)
+		return
+	}
 	v, _, _ := params.GetRequestValues(r)
 	v.Set(upStart, strconv.FormatInt(extent.Start.Unix(), 10))
 	v.Set(upEnd, strconv.FormatInt(extent.End.Unix(), 10))
 	params.SetRequestValues(r, v)
 }
 
+// NormalizeStep will normalize the TimeRangeQuery's Step to the provided canonical steps,
+// and change the upstream request query to use it. Remote Read queries are not normalized.
+func (c *Client) NormalizeStep(r *http.Request, trq *timeseries.TimeRangeQuery,
+	steps []time.Duration) bool {
+	if isRemoteRead(r) || !trq.NormalizeStep(steps) {
+		return false
+	}
+	v, _, _ := params.GetRequestValues(r)
+	v.Set(upStep, strconv.FormatFloat(trq.Step.Seconds(), 'f', -1, 64))
+	params.SetRequestValues(r, v)
+	return true
+}
+
+// MetricNames returns the names of the metrics referenced by the request's PromQL query
+// or series matchers. Remote Read queries, and queries that cannot be scanned, reference
+// no metric names.
+func (c *Client) MetricNames(r *http.Request) []string {
+	if isRemoteRead(r) {
+		return nil
+	}
+	v, _, _ := params.GetRequestValues(r)
+	var out []string
+	for _, q := range append(v[upQuery], v[upMatch]...) {
+		names, err := metricNames(q)
+		if err != nil {
+			continue
+		}
+		out = append(out, names...)
+	}
+	return out
+}
+
 // FastForwardRequest returns an *http.Request crafted to collect Fast Forward
 // data from the Origin, based on the provided HTTP Request
 func (c *Client) FastForwardRequest(r *http.Request) (*http.Request, error) {
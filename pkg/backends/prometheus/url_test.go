@@ -76,6 +76,63 @@ func TestSetExtent(t *testing.T) {
 
 }
 
+func TestNormalizeStep(t *testing.T) {
+
+	c := &Client{}
+	steps := []time.Duration{15 * time.Second, time.Minute}
+	newTRQ := func(step time.Duration) *timeseries.TimeRangeQuery {
+		return &timeseries.TimeRangeQuery{Step: step,
+			Extent: timeseries.Extent{Start: time.Unix(600, 0), End: time.Unix(1200, 0)}}
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "http://0/api/v1/query_range?query=up&step=20", nil)
+	trq := newTRQ(20 * time.Second)
+	if !c.NormalizeStep(r, trq, steps) {
+		t.Error("expected step to be normalized")
+	}
+	if trq.Step != 15*time.Second || trq.RequestedStep != 20*time.Second {
+		t.Errorf("unexpected steps %s, %s", trq.Step, trq.RequestedStep)
+	}
+	if v := r.URL.Query().Get(upStep); v != "15" {
+		t.Errorf("expected %s got %s", "15", v)
+	}
+
+	// a step matching a canonical step is unchanged
+	r, _ = http.NewRequest(http.MethodGet, "http://0/api/v1/query_range?query=up&step=60", nil)
+	if c.NormalizeStep(r, newTRQ(time.Minute), steps) {
+		t.Error("expected step to not be normalized")
+	}
+	if v := r.URL.Query().Get(upStep); v != "60" {
+		t.Errorf("expected %s got %s", "60", v)
+	}
+
+	// remote read queries are never normalized
+	r, _ = http.NewRequest(http.MethodPost, "http://0/api/v1/read", nil)
+	if c.NormalizeStep(r, newTRQ(remoteReadStep), []time.Duration{15 * time.Second}) {
+		t.Error("expected remote read step to not be normalized")
+	}
+}
+
+func TestClientMetricNames(t *testing.T) {
+
+	c := &Client{}
+	r, _ := http.NewRequest(http.MethodGet,
+		"http://0/api/v1/series?match[]=up&match[]=process_cpu_seconds_total", nil)
+	if out := fmt.Sprint(c.MetricNames(r)); out != "[up process_cpu_seconds_total]" {
+		t.Errorf("unexpected metric names %s", out)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "http://0/api/v1/query?query=rate(a{b=\"c}[5m])", nil)
+	if c.MetricNames(r) != nil {
+		t.Error("expected no metric names for an unscannable query")
+	}
+
+	r, _ = http.NewRequest(http.MethodPost, "http://0/api/v1/read", nil)
+	if c.MetricNames(r) != nil {
+		t.Error("expected no metric names for a remote read query")
+	}
+}
+
 func TestFastForwardURL(t *testing.T) {
 
 	expected := "q=up"
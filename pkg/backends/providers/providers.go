@@ -40,6 +40,8 @@ const (
 	IronDB
 	// ClickHouse represents the ClickHouse backend provider
 	ClickHouse
+	// Elasticsearch represents the Elasticsearch (and OpenSearch) backend provider
+	Elasticsearch
 )
 
 // Names is a map of Providers keyed by string name
@@ -52,6 +54,8 @@ var Names = map[string]Provider{
 	"influxdb":          InfluxDB,
 	"irondb":            IronDB,
 	"clickhouse":        ClickHouse,
+	"elasticsearch":     Elasticsearch,
+	"opensearch":        Elasticsearch,
 	"proxy":             RP,
 	"reverseproxy":      RP,
 	"rp":                RP,
@@ -68,13 +72,16 @@ func init() {
 	// and "rp" for proxy
 	Values[RPC] = "rpc"
 	Values[RP] = "rp"
+	Values[Elasticsearch] = "elasticsearch"
 }
 
 var supportedTimeSeries = map[string]Provider{
-	"prometheus": Prometheus,
-	"influxdb":   InfluxDB,
-	"clickhouse": ClickHouse,
-	"irondb":     IronDB,
+	"prometheus":    Prometheus,
+	"influxdb":      InfluxDB,
+	"clickhouse":    ClickHouse,
+	"irondb":        IronDB,
+	"elasticsearch": Elasticsearch,
+	"opensearch":    Elasticsearch,
 }
 
 // IsSupportedTimeSeriesProvider returns true if the provided time series is supported by Trickster
@@ -85,6 +92,9 @@ func IsSupportedTimeSeriesProvider(name string) bool {
 
 var supportedTimeSeriesMerge = map[string]Provider{
 	"prometheus": Prometheus,
+	"influxdb":   InfluxDB,
+	"clickhouse": ClickHouse,
+	"irondb":     IronDB,
 }
 
 // IsSupportedTimeSeriesProvider returns true if the provided time series is supported by Trickster
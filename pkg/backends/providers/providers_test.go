@@ -53,6 +53,8 @@ func TestIsValidProvider(t *testing.T) {
 		{"invalid", false},
 		{"influxdb", true},
 		{"irondb", true},
+		{"elasticsearch", true},
+		{"opensearch", true},
 	}
 
 	for i, test := range tests {
@@ -19,6 +19,7 @@ package registration
 import (
 	"github.com/trickstercache/trickster/pkg/backends/alb"
 	"github.com/trickstercache/trickster/pkg/backends/clickhouse"
+	"github.com/trickstercache/trickster/pkg/backends/elasticsearch"
 	"github.com/trickstercache/trickster/pkg/backends/influxdb"
 	"github.com/trickstercache/trickster/pkg/backends/irondb"
 	"github.com/trickstercache/trickster/pkg/backends/prometheus"
@@ -32,8 +33,10 @@ func SupportedProviders() types.Lookup {
 	return types.Lookup{
 		"alb":               alb.NewClient,
 		"clickhouse":        clickhouse.NewClient,
+		"elasticsearch":     elasticsearch.NewClient,
 		"influxdb":          influxdb.NewClient,
 		"irondb":            irondb.NewClient,
+		"opensearch":        elasticsearch.NewClient,
 		"prometheus":        prometheus.NewClient,
 		"rp":                reverseproxy.NewClient,
 		"proxy":             reverseproxy.NewClient,
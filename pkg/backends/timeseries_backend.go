@@ -19,10 +19,12 @@ package backends
 import (
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/trickstercache/trickster/pkg/backends/healthcheck"
 	ho "github.com/trickstercache/trickster/pkg/backends/healthcheck/options"
 	bo "github.com/trickstercache/trickster/pkg/backends/options"
+	"github.com/trickstercache/trickster/pkg/backends/warmer"
 	"github.com/trickstercache/trickster/pkg/cache"
 	po "github.com/trickstercache/trickster/pkg/proxy/paths/options"
 	"github.com/trickstercache/trickster/pkg/timeseries"
@@ -70,6 +72,10 @@ type TimeseriesBackend interface {
 	HealthHandler(http.ResponseWriter, *http.Request)
 	// HealthCheckHTTPClient returns the HTTP Client used for Health Checking
 	HealthCheckHTTPClient() *http.Client
+	// Warmer returns the Query Warmer for the Backend, or nil if its queries are not warmed
+	Warmer() *warmer.Warmer
+	// SetWarmer sets the Query Warmer for the Backend
+	SetWarmer(*warmer.Warmer)
 	// ProcessTransformations executes any provider-specific transformations, like injecting
 	// labels into the dataset
 	ProcessTransformations(timeseries.Timeseries)
@@ -82,6 +88,21 @@ type MergeableTimeseriesBackend interface {
 	MergeablePaths() []string
 }
 
+// StepNormalizingTimeseriesBackend defines the interface for time series backends that
+// support normalizing query steps to the backend's configured canonical steps
+type StepNormalizingTimeseriesBackend interface {
+	// NormalizeStep should normalize the TimeRangeQuery's Step to the provided canonical
+	// steps and change the upstream request to use it, returning true if the Step changed
+	NormalizeStep(*http.Request, *timeseries.TimeRangeQuery, []time.Duration) bool
+}
+
+// MetricNamingBackend defines the interface for backends that can identify the
+// metric names referenced by a request's query, for tagging cached objects
+type MetricNamingBackend interface {
+	// MetricNames should return the names of the metrics referenced by the request
+	MetricNames(*http.Request) []string
+}
+
 var _ TimeseriesBackend = (*timeseriesBackend)(nil)
 
 type timeseriesBackend struct {
@@ -0,0 +1,30 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package options
+
+// DefaultTopN is the default number of most-requested queries that are warmed
+const DefaultTopN = 20
+
+// DefaultIntervalMS is the default interval in milliseconds between warming cycles
+const DefaultIntervalMS = 30000
+
+// DefaultConcurrency is the default number of queries warmed concurrently
+const DefaultConcurrency = 4
+
+// DefaultTemplateTTLMS is the default time in milliseconds that a query is retained
+// for warming after it was last requested by a client
+const DefaultTemplateTTLMS = 600000
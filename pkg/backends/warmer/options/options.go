@@ -0,0 +1,114 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package options provides the options for the Time Series Query Warmer
+package options
+
+import (
+	"errors"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/util/yamlx"
+)
+
+// Options defines options for the Query Warmer
+type Options struct {
+	// TopN is the maximum number of the most frequently requested queries to warm each cycle
+	TopN int `yaml:"top_n,omitempty"`
+	// IntervalMS is the interval in milliseconds between warming cycles
+	IntervalMS int `yaml:"interval_ms,omitempty"`
+	// Concurrency is the maximum number of queries warmed concurrently during a cycle
+	Concurrency int `yaml:"concurrency,omitempty"`
+	// TemplateTTLMS is the time in milliseconds that a query is retained for warming
+	// after it was last requested by a client
+	TemplateTTLMS int `yaml:"template_ttl_ms,omitempty"`
+
+	// Interval is the time.Duration representation of IntervalMS
+	Interval time.Duration `yaml:"-"`
+	// TemplateTTL is the time.Duration representation of TemplateTTLMS
+	TemplateTTL time.Duration `yaml:"-"`
+}
+
+// New returns a New Options object with the default values
+func New() *Options {
+	return &Options{
+		TopN:          DefaultTopN,
+		IntervalMS:    DefaultIntervalMS,
+		Concurrency:   DefaultConcurrency,
+		TemplateTTLMS: DefaultTemplateTTLMS,
+		Interval:      time.Duration(DefaultIntervalMS) * time.Millisecond,
+		TemplateTTL:   time.Duration(DefaultTemplateTTLMS) * time.Millisecond,
+	}
+}
+
+// Clone returns a perfect copy of the Options
+func (o *Options) Clone() *Options {
+	return &Options{
+		TopN:          o.TopN,
+		IntervalMS:    o.IntervalMS,
+		Concurrency:   o.Concurrency,
+		TemplateTTLMS: o.TemplateTTLMS,
+		Interval:      o.Interval,
+		TemplateTTL:   o.TemplateTTL,
+	}
+}
+
+// SetDefaults iterates the provided Options, and overlays user-set values onto the default Options
+func SetDefaults(name string, options *Options, metadata yamlx.KeyLookup) (*Options, error) {
+
+	if metadata == nil || options == nil {
+		return nil, nil
+	}
+
+	if !metadata.IsDefined("backends", name, "warmer") {
+		return nil, nil
+	}
+
+	o := New()
+
+	if metadata.IsDefined("backends", name, "warmer", "top_n") {
+		if options.TopN < 1 {
+			return nil, errors.New("value for 'top_n' must be >= 1")
+		}
+		o.TopN = options.TopN
+	}
+
+	if metadata.IsDefined("backends", name, "warmer", "interval_ms") {
+		if options.IntervalMS < 1 {
+			return nil, errors.New("value for 'interval_ms' must be >= 1")
+		}
+		o.IntervalMS = options.IntervalMS
+	}
+
+	if metadata.IsDefined("backends", name, "warmer", "concurrency") {
+		if options.Concurrency < 1 {
+			return nil, errors.New("value for 'concurrency' must be >= 1")
+		}
+		o.Concurrency = options.Concurrency
+	}
+
+	if metadata.IsDefined("backends", name, "warmer", "template_ttl_ms") {
+		if options.TemplateTTLMS < 1 {
+			return nil, errors.New("value for 'template_ttl_ms' must be >= 1")
+		}
+		o.TemplateTTLMS = options.TemplateTTLMS
+	}
+
+	o.Interval = time.Duration(o.IntervalMS) * time.Millisecond
+	o.TemplateTTL = time.Duration(o.TemplateTTLMS) * time.Millisecond
+
+	return o, nil
+}
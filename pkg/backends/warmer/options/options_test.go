@@ -0,0 +1,141 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package options
+
+import (
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/util/yamlx"
+
+	"gopkg.in/yaml.v2"
+)
+
+type testOptions1 struct {
+	Backends map[string]*testOptions2 `yaml:"backends,omitempty"`
+}
+
+type testOptions2 struct {
+	Warmer *Options `yaml:"warmer,omitempty"`
+}
+
+func fromYAML(conf string) (*Options, yamlx.KeyLookup, error) {
+	to := &testOptions1{}
+	err := yaml.Unmarshal([]byte(conf), to)
+	if err != nil {
+		return nil, nil, err
+	}
+	md, err := yamlx.GetKeyList(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, v := range to.Backends {
+		if v != nil && v.Warmer != nil {
+			return v.Warmer, md, nil
+		}
+	}
+	return &Options{}, md, nil
+}
+
+const testYAML = `
+backends:
+  test:
+    warmer:
+      top_n: 5
+      interval_ms: 10000
+      concurrency: 2
+      template_ttl_ms: 60000
+`
+
+const testYAMLDefaults = `
+backends:
+  test:
+    warmer:
+      top_n: 5
+`
+
+const testYAMLBadTopN = `
+backends:
+  test:
+    warmer:
+      top_n: 0
+`
+
+const testYAMLNoWarmer = `
+backends:
+  test:
+    provider: prometheus
+`
+
+func TestNew(t *testing.T) {
+	o := New()
+	if o.TopN != DefaultTopN || o.Interval != time.Duration(DefaultIntervalMS)*time.Millisecond {
+		t.Error("expected default values")
+	}
+}
+
+func TestClone(t *testing.T) {
+	o := New()
+	o.TopN = 3
+	co := o.Clone()
+	if co.TopN != 3 || co.Concurrency != DefaultConcurrency || co.TemplateTTL != o.TemplateTTL {
+		t.Error("clone mismatch")
+	}
+}
+
+func TestSetDefaults(t *testing.T) {
+
+	o, md, err := fromYAML(testYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err = SetDefaults("test", o, md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.TopN != 5 || o.Concurrency != 2 || o.Interval != 10*time.Second ||
+		o.TemplateTTL != time.Minute {
+		t.Errorf("unexpected options %v", o)
+	}
+
+	o, md, _ = fromYAML(testYAMLDefaults)
+	o, err = SetDefaults("test", o, md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.TopN != 5 || o.Concurrency != DefaultConcurrency ||
+		o.Interval != time.Duration(DefaultIntervalMS)*time.Millisecond {
+		t.Errorf("unexpected options %v", o)
+	}
+
+	o, md, _ = fromYAML(testYAMLBadTopN)
+	_, err = SetDefaults("test", o, md)
+	if err == nil {
+		t.Error("expected error for invalid top_n")
+	}
+
+	o, md, _ = fromYAML(testYAMLNoWarmer)
+	o, err = SetDefaults("test", o, md)
+	if err != nil || o != nil {
+		t.Error("expected nil options and error")
+	}
+
+	o, err = SetDefaults("test", nil, nil)
+	if err != nil || o != nil {
+		t.Error("expected nil options and error")
+	}
+}
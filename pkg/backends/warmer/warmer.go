@@ -0,0 +1,294 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package warmer records frequently requested time series queries for a Backend,
+// and periodically re-issues them through the Backend's router so that the
+// Delta Proxy Cache is current ahead of client demand
+package warmer
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	wo "github.com/trickstercache/trickster/pkg/backends/warmer/options"
+	"github.com/trickstercache/trickster/pkg/cache/status"
+	tl "github.com/trickstercache/trickster/pkg/observability/logging"
+	"github.com/trickstercache/trickster/pkg/observability/metrics"
+	tctx "github.com/trickstercache/trickster/pkg/proxy/context"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+)
+
+// minRelativeWindow is the minimum distance from the current time that the end of a
+// query's time range may be for the query to be considered relative (e.g., 'last 1h')
+const minRelativeWindow = time.Minute
+
+// scoreDecay is the factor by which each template's request score is reduced after
+// every warming cycle, so that the most recently popular queries are favored
+const scoreDecay = 0.5
+
+// Client is the subset of the Timeseries Backend interface used by the Warmer
+type Client interface {
+	// Router returns a Router that handles HTTP Requests for the Backend
+	Router() http.Handler
+	// SetExtent will update a request's timerange parameters based on the provided timeseries.Extent
+	SetExtent(*http.Request, *timeseries.TimeRangeQuery, *timeseries.Extent)
+}
+
+// Lookup is a map of Warmers keyed by Backend Name
+type Lookup map[string]*Warmer
+
+// Stop stops all Warmers in the Lookup
+func (l Lookup) Stop() {
+	for _, w := range l {
+		w.Stop()
+	}
+}
+
+// Warmer records the query templates requested of a Backend, and periodically re-issues
+// the most frequently requested templates for the current time
+type Warmer struct {
+	name     string
+	provider string
+	options  *wo.Options
+	client   Client
+	logger   interface{}
+
+	mtx       sync.Mutex
+	templates map[string]*template
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// template is a recorded query, whose time range is relative to the current time
+type template struct {
+	request  *http.Request
+	body     []byte
+	trq      *timeseries.TimeRangeQuery
+	duration time.Duration
+	score    float64
+	lastSeen time.Time
+	warming  bool
+}
+
+// New returns a new Warmer for the provided Backend
+func New(name, provider string, o *wo.Options, client Client, logger interface{}) *Warmer {
+	if o == nil {
+		o = wo.New()
+	}
+	return &Warmer{
+		name:      name,
+		provider:  provider,
+		options:   o,
+		client:    client,
+		logger:    logger,
+		templates: make(map[string]*template),
+	}
+}
+
+// Start begins the Warmer's intervaled warming cycles
+func (w *Warmer) Start() {
+	if w.cancel != nil {
+		w.Stop()
+	}
+	w.ctx, w.cancel = context.WithCancel(tctx.WithWarmerFlag(context.Background(), true))
+	w.wg.Add(1)
+	go w.loop(w.ctx)
+}
+
+// Stop ends the Warmer's intervaled warming cycles, and waits for any in-progress cycle to complete
+func (w *Warmer) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	w.wg.Wait()
+	w.cancel = nil
+}
+
+func (w *Warmer) loop(ctx context.Context) {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.options.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.warm(ctx, time.Now())
+		}
+	}
+}
+
+// Observe records a client request for the provided TimeRangeQuery, along with the
+// cache lookup status with which the request was served
+func (w *Warmer) Observe(r *http.Request, trq *timeseries.TimeRangeQuery,
+	cacheStatus status.LookupStatus) {
+	if w == nil || r == nil || trq == nil || trq.Step <= 0 {
+		return
+	}
+	if tctx.WarmerFlag(r.Context()) {
+		metrics.ProxyWarmerFetches.WithLabelValues(w.name, w.provider, cacheStatus.String()).Inc()
+		return
+	}
+	now := time.Now()
+	// only queries with a time range ending at about the current time (e.g., those made by
+	// dashboards displaying the last N hours) benefit from being warmed
+	window := trq.Step * 2
+	if window < minRelativeWindow {
+		window = minRelativeWindow
+	}
+	if trq.IsOffset || now.Sub(trq.Extent.End) > window {
+		return
+	}
+	duration := trq.Extent.End.Sub(trq.Extent.Start)
+	key := templateKey(r, trq, duration)
+
+	w.mtx.Lock()
+	t, ok := w.templates[key]
+	if !ok {
+		t = newTemplate(r, trq, duration)
+		w.templates[key] = t
+		metrics.ProxyWarmerTemplates.WithLabelValues(w.name, w.provider).Set(float64(len(w.templates)))
+	}
+	t.score++
+	t.lastSeen = now
+	warming := t.warming
+	w.mtx.Unlock()
+
+	if warming {
+		result := "cold"
+		if cacheStatus == status.LookupStatusHit {
+			result = "warm"
+		}
+		metrics.ProxyWarmerRequests.WithLabelValues(w.name, w.provider, result).Inc()
+	}
+}
+
+// templateKey returns a key identifying a query independently of its absolute time range
+func templateKey(r *http.Request, trq *timeseries.TimeRangeQuery, duration time.Duration) string {
+	var rawQuery string
+	if trq.TemplateURL != nil {
+		rawQuery = trq.TemplateURL.RawQuery
+	}
+	return r.Method + " " + r.URL.Path + "?" + rawQuery + "\n" + trq.Statement + "\n" +
+		strconv.FormatInt(int64(trq.Step), 10) + "\n" + strconv.FormatInt(int64(duration), 10)
+}
+
+func newTemplate(r *http.Request, trq *timeseries.TimeRangeQuery,
+	duration time.Duration) *template {
+	return &template{
+		request:  r.Clone(context.Background()),
+		body:     tctx.RequestBody(r.Context()),
+		trq:      trq.Clone(),
+		duration: duration,
+	}
+}
+
+// selectTemplates removes templates that have not been requested within the template TTL,
+// and returns the top N remaining templates by request score
+func (w *Warmer) selectTemplates(now time.Time) []*template {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	list := make([]*template, 0, len(w.templates))
+	for k, t := range w.templates {
+		if now.Sub(t.lastSeen) > w.options.TemplateTTL {
+			delete(w.templates, k)
+			continue
+		}
+		list = append(list, t)
+	}
+	metrics.ProxyWarmerTemplates.WithLabelValues(w.name, w.provider).Set(float64(len(w.templates)))
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].score == list[j].score {
+			return list[i].lastSeen.After(list[j].lastSeen)
+		}
+		return list[i].score > list[j].score
+	})
+	for i, t := range list {
+		t.warming = i < w.options.TopN
+		t.score *= scoreDecay
+	}
+	if len(list) > w.options.TopN {
+		list = list[:w.options.TopN]
+	}
+	return list
+}
+
+// warm re-issues the top N templates for a time range ending at the provided time
+func (w *Warmer) warm(ctx context.Context, now time.Time) {
+	list := w.selectTemplates(now)
+	if len(list) == 0 {
+		return
+	}
+	tl.Debug(w.logger, "warming queries",
+		tl.Pairs{"backendName": w.name, "queryCount": len(list)})
+	concurrency := w.options.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, t := range list {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(t *template) {
+			w.issue(ctx, t, now)
+			<-sem
+			wg.Done()
+		}(t)
+	}
+	wg.Wait()
+}
+
+// issue sends the template's request through the Backend's router for the time range
+// of the template's duration, ending at the provided time
+func (w *Warmer) issue(ctx context.Context, t *template, now time.Time) {
+	r := t.request.Clone(ctx)
+	if t.body != nil {
+		r.Body = io.NopCloser(bytes.NewReader(t.body))
+		r.ContentLength = int64(len(t.body))
+		r = r.WithContext(tctx.WithRequestBody(ctx, t.body))
+	}
+	e := &timeseries.Extent{Start: now.Add(-t.duration), End: now}
+	w.client.SetExtent(r, t.trq.Clone(), e)
+	w.client.Router().ServeHTTP(&discardWriter{header: make(http.Header)}, r)
+}
+
+// discardWriter is an http.ResponseWriter that discards the response written to it
+type discardWriter struct {
+	header http.Header
+}
+
+func (dw *discardWriter) Header() http.Header {
+	return dw.header
+}
+
+func (dw *discardWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (dw *discardWriter) WriteHeader(int) {}
@@ -0,0 +1,209 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package warmer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	wo "github.com/trickstercache/trickster/pkg/backends/warmer/options"
+	"github.com/trickstercache/trickster/pkg/cache/status"
+	tctx "github.com/trickstercache/trickster/pkg/proxy/context"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+)
+
+type testClient struct {
+	mtx      sync.Mutex
+	requests []*http.Request
+}
+
+func (c *testClient) Router() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mtx.Lock()
+		c.requests = append(c.requests, r)
+		c.mtx.Unlock()
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("test"))
+	})
+}
+
+func (c *testClient) SetExtent(r *http.Request, trq *timeseries.TimeRangeQuery,
+	e *timeseries.Extent) {
+	v := r.URL.Query()
+	v.Set("start", strconv.FormatInt(e.Start.Unix(), 10))
+	v.Set("end", strconv.FormatInt(e.End.Unix(), 10))
+	r.URL.RawQuery = v.Encode()
+}
+
+func testRequest(query string, duration time.Duration) (*http.Request,
+	*timeseries.TimeRangeQuery) {
+	end := time.Now().Truncate(time.Minute)
+	start := end.Add(-duration)
+	v := url.Values{"query": {query}, "step": {"60"},
+		"start": {strconv.FormatInt(start.Unix(), 10)},
+		"end":   {strconv.FormatInt(end.Unix(), 10)}}
+	r, _ := http.NewRequest(http.MethodGet, "http://0/api/v1/query_range?"+v.Encode(), nil)
+	trq := &timeseries.TimeRangeQuery{Statement: query, Step: time.Minute,
+		Extent: timeseries.Extent{Start: start, End: end}}
+	return r, trq
+}
+
+func TestObserve(t *testing.T) {
+
+	w := New("test", "prometheus", nil, &testClient{}, nil)
+
+	r, trq := testRequest("up", time.Hour)
+	w.Observe(r, trq, status.LookupStatusKeyMiss)
+	w.Observe(r, trq, status.LookupStatusHit)
+
+	// a different duration is a different template
+	r, trq = testRequest("up", 2*time.Hour)
+	w.Observe(r, trq, status.LookupStatusKeyMiss)
+
+	// queries not ending at about the current time are not recorded
+	r, trq = testRequest("absolute", time.Hour)
+	trq.Extent.End = trq.Extent.End.Add(-time.Hour)
+	w.Observe(r, trq, status.LookupStatusKeyMiss)
+
+	// requests issued by the warmer are not recorded
+	r, trq = testRequest("warmer", time.Hour)
+	r = r.WithContext(tctx.WithWarmerFlag(context.Background(), true))
+	w.Observe(r, trq, status.LookupStatusHit)
+
+	// nil values are ignored
+	w.Observe(nil, trq, status.LookupStatusHit)
+	w.Observe(r, nil, status.LookupStatusHit)
+	var nw *Warmer
+	nw.Observe(r, trq, status.LookupStatusHit)
+
+	if len(w.templates) != 2 {
+		t.Fatalf("expected %d got %d", 2, len(w.templates))
+	}
+	r, trq = testRequest("up", time.Hour)
+	tmpl, ok := w.templates[templateKey(r, trq, time.Hour)]
+	if !ok {
+		t.Fatal("expected template for query")
+	}
+	if tmpl.score != 2 {
+		t.Errorf("expected %d got %f", 2, tmpl.score)
+	}
+}
+
+func TestSelectTemplates(t *testing.T) {
+
+	o := wo.New()
+	o.TopN = 2
+	o.TemplateTTL = time.Minute
+	w := New("test", "prometheus", o, &testClient{}, nil)
+
+	for i, q := range []string{"a", "b", "c"} {
+		r, trq := testRequest(q, time.Hour)
+		for j := 0; j <= i; j++ {
+			w.Observe(r, trq, status.LookupStatusKeyMiss)
+		}
+	}
+
+	r, trq := testRequest("old", time.Hour)
+	w.Observe(r, trq, status.LookupStatusKeyMiss)
+	w.templates[templateKey(r, trq, time.Hour)].lastSeen = time.Now().Add(-2 * time.Minute)
+
+	list := w.selectTemplates(time.Now())
+	if len(list) != 2 {
+		t.Fatalf("expected %d got %d", 2, len(list))
+	}
+	if list[0].trq.Statement != "c" || list[1].trq.Statement != "b" {
+		t.Errorf("unexpected template order %s, %s", list[0].trq.Statement, list[1].trq.Statement)
+	}
+	if len(w.templates) != 3 {
+		t.Errorf("expected %d got %d", 3, len(w.templates))
+	}
+	if list[0].score != 1.5 {
+		t.Errorf("expected %f got %f", 1.5, list[0].score)
+	}
+	r, trq = testRequest("a", time.Hour)
+	if w.templates[templateKey(r, trq, time.Hour)].warming {
+		t.Error("expected template to not be warming")
+	}
+}
+
+func TestWarm(t *testing.T) {
+
+	c := &testClient{}
+	o := wo.New()
+	o.Concurrency = 1
+	w := New("test", "prometheus", o, c, nil)
+
+	r, trq := testRequest("up", time.Hour)
+	w.Observe(r, trq, status.LookupStatusKeyMiss)
+
+	now := time.Now().Add(time.Minute)
+	w.warm(context.Background(), now)
+
+	if len(c.requests) != 1 {
+		t.Fatalf("expected %d got %d", 1, len(c.requests))
+	}
+	v := c.requests[0].URL.Query()
+	if v.Get("query") != "up" {
+		t.Errorf("expected %s got %s", "up", v.Get("query"))
+	}
+	if v.Get("end") != strconv.FormatInt(now.Unix(), 10) {
+		t.Errorf("expected %d got %s", now.Unix(), v.Get("end"))
+	}
+	if v.Get("start") != strconv.FormatInt(now.Add(-time.Hour).Unix(), 10) {
+		t.Errorf("expected %d got %s", now.Add(-time.Hour).Unix(), v.Get("start"))
+	}
+
+	// once warming, client requests are classified as warm or cold
+	w.Observe(r, trq, status.LookupStatusHit)
+	if !w.templates[templateKey(r, trq, time.Hour)].warming {
+		t.Error("expected template to be warming")
+	}
+}
+
+func TestStartStop(t *testing.T) {
+
+	c := &testClient{}
+	o := wo.New()
+	o.Interval = 10 * time.Millisecond
+	w := New("test", "prometheus", o, c, nil)
+	r, trq := testRequest("up", time.Hour)
+	w.Observe(r, trq, status.LookupStatusKeyMiss)
+
+	l := Lookup{"test": w}
+	w.Start()
+	time.Sleep(50 * time.Millisecond)
+	l.Stop()
+
+	c.mtx.Lock()
+	n := len(c.requests)
+	c.mtx.Unlock()
+	if n == 0 {
+		t.Error("expected warming requests")
+	}
+	for _, r := range c.requests {
+		if !tctx.WarmerFlag(r.Context()) {
+			t.Error("expected warmer flag on request")
+		}
+	}
+	// stopping an already-stopped warmer is a no-op
+	w.Stop()
+}
@@ -136,6 +136,23 @@ func (c *Cache) BulkRemove(cacheKeys []string) {
 	})
 }
 
+// Keys returns the keys of all objects in the cache that begin with the provided prefix
+func (c *Cache) Keys(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	err := c.dbh.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			keys = append(keys, string(it.Item().KeyCopy(nil)))
+		}
+		return nil
+	})
+	return keys, err
+}
+
 // Close closes the Badger Cache
 func (c *Cache) Close() error {
 	return c.dbh.Close()
@@ -158,6 +175,15 @@ func (c *Cache) SetTTL(cacheKey string, ttl time.Duration) {
 	}
 }
 
+// RemainingTTL returns the time until the object expires
+func (c *Cache) RemainingTTL(cacheKey string) (time.Duration, bool) {
+	expires, err := c.getExpires(cacheKey)
+	if err != nil || expires == 0 {
+		return 0, false
+	}
+	return time.Until(time.Unix(int64(expires), 0)), true
+}
+
 func (c *Cache) getExpires(cacheKey string) (int, error) {
 	var expires int
 	err := c.dbh.View(func(txn *badger.Txn) error {
@@ -170,6 +170,32 @@ func TestBadgerCache_BulkRemove(t *testing.T) {
 
 }
 
+func TestBadgerCache_Keys(t *testing.T) {
+	testDbPath := t.TempDir() + "/test.db"
+	cacheConfig := newCacheConfig(testDbPath)
+	bc := Cache{Config: cacheConfig, Logger: tl.ConsoleLogger("error")}
+
+	if err := bc.Connect(); err != nil {
+		t.Error(err)
+	}
+	defer bc.Close()
+
+	for _, k := range []string{"a.1", "a.2", "b.1"} {
+		err := bc.Store(k, []byte("data"), time.Duration(60)*time.Second)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	keys, err := bc.Keys("a.")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(keys) != 2 || keys[0] != "a.1" || keys[1] != "a.2" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+}
+
 func TestBadgerCache_Retrieve(t *testing.T) {
 	testDbPath := t.TempDir() + "/test.db"
 	cacheConfig := newCacheConfig(testDbPath)
@@ -45,6 +45,16 @@ type Cache struct {
 	dbh *bbolt.DB
 }
 
+// CacheIndex returns the cache's index
+func (c *Cache) CacheIndex() *index.Index {
+	return c.Index
+}
+
+// RemainingTTL returns the time until the object expires, per the cache index
+func (c *Cache) RemainingTTL(cacheKey string) (time.Duration, bool) {
+	return c.Index.RemainingTTL(cacheKey)
+}
+
 // Locker returns the cache's locker
 func (c *Cache) Locker() locks.NamedLocker {
 	return c.locker
@@ -226,6 +236,12 @@ func (c *Cache) BulkRemove(cacheKeys []string) {
 		}(cacheKey)
 	}
 	wg.Wait()
+	c.Index.RemoveObjects(cacheKeys, false)
+}
+
+// Keys returns the keys of all objects in the cache that begin with the provided prefix
+func (c *Cache) Keys(prefix string) ([]string, error) {
+	return c.Index.Keys(prefix), nil
 }
 
 // Close closes the Cache
@@ -31,7 +31,8 @@ import (
 var ErrKNF = errors.New("key not found in cache")
 
 // Cache is the interface for the supported caching fabrics
-// When making new cache providers, Retrieve() must return an error on cache miss
+// When making new cache providers, Retrieve() must return an error on cache miss,
+// and Keys() must return every stored key beginning with the provided prefix
 type Cache interface {
 	Connect() error
 	Store(cacheKey string, data []byte, ttl time.Duration) error
@@ -39,12 +40,20 @@ type Cache interface {
 	SetTTL(cacheKey string, ttl time.Duration)
 	Remove(cacheKey string)
 	BulkRemove(cacheKeys []string)
+	Keys(prefix string) ([]string, error)
 	Close() error
 	Configuration() *options.Options
 	Locker() locks.NamedLocker
 	SetLocker(locks.NamedLocker)
 }
 
+// TTLReporter is the interface for a cache that can report the remaining TTL of an object
+type TTLReporter interface {
+	// RemainingTTL returns the time until the object expires, and false when the object
+	// is not found or does not expire
+	RemainingTTL(cacheKey string) (time.Duration, bool)
+}
+
 // MemoryCache is the interface for an in-memory cache
 // This offers an additional method for storing references to bypass serialization
 type MemoryCache interface {
@@ -54,6 +63,7 @@ type MemoryCache interface {
 	SetTTL(cacheKey string, ttl time.Duration)
 	Remove(cacheKey string)
 	BulkRemove(cacheKeys []string)
+	Keys(prefix string) ([]string, error)
 	Close() error
 	Configuration() *options.Options
 	StoreReference(cacheKey string, data ReferenceObject, ttl time.Duration) error
@@ -67,3 +77,12 @@ type MemoryCache interface {
 type ReferenceObject interface {
 	Size() int
 }
+
+// PersistableReferenceObject defines an interface for a ReferenceObject that can be serialized,
+// so that memory caches storing it by reference can be snapshotted to disk
+type PersistableReferenceObject interface {
+	ReferenceObject
+	// MarshalReference returns the object serialized in the format in which it would be
+	// written to a cache that does not store references
+	MarshalReference() ([]byte, error)
+}
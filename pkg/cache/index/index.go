@@ -19,6 +19,7 @@ package index
 
 import (
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -44,6 +45,9 @@ type Index struct {
 	ObjectCount int64 `msg:"object_count"`
 	// Objects is a map of Objects in the Cache
 	Objects map[string]*Object `msg:"objects"`
+	// Inflation is the priority of the most recently evicted object, which ages the
+	// priorities of objects under the lfu and gdsf eviction policies
+	Inflation float64 `msg:"inflation"`
 
 	name           string                             `msg:"-"`
 	cacheProvider  string                             `msg:"-"`
@@ -51,17 +55,31 @@ type Index struct {
 	bulkRemoveFunc func([]string)                     `msg:"-"`
 	flushFunc      func(cacheKey string, data []byte) `msg:"-"`
 	lastWrite      time.Time                          `msg:"-"`
+	policy         EvictionPolicy                     `msg:"-"`
 
-	isClosing     bool
+	stop          chan struct{}
+	stopOnce      sync.Once
+	wg            sync.WaitGroup
 	flusherExited bool
 	reaperExited  bool
 
 	mtx sync.Mutex
 }
 
-// Close is called to signal the index to shut down any subroutines
+// Indexer is implemented by Caches whose retention is managed by an Index
+type Indexer interface {
+	// CacheIndex returns the Index of the Cache
+	CacheIndex() *Index
+}
+
+// Close signals the index to shut down any subroutines, and waits for them to exit
 func (idx *Index) Close() {
-	idx.isClosing = true
+	idx.stopOnce.Do(func() {
+		if idx.stop != nil {
+			close(idx.stop)
+		}
+	})
+	idx.wg.Wait()
 }
 
 // ToBytes returns a serialized byte slice representing the Index
@@ -83,11 +101,15 @@ type Object struct {
 	LastAccess time.Time `msg:"lastaccess"`
 	// Size the size of the Object in bytes
 	Size int64 `msg:"size"`
+	// AccessCount is the number of times the object has been written or accessed
+	AccessCount int64 `msg:"access_count"`
+	// Priority is the eviction priority of the object under the Index's eviction policy
+	Priority float64 `msg:"priority"`
 	// Value is the value of the Object stored in the Cache
 	// It is used by Caches but not by the Index
 	Value []byte `msg:"value,omitempty"`
 	// DirectValue is an interface value for storing objects by reference to a memory cache
-	// Since we'd never recover a memory cache index from memory on startup, no need to msgpk
+	// Memory cache snapshots serialize it into Value, so no need to msgpk
 	ReferenceValue cache.ReferenceObject `msg:"-"`
 }
 
@@ -121,9 +143,15 @@ func NewIndex(cacheName, cacheProvider string, indexData []byte, o *options.Opti
 	i.flushFunc = flushFunc
 	i.bulkRemoveFunc = bulkRemoveFunc
 	i.options = o
+	i.policy = getEvictionPolicy(o.EvictionPolicy)
+	i.stop = make(chan struct{})
+	for _, obj := range i.Objects {
+		i.policy.Prioritize(obj, i.Inflation)
+	}
 
 	if flushFunc != nil {
 		if o.FlushInterval > 0 {
+			i.wg.Add(1)
 			go i.flusher(logger)
 		} else {
 			tl.Warn(logger, "cache index flusher did not start",
@@ -132,6 +160,7 @@ func NewIndex(cacheName, cacheProvider string, indexData []byte, o *options.Opti
 	}
 
 	if o.ReapInterval > 0 {
+		i.wg.Add(1)
 		go i.reaper(logger)
 	} else {
 		tl.Warn(logger, "cache reaper did not start",
@@ -147,6 +176,13 @@ func NewIndex(cacheName, cacheProvider string, indexData []byte, o *options.Opti
 // UpdateOptions updates the existing Index with a new Options reference
 func (idx *Index) UpdateOptions(o *options.Options) {
 	idx.mtx.Lock()
+	if idx.options == nil || o.EvictionPolicy != idx.options.EvictionPolicy {
+		idx.policy = getEvictionPolicy(o.EvictionPolicy)
+		idx.Inflation = 0
+		for _, obj := range idx.Objects {
+			idx.policy.Prioritize(obj, idx.Inflation)
+		}
+	}
 	idx.options = o
 	idx.mtx.Unlock()
 }
@@ -154,8 +190,10 @@ func (idx *Index) UpdateOptions(o *options.Options) {
 // UpdateObjectAccessTime updates the LastAccess for the object with the provided key
 func (idx *Index) UpdateObjectAccessTime(key string) {
 	idx.mtx.Lock()
-	if _, ok := idx.Objects[key]; ok {
-		idx.Objects[key].LastAccess = time.Now()
+	if o, ok := idx.Objects[key]; ok {
+		o.LastAccess = time.Now()
+		o.AccessCount++
+		idx.policy.Prioritize(o, idx.Inflation)
 	}
 	idx.mtx.Unlock()
 
@@ -193,10 +231,13 @@ func (idx *Index) UpdateObject(obj *Object) {
 
 	if o, ok := idx.Objects[key]; ok {
 		atomic.AddInt64(&idx.CacheSize, obj.Size-o.Size)
+		obj.AccessCount = o.AccessCount
 	} else {
 		atomic.AddInt64(&idx.CacheSize, obj.Size)
 		atomic.AddInt64(&idx.ObjectCount, 1)
+		obj.AccessCount = 1
 	}
+	idx.policy.Prioritize(obj, idx.Inflation)
 
 	metrics.ObserveCacheSizeChange(idx.name, idx.cacheProvider, idx.CacheSize, idx.ObjectCount)
 
@@ -204,6 +245,31 @@ func (idx *Index) UpdateObject(obj *Object) {
 	idx.mtx.Unlock()
 }
 
+// RestoreObject writes the Index Metadata for an Object restored from a snapshot,
+// retaining its original LastWrite and LastAccess times and AccessCount
+func (idx *Index) RestoreObject(obj *Object) {
+	lw, la, ac := obj.LastWrite, obj.LastAccess, obj.AccessCount
+	idx.UpdateObject(obj)
+	idx.mtx.Lock()
+	obj.LastWrite = lw
+	obj.LastAccess = la
+	if ac > 0 {
+		obj.AccessCount = ac
+		idx.policy.Prioritize(obj, idx.Inflation)
+	}
+	idx.mtx.Unlock()
+}
+
+// Metadata returns a copy of the Index Metadata for the object with the provided key
+func (idx *Index) Metadata(key string) (Object, bool) {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+	if o, ok := idx.Objects[key]; ok {
+		return *o, true
+	}
+	return Object{}, false
+}
+
 // RemoveObject removes an Object's Metadata from the Index
 func (idx *Index) RemoveObject(key string) {
 	idx.mtx.Lock()
@@ -240,6 +306,20 @@ func (idx *Index) RemoveObjects(keys []string, noLock bool) {
 	}
 }
 
+// Keys returns the keys of all Objects in the Index that begin with the provided prefix
+func (idx *Index) Keys(prefix string) []string {
+	idx.mtx.Lock()
+	keys := make([]string, 0, len(idx.Objects))
+	for k := range idx.Objects {
+		if k != IndexKey && strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	idx.mtx.Unlock()
+	sort.Strings(keys)
+	return keys
+}
+
 // GetExpiration returns the cache index's expiration for the object of the given key
 func (idx *Index) GetExpiration(cacheKey string) time.Time {
 	idx.mtx.Lock()
@@ -251,18 +331,36 @@ func (idx *Index) GetExpiration(cacheKey string) time.Time {
 	return time.Time{}
 }
 
+// RemainingTTL returns the time until the object of the given key expires, and false
+// when the object is not found or does not expire
+func (idx *Index) RemainingTTL(cacheKey string) (time.Duration, bool) {
+	e := idx.GetExpiration(cacheKey)
+	if e.IsZero() {
+		return 0, false
+	}
+	return time.Until(e), true
+}
+
 // flusher periodically calls the cache's index flush func that writes the cache index to disk
 func (idx *Index) flusher(logger interface{}) {
+	defer idx.wg.Done()
 	var lastFlush time.Time
-	for !idx.isClosing {
-		time.Sleep(idx.options.FlushInterval)
-		if idx.lastWrite.Before(lastFlush) {
+	for {
+		select {
+		case <-idx.stop:
+			idx.flusherExited = true
+			return
+		case <-time.After(idx.flushInterval()):
+		}
+		idx.mtx.Lock()
+		lw := idx.lastWrite
+		idx.mtx.Unlock()
+		if lw.Before(lastFlush) {
 			continue
 		}
 		idx.flushOnce(logger)
 		lastFlush = time.Now()
 	}
-	idx.flusherExited = true
 }
 
 func (idx *Index) flushOnce(logger interface{}) {
@@ -279,11 +377,28 @@ func (idx *Index) flushOnce(logger interface{}) {
 
 // reaper continually iterates through the cache to find expired elements and removes them
 func (idx *Index) reaper(logger interface{}) {
-	for !idx.isClosing {
+	defer idx.wg.Done()
+	for {
 		idx.reap(logger)
-		time.Sleep(idx.options.ReapInterval)
+		select {
+		case <-idx.stop:
+			idx.reaperExited = true
+			return
+		case <-time.After(idx.reapInterval()):
+		}
 	}
-	idx.reaperExited = true
+}
+
+func (idx *Index) flushInterval() time.Duration {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+	return idx.options.FlushInterval
+}
+
+func (idx *Index) reapInterval() time.Duration {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+	return idx.options.ReapInterval
 }
 
 type objectsAtime []*Object
@@ -314,7 +429,7 @@ func (idx *Index) reap(logger interface{}) {
 	}
 
 	if len(removals) > 0 {
-		metrics.ObserveCacheEvent(idx.name, idx.cacheProvider, "eviction", "ttl")
+		metrics.ObserveCacheEvictions(idx.name, idx.cacheProvider, "ttl", len(removals))
 		go idx.bulkRemoveFunc(removals)
 		idx.RemoveObjects(removals, true)
 		cacheChanged = true
@@ -334,9 +449,10 @@ func (idx *Index) reap(logger interface{}) {
 		}
 
 		tl.Debug(logger,
-			"max cache size reached. evicting least-recently-accessed records",
+			"max cache size reached. evicting records by eviction policy",
 			tl.Pairs{
 				"reason":         evictionType,
+				"policy":         idx.options.EvictionPolicy,
 				"cacheSizeBytes": idx.CacheSize, "maxSizeBytes": idx.options.MaxSizeBytes,
 				"cacheSizeObjects": idx.ObjectCount, "maxSizeObjects": idx.options.MaxSizeObjects,
 			},
@@ -344,7 +460,7 @@ func (idx *Index) reap(logger interface{}) {
 
 		removals = make([]string, 0)
 
-		sort.Sort(remainders)
+		idx.policy.Sort(remainders)
 
 		i := 0
 		j := len(remainders)
@@ -373,8 +489,16 @@ func (idx *Index) reap(logger interface{}) {
 			}
 		}
 
+		// age the priorities of the remaining objects by raising the inflation
+		// to the highest priority evicted
+		for _, o := range remainders[:i] {
+			if o.Priority > idx.Inflation {
+				idx.Inflation = o.Priority
+			}
+		}
+
 		if len(removals) > 0 {
-			metrics.ObserveCacheEvent(idx.name, idx.cacheProvider, "eviction", evictionType)
+			metrics.ObserveCacheEvictions(idx.name, idx.cacheProvider, evictionType, len(removals))
 			go idx.bulkRemoveFunc(removals)
 			idx.RemoveObjects(removals, true)
 			cacheChanged = true
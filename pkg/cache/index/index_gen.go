@@ -94,6 +94,12 @@ func (z *Index) DecodeMsg(dc *msgp.Reader) (err error) {
 				}
 				z.Objects[za0001] = za0002
 			}
+		case "inflation":
+			z.Inflation, err = dc.ReadFloat64()
+			if err != nil {
+				err = msgp.WrapError(err, "Inflation")
+				return
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -107,9 +113,9 @@ func (z *Index) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *Index) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 3
+	// map header, size 4
 	// write "cache_size"
-	err = en.Append(0x83, 0xaa, 0x63, 0x61, 0x63, 0x68, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65)
+	err = en.Append(0x84, 0xaa, 0x63, 0x61, 0x63, 0x68, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65)
 	if err != nil {
 		return
 	}
@@ -157,15 +163,25 @@ func (z *Index) EncodeMsg(en *msgp.Writer) (err error) {
 			}
 		}
 	}
+	// write "inflation"
+	err = en.Append(0xa9, 0x69, 0x6e, 0x66, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat64(z.Inflation)
+	if err != nil {
+		err = msgp.WrapError(err, "Inflation")
+		return
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *Index) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 3
+	// map header, size 4
 	// string "cache_size"
-	o = append(o, 0x83, 0xaa, 0x63, 0x61, 0x63, 0x68, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65)
+	o = append(o, 0x84, 0xaa, 0x63, 0x61, 0x63, 0x68, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65)
 	o = msgp.AppendInt64(o, z.CacheSize)
 	// string "object_count"
 	o = append(o, 0xac, 0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74)
@@ -185,6 +201,9 @@ func (z *Index) MarshalMsg(b []byte) (o []byte, err error) {
 			}
 		}
 	}
+	// string "inflation"
+	o = append(o, 0xa9, 0x69, 0x6e, 0x66, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendFloat64(o, z.Inflation)
 	return
 }
 
@@ -259,6 +278,12 @@ func (z *Index) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				}
 				z.Objects[za0001] = za0002
 			}
+		case "inflation":
+			z.Inflation, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Inflation")
+				return
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -285,6 +310,7 @@ func (z *Index) Msgsize() (s int) {
 			}
 		}
 	}
+	s += 10 + msgp.Float64Size
 	return
 }
 
@@ -336,6 +362,18 @@ func (z *Object) DecodeMsg(dc *msgp.Reader) (err error) {
 				err = msgp.WrapError(err, "Size")
 				return
 			}
+		case "access_count":
+			z.AccessCount, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "AccessCount")
+				return
+			}
+		case "priority":
+			z.Priority, err = dc.ReadFloat64()
+			if err != nil {
+				err = msgp.WrapError(err, "Priority")
+				return
+			}
 		case "value":
 			z.Value, err = dc.ReadBytes(z.Value)
 			if err != nil {
@@ -356,11 +394,11 @@ func (z *Object) DecodeMsg(dc *msgp.Reader) (err error) {
 // EncodeMsg implements msgp.Encodable
 func (z *Object) EncodeMsg(en *msgp.Writer) (err error) {
 	// omitempty: check for empty values
-	zb0001Len := uint32(6)
-	var zb0001Mask uint8 /* 6 bits */
+	zb0001Len := uint32(8)
+	var zb0001Mask uint8 /* 8 bits */
 	if z.Value == nil {
 		zb0001Len--
-		zb0001Mask |= 0x20
+		zb0001Mask |= 0x80
 	}
 	// variable map header, size zb0001Len
 	err = en.Append(0x80 | uint8(zb0001Len))
@@ -420,7 +458,27 @@ func (z *Object) EncodeMsg(en *msgp.Writer) (err error) {
 		err = msgp.WrapError(err, "Size")
 		return
 	}
-	if (zb0001Mask & 0x20) == 0 { // if not empty
+	// write "access_count"
+	err = en.Append(0xac, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.AccessCount)
+	if err != nil {
+		err = msgp.WrapError(err, "AccessCount")
+		return
+	}
+	// write "priority"
+	err = en.Append(0xa8, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79)
+	if err != nil {
+		return
+	}
+	err = en.WriteFloat64(z.Priority)
+	if err != nil {
+		err = msgp.WrapError(err, "Priority")
+		return
+	}
+	if (zb0001Mask & 0x80) == 0 { // if not empty
 		// write "value"
 		err = en.Append(0xa5, 0x76, 0x61, 0x6c, 0x75, 0x65)
 		if err != nil {
@@ -439,11 +497,11 @@ func (z *Object) EncodeMsg(en *msgp.Writer) (err error) {
 func (z *Object) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
 	// omitempty: check for empty values
-	zb0001Len := uint32(6)
-	var zb0001Mask uint8 /* 6 bits */
+	zb0001Len := uint32(8)
+	var zb0001Mask uint8 /* 8 bits */
 	if z.Value == nil {
 		zb0001Len--
-		zb0001Mask |= 0x20
+		zb0001Mask |= 0x80
 	}
 	// variable map header, size zb0001Len
 	o = append(o, 0x80|uint8(zb0001Len))
@@ -465,7 +523,13 @@ func (z *Object) MarshalMsg(b []byte) (o []byte, err error) {
 	// string "size"
 	o = append(o, 0xa4, 0x73, 0x69, 0x7a, 0x65)
 	o = msgp.AppendInt64(o, z.Size)
-	if (zb0001Mask & 0x20) == 0 { // if not empty
+	// string "access_count"
+	o = append(o, 0xac, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74)
+	o = msgp.AppendInt64(o, z.AccessCount)
+	// string "priority"
+	o = append(o, 0xa8, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79)
+	o = msgp.AppendFloat64(o, z.Priority)
+	if (zb0001Mask & 0x80) == 0 { // if not empty
 		// string "value"
 		o = append(o, 0xa5, 0x76, 0x61, 0x6c, 0x75, 0x65)
 		o = msgp.AppendBytes(o, z.Value)
@@ -521,6 +585,18 @@ func (z *Object) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				err = msgp.WrapError(err, "Size")
 				return
 			}
+		case "access_count":
+			z.AccessCount, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "AccessCount")
+				return
+			}
+		case "priority":
+			z.Priority, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Priority")
+				return
+			}
 		case "value":
 			z.Value, bts, err = msgp.ReadBytesBytes(bts, z.Value)
 			if err != nil {
@@ -541,6 +617,6 @@ func (z *Object) UnmarshalMsg(bts []byte) (o []byte, err error) {
 
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z *Object) Msgsize() (s int) {
-	s = 1 + 4 + msgp.StringPrefixSize + len(z.Key) + 11 + msgp.TimeSize + 10 + msgp.TimeSize + 11 + msgp.TimeSize + 5 + msgp.Int64Size + 6 + msgp.BytesPrefixSize + len(z.Value)
+	s = 1 + 4 + msgp.StringPrefixSize + len(z.Key) + 11 + msgp.TimeSize + 10 + msgp.TimeSize + 11 + msgp.TimeSize + 5 + msgp.Int64Size + 13 + msgp.Int64Size + 9 + msgp.Float64Size + 6 + msgp.BytesPrefixSize + len(z.Value)
 	return
 }
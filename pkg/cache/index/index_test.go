@@ -56,8 +56,8 @@ func TestNewIndex(t *testing.T) {
 
 	idx.flushOnce(testLogger)
 
+	// close waits for the reaper and flusher to exit
 	idx.Close()
-	time.Sleep(500 * time.Millisecond)
 	if !idx.reaperExited {
 		t.Error("expected true")
 	}
@@ -69,6 +69,7 @@ func TestNewIndex(t *testing.T) {
 	if idx2 == nil {
 		t.Errorf("nil cache index")
 	}
+	idx2.Close()
 
 	cacheConfig.Index.FlushInterval = 0
 	cacheConfig.Index.ReapInterval = 0
@@ -333,3 +334,48 @@ func TestRemoveObjects(t *testing.T) {
 		t.Error("key should not be in map")
 	}
 }
+
+func TestKeys(t *testing.T) {
+	cacheConfig := &co.Options{Provider: "test",
+		Index: &io.Options{ReapInterval: time.Second * time.Duration(10),
+			FlushInterval: time.Second * time.Duration(10)}}
+	idx := NewIndex("test", "test", nil, cacheConfig.Index, testBulkRemoveFunc, fakeFlusherFunc, testLogger)
+	idx.UpdateObject(&Object{Key: "a.test1", Value: []byte("test_value")})
+	idx.UpdateObject(&Object{Key: "a.test2", Value: []byte("test_value")})
+	idx.UpdateObject(&Object{Key: "b.test1", Value: []byte("test_value")})
+	idx.UpdateObject(&Object{Key: IndexKey, Value: []byte("test_value")})
+
+	keys := idx.Keys("a.")
+	if len(keys) != 2 || keys[0] != "a.test1" || keys[1] != "a.test2" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+
+	keys = idx.Keys("")
+	if len(keys) != 3 {
+		t.Errorf("expected %d got %d", 3, len(keys))
+	}
+}
+
+func TestRestoreObject(t *testing.T) {
+	cacheConfig := &co.Options{Provider: "test",
+		Index: &io.Options{ReapInterval: time.Second * time.Duration(10),
+			FlushInterval: time.Second * time.Duration(10)}}
+	idx := NewIndex("test", "test", nil, cacheConfig.Index, testBulkRemoveFunc, fakeFlusherFunc, testLogger)
+	lw := time.Unix(1609459200, 0)
+	la := time.Unix(1609462800, 0)
+	idx.RestoreObject(&Object{Key: "test", Value: []byte("test_value"), LastWrite: lw, LastAccess: la})
+
+	o, ok := idx.Metadata("test")
+	if !ok {
+		t.Fatal("expected metadata for key")
+	}
+	if !o.LastWrite.Equal(lw) || !o.LastAccess.Equal(la) {
+		t.Errorf("unexpected times %v %v", o.LastWrite, o.LastAccess)
+	}
+	if o.Size != 10 || idx.ObjectCount != 1 {
+		t.Errorf("unexpected size %d or count %d", o.Size, idx.ObjectCount)
+	}
+	if _, ok = idx.Metadata("missing"); ok {
+		t.Error("expected no metadata for missing key")
+	}
+}
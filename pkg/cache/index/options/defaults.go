@@ -29,4 +29,6 @@ const (
 	DefaultMaxSizeObjects = 0
 	// DefaultMaxSizeBackoffObjects is the default Max Cache Backoff Object Count
 	DefaultMaxSizeBackoffObjects = 100
+	// DefaultEvictionPolicy is the default Eviction Policy
+	DefaultEvictionPolicy = EvictionPolicyLRU
 )
@@ -18,8 +18,28 @@ package options
 
 import (
 	"time"
+
+	strutil "github.com/trickstercache/trickster/pkg/util/strings"
+)
+
+const (
+	// EvictionPolicyLRU evicts the least-recently-accessed objects first
+	EvictionPolicyLRU = "lru"
+	// EvictionPolicyLFU evicts the least-frequently-accessed objects first, aging the
+	// access counts of objects remaining in the cache as others are evicted
+	EvictionPolicyLFU = "lfu"
+	// EvictionPolicyGDSF evicts objects by Greedy-Dual-Size-Frequency, which favors
+	// evicting large and infrequently-accessed objects, with the same aging as LFU
+	EvictionPolicyGDSF = "gdsf"
 )
 
+// EvictionPolicies is a lookup of the supported Eviction Policy names
+var EvictionPolicies = strutil.Lookup{
+	EvictionPolicyLRU:  nil,
+	EvictionPolicyLFU:  nil,
+	EvictionPolicyGDSF: nil,
+}
+
 // Options defines the operation of the Cache Indexer
 type Options struct {
 	// ReapIntervalMS defines how long the Cache Index reaper sleeps between reap cycles
@@ -38,6 +58,9 @@ type Options struct {
 	// MaxSizeBackoffObjects indicates how far under max_size_objects the cache size must
 	// be to complete object-size-based eviction exercise.
 	MaxSizeBackoffObjects int64 `yaml:"max_size_backoff_objects,omitempty"`
+	// EvictionPolicy determines which objects are evicted first when the cache exceeds
+	// its maximum size. Options are lru, lfu and gdsf
+	EvictionPolicy string `yaml:"eviction_policy,omitempty"`
 
 	ReapInterval  time.Duration `yaml:"-"`
 	FlushInterval time.Duration `yaml:"-"`
@@ -52,6 +75,7 @@ func New() *Options {
 		MaxSizeBackoffBytes:   DefaultMaxSizeBackoffBytes,
 		MaxSizeObjects:        DefaultMaxSizeObjects,
 		MaxSizeBackoffObjects: DefaultMaxSizeBackoffObjects,
+		EvictionPolicy:        DefaultEvictionPolicy,
 	}
 }
 
@@ -68,5 +92,6 @@ func (o *Options) Equal(o2 *Options) bool {
 		o.MaxSizeBytes == o2.MaxSizeBytes &&
 		o.MaxSizeBackoffBytes == o2.MaxSizeBackoffBytes &&
 		o.MaxSizeObjects == o2.MaxSizeObjects &&
-		o.MaxSizeBackoffObjects == o2.MaxSizeBackoffObjects
+		o.MaxSizeBackoffObjects == o2.MaxSizeBackoffObjects &&
+		o.EvictionPolicy == o2.EvictionPolicy
 }
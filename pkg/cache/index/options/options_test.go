@@ -38,3 +38,12 @@ func TestEqual(t *testing.T) {
 	}
 
 }
+
+func TestEqualEvictionPolicy(t *testing.T) {
+	o := New()
+	o2 := New()
+	o2.EvictionPolicy = EvictionPolicyLFU
+	if o.Equal(o2) {
+		t.Error("expected false")
+	}
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package index
+
+import (
+	"sort"
+
+	"github.com/trickstercache/trickster/pkg/cache/index/options"
+)
+
+// EvictionPolicy determines the order in which an Index evicts objects when its cache
+// exceeds its maximum size
+type EvictionPolicy interface {
+	// Prioritize sets the eviction Priority of an object as it is written or accessed,
+	// given the current Inflation of the Index
+	Prioritize(o *Object, inflation float64)
+	// Sort orders the objects so that those to be evicted first come first
+	Sort(objects []*Object)
+}
+
+var evictionPolicies = map[string]EvictionPolicy{
+	options.EvictionPolicyLRU:  lruPolicy{},
+	options.EvictionPolicyLFU:  lfuPolicy{},
+	options.EvictionPolicyGDSF: gdsfPolicy{},
+}
+
+// getEvictionPolicy returns the EvictionPolicy for the provided name,
+// or the LRU policy if the name is unknown
+func getEvictionPolicy(name string) EvictionPolicy {
+	if p, ok := evictionPolicies[name]; ok {
+		return p
+	}
+	return lruPolicy{}
+}
+
+// lruPolicy evicts the least-recently-accessed objects first
+type lruPolicy struct{}
+
+func (lruPolicy) Prioritize(o *Object, inflation float64) {}
+
+func (lruPolicy) Sort(objects []*Object) {
+	sort.Sort(objectsAtime(objects))
+}
+
+// lfuPolicy evicts the least-frequently-accessed objects first, using Dynamic Aging: an
+// object's priority is its access count plus the Index's inflation as of its last access.
+// Since the inflation rises to the priority of each evicted object, objects that were
+// popular in the past cannot remain in the cache indefinitely once they go unaccessed.
+type lfuPolicy struct{}
+
+func (lfuPolicy) Prioritize(o *Object, inflation float64) {
+	o.Priority = inflation + float64(o.AccessCount)
+}
+
+func (lfuPolicy) Sort(objects []*Object) {
+	sort.Sort(objectsPriority(objects))
+}
+
+// gdsfPolicy evicts objects by Greedy-Dual-Size-Frequency, where an object's priority is its
+// access count divided by its size, plus the Index's inflation as of its last access. This
+// keeps many small, frequently-accessed objects in preference to a few large ones.
+type gdsfPolicy struct{}
+
+func (gdsfPolicy) Prioritize(o *Object, inflation float64) {
+	size := o.Size
+	if size < 1 {
+		size = 1
+	}
+	o.Priority = inflation + float64(o.AccessCount)/float64(size)
+}
+
+func (gdsfPolicy) Sort(objects []*Object) {
+	sort.Sort(objectsPriority(objects))
+}
+
+type objectsPriority []*Object
+
+// Len returns the number of elements in the subject slice
+func (o objectsPriority) Len() int {
+	return len(o)
+}
+
+// Less returns true if i comes before j
+func (o objectsPriority) Less(i, j int) bool {
+	if o[i].Priority == o[j].Priority {
+		return o[i].LastAccess.Before(o[j].LastAccess)
+	}
+	return o[i].Priority < o[j].Priority
+}
+
+// Swap modifies the subject slice by swapping the values in indexes i and j
+func (o objectsPriority) Swap(i, j int) {
+	o[i], o[j] = o[j], o[i]
+}
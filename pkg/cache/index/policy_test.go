@@ -0,0 +1,112 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package index
+
+import (
+	"testing"
+	"time"
+
+	io "github.com/trickstercache/trickster/pkg/cache/index/options"
+)
+
+func newPolicyTestIndex(policy string) *Index {
+	o := &io.Options{ReapInterval: time.Second * time.Duration(10),
+		MaxSizeObjects: 3, MaxSizeBackoffObjects: 1, EvictionPolicy: policy}
+	return NewIndex("test", "test", nil, o, testBulkRemoveFunc, nil, testLogger)
+}
+
+func TestEvictionPolicyLFU(t *testing.T) {
+
+	idx := newPolicyTestIndex(io.EvictionPolicyLFU)
+	for _, k := range []string{"a", "b", "c", "d"} {
+		idx.UpdateObject(&Object{Key: k, Value: []byte("test_value")})
+	}
+	// a and d are accessed frequently, while b is accessed most recently
+	for i := 0; i < 3; i++ {
+		idx.UpdateObjectAccessTime("a")
+		idx.UpdateObjectAccessTime("d")
+	}
+	idx.UpdateObjectAccessTime("b")
+
+	// 4 objects exceeds the max of 3, so 2 are evicted with the backoff
+	idx.reap(testLogger)
+	for _, k := range []string{"b", "c"} {
+		if _, ok := idx.Objects[k]; ok {
+			t.Errorf("expected key %s to be missing", k)
+		}
+	}
+	for _, k := range []string{"a", "d"} {
+		if _, ok := idx.Objects[k]; !ok {
+			t.Errorf("expected key %s to be present", k)
+		}
+	}
+
+	// the inflation rises to the highest priority evicted, ageing the survivors
+	if idx.Inflation != 2 {
+		t.Errorf("expected %f got %f", 2.0, idx.Inflation)
+	}
+	idx.UpdateObject(&Object{Key: "e", Value: []byte("test_value")})
+	if idx.Objects["e"].Priority != 3 {
+		t.Errorf("expected %f got %f", 3.0, idx.Objects["e"].Priority)
+	}
+}
+
+func TestEvictionPolicyGDSF(t *testing.T) {
+
+	idx := newPolicyTestIndex(io.EvictionPolicyGDSF)
+	idx.UpdateObject(&Object{Key: "large", Value: make([]byte, 1000)})
+	for _, k := range []string{"a", "b", "c"} {
+		idx.UpdateObject(&Object{Key: k, Value: []byte("test_value")})
+	}
+	// the large object is accessed more often, but not enough to offset its size
+	for i := 0; i < 5; i++ {
+		idx.UpdateObjectAccessTime("large")
+	}
+	idx.UpdateObjectAccessTime("c")
+
+	idx.reap(testLogger)
+	if _, ok := idx.Objects["large"]; ok {
+		t.Error("expected large object to be evicted")
+	}
+	if _, ok := idx.Objects["c"]; !ok {
+		t.Error("expected key c to be present")
+	}
+	if idx.Inflation == 0 {
+		t.Error("expected inflation to be raised")
+	}
+}
+
+func TestUpdateOptionsEvictionPolicy(t *testing.T) {
+
+	idx := newPolicyTestIndex(io.EvictionPolicyLRU)
+	idx.UpdateObject(&Object{Key: "a", Value: []byte("test_value")})
+	idx.UpdateObjectAccessTime("a")
+	if idx.Objects["a"].Priority != 0 {
+		t.Errorf("expected %f got %f", 0.0, idx.Objects["a"].Priority)
+	}
+
+	o := io.New()
+	o.EvictionPolicy = io.EvictionPolicyLFU
+	idx.UpdateOptions(o)
+	if idx.Objects["a"].Priority != 2 {
+		t.Errorf("expected %f got %f", 2.0, idx.Objects["a"].Priority)
+	}
+
+	if _, ok := getEvictionPolicy("invalid").(lruPolicy); !ok {
+		t.Error("expected lru policy for unknown name")
+	}
+}
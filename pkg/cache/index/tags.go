@@ -0,0 +1,273 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package index
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/cache"
+	"github.com/trickstercache/trickster/pkg/cache/status"
+	"github.com/trickstercache/trickster/pkg/locks"
+	"github.com/trickstercache/trickster/pkg/util/md5"
+)
+
+// TagKeyPrefix is the reserved prefix of the cache keys under which a TagIndex is stored
+const TagKeyPrefix = "trickster.tags."
+
+// TagIndex maps tags to the keys of the cache objects tagged with them, so that groups
+// of objects can be located and purged without knowing their keys. The TagIndex is stored
+// in its cache, under keys beginning with TagKeyPrefix: one object per tag lists the keys
+// tagged with it, and one object per tagged key lists its tags. So it is available to every
+// cache provider, survives restarts of persistent caches, and is shared by the Trickster
+// instances sharing a cache like Redis. Updates are serialized within a process only, so
+// concurrent updates of a tag from different instances may drop keys from the tag.
+type TagIndex struct {
+	cache  cache.Cache
+	locker locks.NamedLocker
+}
+
+// taggedKeys maps the keys tagged with a tag to the UnixNano time at which they expire
+type taggedKeys map[string]int64
+
+// keyTags is the stored record of the tags of a tagged key
+type keyTags struct {
+	Tags       []string `json:"tags"`
+	Expiration int64    `json:"expiration"`
+}
+
+// NewTagIndex returns a new TagIndex stored in the provided cache
+func NewTagIndex(c cache.Cache) *TagIndex {
+	return &TagIndex{cache: c, locker: locks.NewNamedLocker()}
+}
+
+var tagIndexes = struct {
+	lookup map[string]*TagIndex
+	mtx    sync.Mutex
+}{lookup: make(map[string]*TagIndex)}
+
+// TagIndexFor returns the TagIndex for the cache, creating it if necessary. The
+// TagIndex is replaced when a cache of the same name replaces the cache, as on reload
+func TagIndexFor(c cache.Cache) *TagIndex {
+	var name string
+	if cc := c.Configuration(); cc != nil {
+		name = cc.Name
+	}
+	tagIndexes.mtx.Lock()
+	defer tagIndexes.mtx.Unlock()
+	ti, ok := tagIndexes.lookup[name]
+	if !ok || ti.cache != c {
+		ti = NewTagIndex(c)
+		tagIndexes.lookup[name] = ti
+	}
+	return ti
+}
+
+// tagKey returns the cache key of the list of keys tagged with the tag
+func tagKey(tag string) string {
+	return TagKeyPrefix + "tag." + md5.Checksum(tag)
+}
+
+// keyTagsKey returns the cache key of the record of the tags of the key
+func keyTagsKey(key string) string {
+	return TagKeyPrefix + "key." + md5.Checksum(key)
+}
+
+// Tag replaces the tags of the object stored under key. The tags expire along with the
+// object after the ttl. An object tagged with no tags, or with no ttl, is not tagged,
+// though any tags from an earlier version of the object remain until they expire.
+func (ti *TagIndex) Tag(key string, tags []string, ttl time.Duration) {
+	if len(tags) == 0 || ttl <= 0 {
+		return
+	}
+	kk := keyTagsKey(key)
+	nl, _ := ti.locker.Acquire(kk)
+	defer nl.Release()
+
+	now := time.Now()
+	tags = sortedTags(tags)
+	prev, _ := ti.loadKeyTags(kk)
+	// the tag lists hold each key for twice its ttl, so that they need not be rewritten
+	// each time an object is stored with the same tags, until it is stored past that time
+	if prev != nil && equalTags(prev.Tags, tags) &&
+		prev.Expiration >= now.Add(ttl).UnixNano() {
+		return
+	}
+	exp := now.Add(2 * ttl).UnixNano()
+	if prev != nil {
+		for _, t := range prev.Tags {
+			if !containsTag(tags, t) {
+				ti.updateTag(t, map[string]int64{key: 0}, now)
+			}
+		}
+	}
+	for _, t := range tags {
+		ti.updateTag(t, map[string]int64{key: exp}, now)
+	}
+	b, _ := json.Marshal(&keyTags{Tags: tags, Expiration: exp})
+	ti.cache.Store(kk, b, time.Duration(exp-now.UnixNano()))
+}
+
+// Keys returns the sorted keys of the unexpired objects tagged with tag
+func (ti *TagIndex) Keys(tag string) []string {
+	tk, _ := ti.loadTaggedKeys(tagKey(tag))
+	if len(tk) == 0 {
+		return nil
+	}
+	now := time.Now().UnixNano()
+	out := make([]string, 0, len(tk))
+	for k, exp := range tk {
+		if exp > now {
+			out = append(out, k)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Tags returns the sorted tags of the object stored under key
+func (ti *TagIndex) Tags(key string) []string {
+	kt, _ := ti.loadKeyTags(keyTagsKey(key))
+	if kt == nil || kt.Expiration <= time.Now().UnixNano() {
+		return nil
+	}
+	return kt.Tags
+}
+
+// Remove removes the keys from the TagIndex
+func (ti *TagIndex) Remove(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	// the keys are locked in order, and the lists of each tag are updated once
+	sorted := sortedTags(keys)
+	removals := make(map[string]map[string]int64)
+	for i, k := range sorted {
+		if i > 0 && k == sorted[i-1] {
+			continue
+		}
+		kk := keyTagsKey(k)
+		nl, _ := ti.locker.Acquire(kk)
+		defer nl.Release()
+		kt, _ := ti.loadKeyTags(kk)
+		if kt == nil {
+			continue
+		}
+		for _, t := range kt.Tags {
+			m, ok := removals[t]
+			if !ok {
+				m = make(map[string]int64)
+				removals[t] = m
+			}
+			m[k] = 0
+		}
+		ti.cache.Remove(kk)
+	}
+	now := time.Now()
+	for t, m := range removals {
+		ti.updateTag(t, m, now)
+	}
+}
+
+// updateTag sets the expirations of the keys in the list of keys tagged with tag,
+// removing those whose expiration is 0, and prunes any expired keys from the list
+func (ti *TagIndex) updateTag(tag string, updates map[string]int64, now time.Time) {
+	tk := tagKey(tag)
+	nl, _ := ti.locker.Acquire(tk)
+	defer nl.Release()
+	keys, _ := ti.loadTaggedKeys(tk)
+	if keys == nil {
+		keys = make(taggedKeys)
+	}
+	for k, exp := range updates {
+		if exp == 0 {
+			delete(keys, k)
+		} else {
+			keys[k] = exp
+		}
+	}
+	var last int64
+	for k, exp := range keys {
+		if exp <= now.UnixNano() {
+			delete(keys, k)
+		} else if exp > last {
+			last = exp
+		}
+	}
+	if len(keys) == 0 {
+		ti.cache.Remove(tk)
+		return
+	}
+	b, _ := json.Marshal(keys)
+	ti.cache.Store(tk, b, time.Duration(last-now.UnixNano()))
+}
+
+func (ti *TagIndex) loadTaggedKeys(tk string) (taggedKeys, error) {
+	b, ls, err := ti.cache.Retrieve(tk, false)
+	if err != nil || ls != status.LookupStatusHit || len(b) == 0 {
+		return nil, err
+	}
+	keys := make(taggedKeys)
+	if err = json.Unmarshal(b, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (ti *TagIndex) loadKeyTags(kk string) (*keyTags, error) {
+	b, ls, err := ti.cache.Retrieve(kk, false)
+	if err != nil || ls != status.LookupStatusHit || len(b) == 0 {
+		return nil, err
+	}
+	kt := &keyTags{}
+	if err = json.Unmarshal(b, kt); err != nil {
+		return nil, err
+	}
+	return kt, nil
+}
+
+// sortedTags returns a sorted copy of the tags or keys
+func sortedTags(tags []string) []string {
+	out := make([]string, len(tags))
+	copy(out, tags)
+	sort.Strings(out)
+	return out
+}
+
+// equalTags returns true if the sorted tag lists are identical
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// containsTag returns true if the sorted tag list contains the tag
+func containsTag(tags []string, tag string) bool {
+	i := sort.SearchStrings(tags, tag)
+	return i < len(tags) && tags[i] == tag
+}
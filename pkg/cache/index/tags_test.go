@@ -0,0 +1,191 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package index
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/cache"
+	co "github.com/trickstercache/trickster/pkg/cache/options"
+	"github.com/trickstercache/trickster/pkg/cache/status"
+	"github.com/trickstercache/trickster/pkg/locks"
+)
+
+// testTagCache is a minimal cache.Cache backed by a map, standing in for a
+// cache provider that stores the TagIndex
+type testTagCache struct {
+	name    string
+	objects map[string]testTagObject
+	mtx     sync.Mutex
+}
+
+type testTagObject struct {
+	data       []byte
+	expiration time.Time
+}
+
+func newTestTagCache(name string) *testTagCache {
+	return &testTagCache{name: name, objects: make(map[string]testTagObject)}
+}
+
+func (c *testTagCache) Connect() error { return nil }
+
+func (c *testTagCache) Store(key string, data []byte, ttl time.Duration) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.objects[key] = testTagObject{data: data, expiration: time.Now().Add(ttl)}
+	return nil
+}
+
+func (c *testTagCache) Retrieve(key string, allowExpired bool) ([]byte, status.LookupStatus, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	o, ok := c.objects[key]
+	if !ok || (!allowExpired && !time.Now().Before(o.expiration)) {
+		return nil, status.LookupStatusKeyMiss, cache.ErrKNF
+	}
+	return o.data, status.LookupStatusHit, nil
+}
+
+func (c *testTagCache) SetTTL(key string, ttl time.Duration) {}
+
+func (c *testTagCache) Remove(key string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	delete(c.objects, key)
+}
+
+func (c *testTagCache) BulkRemove(keys []string) {
+	for _, k := range keys {
+		c.Remove(k)
+	}
+}
+
+func (c *testTagCache) Keys(prefix string) ([]string, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	var out []string
+	for k := range c.objects {
+		if strings.HasPrefix(k, prefix) {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+func (c *testTagCache) Close() error                { return nil }
+func (c *testTagCache) Configuration() *co.Options  { return &co.Options{Name: c.name} }
+func (c *testTagCache) Locker() locks.NamedLocker   { return nil }
+func (c *testTagCache) SetLocker(locks.NamedLocker) {}
+func (c *testTagCache) objectCount() int            { return len(c.objects) }
+
+func TestTagIndex(t *testing.T) {
+
+	c := newTestTagCache("test")
+	ti := NewTagIndex(c)
+	ti.Tag("key1", []string{"tenant:a", "metric:up"}, time.Minute)
+	ti.Tag("key2", []string{"metric:up"}, time.Minute)
+
+	if got := strings.Join(ti.Keys("metric:up"), ","); got != "key1,key2" {
+		t.Errorf("unexpected keys %s", got)
+	}
+	if got := strings.Join(ti.Tags("key1"), ","); got != "metric:up,tenant:a" {
+		t.Errorf("unexpected tags %s", got)
+	}
+
+	// the tag index is stored in the cache, so a new TagIndex for the cache, as in
+	// another Trickster process, finds the tagged keys
+	ti2 := NewTagIndex(c)
+	if got := strings.Join(ti2.Keys("metric:up"), ","); got != "key1,key2" {
+		t.Errorf("unexpected keys %s", got)
+	}
+	keys, _ := c.Keys(TagKeyPrefix)
+	if len(keys) != c.objectCount() {
+		t.Errorf("expected all objects under %s got %v", TagKeyPrefix, keys)
+	}
+
+	// retagging replaces the object's tags
+	ti.Tag("key1", []string{"tenant:b"}, time.Minute)
+	if got := strings.Join(ti.Keys("metric:up"), ","); got != "key2" {
+		t.Errorf("unexpected keys %s", got)
+	}
+	if ti.Keys("tenant:a") != nil {
+		t.Error("expected no keys for tenant:a")
+	}
+
+	// tagging with no tags or ttl is ignored
+	ti.Tag("key3", nil, time.Minute)
+	ti.Tag("key3", []string{"metric:up"}, 0)
+	if ti.Tags("key3") != nil {
+		t.Error("expected key3 to be untagged")
+	}
+
+	ti.Remove("key1", "key2", "key3", "key1")
+	if ti.Keys("tenant:b") != nil || ti.Keys("metric:up") != nil || ti.Tags("key1") != nil {
+		t.Error("expected empty tag index")
+	}
+	if n := c.objectCount(); n != 0 {
+		t.Errorf("expected no tag index objects got %d", n)
+	}
+}
+
+func TestTagIndexExpiration(t *testing.T) {
+
+	c := newTestTagCache("test")
+	ti := NewTagIndex(c)
+	ti.Tag("key1", []string{"metric:up"}, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if ti.Keys("metric:up") != nil || ti.Tags("key1") != nil {
+		t.Error("expected expired key to be excluded")
+	}
+
+	// expired keys are pruned when their tag is updated
+	ti.Tag("key2", []string{"metric:up"}, time.Minute)
+	tk, _ := ti.loadTaggedKeys(tagKey("metric:up"))
+	if _, ok := tk["key1"]; ok || len(tk) != 1 {
+		t.Errorf("expected expired key to be pruned got %v", tk)
+	}
+
+	// storing an object again with the same tags does not rewrite its tag lists until
+	// they would expire before the object
+	c.Remove(tagKey("metric:up"))
+	ti.Tag("key2", []string{"metric:up"}, time.Minute)
+	if ti.Keys("metric:up") != nil {
+		t.Error("expected the tag list to be left as-is")
+	}
+	ti.Tag("key2", []string{"metric:up"}, time.Hour)
+	if got := strings.Join(ti.Keys("metric:up"), ","); got != "key2" {
+		t.Errorf("unexpected keys %s", got)
+	}
+}
+
+func TestTagIndexFor(t *testing.T) {
+	c := newTestTagCache("test-tag-index")
+	ti := TagIndexFor(c)
+	if ti == nil || TagIndexFor(c) != ti {
+		t.Error("expected the same tag index for the cache")
+	}
+	if TagIndexFor(newTestTagCache("test-tag-index-2")) == ti {
+		t.Error("expected a different tag index for a different cache")
+	}
+	if TagIndexFor(newTestTagCache("test-tag-index")) == ti {
+		t.Error("expected a new tag index for a replaced cache")
+	}
+}
@@ -19,6 +19,7 @@
 package memory
 
 import (
+	"os"
 	"sync"
 	"time"
 
@@ -40,6 +41,19 @@ type Cache struct {
 	Logger     interface{}
 	locker     locks.NamedLocker
 	lockPrefix string
+
+	snapshotStop chan bool
+	snapshotWG   sync.WaitGroup
+}
+
+// CacheIndex returns the cache's index
+func (c *Cache) CacheIndex() *index.Index {
+	return c.Index
+}
+
+// RemainingTTL returns the time until the object expires, per the cache index
+func (c *Cache) RemainingTTL(cacheKey string) (time.Duration, bool) {
+	return c.Index.RemainingTTL(cacheKey)
 }
 
 // Locker returns the cache's locker
@@ -64,6 +78,19 @@ func (c *Cache) Connect() error {
 	c.lockPrefix = c.Name + ".memory."
 	c.client = sync.Map{}
 	c.Index = index.NewIndex(c.Name, c.Config.Provider, nil, c.Config.Index, c.BulkRemove, nil, c.Logger)
+
+	if c.snapshotPath() != "" {
+		if err := c.restore(); err != nil && !os.IsNotExist(err) {
+			tl.Warn(c.Logger, "memorycache snapshot could not be restored",
+				tl.Pairs{"cacheName": c.Name, "detail": err.Error()})
+		}
+		if c.Config.Memory.SnapshotIntervalMS > 0 {
+			c.snapshotStop = make(chan bool)
+			c.snapshotWG.Add(1)
+			go c.snapshotter(time.Duration(c.Config.Memory.SnapshotIntervalMS)*time.Millisecond,
+				c.snapshotStop)
+		}
+	}
 	return nil
 }
 
@@ -190,12 +217,28 @@ func (c *Cache) BulkRemove(cacheKeys []string) {
 		}(cacheKey)
 	}
 	wg.Wait()
+	c.Index.RemoveObjects(cacheKeys, false)
+}
+
+// Keys returns the keys of all objects in the cache that begin with the provided prefix
+func (c *Cache) Keys(prefix string) ([]string, error) {
+	return c.Index.Keys(prefix), nil
 }
 
-// Close is not used for Cache, and is here to fully prototype the Cache Interface
+// Close stops the cache's subroutines and, when configured, writes a final snapshot
 func (c *Cache) Close() error {
+	if c.snapshotStop != nil {
+		close(c.snapshotStop)
+		c.snapshotWG.Wait()
+		c.snapshotStop = nil
+	}
+	err := c.Snapshot()
+	if err != nil {
+		tl.Warn(c.Logger, "memorycache snapshot failed",
+			tl.Pairs{"cacheName": c.Name, "detail": err.Error()})
+	}
 	if c.Index != nil {
 		c.Index.Close()
 	}
-	return nil
+	return err
 }
@@ -356,6 +356,39 @@ func TestCache_BulkRemove(t *testing.T) {
 
 }
 
+func TestCache_Keys(t *testing.T) {
+	cacheConfig := newCacheConfig(t)
+	mc := Cache{Config: &cacheConfig, Logger: tl.ConsoleLogger("error"), locker: testLocker}
+
+	err := mc.Connect()
+	if err != nil {
+		t.Error(err)
+	}
+	defer mc.Close()
+
+	for _, k := range []string{"a.1", "a.2", "b.1"} {
+		err = mc.Store(k, []byte("data"), time.Duration(60)*time.Second)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	keys, err := mc.Keys("a.")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("expected %d got %d", 2, len(keys))
+	}
+
+	// it should remove the keys from the index as well as the cache
+	mc.BulkRemove(keys)
+	keys, _ = mc.Keys("")
+	if len(keys) != 1 || keys[0] != "b.1" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+}
+
 func BenchmarkCache_BulkRemove(b *testing.B) {
 	var keyArray []string
 	for n := 0; n < b.N; n++ {
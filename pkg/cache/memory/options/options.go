@@ -0,0 +1,40 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package options
+
+// Options is a collection of Configurations for the memory cache
+type Options struct {
+	// SnapshotPath is the path of the file to which the memory cache is snapshotted, and from
+	// which it is restored when connected. When empty, the memory cache is not persisted
+	SnapshotPath string `yaml:"snapshot_path,omitempty"`
+	// SnapshotIntervalMS is the interval in milliseconds between snapshots of the memory cache.
+	// When 0, the memory cache is only snapshotted when it is closed
+	SnapshotIntervalMS int `yaml:"snapshot_interval_ms,omitempty"`
+}
+
+// New returns a reference to a new memory Options
+func New() *Options {
+	return &Options{SnapshotIntervalMS: DefaultSnapshotIntervalMS}
+}
+
+// Clone returns an exact copy of the subject Options
+func (o *Options) Clone() *Options {
+	return &Options{
+		SnapshotPath:       o.SnapshotPath,
+		SnapshotIntervalMS: o.SnapshotIntervalMS,
+	}
+}
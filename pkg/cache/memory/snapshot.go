@@ -0,0 +1,157 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/cache"
+	"github.com/trickstercache/trickster/pkg/cache/index"
+	tl "github.com/trickstercache/trickster/pkg/observability/logging"
+)
+
+// snapshotPath returns the configured snapshot file path, or an empty string
+// if the cache is not configured to be persisted
+func (c *Cache) snapshotPath() string {
+	if c.Config == nil || c.Config.Memory == nil {
+		return ""
+	}
+	return c.Config.Memory.SnapshotPath
+}
+
+// Snapshot writes the non-expired objects in the cache, along with their index metadata,
+// to the configured snapshot file
+func (c *Cache) Snapshot() error {
+	path := c.snapshotPath()
+	if path == "" || c.Index == nil {
+		return nil
+	}
+
+	now := time.Now()
+	s := &index.Index{Objects: make(map[string]*index.Object)}
+
+	c.client.Range(func(k, v interface{}) bool {
+		key, _ := k.(string)
+		o, ok := v.(*index.Object)
+		if !ok {
+			return true
+		}
+		md, ok := c.Index.Metadata(key)
+		if !ok || (!md.Expiration.IsZero() && md.Expiration.Before(now)) {
+			return true
+		}
+		b := o.Value
+		if o.ReferenceValue != nil {
+			pro, ok := o.ReferenceValue.(cache.PersistableReferenceObject)
+			if !ok {
+				return true
+			}
+			// the cache key lock guards the referenced object against concurrent writers
+			var err error
+			if c.locker != nil {
+				nl, _ := c.locker.RAcquire(key)
+				b, err = pro.MarshalReference()
+				nl.RRelease()
+			} else {
+				b, err = pro.MarshalReference()
+			}
+			if err != nil {
+				tl.Debug(c.Logger, "memorycache snapshot skipped object",
+					tl.Pairs{"cacheName": c.Name, "cacheKey": key, "detail": err.Error()})
+				return true
+			}
+		}
+		md.Value = b
+		md.Size = int64(len(b))
+		md.ReferenceValue = nil
+		s.Objects[key] = &md
+		s.ObjectCount++
+		s.CacheSize += md.Size
+		return true
+	})
+
+	b, err := s.MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// write to a temporary file and rename it, so an interrupted snapshot
+	// never replaces the previous one with a partial file
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return err
+	}
+	tl.Debug(c.Logger, "memorycache snapshot written",
+		tl.Pairs{"cacheName": c.Name, "path": path, "objects": s.ObjectCount, "bytes": len(b)})
+	return nil
+}
+
+// restore loads the non-expired objects in the configured snapshot file into the cache
+func (c *Cache) restore() error {
+	path := c.snapshotPath()
+	if path == "" {
+		return nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	s := &index.Index{}
+	if _, err = s.UnmarshalMsg(b); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var n int
+	for key, o := range s.Objects {
+		if o == nil || len(o.Value) == 0 || o.Expiration.IsZero() || !o.Expiration.After(now) {
+			continue
+		}
+		c.client.Store(key, &index.Object{Key: key, Value: o.Value, Expiration: o.Expiration})
+		c.Index.RestoreObject(&index.Object{Key: key, Value: o.Value, Expiration: o.Expiration,
+			LastWrite: o.LastWrite, LastAccess: o.LastAccess, AccessCount: o.AccessCount})
+		n++
+	}
+	tl.Info(c.Logger, "memorycache snapshot restored",
+		tl.Pairs{"cacheName": c.Name, "path": path, "objects": n})
+	return nil
+}
+
+// snapshotter periodically writes a snapshot of the cache until the cache is closed
+func (c *Cache) snapshotter(interval time.Duration, stop chan bool) {
+	defer c.snapshotWG.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := c.Snapshot(); err != nil {
+				tl.Warn(c.Logger, "memorycache snapshot failed",
+					tl.Pairs{"cacheName": c.Name, "detail": err.Error()})
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mo "github.com/trickstercache/trickster/pkg/cache/memory/options"
+	"github.com/trickstercache/trickster/pkg/cache/status"
+	tl "github.com/trickstercache/trickster/pkg/observability/logging"
+)
+
+type testPersistableObject struct {
+	data []byte
+}
+
+func (r *testPersistableObject) Size() int {
+	return len(r.data)
+}
+
+func (r *testPersistableObject) MarshalReference() ([]byte, error) {
+	return r.data, nil
+}
+
+func TestSnapshot(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "snapshots", "memory.snapshot")
+
+	cacheConfig := newCacheConfig(t)
+	cacheConfig.Memory = &mo.Options{SnapshotPath: path}
+	mc := &Cache{Config: &cacheConfig, Logger: tl.ConsoleLogger("error"), locker: testLocker}
+	if err := mc.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	ttl := time.Duration(60) * time.Second
+	mc.Store("bytes", []byte("data"), ttl)
+	mc.Store("expired", []byte("data"), -ttl)
+	mc.StoreReference("persistable", &testPersistableObject{data: []byte("reference")}, ttl)
+	mc.StoreReference("unpersistable", &testReferenceObject{}, ttl)
+	la := mc.Index.Objects["bytes"].LastAccess
+
+	if err := mc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("expected temporary snapshot file to be removed")
+	}
+
+	mc = &Cache{Config: &cacheConfig, Logger: tl.ConsoleLogger("error"), locker: testLocker}
+	if err := mc.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer mc.Close()
+
+	if mc.Index.ObjectCount != 2 {
+		t.Errorf("expected %d got %d", 2, mc.Index.ObjectCount)
+	}
+	if o, ok := mc.Index.Metadata("bytes"); !ok || !o.LastAccess.Equal(la) {
+		t.Errorf("expected restored access time %v", la)
+	}
+
+	tests := []struct {
+		key      string
+		expected string
+		ls       status.LookupStatus
+	}{
+		{"bytes", "data", status.LookupStatusHit},
+		{"persistable", "reference", status.LookupStatusHit},
+		{"expired", "", status.LookupStatusKeyMiss},
+		{"unpersistable", "", status.LookupStatusKeyMiss},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			b, ls, _ := mc.Retrieve(test.key, false)
+			if ls != test.ls {
+				t.Errorf("expected %s got %s", test.ls, ls)
+			}
+			if string(b) != test.expected {
+				t.Errorf("expected %s got %s", test.expected, string(b))
+			}
+		})
+	}
+}
+
+func TestSnapshotInterval(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "memory.snapshot")
+
+	cacheConfig := newCacheConfig(t)
+	cacheConfig.Memory = &mo.Options{SnapshotPath: path, SnapshotIntervalMS: 10}
+	mc := &Cache{Config: &cacheConfig, Logger: tl.ConsoleLogger("error"), locker: testLocker}
+	if err := mc.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer mc.Close()
+	mc.Store("bytes", []byte("data"), time.Duration(60)*time.Second)
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := os.Stat(path); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRestoreCorrupt(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "memory.snapshot")
+	if err := os.WriteFile(path, []byte("not a snapshot"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cacheConfig := newCacheConfig(t)
+	cacheConfig.Memory = &mo.Options{SnapshotPath: path}
+	mc := &Cache{Config: &cacheConfig, Logger: tl.ConsoleLogger("error"), locker: testLocker}
+	if err := mc.restore(); err == nil {
+		t.Error("expected error for corrupt snapshot")
+	}
+
+	// a corrupt snapshot does not prevent the cache from connecting
+	if err := mc.Connect(); err != nil {
+		t.Error(err)
+	}
+	if mc.Index.ObjectCount != 0 {
+		t.Errorf("expected %d got %d", 0, mc.Index.ObjectCount)
+	}
+}
@@ -46,11 +46,22 @@ func ObserveCacheOperation(cache, cacheProvider, operation, status string, bytes
 	}
 }
 
+// ObserveCacheTierOperation increments counters as operations occur on the tiers of a tiered cache
+func ObserveCacheTierOperation(cache, tier, tierProvider, operation, status string) {
+	metrics.CacheTierOperations.WithLabelValues(cache, tier, tierProvider, operation, status).Inc()
+}
+
 // ObserveCacheEvent increments counters as cache events occur
 func ObserveCacheEvent(cache, cacheProvider, event, reason string) {
 	metrics.CacheEvents.WithLabelValues(cache, cacheProvider, event, reason).Inc()
 }
 
+// ObserveCacheEvictions increments the eviction event counter by the number of objects
+// evicted from the cache for the provided reason
+func ObserveCacheEvictions(cache, cacheProvider, reason string, count int) {
+	metrics.CacheEvents.WithLabelValues(cache, cacheProvider, "eviction", reason).Add(float64(count))
+}
+
 // ObserveCacheSizeChange adjust counters and gauges as the cache size changes due to object operations
 func ObserveCacheSizeChange(cache, cacheProvider string, byteCount, objectCount int64) {
 	metrics.CacheObjects.WithLabelValues(cache, cacheProvider).Set(float64(objectCount))
@@ -49,10 +49,18 @@ func TestObserveCacheOperation(t *testing.T) {
 	ObserveCacheOperation(testCacheName, testCacheProvider, "set", "ok", 1)
 }
 
+func TestObserveCacheTierOperation(t *testing.T) {
+	ObserveCacheTierOperation(testCacheName, "test-tier", testCacheProvider, "get", "hit")
+}
+
 func TestObserveCacheEvent(t *testing.T) {
 	ObserveCacheEvent(testCacheName, testCacheProvider, "test", "test")
 }
 
+func TestObserveCacheEvictions(t *testing.T) {
+	ObserveCacheEvictions(testCacheName, testCacheProvider, "ttl", 2)
+}
+
 func TestObserveCacheSizeChange(t *testing.T) {
 	ObserveCacheSizeChange(testCacheName, testCacheProvider, 0, 0)
 }
@@ -25,9 +25,11 @@ import (
 	bbolt "github.com/trickstercache/trickster/pkg/cache/bbolt/options"
 	filesystem "github.com/trickstercache/trickster/pkg/cache/filesystem/options"
 	index "github.com/trickstercache/trickster/pkg/cache/index/options"
+	memory "github.com/trickstercache/trickster/pkg/cache/memory/options"
 	"github.com/trickstercache/trickster/pkg/cache/options/defaults"
 	"github.com/trickstercache/trickster/pkg/cache/providers"
 	redis "github.com/trickstercache/trickster/pkg/cache/redis/options"
+	tiered "github.com/trickstercache/trickster/pkg/cache/tiered/options"
 	strutil "github.com/trickstercache/trickster/pkg/util/strings"
 	"github.com/trickstercache/trickster/pkg/util/yamlx"
 )
@@ -43,6 +45,8 @@ type Options struct {
 	Provider string `yaml:"provider,omitempty"`
 	// Index provides options for the Cache Index
 	Index *index.Options `yaml:"index,omitempty"`
+	// Memory provides options for Memory caching
+	Memory *memory.Options `yaml:"memory,omitempty"`
 	// Redis provides options for Redis caching
 	Redis *redis.Options `yaml:"redis,omitempty"`
 	// Filesystem provides options for Filesystem caching
@@ -51,6 +55,8 @@ type Options struct {
 	BBolt *bbolt.Options `yaml:"bbolt,omitempty"`
 	// Badger provides options for BadgerDB caching
 	Badger *badger.Options `yaml:"badger,omitempty"`
+	// Tiered provides options for composing a cache from other configured caches
+	Tiered *tiered.Options `yaml:"tiered,omitempty"`
 
 	//  Synthetic Values
 
@@ -64,10 +70,12 @@ func New() *Options {
 	return &Options{
 		Provider:   defaults.DefaultCacheProvider,
 		ProviderID: defaults.DefaultCacheProviderID,
+		Memory:     memory.New(),
 		Redis:      redis.New(),
 		Filesystem: filesystem.New(),
 		BBolt:      bbolt.New(),
 		Badger:     badger.New(),
+		Tiered:     tiered.New(),
 		Index:      index.New(),
 	}
 }
@@ -86,6 +94,7 @@ func (cc *Options) Clone() *Options {
 	c.Index.MaxSizeBackoffObjects = cc.Index.MaxSizeBackoffObjects
 	c.Index.MaxSizeBytes = cc.Index.MaxSizeBytes
 	c.Index.MaxSizeObjects = cc.Index.MaxSizeObjects
+	c.Index.EvictionPolicy = cc.Index.EvictionPolicy
 	c.Index.ReapInterval = cc.Index.ReapInterval
 	c.Index.ReapIntervalMS = cc.Index.ReapIntervalMS
 
@@ -94,6 +103,10 @@ func (cc *Options) Clone() *Options {
 
 	c.Filesystem.CachePath = cc.Filesystem.CachePath
 
+	if cc.Memory != nil {
+		c.Memory = cc.Memory.Clone()
+	}
+
 	c.BBolt.Bucket = cc.BBolt.Bucket
 	c.BBolt.Filename = cc.BBolt.Filename
 
@@ -117,6 +130,8 @@ func (cc *Options) Clone() *Options {
 	c.Redis.SentinelMaster = cc.Redis.SentinelMaster
 	c.Redis.WriteTimeoutMS = cc.Redis.WriteTimeoutMS
 
+	c.Tiered = cc.Tiered.Clone()
+
 	return c
 
 }
@@ -137,6 +152,19 @@ func (cc *Options) Equal(cc2 *Options) bool {
 
 var errMaxSizeBackoffBytesTooBig = errors.New("MaxSizeBackoffBytes can't be larger than MaxSizeBytes")
 var errMaxSizeBackoffObjectsTooBig = errors.New("MaxSizeBackoffObjects can't be larger than MaxSizeObjects")
+var errNoTiers = errors.New("tiered cache must have at least one tier")
+
+// ErrInvalidTier is an error type for an invalid tier in a tiered cache
+type ErrInvalidTier struct {
+	error
+}
+
+// NewErrInvalidTier returns a new invalid tier error
+func NewErrInvalidTier(cacheName, tierName string) error {
+	return &ErrInvalidTier{
+		error: fmt.Errorf("invalid tier [%s] in tiered cache [%s]", tierName, cacheName),
+	}
+}
 
 // SetDefaults iterates the provided Options, and overlays user-set values onto the default Options
 func (l Lookup) SetDefaults(metadata yamlx.KeyLookup, activeCaches strutil.Lookup) ([]string, error) {
@@ -145,6 +173,18 @@ func (l Lookup) SetDefaults(metadata yamlx.KeyLookup, activeCaches strutil.Looku
 
 	lw := make([]string, 0)
 
+	// caches that are tiers of an active tiered cache are also active, even if
+	// they are not used directly by any backend
+	for k, v := range l {
+		if _, ok := activeCaches[k]; !ok || strings.ToLower(v.Provider) != "tiered" ||
+			v.Tiered == nil {
+			continue
+		}
+		for _, tn := range v.Tiered.Tiers {
+			activeCaches[tn] = nil
+		}
+	}
+
 	for k, v := range l {
 
 		if _, ok := activeCaches[k]; !ok {
@@ -195,6 +235,14 @@ func (l Lookup) SetDefaults(metadata yamlx.KeyLookup, activeCaches strutil.Looku
 			return nil, errMaxSizeBackoffObjectsTooBig
 		}
 
+		if metadata.IsDefined("caches", k, "index", "eviction_policy") {
+			cc.Index.EvictionPolicy = strings.ToLower(v.Index.EvictionPolicy)
+			if _, ok := index.EvictionPolicies[cc.Index.EvictionPolicy]; !ok {
+				return nil, fmt.Errorf("invalid eviction policy [%s] for cache [%s]",
+					v.Index.EvictionPolicy, k)
+			}
+		}
+
 		if cc.ProviderID == providers.Redis {
 
 			var hasEndpoint, hasEndpoints bool
@@ -292,6 +340,14 @@ func (l Lookup) SetDefaults(metadata yamlx.KeyLookup, activeCaches strutil.Looku
 			}
 		}
 
+		if metadata.IsDefined("caches", k, "memory", "snapshot_path") {
+			cc.Memory.SnapshotPath = v.Memory.SnapshotPath
+		}
+
+		if metadata.IsDefined("caches", k, "memory", "snapshot_interval_ms") {
+			cc.Memory.SnapshotIntervalMS = v.Memory.SnapshotIntervalMS
+		}
+
 		if metadata.IsDefined("caches", k, "filesystem", "cache_path") {
 			cc.Filesystem.CachePath = v.Filesystem.CachePath
 		}
@@ -312,6 +368,33 @@ func (l Lookup) SetDefaults(metadata yamlx.KeyLookup, activeCaches strutil.Looku
 			cc.Badger.ValueDirectory = v.Badger.ValueDirectory
 		}
 
+		if cc.ProviderID == providers.Tiered {
+
+			if metadata.IsDefined("caches", k, "tiered", "tiers") {
+				cc.Tiered.Tiers = v.Tiered.Tiers
+			}
+
+			if metadata.IsDefined("caches", k, "tiered", "write_behind") {
+				cc.Tiered.WriteBehind = v.Tiered.WriteBehind
+			}
+
+			if metadata.IsDefined("caches", k, "tiered", "backfill_ttl_ms") {
+				cc.Tiered.BackfillTTLMS = v.Tiered.BackfillTTLMS
+			}
+
+			if len(cc.Tiered.Tiers) == 0 {
+				return nil, errNoTiers
+			}
+
+			for _, tn := range cc.Tiered.Tiers {
+				// tiers must be other, non-tiered caches
+				t, ok := l[tn]
+				if !ok || tn == k || strings.ToLower(t.Provider) == "tiered" {
+					return nil, NewErrInvalidTier(k, tn)
+				}
+			}
+		}
+
 		l[k] = cc
 	}
 	return lw, nil
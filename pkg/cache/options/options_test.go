@@ -67,6 +67,9 @@ func TestSetDefaults(t *testing.T) {
 
 	o.Provider = "Redis"
 	o.ProviderID = providers.Redis
+	o.Memory.SnapshotPath = "/tmp/trickster.snapshot"
+	o.Memory.SnapshotIntervalMS = 30000
+	o.Index.EvictionPolicy = "GDSF"
 	l = Lookup{"default": o}
 
 	ac := strutil.Lookup{"default": nil}
@@ -79,6 +82,22 @@ func TestSetDefaults(t *testing.T) {
 		t.Errorf("expected %d got %d", 1, len(lw))
 	}
 
+	if l["default"].Memory.SnapshotPath != "/tmp/trickster.snapshot" ||
+		l["default"].Memory.SnapshotIntervalMS != 30000 {
+		t.Error("expected memory options to be set")
+	}
+
+	if l["default"].Index.EvictionPolicy != "gdsf" {
+		t.Errorf("expected %s got %s", "gdsf", l["default"].Index.EvictionPolicy)
+	}
+
+	l = Lookup{"default": o}
+	o.Index.EvictionPolicy = "invalid"
+	if _, err = l.SetDefaults(kl, ac); err == nil {
+		t.Error("expected error for invalid eviction policy")
+	}
+	o.Index.EvictionPolicy = "gdsf"
+
 	ty := strings.Replace(
 		strings.Replace(testYAML,
 			"client_type: standard", "client_type: sentinel", -1),
@@ -121,6 +140,66 @@ func TestSetDefaults(t *testing.T) {
 
 }
 
+func TestSetDefaultsTiered(t *testing.T) {
+
+	kl, err := yamlx.GetKeyList(testTieredYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newLookup := func(tiers ...string) Lookup {
+		tc := New()
+		tc.Provider = "tiered"
+		tc.Tiered.Tiers = tiers
+		tc.Tiered.WriteBehind = true
+		tc.Tiered.BackfillTTLMS = 30000
+		return Lookup{"tiered": tc, "mem": New(), "unused": New()}
+	}
+
+	// tiers of an active tiered cache are also active
+	l := newLookup("mem")
+	ac := strutil.Lookup{"tiered": nil}
+	if _, err = l.SetDefaults(kl, ac); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := l["mem"]; !ok {
+		t.Error("expected tier to be retained")
+	}
+	if _, ok := l["unused"]; ok {
+		t.Error("expected unused cache to be removed")
+	}
+	if l["tiered"].ProviderID != providers.Tiered || !l["tiered"].Tiered.WriteBehind ||
+		l["tiered"].Tiered.BackfillTTLMS != 30000 {
+		t.Errorf("unexpected tiered options %v", l["tiered"].Tiered)
+	}
+
+	for _, tiers := range [][]string{{"mem", "missing"}, {"tiered"}} {
+		l = newLookup(tiers...)
+		_, err = l.SetDefaults(kl, strutil.Lookup{"tiered": nil})
+		if _, ok := err.(*ErrInvalidTier); !ok {
+			t.Errorf("expected invalid tier error got %v", err)
+		}
+	}
+
+	l = newLookup()
+	kl, _ = yamlx.GetKeyList(strings.Replace(testTieredYAML, "tiers: [ mem ]", "tiers: []", 1))
+	if _, err = l.SetDefaults(kl, strutil.Lookup{"tiered": nil}); err != errNoTiers {
+		t.Errorf("expected %v got %v", errNoTiers, err)
+	}
+}
+
+const testTieredYAML = `
+caches:
+  tiered:
+    provider: tiered
+    tiered:
+      tiers: [ mem ]
+      write_behind: true
+      backfill_ttl_ms: 30000
+  mem:
+    provider: memory
+`
+
 const testYAML = `
 caches:
   default:
@@ -147,6 +226,9 @@ caches:
       idle_check_frequency_ms: 16
     filesystem:
       cache_path: /tmp/trickster
+    memory:
+      snapshot_path: /tmp/trickster.snapshot
+      snapshot_interval_ms: 30000
     bbolt:
       filename: trickster.bbolt.db
       bucket: trickster
@@ -160,5 +242,6 @@ caches:
       max_size_backoff_bytes: 16384
       max_size_objects: 4096
       max_size_backoff_objects: 24
+      eviction_policy: GDSF
 
 `
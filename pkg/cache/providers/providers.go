@@ -32,6 +32,8 @@ const (
 	Bbolt
 	// BadgerDB indicates a BadgerDB cache
 	BadgerDB
+	// Tiered indicates a cache composed of other configured caches
+	Tiered
 )
 
 // Names is a map of cache providers keyed by name
@@ -41,6 +43,7 @@ var Names = map[string]Provider{
 	"redis":      Redis,
 	"bbolt":      Bbolt,
 	"badger":     BadgerDB,
+	"tiered":     Tiered,
 }
 
 // Values is a map of cache providers keyed by internal id
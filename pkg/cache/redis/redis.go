@@ -19,6 +19,8 @@
 package redis
 
 import (
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/trickstercache/trickster/pkg/cache"
@@ -136,6 +138,16 @@ func (c *Cache) SetTTL(cacheKey string, ttl time.Duration) {
 	c.client.Expire(cacheKey, ttl)
 }
 
+// RemainingTTL returns the time until the object expires
+func (c *Cache) RemainingTTL(cacheKey string) (time.Duration, bool) {
+	ttl, err := c.client.TTL(cacheKey).Result()
+	// Redis reports a negative TTL for objects that are not found or do not expire
+	if err != nil || ttl <= 0 {
+		return 0, false
+	}
+	return ttl, true
+}
+
 // BulkRemove removes a list of objects from the cache. noLock is not used for Redis
 func (c *Cache) BulkRemove(cacheKeys []string) {
 	tl.Debug(c.Logger, "redis cache bulk remove", tl.Pairs{})
@@ -143,6 +155,44 @@ func (c *Cache) BulkRemove(cacheKeys []string) {
 	metrics.ObserveCacheDel(c.Name, c.Config.Provider, float64(len(cacheKeys)))
 }
 
+// Keys returns the keys of all objects in the cache that begin with the provided prefix.
+// When using Redis Cluster, each master node is scanned.
+func (c *Cache) Keys(prefix string) ([]string, error) {
+	match := globEscaper.Replace(prefix) + "*"
+	if cc, ok := c.client.(*redis.ClusterClient); ok {
+		keys := make([]string, 0)
+		mtx := sync.Mutex{}
+		err := cc.ForEachMaster(func(client *redis.Client) error {
+			k, err := scanKeys(client, match)
+			mtx.Lock()
+			keys = append(keys, k...)
+			mtx.Unlock()
+			return err
+		})
+		return keys, err
+	}
+	return scanKeys(c.client, match)
+}
+
+// globEscaper escapes the special characters in a Redis glob-style pattern
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+func scanKeys(client redis.Cmdable, match string) ([]string, error) {
+	keys := make([]string, 0)
+	var cursor uint64
+	for {
+		k, next, err := client.Scan(cursor, match, 1000).Result()
+		if err != nil {
+			return keys, err
+		}
+		keys = append(keys, k...)
+		if next == 0 {
+			return keys, nil
+		}
+		cursor = next
+	}
+}
+
 // Close disconnects from the Redis Cache
 func (c *Cache) Close() error {
 	tl.Info(c.Logger, "closing redis connection", tl.Pairs{})
@@ -494,6 +494,42 @@ func TestCache_BulkRemove(t *testing.T) {
 	}
 }
 
+func TestCache_Keys(t *testing.T) {
+
+	rc, close := setupRedisCache(clientTypeStandard)
+	defer close()
+
+	err := rc.Connect()
+	if err != nil {
+		t.Error(err)
+	}
+	defer rc.Close()
+
+	for _, k := range []string{"a*.1", "a*.2", "ab.1"} {
+		err = rc.Store(k, []byte("data"), time.Duration(60)*time.Second)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	// the glob character in the prefix should be matched literally
+	keys, err := rc.Keys("a*")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("expected %d got %d", 2, len(keys))
+	}
+
+	keys, err = rc.Keys("")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(keys) != 3 {
+		t.Errorf("expected %d got %d", 3, len(keys))
+	}
+}
+
 func BenchmarkCache_BulkRemove(b *testing.B) {
 	rc, close := storeBenchmark(b)
 	defer close()
@@ -19,6 +19,8 @@
 package registration
 
 import (
+	"strings"
+
 	"github.com/trickstercache/trickster/cmd/trickster/config"
 	"github.com/trickstercache/trickster/pkg/cache"
 	"github.com/trickstercache/trickster/pkg/cache/badger"
@@ -27,7 +29,9 @@ import (
 	"github.com/trickstercache/trickster/pkg/cache/memory"
 	"github.com/trickstercache/trickster/pkg/cache/options"
 	"github.com/trickstercache/trickster/pkg/cache/redis"
+	"github.com/trickstercache/trickster/pkg/cache/tiered"
 	"github.com/trickstercache/trickster/pkg/locks"
+	tl "github.com/trickstercache/trickster/pkg/observability/logging"
 )
 
 // Cache Interface Types
@@ -36,6 +40,7 @@ const (
 	ctRedis      = "redis"
 	ctBBolt      = "bbolt"
 	ctBadger     = "badger"
+	ctTiered     = "tiered"
 )
 
 // Caches maintains a list of active caches
@@ -53,12 +58,26 @@ const (
 func LoadCachesFromConfig(conf *config.Config, logger interface{}) map[string]cache.Cache {
 	caches := make(map[string]cache.Cache)
 	for k, v := range conf.Caches {
+		if IsTiered(v) {
+			continue
+		}
 		c := NewCache(k, v, logger)
 		caches[k] = c
 	}
+	// tiered caches are composed of the other caches, so they are loaded last
+	for k, v := range conf.Caches {
+		if IsTiered(v) {
+			caches[k] = NewTieredCache(k, v, caches, logger)
+		}
+	}
 	return caches
 }
 
+// IsTiered returns true if the provided cache options are for a tiered cache
+func IsTiered(cfg *options.Options) bool {
+	return cfg != nil && strings.ToLower(cfg.Provider) == ctTiered
+}
+
 // CloseCaches iterates the set of caches and closes each
 func CloseCaches(caches map[string]cache.Cache) error {
 	for _, c := range caches {
@@ -74,7 +93,7 @@ func NewCache(cacheName string, cfg *options.Options, logger interface{}) cache.
 
 	var c cache.Cache
 
-	switch cfg.Provider {
+	switch strings.ToLower(cfg.Provider) {
 	case ctFilesystem:
 		c = &filesystem.Cache{Name: cacheName, Config: cfg, Logger: logger}
 	case ctRedis:
@@ -92,3 +111,24 @@ func NewCache(cacheName string, cfg *options.Options, logger interface{}) cache.
 	c.Connect()
 	return c
 }
+
+// NewTieredCache returns a Tiered Cache composed of the provided caches, in the
+// order of the tiers in the provided config.CachingConfig
+func NewTieredCache(cacheName string, cfg *options.Options, caches map[string]cache.Cache,
+	logger interface{}) cache.Cache {
+	c := &tiered.Cache{Name: cacheName, Config: cfg, Logger: logger}
+	if cfg.Tiered != nil {
+		c.Tiers = make([]cache.Cache, 0, len(cfg.Tiered.Tiers))
+		for _, tn := range cfg.Tiered.Tiers {
+			if t, ok := caches[tn]; ok {
+				c.Tiers = append(c.Tiers, t)
+			}
+		}
+	}
+	c.SetLocker(locks.NewNamedLocker())
+	if err := c.Connect(); err != nil {
+		tl.Error(logger, "tiered cache setup failed",
+			tl.Pairs{"name": cacheName, "detail": err.Error()})
+	}
+	return c
+}
@@ -21,12 +21,15 @@ import (
 
 	"github.com/trickstercache/trickster/cmd/trickster/config"
 	bao "github.com/trickstercache/trickster/pkg/cache/badger/options"
+	"github.com/trickstercache/trickster/pkg/cache/bbolt"
 	bbo "github.com/trickstercache/trickster/pkg/cache/bbolt/options"
 	flo "github.com/trickstercache/trickster/pkg/cache/filesystem/options"
 	io "github.com/trickstercache/trickster/pkg/cache/index/options"
 	co "github.com/trickstercache/trickster/pkg/cache/options"
 	"github.com/trickstercache/trickster/pkg/cache/providers"
 	ro "github.com/trickstercache/trickster/pkg/cache/redis/options"
+	"github.com/trickstercache/trickster/pkg/cache/tiered"
+	tro "github.com/trickstercache/trickster/pkg/cache/tiered/options"
 	tl "github.com/trickstercache/trickster/pkg/observability/logging"
 )
 
@@ -70,6 +73,34 @@ func TestLoadCachesFromConfig(t *testing.T) {
 		t.Errorf("expected error")
 	}
 
+	// the tiered cache is composed of the other caches
+	tc, ok := caches["tiered"].(*tiered.Cache)
+	if !ok {
+		t.Fatal("expected tiered cache")
+	}
+	if len(tc.Tiers) != 2 || tc.Tiers[0] != caches["memory"] || tc.Tiers[1] != caches["filesystem"] {
+		t.Errorf("unexpected tiers %v", tc.Tiers)
+	}
+
+}
+
+func TestProviderCase(t *testing.T) {
+
+	if !IsTiered(&co.Options{Provider: "Tiered"}) {
+		t.Error("expected tiered cache")
+	}
+	if IsTiered(&co.Options{Provider: "memory"}) || IsTiered(nil) {
+		t.Error("expected non-tiered cache")
+	}
+
+	cfg := newCacheConfig(t, "bbolt")
+	cfg.Provider = "BBolt"
+	cfg.BBolt.Filename = t.TempDir() + "/testcache.db"
+	c := NewCache("bbolt", cfg, tl.ConsoleLogger("error"))
+	defer c.Close()
+	if _, ok := c.(*bbolt.Cache); !ok {
+		t.Errorf("expected bbolt cache got %T", c)
+	}
 }
 
 func newCacheConfig(t *testing.T, cacheProvider string) *co.Options {
@@ -95,6 +126,7 @@ func newCacheConfig(t *testing.T, cacheProvider string) *co.Options {
 		Filesystem: &flo.Options{CachePath: fd},
 		BBolt:      &bbo.Options{Filename: "/tmp/test.db", Bucket: "trickster_test"},
 		Badger:     &bao.Options{Directory: bd, ValueDirectory: bd},
+		Tiered:     &tro.Options{Tiers: []string{"memory", "filesystem"}},
 		Index: &io.Options{
 			ReapIntervalMS:        3000,
 			FlushIntervalMS:       5000,
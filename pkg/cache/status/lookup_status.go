@@ -49,20 +49,32 @@ const (
 	LookupStatusError
 	// LookupStatusProxyHit indicates that the request joined an existing proxy download of the same object
 	LookupStatusProxyHit
+	// LookupStatusStaleHit indicates that the cached object exceeded the freshness lifetime and was
+	// served stale, while being revalidated in the background, per its stale-while-revalidate policy
+	LookupStatusStaleHit
+	// LookupStatusStaleErrorHit indicates that the cached object exceeded the freshness lifetime and was
+	// served stale because the upstream server returned an error, per its stale-if-error policy
+	LookupStatusStaleErrorHit
+	// LookupStatusPartialErrorHit indicates that the upstream requests for the uncached portions of a
+	// time series failed, so only the cached portion was served
+	LookupStatusPartialErrorHit
 )
 
 var cacheLookupStatusNames = map[string]LookupStatus{
-	"hit":         LookupStatusHit,
-	"phit":        LookupStatusPartialHit,
-	"rhit":        LookupStatusRevalidated,
-	"rmiss":       LookupStatusRangeMiss,
-	"kmiss":       LookupStatusKeyMiss,
-	"purge":       LookupStatusPurge,
-	"proxy-error": LookupStatusProxyError,
-	"proxy-only":  LookupStatusProxyOnly,
-	"nchit":       LookupStatusNegativeCacheHit,
-	"proxy-hit":   LookupStatusProxyHit,
-	"error":       LookupStatusError,
+	"hit":           LookupStatusHit,
+	"phit":          LookupStatusPartialHit,
+	"rhit":          LookupStatusRevalidated,
+	"rmiss":         LookupStatusRangeMiss,
+	"kmiss":         LookupStatusKeyMiss,
+	"purge":         LookupStatusPurge,
+	"proxy-error":   LookupStatusProxyError,
+	"proxy-only":    LookupStatusProxyOnly,
+	"nchit":         LookupStatusNegativeCacheHit,
+	"proxy-hit":     LookupStatusProxyHit,
+	"error":         LookupStatusError,
+	"stale-hit":     LookupStatusStaleHit,
+	"stale-error":   LookupStatusStaleErrorHit,
+	"partial-error": LookupStatusPartialErrorHit,
 }
 
 var cacheLookupStatusValues = map[LookupStatus]string{
@@ -77,6 +89,9 @@ var cacheLookupStatusValues = map[LookupStatus]string{
 	LookupStatusNegativeCacheHit: "nchit",
 	LookupStatusProxyHit:         "proxy-hit",
 	LookupStatusError:            "error",
+	LookupStatusStaleHit:         "stale-hit",
+	LookupStatusStaleErrorHit:    "stale-error",
+	LookupStatusPartialErrorHit:  "partial-error",
 }
 
 func (s LookupStatus) String() string {
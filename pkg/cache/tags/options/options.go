@@ -0,0 +1,90 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package options provides the options for tagging cached objects so they
+// can be purged by tag
+package options
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/trickstercache/trickster/pkg/util/yamlx"
+)
+
+// Options defines the rules for tagging a backend's cached objects
+type Options struct {
+	// Params is a list of request parameter names whose values tag each cached object
+	// as "<name>:<value>"
+	Params []string `yaml:"params,omitempty"`
+	// TenantHeader is the name of a request header whose value tags each cached object
+	// as "tenant:<value>"
+	TenantHeader string `yaml:"tenant_header,omitempty"`
+	// MetricNames, when true, tags each cached object with the names of the metrics
+	// referenced by the request's query as "metric:<name>", for backends that support it
+	MetricNames bool `yaml:"metric_names,omitempty"`
+}
+
+// New returns a New Options object with the default values
+func New() *Options {
+	return &Options{}
+}
+
+// Clone returns a perfect copy of the Options
+func (o *Options) Clone() *Options {
+	no := &Options{
+		TenantHeader: o.TenantHeader,
+		MetricNames:  o.MetricNames,
+	}
+	if o.Params != nil {
+		no.Params = make([]string, len(o.Params))
+		copy(no.Params, o.Params)
+	}
+	return no
+}
+
+// SetDefaults iterates the provided Options, and overlays user-set values onto the default Options
+func SetDefaults(name string, options *Options, metadata yamlx.KeyLookup) (*Options, error) {
+
+	if metadata == nil || options == nil {
+		return nil, nil
+	}
+
+	if !metadata.IsDefined("backends", name, "cache_tags") {
+		return nil, nil
+	}
+
+	o := New()
+
+	if metadata.IsDefined("backends", name, "cache_tags", "params") {
+		for _, p := range options.Params {
+			if strings.TrimSpace(p) == "" {
+				return nil, errors.New("values for 'params' must not be empty")
+			}
+		}
+		o.Params = options.Params
+	}
+
+	if metadata.IsDefined("backends", name, "cache_tags", "tenant_header") {
+		o.TenantHeader = options.TenantHeader
+	}
+
+	if metadata.IsDefined("backends", name, "cache_tags", "metric_names") {
+		o.MetricNames = options.MetricNames
+	}
+
+	return o, nil
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package options
+
+import (
+	"testing"
+
+	"github.com/trickstercache/trickster/pkg/util/yamlx"
+
+	"gopkg.in/yaml.v2"
+)
+
+type testOptions1 struct {
+	Backends map[string]*testOptions2 `yaml:"backends,omitempty"`
+}
+
+type testOptions2 struct {
+	CacheTags *Options `yaml:"cache_tags,omitempty"`
+}
+
+func fromYAML(conf string) (*Options, yamlx.KeyLookup, error) {
+	to := &testOptions1{}
+	err := yaml.Unmarshal([]byte(conf), to)
+	if err != nil {
+		return nil, nil, err
+	}
+	md, err := yamlx.GetKeyList(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, v := range to.Backends {
+		if v != nil && v.CacheTags != nil {
+			return v.CacheTags, md, nil
+		}
+	}
+	return &Options{}, md, nil
+}
+
+const testYAML = `
+backends:
+  test:
+    cache_tags:
+      params: [ job, instance ]
+      tenant_header: X-Scope-OrgID
+      metric_names: true
+`
+
+const testYAMLBadParams = `
+backends:
+  test:
+    cache_tags:
+      params: [ '' ]
+`
+
+const testYAMLNoTags = `
+backends:
+  test:
+    provider: prometheus
+`
+
+func TestClone(t *testing.T) {
+	o := &Options{Params: []string{"job"}, TenantHeader: "X-Tenant", MetricNames: true}
+	co := o.Clone()
+	if len(co.Params) != 1 || co.Params[0] != "job" || co.TenantHeader != "X-Tenant" ||
+		!co.MetricNames {
+		t.Error("clone mismatch")
+	}
+	co.Params[0] = "instance"
+	if o.Params[0] != "job" {
+		t.Error("expected clone to copy params")
+	}
+}
+
+func TestSetDefaults(t *testing.T) {
+
+	o, md, err := fromYAML(testYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err = SetDefaults("test", o, md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(o.Params) != 2 || o.TenantHeader != "X-Scope-OrgID" || !o.MetricNames {
+		t.Errorf("unexpected options %v", o)
+	}
+
+	o, md, _ = fromYAML(testYAMLBadParams)
+	_, err = SetDefaults("test", o, md)
+	if err == nil {
+		t.Error("expected error for empty param name")
+	}
+
+	o, md, _ = fromYAML(testYAMLNoTags)
+	o, err = SetDefaults("test", o, md)
+	if err != nil || o != nil {
+		t.Error("expected nil options and error")
+	}
+
+	o, err = SetDefaults("test", nil, nil)
+	if err != nil || o != nil {
+		t.Error("expected nil options and error")
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tags provides the tags that group cached objects for purging
+package tags
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+)
+
+// Prefixes of the tags derived from a backend's cache tag rules
+const (
+	PrefixTenant = "tenant:"
+	PrefixMetric = "metric:"
+)
+
+// SurrogateKeys returns the tags listed in the Surrogate-Key header, which
+// holds a space-separated list of tags
+func SurrogateKeys(h http.Header) []string {
+	if h == nil {
+		return nil
+	}
+	var out []string
+	for _, v := range h.Values(headers.NameSurrogateKey) {
+		out = append(out, strings.Fields(v)...)
+	}
+	return out
+}
+
+// Param returns the tag for the request parameter name and value
+func Param(name, value string) string {
+	return name + ":" + value
+}
+
+// Tenant returns the tag for the tenant
+func Tenant(tenant string) string {
+	return PrefixTenant + tenant
+}
+
+// Metric returns the tag for the metric name
+func Metric(name string) string {
+	return PrefixMetric + name
+}
+
+// Normalize returns the sorted, de-duplicated, non-empty tags
+func Normalize(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	sort.Strings(out)
+	return out
+}
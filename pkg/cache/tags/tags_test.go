@@ -0,0 +1,60 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tags
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+)
+
+func TestSurrogateKeys(t *testing.T) {
+	if SurrogateKeys(nil) != nil {
+		t.Error("expected nil tags")
+	}
+	h := http.Header{}
+	h.Add(headers.NameSurrogateKey, "metric:up  tenant:a")
+	h.Add(headers.NameSurrogateKey, "dashboard-1")
+	got := strings.Join(SurrogateKeys(h), ",")
+	if got != "metric:up,tenant:a,dashboard-1" {
+		t.Errorf("unexpected tags %s", got)
+	}
+}
+
+func TestRuleTags(t *testing.T) {
+	if Param("job", "api") != "job:api" {
+		t.Error("unexpected param tag")
+	}
+	if Tenant("a") != "tenant:a" {
+		t.Error("unexpected tenant tag")
+	}
+	if Metric("up") != "metric:up" {
+		t.Error("unexpected metric tag")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if Normalize(nil) != nil || Normalize([]string{""}) != nil {
+		t.Error("expected nil tags")
+	}
+	got := strings.Join(Normalize([]string{"b", "a", "", "b"}), ",")
+	if got != "a,b" {
+		t.Errorf("unexpected tags %s", got)
+	}
+}
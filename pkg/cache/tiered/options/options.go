@@ -0,0 +1,48 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package options
+
+// Options is a collection of Configurations for composing a cache from other configured caches
+type Options struct {
+	// Tiers is the ordered list of the names of the configured caches that make up the tiered cache.
+	// Reads check each tier in order, and writes go to every tier
+	Tiers []string `yaml:"tiers,omitempty"`
+	// WriteBehind, when true, indicates that writes to all tiers after the first are performed
+	// asynchronously, so that the caller is not blocked by the slower tiers
+	WriteBehind bool `yaml:"write_behind,omitempty"`
+	// BackfillTTLMS is the TTL in milliseconds of objects written to earlier tiers, when they
+	// are found in a later tier
+	BackfillTTLMS int `yaml:"backfill_ttl_ms,omitempty"`
+}
+
+// New returns a reference to a new tiered Options
+func New() *Options {
+	return &Options{BackfillTTLMS: DefaultBackfillTTLMS}
+}
+
+// Clone returns an exact copy of the subject Options
+func (o *Options) Clone() *Options {
+	no := &Options{
+		WriteBehind:   o.WriteBehind,
+		BackfillTTLMS: o.BackfillTTLMS,
+	}
+	if o.Tiers != nil {
+		no.Tiers = make([]string, len(o.Tiers))
+		copy(no.Tiers, o.Tiers)
+	}
+	return no
+}
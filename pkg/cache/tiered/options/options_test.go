@@ -0,0 +1,34 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package options
+
+import "testing"
+
+func TestClone(t *testing.T) {
+	o := New()
+	o.Tiers = []string{"mem", "redis"}
+	o.WriteBehind = true
+	o2 := o.Clone()
+	if len(o2.Tiers) != 2 || o2.Tiers[1] != "redis" || !o2.WriteBehind ||
+		o2.BackfillTTLMS != DefaultBackfillTTLMS {
+		t.Errorf("unexpected clone %v", o2)
+	}
+	o2.Tiers[0] = "other"
+	if o.Tiers[0] != "mem" {
+		t.Error("expected deep copy of tiers")
+	}
+}
@@ -0,0 +1,217 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tiered is the tiered implementation of the Trickster Cache, which composes
+// an ordered list of other configured caches (e.g., a local memory cache backed by a
+// shared Redis cache) into a single cache
+package tiered
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/cache"
+	"github.com/trickstercache/trickster/pkg/cache/metrics"
+	"github.com/trickstercache/trickster/pkg/cache/options"
+	"github.com/trickstercache/trickster/pkg/cache/status"
+	"github.com/trickstercache/trickster/pkg/locks"
+	tl "github.com/trickstercache/trickster/pkg/observability/logging"
+)
+
+// ErrNoTiers is an error returned when a tiered cache has no tiers
+var ErrNoTiers = errors.New("tiered cache has no tiers")
+
+// Cache defines a Tiered Cache client that conforms to the Cache interface
+type Cache struct {
+	Name   string
+	Config *options.Options
+	Logger interface{}
+	// Tiers is the ordered list of caches composing the Tiered Cache
+	Tiers  []cache.Cache
+	locker locks.NamedLocker
+	wg     sync.WaitGroup
+}
+
+// Locker returns the cache's locker
+func (c *Cache) Locker() locks.NamedLocker {
+	return c.locker
+}
+
+// SetLocker sets the cache's locker
+func (c *Cache) SetLocker(l locks.NamedLocker) {
+	c.locker = l
+}
+
+// Configuration returns the Configuration for the Cache object
+func (c *Cache) Configuration() *options.Options {
+	return c.Config
+}
+
+// Connect initializes the Cache. The tiers are connected independently, when they are created
+func (c *Cache) Connect() error {
+	if len(c.Tiers) == 0 {
+		return ErrNoTiers
+	}
+	names := make([]string, len(c.Tiers))
+	for i := range c.Tiers {
+		names[i] = c.tierName(i)
+	}
+	tl.Info(c.Logger, "tiered cache setup", tl.Pairs{"name": c.Name, "tiers": names,
+		"writeBehind": c.Config.Tiered.WriteBehind})
+	return nil
+}
+
+func (c *Cache) tierName(i int) string {
+	return c.Tiers[i].Configuration().Name
+}
+
+func (c *Cache) observeTier(i int, operation, status string) {
+	metrics.ObserveCacheTierOperation(c.Name, c.tierName(i),
+		c.Tiers[i].Configuration().Provider, operation, status)
+}
+
+// Store places an object in every tier of the cache using the specified key and ttl.
+// When configured for write-behind, only the first tier is written synchronously
+func (c *Cache) Store(cacheKey string, data []byte, ttl time.Duration) error {
+	metrics.ObserveCacheOperation(c.Name, c.Config.Provider, "set", "none", float64(len(data)))
+	err := c.store(0, cacheKey, data, ttl)
+	if len(c.Tiers) == 1 {
+		return err
+	}
+	if c.Config.Tiered.WriteBehind {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.storeTiers(1, len(c.Tiers), cacheKey, data, ttl)
+		}()
+		return err
+	}
+	if err2 := c.storeTiers(1, len(c.Tiers), cacheKey, data, ttl); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// storeTiers stores the object in tiers [start, end), and returns the first error encountered
+func (c *Cache) storeTiers(start, end int, cacheKey string, data []byte, ttl time.Duration) error {
+	var err error
+	for i := start; i < end; i++ {
+		if err2 := c.store(i, cacheKey, data, ttl); err == nil {
+			err = err2
+		}
+	}
+	return err
+}
+
+func (c *Cache) store(i int, cacheKey string, data []byte, ttl time.Duration) error {
+	err := c.Tiers[i].Store(cacheKey, data, ttl)
+	if err != nil {
+		c.observeTier(i, "set", "error")
+		tl.Error(c.Logger, "tiered cache store failed", tl.Pairs{"cacheName": c.Name,
+			"tier": c.tierName(i), "cacheKey": cacheKey, "detail": err.Error()})
+		return err
+	}
+	c.observeTier(i, "set", "ok")
+	return nil
+}
+
+// Retrieve looks for an object in each tier of the cache, in order, and returns it (or an
+// error if not found). When the object is found in a tier, it is backfilled into the earlier
+// tiers, with a TTL no longer than the object's remaining TTL in the tier it was found in
+func (c *Cache) Retrieve(cacheKey string, allowExpired bool) ([]byte, status.LookupStatus, error) {
+	for i, t := range c.Tiers {
+		data, s, err := t.Retrieve(cacheKey, allowExpired)
+		if err != nil {
+			c.observeTier(i, "get", "miss")
+			continue
+		}
+		c.observeTier(i, "get", "hit")
+		if i > 0 {
+			ttl := time.Duration(c.Config.Tiered.BackfillTTLMS) * time.Millisecond
+			// backfilled objects must not outlive the object in the tier they came from
+			if tr, ok := t.(cache.TTLReporter); ok {
+				if rttl, ok := tr.RemainingTTL(cacheKey); ok && rttl < ttl {
+					ttl = rttl
+				}
+			}
+			for j := 0; j < i; j++ {
+				if c.Tiers[j].Store(cacheKey, data, ttl) == nil {
+					c.observeTier(j, "backfill", "ok")
+				} else {
+					c.observeTier(j, "backfill", "error")
+				}
+			}
+		}
+		tl.Debug(c.Logger, "tiered cache retrieve", tl.Pairs{"cacheKey": cacheKey,
+			"tier": c.tierName(i)})
+		metrics.ObserveCacheOperation(c.Name, c.Config.Provider, "get", "hit", float64(len(data)))
+		return data, s, nil
+	}
+	metrics.ObserveCacheMiss(cacheKey, c.Name, c.Config.Provider)
+	return nil, status.LookupStatusKeyMiss, cache.ErrKNF
+}
+
+// SetTTL updates the TTL for the provided cache object in every tier
+func (c *Cache) SetTTL(cacheKey string, ttl time.Duration) {
+	for _, t := range c.Tiers {
+		t.SetTTL(cacheKey, ttl)
+	}
+}
+
+// Remove removes an object from every tier of the cache
+func (c *Cache) Remove(cacheKey string) {
+	for i, t := range c.Tiers {
+		t.Remove(cacheKey)
+		c.observeTier(i, "del", "none")
+	}
+	metrics.ObserveCacheDel(c.Name, c.Config.Provider, 0)
+}
+
+// BulkRemove removes a list of objects from every tier of the cache
+func (c *Cache) BulkRemove(cacheKeys []string) {
+	for i, t := range c.Tiers {
+		t.BulkRemove(cacheKeys)
+		c.observeTier(i, "del", "none")
+	}
+	metrics.ObserveCacheDel(c.Name, c.Config.Provider, float64(len(cacheKeys)))
+}
+
+// Keys returns the keys of all objects in any tier of the cache that begin with the provided prefix
+func (c *Cache) Keys(prefix string) ([]string, error) {
+	seen := make(map[string]interface{})
+	keys := make([]string, 0)
+	for _, t := range c.Tiers {
+		tk, err := t.Keys(prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range tk {
+			if _, ok := seen[k]; !ok {
+				seen[k] = nil
+				keys = append(keys, k)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// Close waits for any pending write-behind operations to complete. The tiers
+// are closed independently, since they are also managed as standalone caches
+func (c *Cache) Close() error {
+	c.wg.Wait()
+	return nil
+}
@@ -0,0 +1,186 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tiered
+
+import (
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/cache"
+	io "github.com/trickstercache/trickster/pkg/cache/index/options"
+	"github.com/trickstercache/trickster/pkg/cache/memory"
+	co "github.com/trickstercache/trickster/pkg/cache/options"
+	"github.com/trickstercache/trickster/pkg/cache/status"
+	to "github.com/trickstercache/trickster/pkg/cache/tiered/options"
+	"github.com/trickstercache/trickster/pkg/locks"
+	tl "github.com/trickstercache/trickster/pkg/observability/logging"
+)
+
+const cacheKey = "cacheKey"
+
+func newMemoryCache(t *testing.T, name string) *memory.Cache {
+	cfg := &co.Options{Name: name, Provider: "memory", Index: &io.Options{ReapInterval: 0}}
+	mc := &memory.Cache{Name: name, Config: cfg, Logger: tl.ConsoleLogger("error")}
+	mc.SetLocker(locks.NewNamedLocker())
+	if err := mc.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return mc
+}
+
+func newTestCache(t *testing.T, writeBehind bool) (*Cache, *memory.Cache, *memory.Cache) {
+	l1 := newMemoryCache(t, "l1")
+	l2 := newMemoryCache(t, "l2")
+	cfg := &co.Options{Name: "test", Provider: "tiered",
+		Tiered: &to.Options{Tiers: []string{"l1", "l2"}, WriteBehind: writeBehind,
+			BackfillTTLMS: to.DefaultBackfillTTLMS}}
+	c := &Cache{Name: "test", Config: cfg, Logger: tl.ConsoleLogger("error"),
+		Tiers: []cache.Cache{l1, l2}}
+	c.SetLocker(locks.NewNamedLocker())
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return c, l1, l2
+}
+
+func TestConnect(t *testing.T) {
+	c := &Cache{Name: "test", Config: &co.Options{Tiered: to.New()}}
+	if err := c.Connect(); err != ErrNoTiers {
+		t.Errorf("expected %v got %v", ErrNoTiers, err)
+	}
+	c, _, _ = newTestCache(t, false)
+	if c.Configuration().Name != "test" {
+		t.Errorf("expected %s got %s", "test", c.Configuration().Name)
+	}
+	if c.Locker() == nil {
+		t.Error("expected non-nil locker")
+	}
+}
+
+func TestStoreRetrieve(t *testing.T) {
+
+	c, l1, l2 := newTestCache(t, false)
+	defer c.Close()
+
+	if err := c.Store(cacheKey, []byte("data"), time.Minute); err != nil {
+		t.Error(err)
+	}
+	for _, mc := range []*memory.Cache{l1, l2} {
+		if _, _, err := mc.Retrieve(cacheKey, false); err != nil {
+			t.Errorf("expected object in tier %s got %v", mc.Name, err)
+		}
+	}
+
+	data, ls, err := c.Retrieve(cacheKey, false)
+	if err != nil {
+		t.Error(err)
+	}
+	if ls != status.LookupStatusHit || string(data) != "data" {
+		t.Errorf("unexpected retrieve %s %s", ls, string(data))
+	}
+
+	// a hit on a later tier is backfilled into the earlier tiers
+	l1.Remove(cacheKey)
+	if _, _, err = c.Retrieve(cacheKey, false); err != nil {
+		t.Error(err)
+	}
+	if data, _, err = l1.Retrieve(cacheKey, false); err != nil || string(data) != "data" {
+		t.Errorf("expected backfilled object got %v", err)
+	}
+
+	c.Remove(cacheKey)
+	for _, mc := range []*memory.Cache{l1, l2} {
+		if _, _, err := mc.Retrieve(cacheKey, false); err != cache.ErrKNF {
+			t.Errorf("expected %v in tier %s got %v", cache.ErrKNF, mc.Name, err)
+		}
+	}
+	if _, ls, err = c.Retrieve(cacheKey, false); err != cache.ErrKNF || ls != status.LookupStatusKeyMiss {
+		t.Errorf("expected %v got %v", cache.ErrKNF, err)
+	}
+}
+
+func TestBackfillTTL(t *testing.T) {
+
+	c, l1, l2 := newTestCache(t, false)
+	defer c.Close()
+
+	// the backfilled object does not outlive the object in the later tier
+	if err := l2.Store(cacheKey, []byte("data"), 5*time.Second); err != nil {
+		t.Error(err)
+	}
+	if _, _, err := c.Retrieve(cacheKey, false); err != nil {
+		t.Error(err)
+	}
+	ttl, ok := l1.RemainingTTL(cacheKey)
+	if !ok || ttl > 5*time.Second {
+		t.Errorf("expected backfill ttl <= %s got %s", 5*time.Second, ttl)
+	}
+
+	// the backfill ttl applies when the object outlives it
+	l1.Remove(cacheKey)
+	if err := l2.Store(cacheKey, []byte("data"), time.Hour); err != nil {
+		t.Error(err)
+	}
+	if _, _, err := c.Retrieve(cacheKey, false); err != nil {
+		t.Error(err)
+	}
+	ttl, ok = l1.RemainingTTL(cacheKey)
+	if max := time.Duration(to.DefaultBackfillTTLMS) * time.Millisecond; !ok || ttl > max ||
+		ttl < max-5*time.Second {
+		t.Errorf("expected backfill ttl near %s got %s", max, ttl)
+	}
+}
+
+func TestWriteBehind(t *testing.T) {
+
+	c, _, l2 := newTestCache(t, true)
+
+	if err := c.Store(cacheKey, []byte("data"), time.Minute); err != nil {
+		t.Error(err)
+	}
+	// close waits for pending write-behind operations
+	c.Close()
+	if _, _, err := l2.Retrieve(cacheKey, false); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBulkRemoveAndKeys(t *testing.T) {
+
+	c, l1, l2 := newTestCache(t, false)
+	defer c.Close()
+
+	l1.Store("test.1", []byte("data"), time.Minute)
+	l2.Store("test.1", []byte("data"), time.Minute)
+	l2.Store("test.2", []byte("data"), time.Minute)
+	l2.Store("other.1", []byte("data"), time.Minute)
+
+	keys, err := c.Keys("test.")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("expected %d got %d", 2, len(keys))
+	}
+
+	c.SetTTL("test.1", time.Hour)
+
+	c.BulkRemove([]string{"test.1", "test.2"})
+	if keys, _ = c.Keys(""); len(keys) != 1 || keys[0] != "other.1" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+}
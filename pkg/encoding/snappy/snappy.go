@@ -17,6 +17,8 @@
 package snappy
 
 import (
+	"bufio"
+	"bytes"
 	"io"
 
 	"github.com/trickstercache/trickster/pkg/encoding/reader"
@@ -39,6 +41,43 @@ func NewEncoder(w io.Writer, unused int) io.WriteCloser {
 	return snappy.NewWriter(w)
 }
 
+// NewDecoder returns a decoder for the provided snappy-encoded reader. Both the framed
+// (streaming) format and the block format (e.g., used by the Prometheus remote read and
+// write protocols) are supported, and are differentiated by the framed stream identifier.
 func NewDecoder(r io.Reader) reader.ReadCloserResetter {
-	return reader.NewReadCloserResetter(snappy.NewReader(r))
+	return reader.NewReadCloserResetter(&decoder{src: r})
+}
+
+// streamIdentifier is the chunk that begins every stream in the snappy framed format
+const streamIdentifier = "\xff\x06\x00\x00sNaPpY"
+
+type decoder struct {
+	src io.Reader
+	r   io.Reader
+}
+
+func (d *decoder) Read(p []byte) (int, error) {
+	if d.r == nil {
+		br := bufio.NewReader(d.src)
+		if b, _ := br.Peek(len(streamIdentifier)); string(b) == streamIdentifier {
+			d.r = snappy.NewReader(br)
+		} else {
+			b, err := io.ReadAll(br)
+			if err != nil {
+				return 0, err
+			}
+			if b, err = Decode(b); err != nil {
+				return 0, err
+			}
+			d.r = bytes.NewReader(b)
+		}
+	}
+	return d.r.Read(p)
+}
+
+// Reset sets the decoder to read from the provided reader
+func (d *decoder) Reset(r io.Reader) error {
+	d.src = r
+	d.r = nil
+	return nil
 }
@@ -18,6 +18,7 @@ package snappy
 
 import (
 	"bytes"
+	"io"
 	"net/http/httptest"
 	"testing"
 )
@@ -75,3 +76,38 @@ func TestNewEncoder(t *testing.T) {
 		t.Error("expected non-nil encoder")
 	}
 }
+
+func TestDecoderFormats(t *testing.T) {
+	const expected = "trickster"
+
+	// block format
+	b, _ := Encode([]byte(expected))
+	out, err := io.ReadAll(NewDecoder(bytes.NewReader(b)))
+	if err != nil {
+		t.Error(err)
+	}
+	if string(out) != expected {
+		t.Errorf("expected %s got %s", expected, string(out))
+	}
+
+	// framed format
+	buf := bytes.NewBuffer(nil)
+	enc := NewEncoder(buf, 0)
+	enc.Write([]byte(expected))
+	enc.Close()
+	dec := NewDecoder(bytes.NewReader(buf.Bytes()))
+	out, err = io.ReadAll(dec)
+	if err != nil {
+		t.Error(err)
+	}
+	if string(out) != expected {
+		t.Errorf("expected %s got %s", expected, string(out))
+	}
+
+	// reset to a block
+	dec.Reset(bytes.NewReader(b))
+	out, _ = io.ReadAll(dec)
+	if string(out) != expected {
+		t.Errorf("expected %s got %s", expected, string(out))
+	}
+}
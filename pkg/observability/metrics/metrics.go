@@ -65,6 +65,16 @@ var ProxyRequestElements *prometheus.CounterVec
 // ProxyRequestDuration is a Histogram of time required in seconds to proxy a given Prometheus query
 var ProxyRequestDuration *prometheus.HistogramVec
 
+// ProxyWarmerRequests is a Counter of client requests for warmed queries, by whether they were
+// served entirely from cache (warm) or required an upstream fetch (cold)
+var ProxyWarmerRequests *prometheus.CounterVec
+
+// ProxyWarmerFetches is a Counter of requests issued by the Query Warmer, by cache status
+var ProxyWarmerFetches *prometheus.CounterVec
+
+// ProxyWarmerTemplates is a Gauge representing the number of query templates tracked by the Query Warmer
+var ProxyWarmerTemplates *prometheus.GaugeVec
+
 // CacheObjectOperations is a Counter of operations (in # of objects) performed on a Trickster cache
 var CacheObjectOperations *prometheus.CounterVec
 
@@ -86,6 +96,9 @@ var CacheMaxObjects *prometheus.GaugeVec
 // CacheMaxBytes is a Gauge for the Trickster cache's Max Object Threshold for triggering an eviction exercise
 var CacheMaxBytes *prometheus.GaugeVec
 
+// CacheTierOperations is a Counter of operations (in # of objects) performed on each tier of a Trickster tiered cache
+var CacheTierOperations *prometheus.CounterVec
+
 // ProxyMaxConnections is a Gauge representing the max number of active concurrent connections in the server
 var ProxyMaxConnections prometheus.Gauge
 
@@ -196,6 +209,36 @@ func init() {
 		[]string{"backend_name", "provider", "method", "status", "http_status", "path"},
 	)
 
+	ProxyWarmerRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricNamespace,
+			Subsystem: proxySubsystem,
+			Name:      "warmer_requests_total",
+			Help:      "Count of client requests for warmed queries, by whether they were served warm or cold.",
+		},
+		[]string{"backend_name", "provider", "result"},
+	)
+
+	ProxyWarmerFetches = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricNamespace,
+			Subsystem: proxySubsystem,
+			Name:      "warmer_fetches_total",
+			Help:      "Count of requests issued by the Query Warmer.",
+		},
+		[]string{"backend_name", "provider", "cache_status"},
+	)
+
+	ProxyWarmerTemplates = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricNamespace,
+			Subsystem: proxySubsystem,
+			Name:      "warmer_templates",
+			Help:      "Number of query templates tracked by the Query Warmer.",
+		},
+		[]string{"backend_name", "provider"},
+	)
+
 	ProxyMaxConnections = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Namespace: metricNamespace,
@@ -319,6 +362,16 @@ func init() {
 		[]string{"cache_name", "provider"},
 	)
 
+	CacheTierOperations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricNamespace,
+			Subsystem: cacheSubsystem,
+			Name:      "tier_operation_objects_total",
+			Help:      "Count (in # of objects) of operations performed on each tier of a Trickster tiered cache.",
+		},
+		[]string{"cache_name", "tier", "provider", "operation", "status"},
+	)
+
 	// Register Metrics
 	prometheus.MustRegister(FrontendRequestStatus)
 	prometheus.MustRegister(FrontendRequestDuration)
@@ -326,6 +379,9 @@ func init() {
 	prometheus.MustRegister(ProxyRequestStatus)
 	prometheus.MustRegister(ProxyRequestElements)
 	prometheus.MustRegister(ProxyRequestDuration)
+	prometheus.MustRegister(ProxyWarmerRequests)
+	prometheus.MustRegister(ProxyWarmerFetches)
+	prometheus.MustRegister(ProxyWarmerTemplates)
 	prometheus.MustRegister(ProxyMaxConnections)
 	prometheus.MustRegister(ProxyActiveConnections)
 	prometheus.MustRegister(ProxyConnectionRequested)
@@ -339,6 +395,7 @@ func init() {
 	prometheus.MustRegister(CacheBytes)
 	prometheus.MustRegister(CacheMaxObjects)
 	prometheus.MustRegister(CacheMaxBytes)
+	prometheus.MustRegister(CacheTierOperations)
 	prometheus.MustRegister(BuildInfo)
 	prometheus.MustRegister(LastReloadSuccessful)
 	prometheus.MustRegister(LastReloadSuccessfulTimestamp)
@@ -74,8 +74,13 @@ func QueryCache(ctx context.Context, c cache.Cache, key string,
 		if ifc != nil {
 			d, _ = ifc.(*HTTPDocument)
 		} else {
-			tspan.SetAttributes(rsc.Tracer, span, attribute.String("cache.status", status.LookupStatusKeyMiss.String()))
-			return d, status.LookupStatusKeyMiss, ranges, err
+			// objects restored from a memory cache snapshot are stored serialized
+			// until they are next written by reference
+			b, _, _ = c.Retrieve(key, true)
+			if len(b) == 0 {
+				tspan.SetAttributes(rsc.Tracer, span, attribute.String("cache.status", status.LookupStatusKeyMiss.String()))
+				return d, status.LookupStatusKeyMiss, ranges, err
+			}
 		}
 
 	} else {
@@ -92,39 +97,17 @@ func QueryCache(ctx context.Context, c cache.Cache, key string,
 			return d, lookupStatus, nr, err
 		}
 
-		var inflate bool
-		// check and remove compression bit
-		if len(b) > 0 {
-			if b[0] == 1 {
-				inflate = true
-			}
-			b = b[1:]
-		}
-
-		if inflate {
-			tl.Debug(rsc.Logger, "decompressing cached data", tl.Pairs{"cacheKey": key})
-			decoder := brotli.NewReader(bytes.NewReader(b))
-			b, err = io.ReadAll(decoder)
-			if err != nil {
-				tl.Error(rsc.Logger, "error decoding cache document", tl.Pairs{
-					"cacheKey": key,
-					"detail":   err.Error(),
-				})
-				tspan.SetAttributes(rsc.Tracer, span, attribute.String("cache.status", status.LookupStatusKeyMiss.String()))
-				return d, status.LookupStatusKeyMiss, ranges, err
-			}
+	}
 
-		}
-		_, err = d.UnmarshalMsg(b)
-		if err != nil {
-			tl.Error(rsc.Logger, "error unmarshaling cache document", tl.Pairs{
+	if b != nil {
+		if err = decodeDocument(d, b); err != nil {
+			tl.Error(rsc.Logger, "error decoding cache document", tl.Pairs{
 				"cacheKey": key,
 				"detail":   err.Error(),
 			})
 			tspan.SetAttributes(rsc.Tracer, span, attribute.String("cache.status", status.LookupStatusKeyMiss.String()))
 			return d, status.LookupStatusKeyMiss, ranges, err
 		}
-
 	}
 
 	var delta byterange.Ranges
@@ -155,6 +138,28 @@ func QueryCache(ctx context.Context, c cache.Cache, key string,
 	return d, lookupStatus, delta, nil
 }
 
+// decodeDocument unmarshals a document serialized by WriteCache into d,
+// decompressing it first when its compression bit is set
+func decodeDocument(d *HTTPDocument, b []byte) error {
+	var inflate bool
+	// check and remove compression bit
+	if len(b) > 0 {
+		if b[0] == 1 {
+			inflate = true
+		}
+		b = b[1:]
+	}
+	if inflate {
+		var err error
+		b, err = io.ReadAll(brotli.NewReader(bytes.NewReader(b)))
+		if err != nil {
+			return err
+		}
+	}
+	_, err := d.UnmarshalMsg(b)
+	return err
+}
+
 func stripConditionalHeaders(h http.Header) {
 	h.Del(headers.NameIfMatch)
 	h.Del(headers.NameIfUnmodifiedSince)
@@ -439,6 +439,40 @@ func TestQueryCache(t *testing.T) {
 
 }
 
+func TestQueryCacheRestored(t *testing.T) {
+
+	conf, _, err := config.Load("trickster", "test", []string{"-origin-url", "http://1", "-provider", "test"})
+	if err != nil {
+		t.Fatalf("Could not load configuration: %s", err.Error())
+	}
+
+	caches := registration.LoadCachesFromConfig(conf, testLogger)
+	defer registration.CloseCaches(caches)
+	cache := caches["default"]
+
+	ctx := tc.WithResources(context.Background(), &request.Resources{
+		BackendOptions: conf.Backends["default"], Tracer: tu.NewTestTracer(), Logger: testLogger})
+
+	// memory cache objects restored from a snapshot are stored serialized
+	d := &HTTPDocument{StatusCode: 200, Body: []byte("1234")}
+	b, err := d.MarshalReference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Store("testKey", b, time.Duration(60)*time.Second)
+
+	d2, ls, _, err := QueryCache(ctx, cache, "testKey", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ls != status.LookupStatusHit {
+		t.Errorf("expected %s got %s", status.LookupStatusHit, ls)
+	}
+	if string(d2.Body) != "1234" {
+		t.Errorf("expected %s got %s", "1234", string(d2.Body))
+	}
+}
+
 // Mock Cache for testing error conditions
 type testCache struct {
 	configuration *co.Options
@@ -462,6 +496,7 @@ func (tc *testCache) Retrieve(cacheKey string, allowExpired bool) ([]byte, statu
 func (tc *testCache) SetTTL(cacheKey string, ttl time.Duration) {}
 func (tc *testCache) Remove(cacheKey string)                    {}
 func (tc *testCache) BulkRemove(cacheKeys []string)             {}
+func (tc *testCache) Keys(prefix string) ([]string, error)      { return nil, errTest }
 func (tc *testCache) Close() error                              { return errTest }
 func (tc *testCache) Configuration() *co.Options                { return tc.configuration }
 func (tc *testCache) Locker() locks.NamedLocker                 { return tc.locker }
@@ -45,6 +45,12 @@ type CachingPolicy struct {
 	IfNoneMatchResult    bool `msg:"-"`
 
 	FreshnessLifetime int `msg:"freshness_lifetime"`
+	// StaleWhileRevalidate is the number of seconds beyond the freshness lifetime
+	// that the object may be served stale while it is revalidated in the background
+	StaleWhileRevalidate int `msg:"stale_while_revalidate"`
+	// StaleIfError is the number of seconds beyond the freshness lifetime that the
+	// object may be served stale when the upstream server responds with an error
+	StaleIfError int `msg:"stale_if_error"`
 
 	LastModified time.Time `msg:"last_modified"`
 	Expires      time.Time `msg:"expires"`
@@ -65,6 +71,8 @@ func (cp *CachingPolicy) Clone() *CachingPolicy {
 		NoCache:               cp.NoCache,
 		NoTransform:           cp.NoTransform,
 		FreshnessLifetime:     cp.FreshnessLifetime,
+		StaleWhileRevalidate:  cp.StaleWhileRevalidate,
+		StaleIfError:          cp.StaleIfError,
 		CanRevalidate:         cp.CanRevalidate,
 		MustRevalidate:        cp.MustRevalidate,
 		LastModified:          cp.LastModified,
@@ -116,6 +124,8 @@ func (cp *CachingPolicy) Merge(src *CachingPolicy) {
 
 	cp.IsFresh = src.IsFresh
 	cp.FreshnessLifetime = src.FreshnessLifetime
+	cp.StaleWhileRevalidate = src.StaleWhileRevalidate
+	cp.StaleIfError = src.StaleIfError
 	cp.CanRevalidate = src.CanRevalidate
 	cp.MustRevalidate = src.MustRevalidate
 	cp.LastModified = src.LastModified
@@ -135,20 +145,71 @@ func (cp *CachingPolicy) TTL(multiplier float64, max time.Duration) time.Duratio
 	if cp.CanRevalidate {
 		ttl *= time.Duration(multiplier)
 	}
+	// the object must be retained for as long as it may be served stale
+	if s := cp.staleLifetime(); s > 0 {
+		fl := cp.FreshnessLifetime
+		if fl < 0 {
+			fl = 0
+		}
+		if st := time.Duration(fl+s) * time.Second; st > ttl {
+			ttl = st
+		}
+	}
 	if ttl > max {
 		ttl = max
 	}
 	return ttl
 }
 
+// staleLifetime returns the longest number of seconds beyond the freshness lifetime
+// that the object may be served stale
+func (cp *CachingPolicy) staleLifetime() int {
+	if cp.StaleIfError > cp.StaleWhileRevalidate {
+		return cp.StaleIfError
+	}
+	return cp.StaleWhileRevalidate
+}
+
+// CanServeStale returns true if the object has been stale for fewer than the provided
+// number of seconds, which are taken from its StaleWhileRevalidate or StaleIfError values
+func (cp *CachingPolicy) CanServeStale(secs int) bool {
+	if secs <= 0 || cp.NoCache || cp.IsNegativeCache {
+		return false
+	}
+	fl := cp.FreshnessLifetime
+	if fl < 0 {
+		fl = 0
+	}
+	return time.Now().Before(cp.LocalDate.Add(time.Duration(fl+secs) * time.Second))
+}
+
+// ApplyStaleOverrides replaces the subject's StaleWhileRevalidate and StaleIfError values
+// with those provided, when they are non-zero. Negative values disable serving stale content
+func (cp *CachingPolicy) ApplyStaleOverrides(swr, sie int) {
+	if swr != 0 {
+		cp.StaleWhileRevalidate = swr
+	}
+	if sie != 0 {
+		cp.StaleIfError = sie
+	}
+	if cp.StaleWhileRevalidate < 0 {
+		cp.StaleWhileRevalidate = 0
+	}
+	if cp.StaleIfError < 0 {
+		cp.StaleIfError = 0
+	}
+}
+
 func (cp *CachingPolicy) String() string {
 	return fmt.Sprintf(`{ "is_fresh":%t, "no_cache":%t, "no_transform":%t, 
 	"freshness_lifetime":%d, "can_revalidate":%t, "must_revalidate":%t,`+
 		` "last_modified":%d, "expires":%d, "date":%d, "local_date":%d, "etag":"%s", "if_none_match":"%s"`+
-		` "if_modified_since":%d, "if_unmodified_since":%d, "is_negative_cache":%t }`,
+		` "if_modified_since":%d, "if_unmodified_since":%d, "is_negative_cache":%t,`+
+		` "stale_while_revalidate":%d, "stale_if_error":%d }`,
 		cp.IsFresh, cp.NoCache, cp.NoTransform, cp.FreshnessLifetime, cp.CanRevalidate, cp.MustRevalidate,
 		cp.LastModified.Unix(), cp.Expires.Unix(), cp.Date.Unix(), cp.LocalDate.Unix(), cp.ETag,
-		cp.IfNoneMatchValue, cp.IfModifiedSinceTime.Unix(), cp.IfUnmodifiedSinceTime.Unix(), cp.IsNegativeCache)
+		cp.IfNoneMatchValue, cp.IfModifiedSinceTime.Unix(), cp.IfUnmodifiedSinceTime.Unix(), cp.IsNegativeCache,
+		cp.StaleWhileRevalidate, cp.StaleIfError)
 }
 
 // GetResponseCachingPolicy examines HTTP response headers for caching headers
@@ -271,11 +332,21 @@ var supportedCCD = map[string]bool{
 	headers.ValueProxyRevalidate: false,
 }
 
+// parseDirectiveSeconds returns the value of a delta-seconds Cache-Control directive
+func parseDirectiveSeconds(v string) int {
+	secs, err := strconv.Atoi(v)
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return secs
+}
+
 func (cp *CachingPolicy) parseCacheControlDirectives(directives string) {
 	dl := strings.Split(strings.Replace(strings.ToLower(directives), " ", "", -1), ",")
 	var noCache bool
 	var hasSharedMaxAge bool
 	var foundFreshnessDirective bool
+	var mustRevalidate bool
 	for _, d := range dl {
 		var dsub string
 		if i := strings.Index(d, "="); i > 0 {
@@ -313,6 +384,21 @@ func (cp *CachingPolicy) parseCacheControlDirectives(directives string) {
 		if d == headers.ValueNoTransform {
 			cp.NoTransform = true
 		}
+		if d == headers.ValueStaleWhileRevalidate && dsub != "" {
+			cp.StaleWhileRevalidate = parseDirectiveSeconds(dsub)
+		}
+		if d == headers.ValueStaleIfError && dsub != "" {
+			cp.StaleIfError = parseDirectiveSeconds(dsub)
+		}
+		if d == headers.ValueMustRevalidate || d == headers.ValueProxyRevalidate {
+			mustRevalidate = true
+		}
+	}
+
+	// an explicit must-revalidate prohibits serving the object stale
+	if mustRevalidate {
+		cp.StaleWhileRevalidate = 0
+		cp.StaleIfError = 0
 	}
 
 }
@@ -314,3 +314,63 @@ func TestCheckIfNoneMatch(t *testing.T) {
 	}
 
 }
+
+func TestStaleDirectives(t *testing.T) {
+
+	h := http.Header{headers.NameCacheControl: []string{headers.ValueMaxAge + "=60, " +
+		headers.ValueStaleWhileRevalidate + "=30, " + headers.ValueStaleIfError + "=600"}}
+	cp := GetResponseCachingPolicy(http.StatusOK, nil, h)
+	if cp.StaleWhileRevalidate != 30 {
+		t.Errorf("expected %d got %d", 30, cp.StaleWhileRevalidate)
+	}
+	if cp.StaleIfError != 600 {
+		t.Errorf("expected %d got %d", 600, cp.StaleIfError)
+	}
+
+	// the object is retained for as long as it can be served stale
+	if ttl := cp.TTL(1, time.Hour); ttl != 660*time.Second {
+		t.Errorf("expected %s got %s", 660*time.Second, ttl)
+	}
+
+	h.Set(headers.NameCacheControl, headers.ValueMaxAge+"=60, "+
+		headers.ValueStaleIfError+"=600, "+headers.ValueMustRevalidate)
+	cp = GetResponseCachingPolicy(http.StatusOK, nil, h)
+	if cp.StaleIfError != 0 {
+		t.Errorf("expected %d got %d", 0, cp.StaleIfError)
+	}
+}
+
+func TestCanServeStale(t *testing.T) {
+
+	cp := &CachingPolicy{LocalDate: time.Now().Add(-90 * time.Second), FreshnessLifetime: 60,
+		StaleWhileRevalidate: 10, StaleIfError: 60}
+
+	if cp.CanServeStale(cp.StaleWhileRevalidate) {
+		t.Error("expected false")
+	}
+	if !cp.CanServeStale(cp.StaleIfError) {
+		t.Error("expected true")
+	}
+	if cp.CanServeStale(0) {
+		t.Error("expected false")
+	}
+	cp.IsNegativeCache = true
+	if cp.CanServeStale(cp.StaleIfError) {
+		t.Error("expected false")
+	}
+}
+
+func TestApplyStaleOverrides(t *testing.T) {
+
+	cp := &CachingPolicy{StaleWhileRevalidate: 10, StaleIfError: 60}
+
+	cp.ApplyStaleOverrides(0, 0)
+	if cp.StaleWhileRevalidate != 10 || cp.StaleIfError != 60 {
+		t.Errorf("unexpected policy %s", cp.String())
+	}
+
+	cp.ApplyStaleOverrides(20, -1)
+	if cp.StaleWhileRevalidate != 20 || cp.StaleIfError != 0 {
+		t.Errorf("unexpected policy %s", cp.String())
+	}
+}
@@ -0,0 +1,232 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/cache"
+	"github.com/trickstercache/trickster/pkg/cache/status"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+)
+
+// chunkIndexSuffix is appended to a time series' cache key to form the key of its chunk
+// index, which holds the document and extents of a time series stored in chunks, without
+// its data. Each chunk is stored beneath the chunk index key, suffixed with the epoch
+// second of the chunk's start, so a purge of the time series' cache key includes the
+// chunk index and every chunk.
+const chunkIndexSuffix = ".chunks"
+
+// chunkKey returns the cache key of the chunk starting at t
+func chunkKey(indexKey string, t time.Time) string {
+	return indexKey + "." + strconv.FormatInt(t.Unix(), 10)
+}
+
+// chunkExtents returns the extents of the chunks of duration cd that overlap the
+// extent e. Chunks are aligned to multiples of cd and end on their final step.
+func chunkExtents(e timeseries.Extent, cd, step time.Duration) timeseries.ExtentList {
+	if cd <= 0 || e.End.Before(e.Start) {
+		return nil
+	}
+	out := make(timeseries.ExtentList, 0, int(e.End.Sub(e.Start)/cd)+1)
+	for t := e.Start.Truncate(cd); !t.After(e.End); t = t.Add(cd) {
+		out = append(out, timeseries.Extent{Start: t, End: t.Add(cd - step)})
+	}
+	return out
+}
+
+// retainedExtent returns the extent of the newest sz timestamps at the step in the extent
+// list, ending no later than t. Since a time series loaded from a chunk index only holds the
+// data of the chunks that were read, its size is determined from the index's extents.
+func retainedExtent(el timeseries.ExtentList, sz int, step time.Duration,
+	t time.Time) timeseries.Extent {
+	out := timeseries.Extent{End: t}
+	if sz <= 0 || step <= 0 {
+		return out
+	}
+	n := time.Duration(sz)
+	for i := len(el) - 1; i >= 0; i-- {
+		e := el[i]
+		if e.Start.After(t) {
+			continue
+		}
+		end := e.End
+		if end.After(t) {
+			end = e.Start.Add(t.Sub(e.Start) / step * step)
+		}
+		c := end.Sub(e.Start)/step + 1
+		if c >= n {
+			out.Start = end.Add(-(n - 1) * step)
+			return out
+		}
+		n -= c
+	}
+	return out
+}
+
+// droppedChunkKeys returns the keys of the chunks overlapping the extents in before that
+// do not overlap the extents in after, such as the chunks cropped by the retention policy
+func droppedChunkKeys(indexKey string, before, after timeseries.ExtentList,
+	step, cd time.Duration) []string {
+	kept := make(map[int64]bool)
+	for _, e := range after {
+		for _, ce := range chunkExtents(e, cd, step) {
+			kept[ce.Start.Unix()] = true
+		}
+	}
+	var keys []string
+	for _, e := range before {
+		for _, ce := range chunkExtents(e, cd, step) {
+			if kept[ce.Start.Unix()] {
+				continue
+			}
+			kept[ce.Start.Unix()] = true
+			keys = append(keys, chunkKey(indexKey, ce.Start))
+		}
+	}
+	return keys
+}
+
+// readChunks returns a copy of cts, the time series loaded from a chunk index, merged
+// with the data of its chunks that overlap the TimeRangeQuery's extent. The extents of
+// any chunks missing from the cache are removed, so that they are fetched again.
+func readChunks(ctx context.Context, c cache.Cache, indexKey string, cts timeseries.Timeseries,
+	trq *timeseries.TimeRangeQuery, cd time.Duration, modeler *timeseries.Modeler) timeseries.Timeseries {
+
+	cts = cts.Clone()
+	el := cts.Extents().Clone()
+	var chunks timeseries.ExtentList
+	for _, ce := range chunkExtents(trq.Extent, cd, trq.Step) {
+		if len(el.Clone().Crop(ce)) > 0 {
+			chunks = append(chunks, ce)
+		}
+	}
+	if len(chunks) == 0 {
+		return cts
+	}
+
+	loaded := make([]timeseries.Timeseries, len(chunks))
+	var wg sync.WaitGroup
+	for i, ce := range chunks {
+		wg.Add(1)
+		go func(i int, ce timeseries.Extent) {
+			defer wg.Done()
+			loaded[i] = readChunk(ctx, c, chunkKey(indexKey, ce.Start), trq, modeler)
+		}(i, ce)
+	}
+	wg.Wait()
+
+	// a chunk straddling the start of the retained extents may still hold older data
+	bounds := timeseries.Extent{Start: el[0].Start, End: el[len(el)-1].End}
+	var missing timeseries.ExtentList
+	for i, ts := range loaded {
+		if ts == nil {
+			missing = append(missing, chunks[i])
+			continue
+		}
+		ts.CropToRange(bounds)
+	}
+	cts.Merge(true, loaded...)
+	if len(missing) > 0 {
+		el = el.Remove(missing, trq.Step)
+	}
+	cts.SetExtents(el)
+	return cts
+}
+
+// readChunk returns the time series stored in the chunk under key, or nil if it is
+// not cached or cannot be unmarshaled
+func readChunk(ctx context.Context, c cache.Cache, key string,
+	trq *timeseries.TimeRangeQuery, modeler *timeseries.Modeler) timeseries.Timeseries {
+	d, lookupStatus, _, err := QueryCache(ctx, c, key, nil)
+	if err != nil || lookupStatus != status.LookupStatusHit || d == nil {
+		return nil
+	}
+	// chunks stored by reference are cloned, since they are merged into the response
+	if d.timeseries != nil {
+		return d.timeseries.Clone()
+	}
+	ts, err := modeler.CacheUnmarshaler(d.Body, trq)
+	if err != nil {
+		return nil
+	}
+	return ts
+}
+
+// writeChunks writes each chunk of cts that overlaps the changed extents, followed by the
+// chunk index, which is doc holding the extents of cts without its data. It returns the
+// keys that were written.
+func writeChunks(ctx context.Context, c cache.Cache, indexKey string, doc *HTTPDocument,
+	cts timeseries.Timeseries, changed timeseries.ExtentList, step, cd, ttl time.Duration,
+	modeler *timeseries.Modeler, byReference bool,
+	compressTypes map[string]interface{}) ([]string, error) {
+
+	var keys []string
+	seen := make(map[int64]bool)
+	for _, e := range changed {
+		for _, ce := range chunkExtents(e, cd, step) {
+			if seen[ce.Start.Unix()] {
+				continue
+			}
+			seen[ce.Start.Unix()] = true
+			chunk := cts.CroppedClone(ce)
+			if len(chunk.Extents()) == 0 {
+				continue
+			}
+			d := &HTTPDocument{StatusCode: doc.StatusCode, ContentType: doc.ContentType}
+			if err := setDocumentTimeseries(d, chunk, modeler, byReference); err != nil {
+				return keys, err
+			}
+			key := chunkKey(indexKey, ce.Start)
+			if err := WriteCache(ctx, c, key, d, ttl, compressTypes); err != nil {
+				return keys, err
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	idx := cts.CroppedClone(timeseries.Extent{})
+	idx.SetExtents(cts.Extents().Clone())
+	idx.SetVolatileExtents(cts.VolatileExtents().Clone())
+	if err := setDocumentTimeseries(doc, idx, modeler, byReference); err != nil {
+		return keys, err
+	}
+	if err := WriteCache(ctx, c, indexKey, doc, ttl, compressTypes); err != nil {
+		return keys, err
+	}
+	return append(keys, indexKey), nil
+}
+
+// setDocumentTimeseries sets the time series as the document's content, by reference for
+// memory caches, or otherwise serialized into the document's body
+func setDocumentTimeseries(d *HTTPDocument, ts timeseries.Timeseries,
+	modeler *timeseries.Modeler, byReference bool) error {
+	if byReference {
+		d.timeseries = ts
+		d.timeseriesMarshaler = modeler.CacheMarshaler
+		return nil
+	}
+	b, err := modeler.CacheMarshaler(ts, nil, 0)
+	if err != nil {
+		return err
+	}
+	d.Body = b
+	return nil
+}
@@ -0,0 +1,229 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockprom "github.com/trickstercache/mockster/pkg/mocks/prometheus"
+	"github.com/trickstercache/trickster/pkg/cache/evictionmethods"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+)
+
+func TestChunkExtents(t *testing.T) {
+
+	e := timeseries.Extent{Start: time.Unix(4200, 0), End: time.Unix(10860, 0)}
+	el := chunkExtents(e, time.Hour, time.Minute)
+	expected := timeseries.ExtentList{
+		{Start: time.Unix(3600, 0), End: time.Unix(7140, 0)},
+		{Start: time.Unix(7200, 0), End: time.Unix(10740, 0)},
+		{Start: time.Unix(10800, 0), End: time.Unix(14340, 0)},
+	}
+	if !el.Equal(expected) {
+		t.Errorf("expected %s got %s", expected, el)
+	}
+
+	if chunkExtents(e, 0, time.Minute) != nil {
+		t.Error("expected no chunks when chunking is disabled")
+	}
+
+	if k := chunkKey("test.chunks", time.Unix(3600, 0)); k != "test.chunks.3600" {
+		t.Errorf("expected %s got %s", "test.chunks.3600", k)
+	}
+}
+
+func TestRetainedExtent(t *testing.T) {
+
+	m := func(i int) time.Time { return time.Unix(int64(i)*60, 0) }
+	el := timeseries.ExtentList{{Start: m(0), End: m(9)}, {Start: m(20), End: m(29)}}
+
+	tests := []struct {
+		sz       int
+		t        time.Time
+		expected timeseries.Extent
+	}{
+		{5, m(100), timeseries.Extent{Start: m(25), End: m(100)}},
+		{10, m(100), timeseries.Extent{Start: m(20), End: m(100)}},
+		// the retained timestamps span the gap between extents
+		{15, m(100), timeseries.Extent{Start: m(5), End: m(100)}},
+		// timestamps after t are not counted
+		{5, m(24), timeseries.Extent{Start: m(20), End: m(24)}},
+		{30, m(100), timeseries.Extent{End: m(100)}},
+		{0, m(100), timeseries.Extent{End: m(100)}},
+	}
+	for i, test := range tests {
+		if e := retainedExtent(el, test.sz, time.Minute, test.t); e != test.expected {
+			t.Errorf("%d: expected %s got %s", i, test.expected, e)
+		}
+	}
+}
+
+func TestDroppedChunkKeys(t *testing.T) {
+
+	h := func(i int) time.Time { return time.Unix(int64(i)*3600, 0) }
+	before := timeseries.ExtentList{{Start: h(0), End: h(4)}}
+	after := timeseries.ExtentList{{Start: h(2).Add(30 * time.Minute), End: h(4)}}
+
+	keys := droppedChunkKeys("test.chunks", before, after, time.Minute, time.Hour)
+	expected := []string{"test.chunks.0", "test.chunks.3600"}
+	if strings.Join(keys, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v got %v", expected, keys)
+	}
+
+	if keys = droppedChunkKeys("test.chunks", before, nil, time.Minute, time.Hour); len(keys) != 5 {
+		t.Errorf("expected %d got %d", 5, len(keys))
+	}
+}
+
+func TestDeltaProxyCacheRequestChunks(t *testing.T) {
+	for _, provider := range []string{"memory", "test"} {
+		t.Run(provider, func(t *testing.T) {
+			testDeltaProxyCacheRequestChunks(t, provider)
+		})
+	}
+}
+
+func testDeltaProxyCacheRequestChunks(t *testing.T, provider string) {
+
+	ts, _, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	client := rsc.BackendClient.(*TestClient)
+	o := rsc.BackendOptions
+	c := rsc.CacheClient
+	rsc.CacheConfig.Provider = provider
+
+	client.RangeCacheKey = "test-range-key-chunks-" + provider
+	client.InstantCacheKey = "test-instant-key-chunks-" + provider
+
+	o.FastForwardDisable = true
+	// each chunk holds one hour of 1m-step data
+	o.TimeseriesChunkFactor = 60
+
+	const step = time.Minute
+	end := time.Now().Add(-time.Duration(2) * time.Hour).Truncate(time.Hour)
+
+	u := r.URL
+	u.Path = "/prometheus/api/v1/query_range"
+
+	// chunkKeys returns the chunk keys written by this test, since the cache is shared
+	existing := make(map[string]bool)
+	chunkKeys := func() []string {
+		keys, _ := c.Keys(o.CacheKeyPrefix + ".dpc.")
+		out := make([]string, 0, len(keys))
+		for _, k := range keys {
+			if strings.Contains(k, chunkIndexSuffix+".") && !existing[k] {
+				out = append(out, k)
+			}
+		}
+		return out
+	}
+	for _, k := range chunkKeys() {
+		existing[k] = true
+	}
+
+	tests := []struct {
+		start, end time.Time
+		status     string
+	}{
+		{end.Add(-3 * time.Hour), end, "kmiss"},
+		{end.Add(-3 * time.Hour), end, "hit"},
+		// only the chunk overlapping the uncached hour is written
+		{end.Add(-4 * time.Hour), end, "phit"},
+		// only the chunks overlapping the request are read
+		{end.Add(-150 * time.Minute), end.Add(-time.Hour), "hit"},
+	}
+
+	doRequest := func(start, end time.Time, status string) {
+		expected, _, _ := mockprom.GetTimeSeriesData(queryReturnsOKNoLatency, start, end, step)
+		u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s&rk=%s&ik=%s",
+			int(step.Seconds()), start.Unix(), end.Unix(),
+			queryReturnsOKNoLatency, client.RangeCacheKey, client.InstantCacheKey)
+		r.URL = u
+
+		w := httptest.NewRecorder()
+		client.QueryRangeHandler(w, r)
+		resp := w.Result()
+		bodyBytes, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if err = testStringMatch(string(bodyBytes), expected); err != nil {
+			t.Error(err)
+		}
+		if err = testStatusCodeMatch(resp.StatusCode, http.StatusOK); err != nil {
+			t.Error(err)
+		}
+		if err = testResultHeaderPartMatch(resp.Header,
+			map[string]string{"status": status}); err != nil {
+			t.Error(err)
+		}
+		// chunks are written to the cache asynchronously
+		time.Sleep(time.Millisecond * 50)
+	}
+
+	for i, test := range tests {
+		doRequest(test.start, test.end, test.status)
+		if i == 0 {
+			// the 3h range ending on an hour boundary spans 4 chunks
+			if n := len(chunkKeys()); n != 4 {
+				t.Errorf("expected %d chunks got %d", 4, n)
+			}
+		}
+	}
+	if n := len(chunkKeys()); n != 5 {
+		t.Errorf("expected %d chunks got %d", 5, n)
+	}
+
+	// a chunk missing from the cache is fetched again
+	var removed string
+	suffix := fmt.Sprintf("%s.%d", chunkIndexSuffix, end.Add(-2*time.Hour).Unix())
+	for _, k := range chunkKeys() {
+		if strings.HasSuffix(k, suffix) {
+			removed = k
+		}
+	}
+	if removed == "" {
+		t.Fatalf("expected a chunk key ending in %s", suffix)
+	}
+	c.Remove(removed)
+	doRequest(end.Add(-3*time.Hour), end, "phit")
+	if _, _, err := c.Retrieve(removed, false); err != nil {
+		t.Errorf("expected chunk %s to be written again", removed)
+	}
+	doRequest(end.Add(-3*time.Hour), end, "hit")
+
+	// retention applies to the extents of the chunk index, and the chunks that fall
+	// out of retention are removed from the cache
+	o.TimeseriesEvictionMethod = evictionmethods.EvictionMethodLRU
+	o.TimeseriesRetentionFactor = 120
+	doRequest(end.Add(-4*time.Hour), end.Add(time.Hour), "phit")
+	if n := len(chunkKeys()); n != 3 {
+		t.Errorf("expected %d chunks got %d", 3, n)
+	}
+	// the newest 120 timestamps remain cached
+	doRequest(end.Add(-time.Hour+step), end.Add(time.Hour), "hit")
+}
@@ -326,6 +326,18 @@ func (c *TestClient) SetExtent(r *http.Request, trq *timeseries.TimeRangeQuery,
 	params.SetRequestValues(r, v)
 }
 
+// NormalizeStep will normalize the TimeRangeQuery's Step to the provided canonical steps
+func (c *TestClient) NormalizeStep(r *http.Request, trq *timeseries.TimeRangeQuery,
+	steps []time.Duration) bool {
+	if !trq.NormalizeStep(steps) {
+		return false
+	}
+	v, _, _ := params.GetRequestValues(r)
+	v.Set(upStep, strconv.FormatInt(int64(trq.Step.Seconds()), 10))
+	params.SetRequestValues(r, v)
+	return true
+}
+
 // FastForwardRequest returns an *http.Request crafted to collect Fast Forward
 // data from the Origin, based on the provided HTTP Request
 func (c *TestClient) FastForwardRequest(r *http.Request) (*http.Request, error) {
@@ -0,0 +1,41 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+)
+
+// cacheCodecModeler returns the modeler to use for the backend's configured time series
+// cache codec. For the gorilla codec, this is a copy of the modeler whose CacheMarshaler
+// compresses DataSets, while other time series types use the modeler's own marshaler.
+// No change is needed for reading, since dataset.UnmarshalDataSet detects the encoding
+func cacheCodecModeler(modeler *timeseries.Modeler, codec string) *timeseries.Modeler {
+	if modeler == nil || modeler.CacheMarshaler == nil || codec != dataset.CacheCodecGorilla {
+		return modeler
+	}
+	m := *modeler
+	m.CacheMarshaler = func(ts timeseries.Timeseries, rlo *timeseries.RequestOptions,
+		status int) ([]byte, error) {
+		if _, ok := ts.(*dataset.DataSet); ok {
+			return dataset.MarshalDataSetGorilla(ts, rlo, status)
+		}
+		return modeler.CacheMarshaler(ts, rlo, status)
+	}
+	return &m
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockprom "github.com/trickstercache/mockster/pkg/mocks/prometheus"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+)
+
+func TestCacheCodecModeler(t *testing.T) {
+
+	modeler := &timeseries.Modeler{CacheUnmarshaler: dataset.UnmarshalDataSet,
+		CacheMarshaler: dataset.MarshalDataSet}
+
+	if m := cacheCodecModeler(modeler, ""); m != modeler {
+		t.Error("expected the default codec to use the provided modeler")
+	}
+	if m := cacheCodecModeler(modeler, dataset.CacheCodecMsgpack); m != modeler {
+		t.Error("expected the msgpack codec to use the provided modeler")
+	}
+	if m := cacheCodecModeler(nil, dataset.CacheCodecGorilla); m != nil {
+		t.Error("expected nil modeler")
+	}
+
+	m := cacheCodecModeler(modeler, dataset.CacheCodecGorilla)
+	if m == modeler {
+		t.Fatal("expected a new modeler")
+	}
+	ds := &dataset.DataSet{Results: []*dataset.Result{{}}}
+	b, err := m.CacheMarshaler(ds, nil, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dataset.IsGorillaEncoded(b) {
+		t.Error("expected gorilla encoding")
+	}
+	if _, err = m.CacheUnmarshaler(b, nil); err != nil {
+		t.Error(err)
+	}
+	b, err = modeler.CacheMarshaler(ds, nil, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dataset.IsGorillaEncoded(b) {
+		t.Error("expected the provided modeler to be unchanged")
+	}
+}
+
+func TestDeltaProxyCacheRequestGorillaCodec(t *testing.T) {
+
+	ts, _, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	client := rsc.BackendClient.(*TestClient)
+	o := rsc.BackendOptions
+	c := rsc.CacheClient
+	rsc.CacheConfig.Provider = "test"
+
+	client.RangeCacheKey = "test-range-key-gorilla"
+	client.InstantCacheKey = "test-instant-key-gorilla"
+
+	o.FastForwardDisable = true
+
+	const step = time.Minute
+	end := time.Now().Add(-time.Duration(2) * time.Hour).Truncate(time.Minute)
+
+	u := r.URL
+	u.Path = "/prometheus/api/v1/query_range"
+
+	existing := make(map[string]bool)
+	keys, _ := c.Keys(o.CacheKeyPrefix + ".dpc.")
+	for _, k := range keys {
+		existing[k] = true
+	}
+
+	// cachedBody returns the cached body of the time series written by this test
+	cachedBody := func() []byte {
+		keys, _ := c.Keys(o.CacheKeyPrefix + ".dpc.")
+		for _, k := range keys {
+			if existing[k] {
+				continue
+			}
+			d, _, _, err := QueryCache(r.Context(), c, k, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return d.Body
+		}
+		t.Fatal("expected a cached time series")
+		return nil
+	}
+
+	doRequest := func(start, end time.Time, status string) {
+		expected, _, _ := mockprom.GetTimeSeriesData(queryReturnsOKNoLatency, start, end, step)
+		u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s&rk=%s&ik=%s",
+			int(step.Seconds()), start.Unix(), end.Unix(),
+			queryReturnsOKNoLatency, client.RangeCacheKey, client.InstantCacheKey)
+		r.URL = u
+
+		w := httptest.NewRecorder()
+		client.QueryRangeHandler(w, r)
+		resp := w.Result()
+		bodyBytes, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if err = testStringMatch(string(bodyBytes), expected); err != nil {
+			t.Error(err)
+		}
+		if err = testStatusCodeMatch(resp.StatusCode, http.StatusOK); err != nil {
+			t.Error(err)
+		}
+		if err = testResultHeaderPartMatch(resp.Header,
+			map[string]string{"status": status}); err != nil {
+			t.Error(err)
+		}
+		// the cache is written asynchronously
+		time.Sleep(time.Millisecond * 50)
+	}
+
+	// objects written with msgpack are read transparently once the codec is changed
+	doRequest(end.Add(-time.Hour), end, "kmiss")
+	if dataset.IsGorillaEncoded(cachedBody()) {
+		t.Error("expected msgpack encoding")
+	}
+	o.TimeseriesCacheCodec = dataset.CacheCodecGorilla
+	doRequest(end.Add(-time.Hour), end, "hit")
+	doRequest(end.Add(-2*time.Hour), end, "phit")
+	if !dataset.IsGorillaEncoded(cachedBody()) {
+		t.Error("expected gorilla encoding")
+	}
+	doRequest(end.Add(-2*time.Hour), end, "hit")
+	doRequest(end.Add(-90*time.Minute), end.Add(-30*time.Minute), "hit")
+}
@@ -19,6 +19,7 @@ package engines
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"sync"
@@ -38,8 +39,10 @@ import (
 	tctx "github.com/trickstercache/trickster/pkg/proxy/context"
 	tpe "github.com/trickstercache/trickster/pkg/proxy/errors"
 	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/proxy/methods"
 	"github.com/trickstercache/trickster/pkg/proxy/request"
 	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/transformations"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -57,6 +60,7 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 		rsc.TSUnmarshaler = modeler.WireUnmarshaler
 	}
 	o := rsc.BackendOptions
+	modeler = cacheCodecModeler(modeler, o.TimeseriesCacheCodec)
 	ctx, span := tspan.NewChildSpan(r.Context(), rsc.Tracer, "DeltaProxyCacheRequest")
 	if span != nil {
 		defer span.End()
@@ -89,6 +93,13 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 
 	pr := newProxyRequest(r, w)
 	rlo.FastForwardDisable = o.FastForwardDisable || rlo.FastForwardDisable
+
+	// when canonical steps are configured, the query step is normalized so that queries
+	// with similar steps share a cached object, which is resampled to the requested step
+	if sn, ok := client.(backends.StepNormalizingTimeseriesBackend); ok && len(o.CanonicalSteps) > 0 {
+		sn.NormalizeStep(pr.upstreamRequest, trq, o.CanonicalSteps)
+	}
+
 	trq.NormalizeExtent()
 	now := time.Now()
 
@@ -108,7 +119,15 @@ func DeltaProxyCacheRequest(w http.ResponseWriter, r *http.Request, modeler *tim
 	}
 
 	client.SetExtent(pr.upstreamRequest, trq, &trq.Extent)
-	key := o.CacheKeyPrefix + ".dpc." + pr.DeriveCacheKey("")
+	key := o.CacheKeyPrefix + dpcKeyNamespace + pr.DeriveCacheKey("")
+
+	// when chunked storage is enabled, the time series is stored in chunks of cd duration,
+	// beneath a chunk index that is stored in place of the whole time series
+	var cd time.Duration
+	if o.TimeseriesChunkFactor > 0 {
+		cd = trq.Step * time.Duration(o.TimeseriesChunkFactor)
+		key += chunkIndexSuffix
+	}
 	pr.cacheLock, _ = locker.RAcquire(key)
 
 	// this is used to determine if Fast Forward should be activated for this request
@@ -156,11 +175,15 @@ checkCache:
 			if doc == nil {
 				err = tpe.ErrEmptyDocumentBody
 			} else {
-				if cc.Provider == "memory" {
+				if cc.Provider == "memory" && doc.timeseries != nil {
 					cts = doc.timeseries
 				} else {
+					// memory cache documents restored from a snapshot remain serialized
 					cts, err = modeler.CacheUnmarshaler(doc.Body, trq)
 				}
+				if err == nil && cd > 0 {
+					cts = readChunks(ctx, cache, key, cts, trq, cd, modeler)
+				}
 			}
 			if err != nil {
 				tl.Error(pr.Logger, "cache object unmarshaling failed",
@@ -346,13 +369,59 @@ checkCache:
 	wg.Wait()
 
 	if ferr != nil {
+		// when configured, the cached portion of the requested time range is served in
+		// place of the upstream error, so long as there is something cached to serve
+		var rts timeseries.Timeseries
+		if o.ServeStaleOnError && cts != nil {
+			if rts = cts.CroppedClone(trq.Extent); rts.ValueCount() == 0 {
+				rts = nil
+			} else {
+				resampleTimeseries(rts, trq)
+			}
+		}
 		if writeLock != nil {
 			writeLock.Release()
 		}
-		Respond(w, mresp.StatusCode, mresp.Header, mresp.Body)
+		if rts == nil {
+			Respond(w, mresp.StatusCode, mresp.Header, mresp.Body)
+			return
+		}
+		tl.Warn(pr.Logger, "upstream requests failed, serving cached portion of time series",
+			tl.Pairs{"cacheKey": key, "statusCode": mresp.StatusCode, "detail": ferr.Error(),
+				"extentsFailed": missRanges.String()})
+		if wr, ok := rts.(timeseries.Warner); ok {
+			wr.AddWarning(fmt.Sprintf("partial data: upstream requests for %s failed with status %d",
+				missRanges.String(), mresp.StatusCode))
+		}
+		lm := lastModified(rts)
+		rts.SetExtents(nil)
+		rh := doc.SafeHeaderClone()
+		recordDPCResult(r, status.LookupStatusPartialErrorHit, doc.StatusCode, r.URL.Path, ffStatus,
+			time.Since(now).Seconds(), missRanges, rh)
+		writeTimeseries(w, r, rsc, modeler, rts, rh, doc.StatusCode, lm)
 		return
 	}
 
+	// upstream warnings (e.g., Thanos or Cortex partial responses) apply only to this
+	// request, so they are moved from the fetched time series onto the user's response
+	var warnings []string
+	for _, ts := range append([]timeseries.Timeseries{cts}, mts...) {
+		if wr, ok := ts.(timeseries.Warner); ok {
+			warnings = appendWarnings(warnings, wr.ResetWarnings())
+		}
+	}
+	// the fetched data may be incomplete, so it is served but never cached. The cached
+	// time series is cloned so that the merge does not alter the cache's reference.
+	if len(warnings) > 0 && writeLock != nil {
+		writeLock.Release()
+		writeLock = nil
+		if cacheStatus != status.LookupStatusKeyMiss {
+			cts = cts.Clone()
+		}
+		tl.Debug(pr.Logger, "upstream response has warnings, not caching",
+			tl.Pairs{"cacheKey": key, "warnings": warnings})
+	}
+
 	// Merge the new delta timeseries into the cached timeseries
 	if len(mts) > 0 {
 		// on phit, elapsed records the time spent waiting for all upstream requests to complete
@@ -404,34 +473,80 @@ checkCache:
 	} else {
 		rts = cts.Clone()
 	}
+	if wr, ok := rts.(timeseries.Warner); ok {
+		for _, w := range warnings {
+			wr.AddWarning(w)
+		}
+	}
+	resampleTimeseries(rts, trq)
 
 	if writeLock != nil {
+		// the tags are derived before writing, while the request and document are in use
+		ctags := cacheTags(r, rsc, doc.SafeHeaderClone())
 		// if the mutex is still locked, it means we need to write the time series to cache
 		go func() {
 			defer writeLock.Release()
 			// Crop the Cache Object down to the Sample Size or Age Retention Policy and the
 			// Backfill Tolerance before storing to cache
-			switch o.TimeseriesEvictionMethod {
-			case evictionmethods.EvictionMethodLRU:
+			var before timeseries.ExtentList
+			if cd > 0 {
+				before = cts.Extents().Clone()
+			}
+			switch {
+			case o.TimeseriesEvictionMethod == evictionmethods.EvictionMethodLRU && cd > 0:
+				cts.CropToRange(retainedExtent(cts.Extents(), o.TimeseriesRetentionFactor,
+					trq.Step, now))
+			case o.TimeseriesEvictionMethod == evictionmethods.EvictionMethodLRU:
 				cts.CropToSize(o.TimeseriesRetentionFactor, now, trq.Extent)
 			default:
 				cts.CropToRange(timeseries.Extent{End: now, Start: OldestRetainedTimestamp})
 			}
+			// chunks that fall out of retention are removed, along with the chunk
+			// index when nothing remains
+			if cd > 0 {
+				if dropped := droppedChunkKeys(key, before, cts.Extents(), trq.Step,
+					cd); len(dropped) > 0 {
+					if len(cts.Extents()) == 0 {
+						dropped = append(dropped, key)
+					}
+					cache.BulkRemove(dropped)
+					untagCacheObjects(cache, dropped)
+				}
+			}
 			// Don't cache datasets with empty extents
 			// (everything was cropped so there is nothing to cache)
 			if len(cts.Extents()) > 0 {
-				if cc.Provider == "memory" {
-					doc.timeseries = cts
-				} else {
-					cdata, err := modeler.CacheMarshaler(cts, nil, 0)
+				if cd > 0 {
+					// only the chunks overlapping the fetched ranges have changed, unless
+					// the whole time series was fetched
+					changed := missRanges
+					if cacheStatus != status.LookupStatusPartialHit &&
+						cacheStatus != status.LookupStatusRangeMiss {
+						changed = cts.Extents()
+					}
+					keys, err := writeChunks(ctx, cache, key, doc, cts, changed, trq.Step, cd,
+						o.TimeseriesTTL, modeler, cc.Provider == "memory", o.CompressibleTypes)
+					for _, k := range keys {
+						tagCacheObject(cache, k, ctags, o.TimeseriesTTL)
+					}
 					if err != nil {
-						tl.Error(pr.Logger, "error marshaling timeseries", tl.Pairs{
-							"cacheKey": key,
-							"detail":   err.Error(),
-						})
-						return
+						tl.Error(pr.Logger, "error writing time series chunks to cache",
+							tl.Pairs{
+								"backendName": o.Name,
+								"cacheName":   cache.Configuration().Name,
+								"cacheKey":    key,
+								"detail":      err.Error(),
+							},
+						)
 					}
-					doc.Body = cdata
+					return
+				}
+				if err := setDocumentTimeseries(doc, cts, modeler, cc.Provider == "memory"); err != nil {
+					tl.Error(pr.Logger, "error marshaling timeseries", tl.Pairs{
+						"cacheKey": key,
+						"detail":   err.Error(),
+					})
+					return
 				}
 				if err := WriteCache(ctx, cache, key, doc, o.TimeseriesTTL, o.CompressibleTypes); err != nil {
 					tl.Error(pr.Logger, "error writing object to cache",
@@ -442,7 +557,9 @@ checkCache:
 							"detail":      err.Error(),
 						},
 					)
+					return
 				}
+				tagCacheObject(cache, key, ctags, o.TimeseriesTTL)
 			}
 		}()
 	}
@@ -459,6 +576,8 @@ checkCache:
 			o.Provider, "cached", r.URL.Path).Add(float64(cachedValueCount))
 	}
 
+	lm := lastModified(rts)
+
 	// Merge Fast Forward data if present. This must be done after the Downstream Crop since
 	// the cropped extent was normalized to stepboundaries and would remove fast forward data
 	// If the fast forward data point is older (e.g. cached) than the last datapoint in the
@@ -466,6 +585,8 @@ checkCache:
 	if hasFastForwardData && len(ffts.Extents()) == 1 &&
 		ffts.Extents()[0].Start.Truncate(time.Second).After(normalizedNow.Extent.End) {
 		rts.Merge(false, ffts)
+		// fast forward data is never cached, so it has no stable Last-Modified time
+		lm = time.Time{}
 	}
 	rts.SetExtents(nil) // so they are not included in the client response json
 	//rts.SetTimeRangeQuery(&timeseries.TimeRangeQuery{})
@@ -477,18 +598,91 @@ checkCache:
 	logDeltaRoutine(pr.Logger, dpStatus)
 	recordDPCResult(r, cacheStatus, sc, r.URL.Path, ffStatus, elapsed.Seconds(), missRanges, rh)
 
+	writeTimeseries(w, r, rsc, modeler, rts, rh, sc, lm)
+}
+
+// resampleTimeseries resamples the response timeseries from the query's canonical
+// step to the client's requested step, when the step was normalized
+func resampleTimeseries(rts timeseries.Timeseries, trq *timeseries.TimeRangeQuery) {
+	if trq.RequestedStep <= 0 {
+		return
+	}
+	if rs, ok := rts.(timeseries.Resampler); ok {
+		rs.Resample(trq.RequestedStep, trq.Step, trq.Extent)
+	}
+}
+
+// writeTimeseries writes the response timeseries to the client, or hands it off to the
+// response merger when the request is a member of a time series merge
+func writeTimeseries(w io.Writer, r *http.Request, rsc *request.Resources, modeler *timeseries.Modeler,
+	rts timeseries.Timeseries, rh http.Header, sc int, lastModified time.Time) {
 	rsc.TS = rts
-	Respond(w, 0, rh, nil) // body and code are nil so this only sets appropriate headers; no writes
 	if rsc.TSTransformer != nil {
 		rsc.TSTransformer(rts)
 	}
+	if rsc.BackendOptions != nil {
+		transformations.Process(rts, rsc.BackendOptions.Transformations)
+	}
 	if rsc.IsMergeMember { // don't bother marshaling this dataset if it's just going to be merged internally
+		Respond(w, 0, rh, nil) // body and code are nil so this only sets appropriate headers; no writes
 		if rsc.Response == nil {
 			rsc.Response = &http.Response{StatusCode: sc}
 		}
 		return
 	}
-	modeler.WireMarshalWriter(rts, rlo, sc, w)
+	if sc == http.StatusOK && isTimeseriesClientFresh(r, rts, rh, lastModified) {
+		Respond(w, http.StatusNotModified, rh, nil)
+		return
+	}
+	Respond(w, 0, rh, nil) // body and code are nil so this only sets appropriate headers; no writes
+	modeler.WireMarshalWriter(rts, rsc.TSReqestOptions, sc, w)
+}
+
+// isTimeseriesClientFresh sets the ETag and Last-Modified response headers for the
+// timeseries, and returns true if the client's If-None-Match or If-Modified-Since
+// conditions are satisfied, so that the timeseries need not be sent to the client
+func isTimeseriesClientFresh(r *http.Request, rts timeseries.Timeseries, rh http.Header,
+	lastModified time.Time) bool {
+	cs, ok := rts.(timeseries.Checksummer)
+	if !ok || rh == nil {
+		return false
+	}
+	etag := cs.Checksum()
+	rh.Set(headers.NameETag, `"`+etag+`"`)
+	rh.Del(headers.NameLastModified)
+	if !lastModified.IsZero() {
+		rh.Set(headers.NameLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+	if r == nil || !methods.IsCacheable(r.Method) {
+		return false
+	}
+	cp := GetRequestCachingPolicy(r.Header)
+	cp.ETag = etag
+	cp.LastModified = lastModified
+	cp.IfUnmodifiedSinceTime = time.Time{}
+	if lastModified.IsZero() {
+		cp.IfModifiedSinceTime = time.Time{}
+	}
+	cp.ParseClientConditionals()
+	cp.ResolveClientConditionals(status.LookupStatusHit)
+	return cp.IsClientFresh
+}
+
+// lastModified returns the end of the newest extent of the timeseries, truncated to the second,
+// for use as its Last-Modified time. Since data in volatile extents can be refetched with new
+// values while the newest extent is unchanged, the zero time is returned when any volatile
+// extent overlaps the timeseries, so that clients must revalidate with its ETag instead
+func lastModified(rts timeseries.Timeseries) time.Time {
+	if len(rts.VolatileExtents()) > 0 {
+		return time.Time{}
+	}
+	var t time.Time
+	for _, e := range rts.Extents() {
+		if e.End.After(t) {
+			t = e.End
+		}
+	}
+	return t.Truncate(time.Second)
 }
 
 func logDeltaRoutine(logger interface{}, p tl.Pairs) {
@@ -561,6 +755,11 @@ func recordDPCResult(r *http.Request, cacheStatus status.LookupStatus, httpStatu
 	ffStatus string, elapsed float64, needed []timeseries.Extent, header http.Header) {
 	recordResults(r, "DeltaProxyCache", cacheStatus, httpStatus, path, ffStatus, elapsed,
 		timeseries.ExtentList(needed), header)
+	if rsc := request.GetResources(r); rsc != nil && rsc.BackendClient != nil {
+		if w := rsc.BackendClient.Warmer(); w != nil && httpStatus < http.StatusBadRequest {
+			w.Observe(r, rsc.TimeRangeQuery, cacheStatus)
+		}
+	}
 }
 
 func getDecoderReader(resp *http.Response) io.Reader {
@@ -591,12 +790,25 @@ func fetchExtents(el timeseries.ExtentList, rsc *request.Resources, h http.Heade
 	// the meta-response aggregating all upstream responses
 	mresp := &http.Response{Header: h}
 
+	// when configured, this limits the number of upstream requests that are in flight at once
+	var sem chan struct{}
+	if rsc.BackendOptions != nil && rsc.BackendOptions.MaxShardConcurrency > 0 &&
+		rsc.BackendOptions.MaxShardConcurrency < len(el) {
+		sem = make(chan struct{}, rsc.BackendOptions.MaxShardConcurrency)
+	}
+
 	// iterate each time range that the client needs and fetch from the upstream origin
 	for i := range el {
+		if sem != nil {
+			sem <- struct{}{}
+		}
 		wg.Add(1)
 		// This concurrently fetches gaps from the origin and adds their datasets to the merge list
 		go func(e *timeseries.Extent, rq *proxyRequest) {
 			defer wg.Done()
+			if sem != nil {
+				defer func() { <-sem }()
+			}
 			mrsc := rsc.Clone()
 			rq.upstreamRequest = rq.WithContext(tctx.WithResources(
 				trace.ContextWithSpan(context.Background(), span),
@@ -640,7 +852,9 @@ func fetchExtents(el timeseries.ExtentList, rsc *request.Resources, h http.Heade
 				mts = append(mts, nts)
 				appendLock.Unlock()
 			} else if resp.StatusCode != 200 {
+				appendLock.Lock()
 				err = tpe.ErrUnexpectedUpstreamResponse
+				appendLock.Unlock()
 				var b []byte
 				var s string
 				if resp.Body != nil {
@@ -672,3 +886,21 @@ func fetchExtents(el timeseries.ExtentList, rsc *request.Resources, h http.Heade
 	wg.Wait()
 	return mts, uncachedValueCount, mresp, err
 }
+
+// appendWarnings appends the warnings that are not already in the list, such as those
+// repeated by each sharded upstream response
+func appendWarnings(list, warnings []string) []string {
+	for _, w := range warnings {
+		var found bool
+		for _, w2 := range list {
+			if w == w2 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, w)
+		}
+	}
+	return list
+}
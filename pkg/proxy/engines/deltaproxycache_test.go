@@ -17,18 +17,25 @@
 package engines
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	mockprom "github.com/trickstercache/mockster/pkg/mocks/prometheus"
 	"github.com/trickstercache/trickster/pkg/backends"
+	bo "github.com/trickstercache/trickster/pkg/backends/options"
 	"github.com/trickstercache/trickster/pkg/proxy/headers"
 	"github.com/trickstercache/trickster/pkg/proxy/request"
 	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+	tfo "github.com/trickstercache/trickster/pkg/timeseries/transformations/options"
 	tu "github.com/trickstercache/trickster/pkg/util/testing"
 )
 
@@ -1627,3 +1634,510 @@ func TestDeltaProxyCacheRequestShardByPoints(t *testing.T) {
 	}
 
 }
+
+func TestDeltaProxyCacheRequestShardConcurrency(t *testing.T) {
+
+	ts, w, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Error(err)
+	}
+	defer ts.Close()
+
+	client := rsc.BackendClient.(*TestClient)
+	o := rsc.BackendOptions
+	rsc.CacheConfig.Provider = "test"
+
+	client.RangeCacheKey = "test-range-key-shard-concurrency"
+	client.InstantCacheKey = "test-instant-key-shard-concurrency"
+
+	o.FastForwardDisable = true
+	o.MaxShardSize = time.Hour
+	o.MaxShardConcurrency = 2
+	o.DoesShard = true
+
+	// track the upstream requests in flight, delaying each so that they would overlap
+	// if the concurrency were not limited
+	ift := &inFlightTransport{next: o.HTTPClient.Transport, delay: 20 * time.Millisecond}
+	hc := *o.HTTPClient
+	hc.Transport = ift
+	o.HTTPClient = &hc
+
+	step := time.Duration(300) * time.Second
+	end := time.Now().Add(-12 * time.Hour)
+
+	extr := timeseries.Extent{Start: end.Add(-time.Duration(18) * time.Hour), End: end}
+	extn := timeseries.Extent{Start: normalizeTime(extr.Start, step), End: normalizeTime(extr.End, step)}
+
+	expected, _, _ := mockprom.GetTimeSeriesData(queryReturnsOKNoLatency, extn.Start, extn.End, step)
+
+	u := r.URL
+	u.Path = "/prometheus/api/v1/query_range"
+	u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s&rk=%s&ik=%s", int(step.Seconds()),
+		extr.Start.Unix(), extr.End.Unix(), queryReturnsOKNoLatency, client.RangeCacheKey, client.InstantCacheKey)
+
+	client.QueryRangeHandler(w, r)
+	resp := w.Result()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = testStringMatch(string(bodyBytes), expected)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = testStatusCodeMatch(resp.StatusCode, http.StatusOK)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = testResultHeaderPartMatch(resp.Header, map[string]string{"status": "kmiss"})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if n := atomic.LoadInt32(&ift.count); n != 19 {
+		t.Errorf("expected %d upstream requests got %d", 19, n)
+	}
+	if n := atomic.LoadInt32(&ift.max); n != int32(o.MaxShardConcurrency) {
+		t.Errorf("expected at most %d upstream requests in flight got %d",
+			o.MaxShardConcurrency, n)
+	}
+
+}
+
+// inFlightTransport is an http.RoundTripper that records the maximum number of
+// requests in flight at once
+type inFlightTransport struct {
+	next                 http.RoundTripper
+	delay                time.Duration
+	inFlight, max, count int32
+}
+
+func (t *inFlightTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	n := atomic.AddInt32(&t.inFlight, 1)
+	defer atomic.AddInt32(&t.inFlight, -1)
+	atomic.AddInt32(&t.count, 1)
+	for {
+		m := atomic.LoadInt32(&t.max)
+		if n <= m || atomic.CompareAndSwapInt32(&t.max, m, n) {
+			break
+		}
+	}
+	time.Sleep(t.delay)
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return next.RoundTrip(r)
+}
+
+func TestDeltaProxyCacheRequestServeStaleOnError(t *testing.T) {
+
+	ts, w, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Error(err)
+	}
+
+	client := rsc.BackendClient.(*TestClient)
+	o := rsc.BackendOptions
+	rsc.CacheConfig.Provider = "test"
+
+	client.RangeCacheKey = "test-range-key-stale-on-error"
+	client.InstantCacheKey = "test-instant-key-stale-on-error"
+
+	o.FastForwardDisable = true
+	o.ServeStaleOnError = true
+
+	step := time.Duration(300) * time.Second
+	end := time.Now().Add(-12 * time.Hour)
+
+	extr := timeseries.Extent{Start: end.Add(-time.Duration(18) * time.Hour), End: end}
+	extn := timeseries.Extent{Start: normalizeTime(extr.Start, step), End: normalizeTime(extr.End, step)}
+
+	expected, _, _ := mockprom.GetTimeSeriesData(queryReturnsOKNoLatency, extn.Start, extn.End, step)
+
+	u := r.URL
+	u.Path = "/prometheus/api/v1/query_range"
+	u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s&rk=%s&ik=%s", int(step.Seconds()),
+		extr.Start.Unix(), extr.End.Unix(), queryReturnsOKNoLatency, client.RangeCacheKey, client.InstantCacheKey)
+
+	client.QueryRangeHandler(w, r)
+	resp := w.Result()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = testStringMatch(string(bodyBytes), expected)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = testResultHeaderPartMatch(resp.Header, map[string]string{"status": "kmiss"})
+	if err != nil {
+		t.Error(err)
+	}
+
+	// the origin is now unreachable, so the extended range is served from cache
+	ts.Close()
+	extr.End = extr.End.Add(time.Hour)
+	u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s&rk=%s&ik=%s", int(step.Seconds()),
+		extr.Start.Unix(), extr.End.Unix(), queryReturnsOKNoLatency, client.RangeCacheKey, client.InstantCacheKey)
+
+	r.URL = u
+	w = httptest.NewRecorder()
+	client.QueryRangeHandler(w, r)
+	resp = w.Result()
+
+	bodyBytes, err = io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = testStatusCodeMatch(resp.StatusCode, http.StatusOK)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = testResultHeaderPartMatch(resp.Header, map[string]string{"status": "partial-error"})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(bodyBytes) == 0 {
+		t.Error("expected non-empty body")
+	}
+
+	ds, ok := rsc.TS.(*dataset.DataSet)
+	if !ok {
+		t.Fatal("expected dataset")
+	}
+	if len(ds.Warnings) != 1 || !strings.HasPrefix(ds.Warnings[0], "partial data: upstream requests for") {
+		t.Errorf("expected partial data warning got %v", ds.Warnings)
+	}
+
+	// without serve_stale_on_error, the upstream error is returned
+	o.ServeStaleOnError = false
+	w = httptest.NewRecorder()
+	client.QueryRangeHandler(w, r)
+	resp = w.Result()
+
+	err = testStatusCodeMatch(resp.StatusCode, http.StatusBadGateway)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// warningTransport is an http.RoundTripper that adds a warning to the upstream responses
+// while warn is set, like a Thanos or Cortex partial response
+type warningTransport struct {
+	next  http.RoundTripper
+	warn  int32
+	count int32
+}
+
+func (t *warningTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.count, 1)
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(r)
+	if err != nil || atomic.LoadInt32(&t.warn) == 0 {
+		return resp, err
+	}
+	var doc map[string]interface{}
+	if err = json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	doc["warnings"] = []string{"partial response"}
+	b, _ := json.Marshal(doc)
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+	resp.ContentLength = int64(len(b))
+	resp.Header.Del(headers.NameContentLength)
+	return resp, nil
+}
+
+func TestDeltaProxyCacheRequestPartialResponseWarnings(t *testing.T) {
+
+	ts, w, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Error(err)
+	}
+	defer ts.Close()
+
+	client := rsc.BackendClient.(*TestClient)
+	o := rsc.BackendOptions
+	rsc.CacheConfig.Provider = "test"
+
+	client.RangeCacheKey = "test-range-key-warnings"
+	client.InstantCacheKey = "test-instant-key-warnings"
+
+	o.FastForwardDisable = true
+
+	wt := &warningTransport{next: o.HTTPClient.Transport}
+	hc := *o.HTTPClient
+	hc.Transport = wt
+	o.HTTPClient = &hc
+
+	step := time.Duration(300) * time.Second
+	end := time.Now().Add(-12 * time.Hour)
+	extr := timeseries.Extent{Start: end.Add(-6 * time.Hour), End: end}
+
+	u := r.URL
+	u.Path = "/prometheus/api/v1/query_range"
+	request := func(e timeseries.Extent, expectedStatus string, expectedWarnings int) {
+		t.Helper()
+		u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s&rk=%s&ik=%s",
+			int(step.Seconds()), e.Start.Unix(), e.End.Unix(), queryReturnsOKNoLatency,
+			client.RangeCacheKey, client.InstantCacheKey)
+		r.URL = u
+		w = httptest.NewRecorder()
+		client.QueryRangeHandler(w, r)
+		resp := w.Result()
+		if err := testStatusCodeMatch(resp.StatusCode, http.StatusOK); err != nil {
+			t.Error(err)
+		}
+		err := testResultHeaderPartMatch(resp.Header, map[string]string{"status": expectedStatus})
+		if err != nil {
+			t.Error(err)
+		}
+		ds, ok := rsc.TS.(*dataset.DataSet)
+		if !ok {
+			t.Fatal("expected dataset")
+		}
+		if len(ds.Warnings) != expectedWarnings {
+			t.Errorf("expected %d warnings got %v", expectedWarnings, ds.Warnings)
+		}
+	}
+
+	// a partial response is served with its warnings, but is not cached
+	atomic.StoreInt32(&wt.warn, 1)
+	request(extr, "kmiss", 1)
+	atomic.StoreInt32(&wt.warn, 0)
+	request(extr, "kmiss", 0)
+	request(extr, "hit", 0)
+
+	// nor are the extents of a partial response merged into the cached time series
+	atomic.StoreInt32(&wt.warn, 1)
+	extr2 := timeseries.Extent{Start: extr.Start, End: extr.End.Add(time.Hour)}
+	request(extr2, "phit", 1)
+	atomic.StoreInt32(&wt.warn, 0)
+	request(extr2, "phit", 0)
+	request(extr2, "hit", 0)
+	request(extr, "hit", 0)
+
+	if n := atomic.LoadInt32(&wt.count); n != 4 {
+		t.Errorf("expected %d upstream requests got %d", 4, n)
+	}
+}
+
+func TestDeltaProxyCacheRequestCanonicalSteps(t *testing.T) {
+
+	ts, _, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Error(err)
+	}
+	defer ts.Close()
+
+	client := rsc.BackendClient.(*TestClient)
+	o := rsc.BackendOptions
+	rsc.CacheConfig.Provider = "test"
+
+	client.RangeCacheKey = "test-range-key-canonical"
+	client.InstantCacheKey = "test-instant-key-canonical"
+
+	o.FastForwardDisable = true
+	o.CanonicalSteps = []time.Duration{time.Minute}
+
+	end := time.Now().Add(-time.Duration(2) * time.Hour).Truncate(time.Hour)
+	extr := timeseries.Extent{Start: end.Add(-time.Duration(4) * time.Hour), End: end}
+
+	u := r.URL
+	u.Path = "/prometheus/api/v1/query_range"
+
+	tests := []struct {
+		step   time.Duration
+		status string
+	}{
+		{time.Minute, "kmiss"},
+		// larger steps are served from the same cached object, resampled to the requested step
+		{2 * time.Minute, "hit"},
+		{5 * time.Minute, "hit"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			extn := timeseries.Extent{Start: normalizeTime(extr.Start, test.step),
+				End: normalizeTime(extr.End, test.step)}
+			expected, _, _ := mockprom.GetTimeSeriesData(queryReturnsOKNoLatency,
+				extn.Start, extn.End, test.step)
+
+			u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s&rk=%s&ik=%s",
+				int(test.step.Seconds()), extr.Start.Unix(), extr.End.Unix(),
+				queryReturnsOKNoLatency, client.RangeCacheKey, client.InstantCacheKey)
+			r.URL = u
+
+			time.Sleep(time.Millisecond * 10)
+
+			w := httptest.NewRecorder()
+			client.QueryRangeHandler(w, r)
+			resp := w.Result()
+
+			bodyBytes, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			err = testStringMatch(string(bodyBytes), expected)
+			if err != nil {
+				t.Error(err)
+			}
+
+			err = testStatusCodeMatch(resp.StatusCode, http.StatusOK)
+			if err != nil {
+				t.Error(err)
+			}
+
+			err = testResultHeaderPartMatch(resp.Header, map[string]string{"status": test.status})
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestDeltaProxyCacheRequestConditional(t *testing.T) {
+
+	ts, _, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Error(err)
+	}
+	defer ts.Close()
+
+	client := rsc.BackendClient.(*TestClient)
+	o := rsc.BackendOptions
+	rsc.CacheConfig.Provider = "test"
+
+	client.RangeCacheKey = "test-range-key-conditional"
+	client.InstantCacheKey = "test-instant-key-conditional"
+
+	o.FastForwardDisable = true
+
+	step := time.Duration(300) * time.Second
+	end := time.Now().Add(-time.Duration(12) * time.Hour)
+	extr := timeseries.Extent{Start: end.Add(-time.Duration(6) * time.Hour), End: end}
+	extn := timeseries.Extent{Start: normalizeTime(extr.Start, step), End: normalizeTime(extr.End, step)}
+
+	u := r.URL
+	u.Path = "/prometheus/api/v1/query_range"
+	u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s&rk=%s&ik=%s", int(step.Seconds()),
+		extr.Start.Unix(), extr.End.Unix(), queryReturnsOKNoLatency, client.RangeCacheKey, client.InstantCacheKey)
+	r.URL = u
+
+	expected, _, _ := mockprom.GetTimeSeriesData(queryReturnsOKNoLatency, extn.Start, extn.End, step)
+
+	fetch := func(h map[string]string) (*http.Response, string) {
+		time.Sleep(time.Millisecond * 10)
+		r2 := r.Clone(r.Context())
+		for k, v := range h {
+			r2.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		client.QueryRangeHandler(w, r2)
+		resp := w.Result()
+		b, _ := io.ReadAll(resp.Body)
+		return resp, string(b)
+	}
+
+	resp, body := fetch(nil)
+	if err = testStringMatch(body, expected); err != nil {
+		t.Error(err)
+	}
+	etag := resp.Header.Get(headers.NameETag)
+	if etag == "" {
+		t.Fatal("expected an ETag")
+	}
+	lm := resp.Header.Get(headers.NameLastModified)
+	if lm != extn.End.UTC().Format(http.TimeFormat) {
+		t.Errorf("expected %s got %s", extn.End.UTC().Format(http.TimeFormat), lm)
+	}
+
+	tests := []struct {
+		h    map[string]string
+		code int
+	}{
+		{map[string]string{headers.NameIfNoneMatch: etag}, http.StatusNotModified},
+		{map[string]string{headers.NameIfNoneMatch: `"other", ` + etag}, http.StatusNotModified},
+		{map[string]string{headers.NameIfNoneMatch: `"other"`}, http.StatusOK},
+		{map[string]string{headers.NameIfModifiedSince: lm}, http.StatusNotModified},
+		{map[string]string{headers.NameIfModifiedSince: extn.End.Add(-step).UTC().Format(http.TimeFormat)},
+			http.StatusOK},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			resp, body := fetch(test.h)
+			if resp.StatusCode != test.code {
+				t.Errorf("expected %d got %d", test.code, resp.StatusCode)
+			}
+			if resp.Header.Get(headers.NameETag) != etag {
+				t.Errorf("expected %s got %s", etag, resp.Header.Get(headers.NameETag))
+			}
+			if test.code == http.StatusNotModified && body != "" {
+				t.Errorf("expected empty body got %s", body)
+			}
+			if test.code == http.StatusOK {
+				if err := testStringMatch(body, expected); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+
+	// responses overlapping volatile extents have no Last-Modified time, since their data
+	// can change when backfilled, and are only revalidated by their ETag
+	o.BackfillTolerance = time.Hour
+	end = time.Now()
+	u.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s&rk=%s&ik=%s", int(step.Seconds()),
+		end.Add(-time.Duration(6)*time.Hour).Unix(), end.Unix(), queryReturnsOKNoLatency,
+		client.RangeCacheKey, client.InstantCacheKey)
+	resp, _ = fetch(nil)
+	if lm = resp.Header.Get(headers.NameLastModified); lm != "" {
+		t.Errorf("expected no Last-Modified got %s", lm)
+	}
+	resp, _ = fetch(map[string]string{
+		headers.NameIfModifiedSince: end.Add(time.Hour).UTC().Format(http.TimeFormat)})
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestWriteTimeseriesTransformations(t *testing.T) {
+
+	l := tfo.List{{Type: tfo.TypeDropSeries, Label: "job", Regex: "b"}}
+	if err := l.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	rsc := &request.Resources{BackendOptions: bo.New(), IsMergeMember: true}
+	rsc.BackendOptions.Transformations = l
+
+	newSeries := func(job string) *dataset.Series {
+		return &dataset.Series{Header: dataset.SeriesHeader{Tags: dataset.Tags{"job": job}}}
+	}
+	rts := &dataset.DataSet{Results: []*dataset.Result{{SeriesList: []*dataset.Series{
+		newSeries("a"), newSeries("b")}}}}
+
+	writeTimeseries(httptest.NewRecorder(), nil, rsc, nil, rts, http.Header{}, http.StatusOK, time.Time{})
+	if n := len(rts.Results[0].SeriesList); n != 1 {
+		t.Errorf("expected %d got %d", 1, n)
+	}
+	if rsc.TS != rts || rsc.Response.StatusCode != http.StatusOK {
+		t.Error("expected the transformed timeseries to be handed off for merging")
+	}
+}
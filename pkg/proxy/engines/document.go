@@ -53,7 +53,9 @@ type HTTPDocument struct {
 	isFulfillment    bool
 	isLoaded         bool
 	timeseries       timeseries.Timeseries
-	headerLock       sync.Mutex
+	// timeseriesMarshaler serializes timeseries when the document is marshaled by reference
+	timeseriesMarshaler timeseries.MarshalerFunc
+	headerLock          sync.Mutex
 }
 
 // SafeHeaderClone returns a threadsafe copy of the Document Header
@@ -85,6 +87,36 @@ func (d *HTTPDocument) Size() int {
 	return i
 }
 
+// MarshalReference returns the document serialized as it would be written to a cache
+// that does not store references, so that memory caches can persist it
+func (d *HTTPDocument) MarshalReference() ([]byte, error) {
+	d.headerLock.Lock()
+	nd := &HTTPDocument{
+		StatusCode:       d.StatusCode,
+		Status:           d.Status,
+		Headers:          http.Header(d.Headers).Clone(),
+		Body:             d.Body,
+		ContentLength:    d.ContentLength,
+		ContentType:      d.ContentType,
+		CachingPolicy:    d.CachingPolicy,
+		Ranges:           d.Ranges,
+		StoredRangeParts: d.StoredRangeParts,
+	}
+	d.headerLock.Unlock()
+	if d.timeseries != nil {
+		if d.timeseriesMarshaler == nil {
+			return nil, errors.New("no marshaler for document timeseries")
+		}
+		b, err := d.timeseriesMarshaler(d.timeseries, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		nd.Body = b
+	}
+	// the leading 0 indicates an uncompressed document, as with WriteCache
+	return nd.MarshalMsg([]byte{0})
+}
+
 // SetBody sets the Document Body as well as the Content Length, based on the length of body.
 // This assumes that the caller has checked that the request is not a Range request
 func (d *HTTPDocument) SetBody(body []byte) {
@@ -24,6 +24,7 @@ import (
 	txe "github.com/trickstercache/trickster/pkg/proxy/errors"
 	"github.com/trickstercache/trickster/pkg/proxy/headers"
 	"github.com/trickstercache/trickster/pkg/proxy/ranges/byterange"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
 )
 
 func TestDocumentFromHTTPResponse(t *testing.T) {
@@ -230,3 +231,47 @@ func TestLoadRangeParts(t *testing.T) {
 	}
 
 }
+
+func TestMarshalReference(t *testing.T) {
+
+	d := &HTTPDocument{StatusCode: 200, Status: "200 OK", Body: []byte("1234"),
+		Headers: http.Header{headers.NameContentType: []string{"text/plain"}}}
+
+	b, err := d.MarshalReference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) == 0 || b[0] != 0 {
+		t.Fatal("expected uncompressed document flag")
+	}
+	d2 := &HTTPDocument{}
+	if _, err = d2.UnmarshalMsg(b[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if d2.StatusCode != 200 || string(d2.Body) != "1234" ||
+		http.Header(d2.Headers).Get(headers.NameContentType) != "text/plain" {
+		t.Errorf("unexpected document %v", d2)
+	}
+
+	// documents with a timeseries require a marshaler
+	d.timeseries = &dataset.DataSet{}
+	if _, err = d.MarshalReference(); err == nil {
+		t.Error("expected error for missing timeseries marshaler")
+	}
+	d.timeseriesMarshaler = dataset.MarshalDataSet
+	b, err = d.MarshalReference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2 = &HTTPDocument{}
+	if _, err = d2.UnmarshalMsg(b[1:]); err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := dataset.MarshalDataSet(d.timeseries, nil, 0)
+	if string(d2.Body) != string(expected) {
+		t.Errorf("expected timeseries body got %s", string(d2.Body))
+	}
+	if string(d.Body) != "1234" {
+		t.Error("expected source document body to be unchanged")
+	}
+}
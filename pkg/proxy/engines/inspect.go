@@ -0,0 +1,187 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/trickstercache/trickster/pkg/cache"
+	"github.com/trickstercache/trickster/pkg/cache/status"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+)
+
+// CacheObjectInfo describes an HTTPDocument stored in a cache, and the timeseries it holds
+type CacheObjectInfo struct {
+	// Key is the key under which the object was found, which is the chunk index key
+	// when a chunked time series is inspected by its time series key
+	Key           string          `json:"-"`
+	StatusCode    int             `json:"status_code"`
+	ContentType   string          `json:"content_type,omitempty"`
+	ContentLength int64           `json:"content_length"`
+	Timeseries    *TimeseriesInfo `json:"timeseries,omitempty"`
+	// Chunks lists the keys of the chunks of a time series stored in chunks
+	Chunks []string `json:"chunks,omitempty"`
+}
+
+// TimeseriesInfo describes a timeseries stored in a cache
+type TimeseriesInfo struct {
+	Extents         timeseries.ExtentList `json:"extents"`
+	VolatileExtents timeseries.ExtentList `json:"volatile_extents"`
+	SeriesCount     int                   `json:"series_count"`
+	ValueCount      int64                 `json:"value_count"`
+}
+
+// InspectCacheObject retrieves the HTTPDocument stored in the cache under the provided key
+// and describes it. When a modeler is provided, the timeseries held by the document, if any,
+// is decoded and described as well. A time series stored in chunks is described by its chunk
+// index, which is also found by the time series' cache key, with the counts of its chunks.
+func InspectCacheObject(c cache.Cache, key string,
+	modeler *timeseries.Modeler) (*CacheObjectInfo, status.LookupStatus, error) {
+
+	// hold the key's lock so the document is not modified while it is inspected
+	nl, _ := c.Locker().RAcquire(key)
+	d, ls, err := retrieveDocument(c, key)
+	if err == cache.ErrKNF && !strings.HasSuffix(key, chunkIndexSuffix) {
+		nl.RRelease()
+		key += chunkIndexSuffix
+		nl, _ = c.Locker().RAcquire(key)
+		if d, ls, err = retrieveDocument(c, key); err == cache.ErrKNF {
+			ls = status.LookupStatusKeyMiss
+		}
+	}
+	defer nl.RRelease()
+	if err != nil {
+		return nil, ls, err
+	}
+
+	info := &CacheObjectInfo{
+		Key:           key,
+		StatusCode:    d.StatusCode,
+		ContentType:   d.ContentType,
+		ContentLength: d.ContentLength,
+	}
+
+	ts := documentTimeseries(d, modeler)
+	if ts == nil {
+		return info, ls, nil
+	}
+	info.Timeseries = &TimeseriesInfo{
+		Extents:         ts.Extents(),
+		VolatileExtents: ts.VolatileExtents(),
+	}
+	if info.Timeseries.Extents == nil {
+		info.Timeseries.Extents = timeseries.ExtentList{}
+	}
+	if info.Timeseries.VolatileExtents == nil {
+		info.Timeseries.VolatileExtents = timeseries.ExtentList{}
+	}
+	// the chunk index holds the extents of a chunked time series, but not its data
+	if strings.HasSuffix(key, chunkIndexSuffix) {
+		info.Chunks, ts = inspectChunks(c, key, ts, modeler)
+	}
+	info.Timeseries.SeriesCount = ts.SeriesCount()
+	info.Timeseries.ValueCount = ts.ValueCount()
+
+	return info, ls, nil
+}
+
+// retrieveDocument returns the HTTPDocument stored in the cache under the provided key,
+// including those that have expired
+func retrieveDocument(c cache.Cache, key string) (*HTTPDocument, status.LookupStatus, error) {
+	d := &HTTPDocument{}
+	var b []byte
+	var ls status.LookupStatus
+	var err error
+
+	if c.Configuration().Provider == "memory" {
+		var ifc interface{}
+		ifc, ls, err = c.(cache.MemoryCache).RetrieveReference(key, true)
+		if err != nil {
+			return nil, ls, err
+		}
+		if rd, ok := ifc.(*HTTPDocument); ok {
+			d = rd
+		} else if b, _, _ = c.Retrieve(key, true); len(b) == 0 {
+			return nil, status.LookupStatusKeyMiss, cache.ErrKNF
+		}
+	} else if b, ls, err = c.Retrieve(key, true); err != nil {
+		return nil, ls, err
+	}
+
+	if b != nil {
+		if err = decodeDocument(d, b); err != nil {
+			return nil, ls, err
+		}
+	}
+	return d, ls, nil
+}
+
+// documentTimeseries returns the timeseries held by the document, or nil if it holds none
+func documentTimeseries(d *HTTPDocument, modeler *timeseries.Modeler) timeseries.Timeseries {
+	if d.timeseries != nil {
+		return d.timeseries
+	}
+	if modeler == nil || modeler.CacheUnmarshaler == nil || len(d.Body) == 0 {
+		return nil
+	}
+	// objects that are not timeseries fail to unmarshal and are described without one
+	ts, err := modeler.CacheUnmarshaler(d.Body, nil)
+	if err != nil {
+		return nil
+	}
+	return ts
+}
+
+// inspectChunks returns the sorted keys of the chunks stored beneath the chunk index key,
+// and a copy of the index's time series merged with the data of its chunks
+func inspectChunks(c cache.Cache, indexKey string, ts timeseries.Timeseries,
+	modeler *timeseries.Modeler) ([]string, timeseries.Timeseries) {
+	keys, err := c.Keys(indexKey + ".")
+	if err != nil {
+		return nil, ts
+	}
+	base := strings.TrimSuffix(indexKey, chunkIndexSuffix)
+	chunks := make([]string, 0, len(keys))
+	loaded := make([]timeseries.Timeseries, 0, len(keys))
+	for _, k := range keys {
+		if !IsObjectKey(base, k) {
+			continue
+		}
+		d, _, err := retrieveDocument(c, k)
+		if err != nil {
+			continue
+		}
+		cts := documentTimeseries(d, modeler)
+		if cts == nil {
+			continue
+		}
+		// the merge may take up data of the chunks, so those held by reference are cloned
+		if d.timeseries != nil {
+			cts = cts.Clone()
+		}
+		chunks = append(chunks, k)
+		loaded = append(loaded, cts)
+	}
+	sort.Strings(chunks)
+	if len(loaded) == 0 {
+		return chunks, ts
+	}
+	ts = ts.Clone()
+	ts.Merge(false, loaded...)
+	return chunks, ts
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/cmd/trickster/config"
+	"github.com/trickstercache/trickster/pkg/cache"
+	"github.com/trickstercache/trickster/pkg/cache/registration"
+	tc "github.com/trickstercache/trickster/pkg/proxy/context"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+	"github.com/trickstercache/trickster/pkg/timeseries/epoch"
+	tu "github.com/trickstercache/trickster/pkg/util/testing"
+)
+
+func testInspectDataSet() *dataset.DataSet {
+	e := timeseries.Extent{Start: time.Unix(1609459200, 0), End: time.Unix(1609459260, 0)}
+	return &dataset.DataSet{
+		ExtentList:         timeseries.ExtentList{e},
+		VolatileExtentList: timeseries.ExtentList{{Start: e.End, End: e.End}},
+		Results: []*dataset.Result{{SeriesList: []*dataset.Series{{
+			Header: dataset.SeriesHeader{Name: "a"},
+			Points: dataset.Points{
+				{Epoch: epoch.Epoch(e.Start.UnixNano()), Size: 16, Values: []interface{}{1.0}},
+				{Epoch: epoch.Epoch(e.End.UnixNano()), Size: 16, Values: []interface{}{2.0}},
+			},
+		}}}},
+	}
+}
+
+func TestInspectCacheObject(t *testing.T) {
+
+	conf, _, err := config.Load("trickster", "test", []string{"-origin-url", "http://1", "-provider", "test"})
+	if err != nil {
+		t.Fatalf("Could not load configuration: %s", err.Error())
+	}
+	caches := registration.LoadCachesFromConfig(conf, testLogger)
+	defer registration.CloseCaches(caches)
+	c := caches["default"]
+
+	modeler := &timeseries.Modeler{CacheUnmarshaler: dataset.UnmarshalDataSet,
+		CacheMarshaler: dataset.MarshalDataSet}
+
+	check := func(info *CacheObjectInfo) {
+		t.Helper()
+		if info.StatusCode != 200 {
+			t.Errorf("expected %d got %d", 200, info.StatusCode)
+		}
+		if info.Timeseries == nil {
+			t.Fatal("expected timeseries info")
+		}
+		if len(info.Timeseries.Extents) != 1 || len(info.Timeseries.VolatileExtents) != 1 {
+			t.Errorf("unexpected extents %v %v", info.Timeseries.Extents,
+				info.Timeseries.VolatileExtents)
+		}
+		if info.Timeseries.SeriesCount != 1 || info.Timeseries.ValueCount != 2 {
+			t.Errorf("unexpected counts %d %d", info.Timeseries.SeriesCount,
+				info.Timeseries.ValueCount)
+		}
+	}
+
+	// a memory cache document referencing its timeseries
+	d := &HTTPDocument{StatusCode: 200, timeseries: testInspectDataSet(),
+		timeseriesMarshaler: dataset.MarshalDataSet}
+	c.(cache.MemoryCache).StoreReference("ref", d, time.Minute)
+	info, _, err := InspectCacheObject(c, "ref", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(info)
+
+	// a serialized document whose body is the cached timeseries
+	b, err := d.MarshalReference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Store("bytes", b, time.Minute)
+	info, _, err = InspectCacheObject(c, "bytes", modeler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(info)
+
+	// a document that is not a timeseries
+	b, _ = (&HTTPDocument{StatusCode: 200, Body: []byte("data")}).MarshalReference()
+	c.Store("opc", b, time.Minute)
+	info, _, err = InspectCacheObject(c, "opc", modeler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Timeseries != nil {
+		t.Error("expected no timeseries info")
+	}
+
+	if _, _, err = InspectCacheObject(c, "missing", modeler); err != cache.ErrKNF {
+		t.Errorf("expected %v got %v", cache.ErrKNF, err)
+	}
+}
+
+func TestInspectCacheObjectChunks(t *testing.T) {
+
+	conf, _, err := config.Load("trickster", "test", []string{"-origin-url", "http://1", "-provider", "test"})
+	if err != nil {
+		t.Fatalf("Could not load configuration: %s", err.Error())
+	}
+	caches := registration.LoadCachesFromConfig(conf, testLogger)
+	defer registration.CloseCaches(caches)
+	c := caches["default"]
+
+	modeler := &timeseries.Modeler{CacheUnmarshaler: dataset.UnmarshalDataSet,
+		CacheMarshaler: dataset.MarshalDataSet}
+
+	ctx := tc.WithResources(context.Background(),
+		&request.Resources{BackendOptions: conf.Backends["default"], Tracer: tu.NewTestTracer()})
+
+	// a timeseries spanning two hourly chunks
+	ts := testInspectDataSet()
+	start := ts.ExtentList[0].Start
+	ts.ExtentList[0].End = start.Add(61 * time.Minute)
+	ts.VolatileExtentList = nil
+	for _, m := range []int{60, 61} {
+		ts.Results[0].SeriesList[0].Points = append(ts.Results[0].SeriesList[0].Points,
+			dataset.Point{Epoch: epoch.Epoch(start.Add(time.Duration(m) * time.Minute).UnixNano()),
+				Size: 16, Values: []interface{}{float64(m)}})
+	}
+
+	for _, byReference := range []bool{true, false} {
+		key := "chunked"
+		if !byReference {
+			key = "chunked-bytes"
+		}
+		indexKey := key + chunkIndexSuffix
+		doc := &HTTPDocument{StatusCode: 200}
+		written, err := writeChunks(ctx, c, indexKey, doc, ts,
+			ts.Extents(), time.Minute, time.Hour, time.Minute, modeler, byReference, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the two chunks and the chunk index
+		if len(written) != 3 {
+			t.Fatalf("expected %d keys got %d", 3, len(written))
+		}
+
+		// the chunk index is found by its own key and by the timeseries' key
+		for _, k := range []string{indexKey, key} {
+			info, _, err := InspectCacheObject(c, k, modeler)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Timeseries == nil {
+				t.Fatal("expected timeseries info")
+			}
+			if info.Timeseries.SeriesCount != 1 || info.Timeseries.ValueCount != 4 {
+				t.Errorf("unexpected counts %d %d", info.Timeseries.SeriesCount,
+					info.Timeseries.ValueCount)
+			}
+			if len(info.Timeseries.Extents) != 1 ||
+				!info.Timeseries.Extents[0].End.Equal(ts.ExtentList[0].End) {
+				t.Errorf("unexpected extents %v", info.Timeseries.Extents)
+			}
+			if info.Key != indexKey {
+				t.Errorf("expected %s got %s", indexKey, info.Key)
+			}
+			if got := strings.Join(info.Chunks, ","); got !=
+				indexKey+".1609459200,"+indexKey+".1609462800" {
+				t.Errorf("unexpected chunks %s", got)
+			}
+		}
+	}
+}
@@ -19,11 +19,13 @@ package engines
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/trickstercache/trickster/pkg/backends"
 	"github.com/trickstercache/trickster/pkg/proxy/errors"
 	"github.com/trickstercache/trickster/pkg/proxy/headers"
 	"github.com/trickstercache/trickster/pkg/proxy/methods"
@@ -32,8 +34,69 @@ import (
 	"github.com/trickstercache/trickster/pkg/util/md5"
 )
 
-// DeriveCacheKey calculates a query-specific keyname based on the user request
+// Cache key namespaces, which follow a backend's CacheKeyPrefix in the keys of objects
+// stored by the Object Proxy Cache and Delta Proxy Cache
+const (
+	opcKeyNamespace = ".opc."
+	dpcKeyNamespace = ".dpc."
+)
+
+// BackendKeyPrefixes returns the key prefixes beneath which the cache engines store the
+// objects of the backend with the provided cache key prefix
+func BackendKeyPrefixes(prefix string) []string {
+	return []string{prefix + opcKeyNamespace, prefix + dpcKeyNamespace}
+}
+
+// IsObjectKey returns true if key is the base cache key, or one of the keys stored beneath
+// it: the variant index and variants of an object that varies by request headers, or the
+// chunk index and chunks of a time series stored in chunks
+func IsObjectKey(base, key string) bool {
+	if key == base {
+		return true
+	}
+	if !strings.HasPrefix(key, base) {
+		return false
+	}
+	s := key[len(base):]
+	switch {
+	case s == varyIndexSuffix, s == chunkIndexSuffix:
+		return true
+	case strings.HasPrefix(s, chunkIndexSuffix+"."):
+		_, err := strconv.ParseInt(s[len(chunkIndexSuffix)+1:], 10, 64)
+		return err == nil
+	case len(s) == 33 && s[0] == '.':
+		// a variant key is suffixed with the md5 checksum of its varied header values
+		for _, c := range s[1:] {
+			if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// DeriveCacheKey calculates a query-specific keyname based on the user request. When the
+// object varies by request headers, the request's variant is identified by the key
 func (pr *proxyRequest) DeriveCacheKey(extra string) string {
+	k := pr.deriveBaseCacheKey(extra)
+	if len(pr.varyHeaders) > 0 {
+		k += "." + varySuffix(pr.Request.Header, pr.varyHeaders)
+	}
+	return k
+}
+
+// cacheKeyParamValue returns the value of the query parameter p for inclusion in a cache
+// key. Array parameters, like Prometheus's match[], are keyed by all of their values, while
+// other parameters are keyed by their first value.
+func cacheKeyParamValue(qp url.Values, p string) string {
+	if strings.HasSuffix(p, "[]") {
+		return strings.Join(qp[p], ",")
+	}
+	return qp.Get(p)
+}
+
+func (pr *proxyRequest) deriveBaseCacheKey(extra string) string {
 
 	rsc := request.GetResources(pr.Request)
 	pc := rsc.PathConfig
@@ -78,11 +141,11 @@ func (pr *proxyRequest) DeriveCacheKey(extra string) string {
 
 	if len(pc.CacheKeyParams) == 1 && pc.CacheKeyParams[0] == "*" {
 		for p := range qp {
-			vals = append(vals, fmt.Sprintf("%s.%s.", p, qp.Get(p)))
+			vals = append(vals, fmt.Sprintf("%s.%s.", p, cacheKeyParamValue(qp, p)))
 		}
 	} else {
 		for _, p := range pc.CacheKeyParams {
-			if v := qp.Get(p); v != "" {
+			if v := cacheKeyParamValue(qp, p); v != "" {
 				vals = append(vals, fmt.Sprintf("%s.%s.", p, v))
 			}
 		}
@@ -131,6 +194,41 @@ func (pr *proxyRequest) DeriveCacheKey(extra string) string {
 	return md5.Checksum(pr.URL.Path + "." + strings.Join(vals, "") + extra)
 }
 
+// DeriveCacheKeys returns the cache keys that the Object Proxy Cache and, when the
+// request is a parsable time range query, the Delta Proxy Cache would use to store
+// the response to the provided request. The request must carry Resources in its context.
+// The Object Proxy Cache key is the object's base key, beneath which any variants are stored.
+func DeriveCacheKeys(r *http.Request) []string {
+	rsc := request.GetResources(r)
+	if rsc == nil || rsc.BackendOptions == nil {
+		return nil
+	}
+	prefix := rsc.BackendOptions.CacheKeyPrefix
+	keys := []string{prefix + opcKeyNamespace + newProxyRequest(r, nil).DeriveCacheKey("")}
+
+	client, ok := rsc.BackendClient.(backends.TimeseriesBackend)
+	if !ok {
+		return keys
+	}
+	trq, _, _, err := client.ParseTimeRangeQuery(r)
+	if err != nil || trq == nil {
+		return keys
+	}
+	// this mirrors the key derivation sequence in DeltaProxyCacheRequest
+	otrq := rsc.TimeRangeQuery
+	rsc.TimeRangeQuery = trq
+	pr := newProxyRequest(r, nil)
+	if sn, ok := client.(backends.StepNormalizingTimeseriesBackend); ok &&
+		len(rsc.BackendOptions.CanonicalSteps) > 0 {
+		sn.NormalizeStep(pr.upstreamRequest, trq, rsc.BackendOptions.CanonicalSteps)
+	}
+	trq.NormalizeExtent()
+	client.SetExtent(pr.upstreamRequest, trq, &trq.Extent)
+	keys = append(keys, prefix+dpcKeyNamespace+pr.DeriveCacheKey(""))
+	rsc.TimeRangeQuery = otrq
+	return keys
+}
+
 func deepSearch(document map[string]interface{}, key string) (string, error) {
 
 	if key == "" {
@@ -20,12 +20,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strconv"
 	"testing"
+	"time"
 
 	bo "github.com/trickstercache/trickster/pkg/backends/options"
 	tl "github.com/trickstercache/trickster/pkg/observability/logging"
@@ -33,6 +35,7 @@ import (
 	"github.com/trickstercache/trickster/pkg/proxy/headers"
 	po "github.com/trickstercache/trickster/pkg/proxy/paths/options"
 	"github.com/trickstercache/trickster/pkg/proxy/request"
+	"github.com/trickstercache/trickster/pkg/util/md5"
 	tu "github.com/trickstercache/trickster/pkg/util/testing"
 )
 
@@ -205,6 +208,44 @@ func TestDeriveCacheKey(t *testing.T) {
 	}
 }
 
+func TestDeriveCacheKeyMultiValue(t *testing.T) {
+
+	cfg := &bo.Options{
+		Paths: map[string]*po.Options{
+			"root": {
+				Path:            "/",
+				CacheKeyParams:  []string{"match[]", "q"},
+				CacheKeyHeaders: []string{headers.NameScopeOrgID},
+			},
+		},
+	}
+
+	deriveKey := func(rawQuery, tenant string) string {
+		tr := httptest.NewRequest(http.MethodGet, "http://127.0.0.1/?"+rawQuery, nil)
+		tr = tr.WithContext(ct.WithResources(context.Background(),
+			request.NewResources(cfg, cfg.Paths["root"], nil, nil, nil, nil, tl.ConsoleLogger("error"))))
+		if tenant != "" {
+			tr.Header.Set(headers.NameScopeOrgID, tenant)
+		}
+		return newProxyRequest(tr, nil).DeriveCacheKey("")
+	}
+
+	k1 := deriveKey("match[]=up", "")
+	k2 := deriveKey("match[]=up&match[]=down", "")
+	if k1 == k2 {
+		t.Error("expected all values of a multi-valued parameter to be included in the key")
+	}
+	// other parameters are keyed by their first value, as they always have been
+	if k5, k6 := deriveKey("q=a", ""), deriveKey("q=a&q=b", ""); k5 != k6 {
+		t.Error("expected only the first value of a non-array parameter in the key")
+	}
+	if k3 := deriveKey("match[]=up", "tenant1"); k3 == k1 {
+		t.Error("expected the tenant header to be included in the key")
+	} else if k4 := deriveKey("match[]=up", "tenant2"); k4 == k3 {
+		t.Error("expected distinct keys for distinct tenants")
+	}
+}
+
 func exampleKeyHasher(path string, params url.Values, headers http.Header,
 	body io.ReadCloser, extra string) (string, io.ReadCloser) {
 	return "test-key", nil
@@ -283,3 +324,63 @@ func TestDeriveCacheKeyNilURL(t *testing.T) {
 		t.Errorf("unexpected cache key: %s", k)
 	}
 }
+
+func TestDeriveCacheKeysCanonicalSteps(t *testing.T) {
+
+	ts, _, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	rsc.BackendOptions.CanonicalSteps = []time.Duration{time.Minute, 2 * time.Minute}
+	rsc.PathConfig.CacheKeyParams = []string{"query", "step"}
+	keys := func(step int) []string {
+		r.URL.RawQuery = fmt.Sprintf("step=%d&start=%d&end=%d&query=%s",
+			step, 3600, 7200, queryReturnsOKNoLatency)
+		return DeriveCacheKeys(r)
+	}
+
+	// the time series key mirrors the step normalization of the Delta Proxy Cache
+	k1, k2, k3 := keys(60), keys(90), keys(120)
+	if len(k1) != 2 || len(k2) != 2 || len(k3) != 2 {
+		t.Fatalf("expected %d keys got %d, %d and %d", 2, len(k1), len(k2), len(k3))
+	}
+	if k1[1] != k2[1] {
+		t.Errorf("expected %s got %s", k1[1], k2[1])
+	}
+	if k1[1] == k3[1] {
+		t.Error("expected a different time series key for a different canonical step")
+	}
+	// the object key is derived from the requested step
+	if k1[0] == k2[0] {
+		t.Error("expected different object keys for different steps")
+	}
+}
+
+func TestIsObjectKey(t *testing.T) {
+	const base = "test.opc.5f4dcc3b5aa765d61d8327deb882cf99"
+	tests := []struct {
+		key      string
+		expected bool
+	}{
+		{base, true},
+		{base + varyIndexSuffix, true},
+		{base + "." + md5.Checksum("Accept-Language.en."), true},
+		{base + chunkIndexSuffix, true},
+		{chunkKey(base+chunkIndexSuffix, time.Unix(3600, 0)), true},
+		{base + "0", false},
+		{base + ".other", false},
+		{base + chunkIndexSuffix + ".abc", false},
+		{base + ".5F4DCC3B5AA765D61D8327DEB882CF99", false},
+		{"test.opc.5f4dcc3b", false},
+	}
+	for i, test := range tests {
+		if v := IsObjectKey(base, test.key); v != test.expected {
+			t.Errorf("test %d: expected %t got %t for %s", i, test.expected, v, test.key)
+		}
+	}
+	if v := BackendKeyPrefixes("test"); len(v) != 2 || v[0] != "test.opc." || v[1] != "test.dpc." {
+		t.Errorf("unexpected prefixes %v", v)
+	}
+}
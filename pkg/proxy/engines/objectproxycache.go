@@ -130,9 +130,19 @@ func handleCachePartialHit(pr *proxyRequest) error {
 func confirmTrueCacheHit(pr *proxyRequest) (bool, error) {
 
 	pr.cachingPolicy.Merge(pr.cacheDocument.CachingPolicy)
+	pr.cachingPolicy.ApplyStaleOverrides(staleOverrides(request.GetResources(pr.Request).BackendOptions))
 
-	if (!pr.checkCacheFreshness()) && (pr.cachingPolicy.CanRevalidate) {
-		return false, handleCacheRevalidation(pr)
+	if !pr.checkCacheFreshness() {
+		if pr.canServeStaleWhileRevalidate() {
+			pr.cacheStatus = status.LookupStatusStaleHit
+			revalidateInBackground(pr)
+			return true, nil
+		}
+		pr.canServeStaleOnError = pr.cacheStatus == status.LookupStatusHit &&
+			pr.cachingPolicy.CanServeStale(pr.cachingPolicy.StaleIfError)
+		if pr.cachingPolicy.CanRevalidate {
+			return false, handleCacheRevalidation(pr)
+		}
 	}
 	if !pr.cachingPolicy.IsFresh {
 		pr.cacheStatus = status.LookupStatusKeyMiss
@@ -217,6 +227,9 @@ func handleCacheRevalidationResponse(pr *proxyRequest) error {
 	}
 
 	pr.revalidation = RevalStatusFailed
+	if pr.canServeStaleOnError && pr.upstreamResponse.StatusCode >= http.StatusInternalServerError {
+		return handleStaleIfError(pr)
+	}
 	pr.cacheStatus = status.LookupStatusKeyMiss
 	return handleAllWrites(pr)
 }
@@ -262,9 +275,10 @@ func handleCacheKeyMiss(pr *proxyRequest) error {
 	rsc := request.GetResources(pr.Request)
 	pc := rsc.PathConfig
 
-	// if a we're using PCF, handle that separately
+	// if a we're using PCF, handle that separately. PCF is bypassed when a stale
+	// object may need to be served in place of an upstream error response
 	if !methods.HasBody(pr.Method) && !pr.wantsRanges && pc != nil &&
-		pc.CollapsedForwardingType == forwarding.CFTypeProgressive {
+		pc.CollapsedForwardingType == forwarding.CFTypeProgressive && !pr.canServeStaleOnError {
 		if err := handlePCF(pr); err != errors.ErrPCFContentLength {
 			// if err is nil, or something else, we'll proceed.
 			return err
@@ -273,6 +287,10 @@ func handleCacheKeyMiss(pr *proxyRequest) error {
 
 	pr.prepareUpstreamRequests()
 	handleUpstreamTransactions(pr)
+	if pr.canServeStaleOnError && pr.cacheDocument != nil &&
+		pr.upstreamResponse.StatusCode >= http.StatusInternalServerError {
+		return handleStaleIfError(pr)
+	}
 	return handleAllWrites(pr)
 }
 
@@ -289,7 +307,8 @@ func handlePCF(pr *proxyRequest) error {
 	o := rsc.BackendOptions
 
 	pr.isPCF = true
-	pcfResult, pcfExists := reqs.Load(pr.key)
+	key := pr.key // store may change pr.key to a variant key while the PCF session is active
+	pcfResult, pcfExists := reqs.Load(key)
 	// a PCF session is in progress for this URL, join this client to it.
 	if pcfExists {
 		pr.cacheLock.Release()
@@ -319,7 +338,7 @@ func handlePCF(pr *proxyRequest) error {
 	// Check if we know the content length and if it is less than our max object size.
 	if contentLength > 0 && contentLength < int64(o.MaxObjectSizeBytes) {
 		pcf := NewPCF(resp, contentLength)
-		reqs.Store(pr.key, pcf)
+		reqs.Store(key, pcf)
 		// Blocks until server completes
 
 		pr.cachingPolicy.Merge(GetResponseCachingPolicy(pr.upstreamResponse.StatusCode,
@@ -334,7 +353,7 @@ func handlePCF(pr *proxyRequest) error {
 			}
 			io.Copy(dest, reader)
 			pcf.Close()
-			reqs.Delete(pr.key)
+			reqs.Delete(key)
 		}()
 
 		pcf.AddClient(pr.responseWriter)
@@ -401,7 +420,9 @@ func fetchViaObjectProxyCache(w io.Writer, r *http.Request) (*http.Response, sta
 
 	pr.cachingPolicy = GetRequestCachingPolicy(pr.Header)
 
-	pr.key = o.CacheKeyPrefix + ".opc." + pr.DeriveCacheKey("")
+	pr.baseKey = o.CacheKeyPrefix + opcKeyNamespace + pr.DeriveCacheKey("")
+	pr.varyHeaders = loadVaryIndex(cc, pr.baseKey)
+	pr.key = pr.variantKey()
 
 	// if a PCF entry exists, or the client requested no-cache for this object, proxy out to it
 	pcfResult, pcfExists := reqs.Load(pr.key)
@@ -64,6 +64,8 @@ type proxyRequest struct {
 	mapLock       *sync.Mutex
 
 	key         string
+	baseKey     string
+	varyHeaders []string
 	started     time.Time
 	elapsed     time.Duration
 	cacheStatus status.LookupStatus
@@ -89,6 +91,9 @@ type proxyRequest struct {
 	wantsRanges       bool
 	isPartialResponse bool
 	wasReconstituted  bool
+	// canServeStaleOnError is true when the cached object may be served in place
+	// of an upstream error response, per its stale-if-error policy
+	canServeStaleOnError bool
 }
 
 // newProxyRequest accepts the original inbound HTTP Request and Response
@@ -129,6 +134,8 @@ func (pr *proxyRequest) Clone() *proxyRequest {
 		Logger:             pr.Logger,
 		cacheDocument:      pr.cacheDocument,
 		key:                pr.key,
+		baseKey:            pr.baseKey,
+		varyHeaders:        pr.varyHeaders,
 		cacheStatus:        pr.cacheStatus,
 		writeToCache:       pr.writeToCache,
 		wantsRanges:        pr.wantsRanges,
@@ -437,6 +444,8 @@ func (pr *proxyRequest) determineCacheability() {
 		return
 	}
 
+	pr.cachingPolicy.ApplyStaleOverrides(staleOverrides(rsc.BackendOptions))
+
 	if pr.cachingPolicy.NoCache || (!pr.cachingPolicy.CanRevalidate && pr.cachingPolicy.FreshnessLifetime <= 0) {
 		pr.writeToCache = false
 		rsc.CacheClient.Remove(pr.key)
@@ -474,12 +483,31 @@ func (pr *proxyRequest) store() error {
 		rf = 1
 	}
 
+	// the variant index is updated when the origin's Vary header differs from
+	// the index, and objects varying by every request ("Vary: *") are not cached
+	if pr.baseKey != "" {
+		names, all := parseVary(http.Header(d.Headers))
+		if all {
+			rsc.CacheClient.Remove(pr.key)
+			storeVaryIndex(rsc.CacheClient, pr.baseKey, nil, 0)
+			return nil
+		}
+		if !equalNames(names, pr.varyHeaders) {
+			pr.varyHeaders = names
+			storeVaryIndex(rsc.CacheClient, pr.baseKey, names,
+				pr.cachingPolicy.TTL(rf, o.MaxTTL))
+			pr.key = pr.variantKey()
+		}
+	}
+
 	d.CachingPolicy = pr.cachingPolicy
+	ttl := pr.cachingPolicy.TTL(rf, o.MaxTTL)
 	err := WriteCache(pr.upstreamRequest.Context(), rsc.CacheClient, pr.key, d,
-		pr.cachingPolicy.TTL(rf, o.MaxTTL), o.CompressibleTypes)
+		ttl, o.CompressibleTypes)
 	if err != nil {
 		return err
 	}
+	tagCacheObject(rsc.CacheClient, pr.key, cacheTags(pr.Request, rsc, d.SafeHeaderClone()), ttl)
 	return nil
 }
 
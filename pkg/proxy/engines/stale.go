@@ -0,0 +1,97 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	bo "github.com/trickstercache/trickster/pkg/backends/options"
+	"github.com/trickstercache/trickster/pkg/cache/status"
+	tctx "github.com/trickstercache/trickster/pkg/proxy/context"
+	"github.com/trickstercache/trickster/pkg/proxy/methods"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+)
+
+// staleRevalidations tracks the cache keys that are being revalidated in the
+// background, so that only one revalidation per key is in flight at a time
+var staleRevalidations sync.Map
+
+type staleRevalidationKey struct{}
+
+// isStaleRevalidation returns true if the request is a background revalidation
+// of an object that is being served stale
+func isStaleRevalidation(r *http.Request) bool {
+	v, _ := r.Context().Value(staleRevalidationKey{}).(bool)
+	return v
+}
+
+// staleOverrides returns the backend's stale-while-revalidate and stale-if-error
+// overrides, converted from milliseconds to the seconds used by CachingPolicy
+func staleOverrides(o *bo.Options) (int, int) {
+	if o == nil {
+		return 0, 0
+	}
+	return msToStaleSeconds(o.StaleWhileRevalidateMS), msToStaleSeconds(o.StaleIfErrorMS)
+}
+
+func msToStaleSeconds(ms int) int {
+	switch {
+	case ms < 0:
+		return -1
+	case ms > 0 && ms < 1000:
+		return 1
+	}
+	return ms / 1000
+}
+
+// canServeStaleWhileRevalidate returns true if the cached object, which is no longer
+// fresh, can be served to the client while it is revalidated in the background
+func (pr *proxyRequest) canServeStaleWhileRevalidate() bool {
+	return pr.cacheStatus == status.LookupStatusHit && !methods.HasBody(pr.Method) &&
+		!isStaleRevalidation(pr.Request) &&
+		pr.cachingPolicy.CanServeStale(pr.cachingPolicy.StaleWhileRevalidate)
+}
+
+// revalidateInBackground refreshes the cached object using a copy of the client request,
+// unless a background revalidation for the object is already in progress
+func revalidateInBackground(pr *proxyRequest) {
+	if _, ok := staleRevalidations.LoadOrStore(pr.key, true); ok {
+		return
+	}
+	rsc := request.GetResources(pr.Request)
+	ctx := context.WithValue(tctx.WithResources(context.Background(), rsc.Clone()),
+		staleRevalidationKey{}, true)
+	r := pr.Request.Clone(ctx)
+	go func(key string) {
+		defer staleRevalidations.Delete(key)
+		fetchViaObjectProxyCache(io.Discard, r)
+	}(pr.key)
+}
+
+// handleStaleIfError serves the cached object in place of an upstream error response
+func handleStaleIfError(pr *proxyRequest) error {
+	pr.cacheStatus = status.LookupStatusStaleErrorHit
+	pr.writeToCache = false
+	// the upstream error response was merged into the caching policy, so this restores
+	// the cached object's policy for resolving any client conditionals
+	pr.cachingPolicy.Merge(pr.cacheDocument.CachingPolicy)
+	pr.cachingPolicy.IsFresh = false
+	return handleTrueCacheHit(pr)
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+)
+
+func TestMSToStaleSeconds(t *testing.T) {
+	tests := []struct {
+		ms, expected int
+	}{
+		{0, 0}, {-1, -1}, {500, 1}, {1000, 1}, {30000, 30},
+	}
+	for _, test := range tests {
+		if v := msToStaleSeconds(test.ms); v != test.expected {
+			t.Errorf("expected %d got %d", test.expected, v)
+		}
+	}
+	if swr, sie := staleOverrides(nil); swr != 0 || sie != 0 {
+		t.Errorf("expected 0 got %d, %d", swr, sie)
+	}
+}
+
+func TestObjectProxyCacheStaleWhileRevalidate(t *testing.T) {
+
+	hdrs := map[string]string{headers.NameCacheControl: headers.ValueMaxAge + "=1, " +
+		headers.ValueStaleWhileRevalidate + "=30"}
+	ts, _, r, rsc, err := setupTestHarnessOPC("", "test", http.StatusOK, hdrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	rsc.PathConfig.ResponseHeaders = hdrs
+
+	_, e := testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "kmiss"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+	time.Sleep(1010 * time.Millisecond)
+
+	_, e = testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "stale-hit"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+	// wait for the background revalidation to complete
+	for i := 0; i < 100; i++ {
+		var pending bool
+		staleRevalidations.Range(func(k, v interface{}) bool {
+			pending = true
+			return false
+		})
+		if !pending {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_, e = testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "hit"})
+	for _, err = range e {
+		t.Error(err)
+	}
+}
+
+func TestObjectProxyCacheStaleIfError(t *testing.T) {
+
+	hdrs := map[string]string{headers.NameCacheControl: headers.ValueMaxAge + "=1, " +
+		headers.ValueStaleIfError + "=30"}
+	ts, _, r, rsc, err := setupTestHarnessOPC("", "test", http.StatusOK, hdrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsc.PathConfig.ResponseHeaders = hdrs
+
+	_, e := testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "kmiss"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+	time.Sleep(1010 * time.Millisecond)
+
+	// the origin is now unreachable, so the stale object is served
+	ts.Close()
+	_, e = testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "stale-error"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+	// with stale-if-error disabled by the backend, the error is returned
+	rsc.BackendOptions.StaleIfErrorMS = -1
+	_, e = testFetchOPC(r, http.StatusBadGateway, "", map[string]string{"status": "kmiss"})
+	for _, err = range e {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/backends"
+	"github.com/trickstercache/trickster/pkg/cache"
+	"github.com/trickstercache/trickster/pkg/cache/index"
+	"github.com/trickstercache/trickster/pkg/cache/tags"
+	"github.com/trickstercache/trickster/pkg/proxy/params"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+)
+
+// cacheTags returns the tags of the object cached for the request: those listed in the
+// origin's Surrogate-Key response header, and those derived from the backend's cache tag rules
+func cacheTags(r *http.Request, rsc *request.Resources, h http.Header) []string {
+	t := tags.SurrogateKeys(h)
+	if rsc == nil || rsc.BackendOptions == nil || rsc.BackendOptions.CacheTags == nil {
+		return tags.Normalize(t)
+	}
+	o := rsc.BackendOptions.CacheTags
+	if len(o.Params) > 0 {
+		v, _, _ := params.GetRequestValues(r)
+		for _, p := range o.Params {
+			for _, pv := range v[p] {
+				t = append(t, tags.Param(p, pv))
+			}
+		}
+	}
+	if o.TenantHeader != "" {
+		if v := r.Header.Get(o.TenantHeader); v != "" {
+			t = append(t, tags.Tenant(v))
+		}
+	}
+	if o.MetricNames {
+		if mn, ok := rsc.BackendClient.(backends.MetricNamingBackend); ok {
+			for _, n := range mn.MetricNames(r) {
+				t = append(t, tags.Metric(n))
+			}
+		}
+	}
+	return tags.Normalize(t)
+}
+
+// tagCacheObject replaces the tags of the object stored under key in the cache's tag index
+func tagCacheObject(c cache.Cache, key string, t []string, ttl time.Duration) {
+	index.TagIndexFor(c).Tag(key, t, ttl)
+}
+
+// untagCacheObjects removes the objects stored under keys from the cache's tag index
+func untagCacheObjects(c cache.Cache, keys []string) {
+	index.TagIndexFor(c).Remove(keys...)
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/backends"
+	bo "github.com/trickstercache/trickster/pkg/backends/options"
+	"github.com/trickstercache/trickster/pkg/cache"
+	"github.com/trickstercache/trickster/pkg/cache/index"
+	co "github.com/trickstercache/trickster/pkg/cache/options"
+	cr "github.com/trickstercache/trickster/pkg/cache/registration"
+	tgo "github.com/trickstercache/trickster/pkg/cache/tags/options"
+	"github.com/trickstercache/trickster/pkg/cache/tiered"
+	to "github.com/trickstercache/trickster/pkg/cache/tiered/options"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+)
+
+type metricNamingTestClient struct {
+	backends.Backend
+}
+
+func (c *metricNamingTestClient) MetricNames(r *http.Request) []string {
+	return []string{r.URL.Query().Get("query")}
+}
+
+func TestCacheTags(t *testing.T) {
+
+	h := http.Header{}
+	h.Set("Surrogate-Key", "dashboard-1 metric:up")
+	r, _ := http.NewRequest(http.MethodGet, "http://0/query?query=up&job=api&job=db", nil)
+	r.Header.Set("X-Scope-OrgID", "team-a")
+
+	if got := strings.Join(cacheTags(r, nil, h), ","); got != "dashboard-1,metric:up" {
+		t.Errorf("unexpected tags %s", got)
+	}
+
+	o := bo.New()
+	o.CacheTags = &tgo.Options{Params: []string{"job"}, TenantHeader: "X-Scope-OrgID",
+		MetricNames: true}
+	rsc := &request.Resources{BackendOptions: o, BackendClient: &metricNamingTestClient{}}
+	expected := "dashboard-1,job:api,job:db,metric:up,tenant:team-a"
+	if got := strings.Join(cacheTags(r, rsc, h), ","); got != expected {
+		t.Errorf("expected %s got %s", expected, got)
+	}
+
+	if cacheTags(r, &request.Resources{BackendOptions: bo.New()}, nil) != nil {
+		t.Error("expected no tags")
+	}
+}
+
+// persistentTestCache is a cache that does not store objects by reference
+type persistentTestCache struct {
+	cache.Cache
+}
+
+func TestTagCacheObject(t *testing.T) {
+
+	cc := co.New()
+	cc.Name = "test-tag-cache-object"
+	mc := cr.NewCache(cc.Name, cc, testLogger)
+	pc := &persistentTestCache{Cache: mc}
+
+	// objects are tagged in every kind of cache
+	tc := &tiered.Cache{Name: "test-tag-cache-object-tiered", Logger: testLogger,
+		Config: &co.Options{Name: "test-tag-cache-object-tiered", Provider: "tiered",
+			Tiered: to.New()}, Tiers: []cache.Cache{mc, pc}}
+	for i, c := range []cache.Cache{mc, pc, tc} {
+		tagCacheObject(c, "test-key", []string{"test-tag"}, time.Minute)
+		if keys := index.TagIndexFor(c).Keys("test-tag"); len(keys) != 1 || keys[0] != "test-key" {
+			t.Errorf("%d: unexpected keys %v", i, keys)
+		}
+		untagCacheObjects(c, []string{"test-key"})
+		if keys := index.TagIndexFor(c).Keys("test-tag"); keys != nil {
+			t.Errorf("%d: expected nil got %v", i, keys)
+		}
+	}
+}
+
+func TestObjectProxyCacheSurrogateKey(t *testing.T) {
+
+	hdrs := map[string]string{"Cache-Control": "max-age=60",
+		"Surrogate-Key": "opc-surrogate-key-1 opc-surrogate-key-2"}
+	ts, _, r, rsc, err := setupTestHarnessOPC("", "test", http.StatusOK, hdrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	_, e := testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "kmiss"})
+	for _, err = range e {
+		t.Error(err)
+	}
+
+	ti := index.TagIndexFor(rsc.CacheClient)
+	keys := ti.Keys("opc-surrogate-key-2")
+	if len(keys) != 1 {
+		t.Fatalf("expected %d got %d", 1, len(keys))
+	}
+	if got := strings.Join(ti.Tags(keys[0]), ","); got != "opc-surrogate-key-1,opc-surrogate-key-2" {
+		t.Errorf("unexpected tags %s", got)
+	}
+	if _, _, err = rsc.CacheClient.Retrieve(keys[0], false); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeltaProxyCacheRequestTags(t *testing.T) {
+
+	ts, _, r, rsc, err := setupTestHarnessDPC()
+	if err != nil {
+		t.Error(err)
+	}
+	defer ts.Close()
+
+	client := rsc.BackendClient.(*TestClient)
+	o := rsc.BackendOptions
+	rsc.CacheConfig.Provider = "test"
+
+	client.RangeCacheKey = "test-range-key-tags"
+	client.InstantCacheKey = "test-instant-key-tags"
+
+	o.FastForwardDisable = true
+	o.CacheTags = &tgo.Options{Params: []string{"rk"}}
+
+	end := time.Now().Add(-time.Duration(2) * time.Hour).Truncate(time.Hour)
+	u := r.URL
+	u.Path = "/prometheus/api/v1/query_range"
+	u.RawQuery = fmt.Sprintf("step=60&start=%d&end=%d&query=%s&rk=%s&ik=%s",
+		end.Add(-time.Hour).Unix(), end.Unix(), queryReturnsOKNoLatency,
+		client.RangeCacheKey, client.InstantCacheKey)
+	r.URL = u
+
+	w := httptest.NewRecorder()
+	client.QueryRangeHandler(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d got %d", http.StatusOK, w.Code)
+	}
+
+	// the time series is written to the cache asynchronously
+	time.Sleep(time.Millisecond * 100)
+
+	ti := index.TagIndexFor(rsc.CacheClient)
+	keys := ti.Keys("rk:test-range-key-tags")
+	if len(keys) != 1 {
+		t.Fatalf("expected %d got %d", 1, len(keys))
+	}
+	if _, _, err = rsc.CacheClient.Retrieve(keys[0], false); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/cache"
+	"github.com/trickstercache/trickster/pkg/cache/status"
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/util/md5"
+)
+
+// varyIndexSuffix is appended to an object's base cache key to form the key of its
+// variant index, which records the request headers named in the origin's Vary header.
+// Each variant of the object is stored beneath the base key, suffixed with the checksum
+// of its varied header values, so a purge of the base key includes the full variant set.
+const varyIndexSuffix = ".vary"
+
+// parseVary returns the sorted, canonicalized request header names listed in the
+// Vary headers of h, and true if any of them is the "*" wildcard
+func parseVary(h http.Header) ([]string, bool) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, v := range h.Values(headers.NameVary) {
+		for _, n := range strings.Split(v, ",") {
+			n = strings.TrimSpace(n)
+			if n == "" {
+				continue
+			}
+			if n == "*" {
+				return nil, true
+			}
+			n = textproto.CanonicalMIMEHeaderKey(n)
+			if !seen[n] {
+				seen[n] = true
+				names = append(names, n)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, false
+}
+
+// varySuffix returns the portion of a variant's cache key identifying the
+// request's values for the provided varied header names
+func varySuffix(h http.Header, names []string) string {
+	vals := make([]string, 0, len(names))
+	for _, n := range names {
+		vals = append(vals, n+"."+strings.Join(h.Values(n), ",")+".")
+	}
+	return md5.Checksum(strings.Join(vals, ""))
+}
+
+// loadVaryIndex returns the varied header names recorded in the variant index of the
+// object at the base key, or nil if the object does not vary
+func loadVaryIndex(c cache.Cache, baseKey string) []string {
+	b, ls, err := c.Retrieve(baseKey+varyIndexSuffix, false)
+	if err != nil || ls != status.LookupStatusHit || len(b) == 0 {
+		return nil
+	}
+	return strings.Split(string(b), ",")
+}
+
+// storeVaryIndex records the varied header names in the variant index of the object
+// at the base key. When names is empty, the variant index is removed
+func storeVaryIndex(c cache.Cache, baseKey string, names []string, ttl time.Duration) error {
+	if len(names) == 0 {
+		c.Remove(baseKey + varyIndexSuffix)
+		return nil
+	}
+	return c.Store(baseKey+varyIndexSuffix, []byte(strings.Join(names, ",")), ttl)
+}
+
+// variantKey returns the cache key of the request's variant of the object at the
+// base key, based on the request's values of the object's varied headers
+func (pr *proxyRequest) variantKey() string {
+	if len(pr.varyHeaders) == 0 {
+		return pr.baseKey
+	}
+	return pr.baseKey + "." + varySuffix(pr.Request.Header, pr.varyHeaders)
+}
+
+// equalNames returns true if the sorted header name lists are identical
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engines
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+)
+
+func TestParseVary(t *testing.T) {
+
+	h := http.Header{}
+	h.Add(headers.NameVary, "accept-language, Accept-Encoding")
+	h.Add(headers.NameVary, "Accept-Language,,x-tenant")
+	names, all := parseVary(h)
+	if all {
+		t.Error("expected false")
+	}
+	expected := []string{"Accept-Encoding", "Accept-Language", "X-Tenant"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v got %v", expected, names)
+	}
+
+	h.Add(headers.NameVary, "*")
+	if _, all = parseVary(h); !all {
+		t.Error("expected true")
+	}
+
+	if names, all = parseVary(http.Header{}); names != nil || all {
+		t.Errorf("unexpected result %v %t", names, all)
+	}
+}
+
+func TestVarySuffix(t *testing.T) {
+
+	names := []string{"Accept-Language"}
+	h1 := http.Header{"Accept-Language": []string{"en"}}
+	h2 := http.Header{"Accept-Language": []string{"fr"}}
+	h3 := http.Header{"Accept-Language": []string{"en"}, "Accept-Encoding": []string{"gzip"}}
+
+	if varySuffix(h1, names) == varySuffix(h2, names) {
+		t.Error("expected different suffixes for different header values")
+	}
+	if varySuffix(h1, names) != varySuffix(h3, names) {
+		t.Error("expected the same suffix when unvaried headers differ")
+	}
+}
+
+func TestVaryIndex(t *testing.T) {
+
+	ts, _, _, rsc, err := setupTestHarnessOPC("", "test", http.StatusOK, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	c := rsc.CacheClient
+
+	if names := loadVaryIndex(c, "test-vary-index"); names != nil {
+		t.Errorf("expected nil got %v", names)
+	}
+
+	expected := []string{"Accept-Encoding", "Accept-Language"}
+	if err = storeVaryIndex(c, "test-vary-index", expected, time.Minute); err != nil {
+		t.Error(err)
+	}
+	if names := loadVaryIndex(c, "test-vary-index"); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v got %v", expected, names)
+	}
+
+	storeVaryIndex(c, "test-vary-index", nil, time.Minute)
+	if names := loadVaryIndex(c, "test-vary-index"); names != nil {
+		t.Errorf("expected nil got %v", names)
+	}
+}
+
+func TestObjectProxyCacheVary(t *testing.T) {
+
+	hdrs := map[string]string{"Cache-Control": "max-age=60", "Vary": "Accept-Language"}
+	ts, _, r, _, err := setupTestHarnessOPC("", "test", http.StatusOK, hdrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	tests := []struct {
+		lang, status string
+	}{
+		{"en", "kmiss"},
+		{"en", "hit"},
+		// a different variant is not served from the cached variant
+		{"fr", "kmiss"},
+		{"fr", "hit"},
+		{"en", "hit"},
+	}
+
+	for _, test := range tests {
+		r.Header.Set("Accept-Language", test.lang)
+		_, e := testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": test.status})
+		for _, err = range e {
+			t.Errorf("%s: %s", test.lang, err)
+		}
+	}
+}
+
+func TestObjectProxyCacheVaryAll(t *testing.T) {
+
+	hdrs := map[string]string{"Cache-Control": "max-age=60", "Vary": "*"}
+	ts, _, r, rsc, err := setupTestHarnessOPC("", "test", http.StatusOK, hdrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	// a variant index left by an earlier response is removed
+	pr := newProxyRequest(r, nil)
+	baseKey := rsc.BackendOptions.CacheKeyPrefix + opcKeyNamespace + pr.DeriveCacheKey("")
+	storeVaryIndex(rsc.CacheClient, baseKey, []string{"Accept-Language"}, time.Minute)
+
+	// objects varying by every request are never cached
+	for i := 0; i < 2; i++ {
+		_, e := testFetchOPC(r, http.StatusOK, "test", map[string]string{"status": "kmiss"})
+		for _, err = range e {
+			t.Error(err)
+		}
+	}
+
+	if names := loadVaryIndex(rsc.CacheClient, baseKey); names != nil {
+		t.Errorf("expected nil got %v", names)
+	}
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/backends"
+	"github.com/trickstercache/trickster/pkg/cache"
+	"github.com/trickstercache/trickster/pkg/cache/index"
+	tl "github.com/trickstercache/trickster/pkg/observability/logging"
+	"github.com/trickstercache/trickster/pkg/proxy/engines"
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+)
+
+// Cache Inspection API query parameter names
+const (
+	cacheParamBackend = "backend"
+	cacheParamKey     = "key"
+	cacheParamPrefix  = "prefix"
+)
+
+// CacheInspectResult describes the outcome of a Cache Inspection API request
+type CacheInspectResult struct {
+	Backend string                   `json:"backend"`
+	Cache   string                   `json:"cache"`
+	Objects []*CacheObjectMetadata   `json:"objects,omitempty"`
+	Object  *CacheObjectMetadata     `json:"object,omitempty"`
+	Content *engines.CacheObjectInfo `json:"content,omitempty"`
+	Error   string                   `json:"error,omitempty"`
+}
+
+// CacheObjectMetadata describes an object in a cache. Size, LastAccess and Expiration
+// are only known for caches whose retention is managed by an Index
+type CacheObjectMetadata struct {
+	Key        string     `json:"key"`
+	Size       int64      `json:"size,omitempty"`
+	LastAccess *time.Time `json:"last_access,omitempty"`
+	Expiration *time.Time `json:"expiration,omitempty"`
+}
+
+// CacheInspectHandleFunc describes the objects in a backend's cache. By default, the keys
+// beneath the backend's cache key prefix (or the provided prefix=) are listed, along with
+// their index metadata. When a key= is provided, the object stored under it is decoded
+// and described, including the extents and size of any timeseries it holds.
+func CacheInspectHandleFunc(clients backends.Backends,
+	log *tl.Logger) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Method != http.MethodGet {
+			w.Header().Set(headers.NameAllow, http.MethodGet)
+			writeCacheInspectResult(w, http.StatusMethodNotAllowed,
+				&CacheInspectResult{Error: "method not allowed"})
+			return
+		}
+
+		qp := r.URL.Query()
+		cr := &CacheInspectResult{Backend: qp.Get(cacheParamBackend)}
+
+		client := clients.Get(cr.Backend)
+		if client == nil || client.Cache() == nil || !backends.UsesCache(client.Configuration().Provider) {
+			cr.Error = "unknown or uncached backend"
+			writeCacheInspectResult(w, http.StatusNotFound, cr)
+			return
+		}
+		o := client.Configuration()
+		c := client.Cache()
+		cr.Cache = o.CacheName
+
+		var idx *index.Index
+		if ic, ok := c.(index.Indexer); ok {
+			idx = ic.CacheIndex()
+		}
+
+		if key := qp.Get(cacheParamKey); key != "" {
+			var modeler *timeseries.Modeler
+			if tc, ok := client.(backends.TimeseriesBackend); ok {
+				modeler = tc.Modeler()
+			}
+			info, _, err := engines.InspectCacheObject(c, key, modeler)
+			if err == cache.ErrKNF {
+				cr.Error = "key not found"
+				writeCacheInspectResult(w, http.StatusNotFound, cr)
+				return
+			}
+			if err != nil {
+				cr.Error = err.Error()
+				writeCacheInspectResult(w, http.StatusInternalServerError, cr)
+				return
+			}
+			cr.Object = objectMetadata(idx, info.Key)
+			cr.Content = info
+			writeCacheInspectResult(w, http.StatusOK, cr)
+			return
+		}
+
+		prefix := qp.Get(cacheParamPrefix)
+		if prefix == "" {
+			prefix = o.CacheKeyPrefix + "."
+		}
+		keys, err := c.Keys(prefix)
+		if err != nil {
+			tl.Error(log, "cache key lookup failed during inspection",
+				tl.Pairs{"backendName": cr.Backend, "cacheName": cr.Cache, "detail": err.Error()})
+			cr.Error = err.Error()
+			writeCacheInspectResult(w, http.StatusInternalServerError, cr)
+			return
+		}
+		cr.Objects = make([]*CacheObjectMetadata, 0, len(keys))
+		for _, k := range keys {
+			cr.Objects = append(cr.Objects, objectMetadata(idx, k))
+		}
+		writeCacheInspectResult(w, http.StatusOK, cr)
+	}
+}
+
+// objectMetadata returns the metadata for the key, including that from the index when present
+func objectMetadata(idx *index.Index, key string) *CacheObjectMetadata {
+	om := &CacheObjectMetadata{Key: key}
+	if idx == nil {
+		return om
+	}
+	if o, ok := idx.Metadata(key); ok {
+		om.Size = o.Size
+		om.LastAccess = &o.LastAccess
+		om.Expiration = &o.Expiration
+	}
+	return om
+}
+
+func writeCacheInspectResult(w http.ResponseWriter, code int, cr *CacheInspectResult) {
+	b, _ := json.Marshal(cr)
+	w.Header().Set(headers.NameContentType, headers.ValueApplicationJSON)
+	w.Header().Set(headers.NameCacheControl, headers.ValueNoCache)
+	w.WriteHeader(code)
+	w.Write(b)
+}
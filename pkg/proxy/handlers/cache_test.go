@@ -0,0 +1,100 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/backends"
+	tl "github.com/trickstercache/trickster/pkg/observability/logging"
+	"github.com/trickstercache/trickster/pkg/proxy/engines"
+)
+
+func doCacheInspect(t *testing.T, clients backends.Backends, method,
+	query string) (int, *CacheInspectResult) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(method, "http://0/trickster/cache?"+query, nil)
+	CacheInspectHandleFunc(clients, tl.ConsoleLogger("error"))(w, r)
+	cr := &CacheInspectResult{}
+	if err := json.Unmarshal(w.Body.Bytes(), cr); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, cr
+}
+
+func TestCacheInspectHandleFunc(t *testing.T) {
+
+	clients := newPurgeTestBackends(t)
+	c := clients["test"].Cache()
+
+	b, err := (&engines.HTTPDocument{StatusCode: 200, ContentType: "text/plain",
+		Body: []byte("data")}).MarshalReference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Store("test.opc.a", b, time.Minute)
+	c.Store("test.opc.b", b, time.Minute)
+	c.Store("other.opc.a", b, time.Minute)
+
+	code, _ := doCacheInspect(t, clients, http.MethodPost, "backend=test")
+	if code != http.StatusMethodNotAllowed {
+		t.Errorf("expected %d got %d", http.StatusMethodNotAllowed, code)
+	}
+
+	code, _ = doCacheInspect(t, clients, http.MethodGet, "backend=invalid")
+	if code != http.StatusNotFound {
+		t.Errorf("expected %d got %d", http.StatusNotFound, code)
+	}
+
+	code, cr := doCacheInspect(t, clients, http.MethodGet, "backend=test")
+	if code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, code)
+	}
+	if len(cr.Objects) != 2 || cr.Objects[0].Key != "test.opc.a" {
+		t.Fatalf("unexpected objects %v", cr.Objects)
+	}
+	if cr.Objects[0].Size != int64(len(b)) || cr.Objects[0].Expiration == nil ||
+		cr.Objects[0].LastAccess == nil {
+		t.Errorf("expected index metadata got %v", cr.Objects[0])
+	}
+
+	code, cr = doCacheInspect(t, clients, http.MethodGet, "backend=test&prefix=other.")
+	if code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, code)
+	}
+	if len(cr.Objects) != 1 || cr.Objects[0].Key != "other.opc.a" {
+		t.Errorf("unexpected objects %v", cr.Objects)
+	}
+
+	code, cr = doCacheInspect(t, clients, http.MethodGet, "backend=test&key=test.opc.b")
+	if code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, code)
+	}
+	if cr.Object == nil || cr.Object.Key != "test.opc.b" || cr.Content == nil ||
+		cr.Content.StatusCode != 200 || cr.Content.ContentType != "text/plain" {
+		t.Errorf("unexpected result %v", cr)
+	}
+
+	code, _ = doCacheInspect(t, clients, http.MethodGet, "backend=test&key=test.opc.missing")
+	if code != http.StatusNotFound {
+		t.Errorf("expected %d got %d", http.StatusNotFound, code)
+	}
+}
@@ -0,0 +1,263 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/trickstercache/trickster/pkg/backends"
+	bo "github.com/trickstercache/trickster/pkg/backends/options"
+	"github.com/trickstercache/trickster/pkg/cache"
+	"github.com/trickstercache/trickster/pkg/cache/index"
+	co "github.com/trickstercache/trickster/pkg/cache/options"
+	tl "github.com/trickstercache/trickster/pkg/observability/logging"
+	"github.com/trickstercache/trickster/pkg/proxy/engines"
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
+	"github.com/trickstercache/trickster/pkg/proxy/paths/matching"
+	po "github.com/trickstercache/trickster/pkg/proxy/paths/options"
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+	"github.com/trickstercache/trickster/pkg/proxy/urls"
+)
+
+// Purge API query parameter names
+const (
+	purgeParamBackend = "backend"
+	purgeParamKey     = "key"
+	purgeParamPath    = "path"
+	purgeParamMethod  = "method"
+	purgeParamBulk    = "bulk"
+	purgeParamTag     = "tag"
+)
+
+// PurgeResult describes the outcome of a Purge API request
+type PurgeResult struct {
+	Backend string   `json:"backend"`
+	Cache   string   `json:"cache"`
+	Mode    string   `json:"mode"`
+	Tag     string   `json:"tag,omitempty"`
+	Caches  []string `json:"caches,omitempty"`
+	Removed int      `json:"removed"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// PurgeHandleFunc purges objects from a backend's cache. Objects are identified by an
+// exact cache key (key=), by deriving the cache keys for a request path and query (path=),
+// or in bulk by the backend's cache key prefix (bulk=true). The objects stored beneath an
+// identified key are removed along with it, including the variant index and every variant
+// of an object that varies by request headers, and the chunks of a chunked time series.
+//
+// Objects may also be purged by a tag (tag=) assigned by the origin's Surrogate-Key header
+// or the backend's cache tag rules, in which case the backend is optional, and the tagged
+// objects are purged from every cache.
+func PurgeHandleFunc(clients backends.Backends,
+	log *tl.Logger) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			w.Header().Set(headers.NameAllow, http.MethodPost+", "+http.MethodDelete)
+			writePurgeResult(w, http.StatusMethodNotAllowed,
+				&PurgeResult{Error: "method not allowed"})
+			return
+		}
+
+		qp := r.URL.Query()
+		pr := &PurgeResult{Backend: qp.Get(purgeParamBackend)}
+
+		if pr.Backend == "" && qp.Get(purgeParamTag) != "" {
+			purgeTag(w, clients, qp.Get(purgeParamTag), log)
+			return
+		}
+
+		client := clients.Get(pr.Backend)
+		if client == nil || client.Cache() == nil || !backends.UsesCache(client.Configuration().Provider) {
+			pr.Error = "unknown or uncached backend"
+			writePurgeResult(w, http.StatusNotFound, pr)
+			return
+		}
+		o := client.Configuration()
+		c := client.Cache()
+		pr.Cache = o.CacheName
+
+		// objects are looked up by prefix, and unless purging in bulk, only the exact
+		// keys and the keys stored beneath them are removed
+		var prefixes, keys []string
+		exact := true
+		switch {
+		case qp.Get(purgeParamKey) != "":
+			pr.Mode = purgeParamKey
+			prefixes = []string{qp.Get(purgeParamKey)}
+		case qp.Get(purgeParamPath) != "":
+			pr.Mode = purgeParamPath
+			pr2, err := purgeRequest(r, client, qp.Get(purgeParamPath), qp.Get(purgeParamMethod), log)
+			if err != nil {
+				pr.Error = err.Error()
+				writePurgeResult(w, http.StatusBadRequest, pr)
+				return
+			}
+			prefixes = engines.DeriveCacheKeys(pr2)
+		case qp.Get(purgeParamBulk) == "true":
+			pr.Mode = purgeParamBulk
+			prefixes = engines.BackendKeyPrefixes(o.CacheKeyPrefix)
+			exact = false
+		case qp.Get(purgeParamTag) != "":
+			pr.Mode = purgeParamTag
+			pr.Tag = qp.Get(purgeParamTag)
+			keys = index.TagIndexFor(c).Keys(pr.Tag)
+		default:
+			pr.Error = "one of key, path, bulk or tag must be provided"
+			writePurgeResult(w, http.StatusBadRequest, pr)
+			return
+		}
+
+		for _, p := range prefixes {
+			k, err := c.Keys(p)
+			if err != nil {
+				tl.Error(log, "cache key lookup failed during purge",
+					tl.Pairs{"backendName": pr.Backend, "cacheName": pr.Cache, "detail": err.Error()})
+				pr.Error = err.Error()
+				writePurgeResult(w, http.StatusInternalServerError, pr)
+				return
+			}
+			for _, key := range k {
+				if !exact || engines.IsObjectKey(p, key) {
+					keys = append(keys, key)
+				}
+			}
+		}
+
+		removeKeys(c, keys)
+		pr.Removed = len(keys)
+
+		tl.Info(log, "cache purge completed", tl.Pairs{"backendName": pr.Backend,
+			"cacheName": pr.Cache, "mode": pr.Mode, "removed": pr.Removed})
+		writePurgeResult(w, http.StatusOK, pr)
+	}
+}
+
+// purgeTag purges the objects tagged with tag from every cache used by the backends
+func purgeTag(w http.ResponseWriter, clients backends.Backends, tag string, log *tl.Logger) {
+	pr := &PurgeResult{Mode: purgeParamTag, Tag: tag}
+	seen := make(map[string]bool)
+	for _, client := range clients {
+		c := client.Cache()
+		if c == nil || !backends.UsesCache(client.Configuration().Provider) {
+			continue
+		}
+		name := c.Configuration().Name
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		pr.Caches = append(pr.Caches, name)
+		keys := index.TagIndexFor(c).Keys(tag)
+		removeKeys(c, keys)
+		pr.Removed += len(keys)
+	}
+	sort.Strings(pr.Caches)
+	tl.Info(log, "cache purge completed", tl.Pairs{"mode": pr.Mode, "tag": tag,
+		"caches": strings.Join(pr.Caches, ","), "removed": pr.Removed})
+	writePurgeResult(w, http.StatusOK, pr)
+}
+
+// removeKeys removes the keys from the cache, along with any of their tags
+func removeKeys(c cache.Cache, keys []string) {
+	if len(keys) == 1 {
+		c.Remove(keys[0])
+	} else if len(keys) > 1 {
+		c.BulkRemove(keys)
+	}
+	if len(keys) > 0 {
+		index.TagIndexFor(c).Remove(keys...)
+	}
+}
+
+// purgeRequest crafts a request for the provided path and query that mirrors the
+// request the backend would have received from a client, including the Resources
+// needed to derive its cache keys. Headers on the purge request (e.g., Authorization)
+// are carried over, since they may contribute to the cache key.
+func purgeRequest(r *http.Request, client backends.Backend, path, method string,
+	log *tl.Logger) (*http.Request, error) {
+
+	if method == "" {
+		method = http.MethodGet
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	r2, err := http.NewRequest(strings.ToUpper(method), u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	r2.Header = r.Header.Clone()
+	return BackendRequest(r2, client, log), nil
+}
+
+// BackendRequest attaches the Resources that the backend would attach to the request when
+// receiving it from a client, including its most specific path config, and sets the
+// request's upstream URL, so that the cache keys the backend would use can be derived.
+func BackendRequest(r *http.Request, client backends.Backend, log *tl.Logger) *http.Request {
+	o := client.Configuration()
+	pc := matchPathConfig(o, r.Method, r.URL.Path)
+	var cc *co.Options
+	if client.Cache() != nil {
+		cc = client.Cache().Configuration()
+	}
+	rsc := request.NewResources(o, pc, cc, client.Cache(), client, nil, log)
+	r = request.SetResources(r, rsc)
+	r.URL = urls.BuildUpstreamURL(r, client.BaseUpstreamURL())
+	return r
+}
+
+// matchPathConfig returns the backend's most specific path config for the method and path
+func matchPathConfig(o *bo.Options, method, path string) *po.Options {
+	var out *po.Options
+	for _, p := range o.Paths {
+		if p.Path != path &&
+			(p.MatchType != matching.PathMatchTypePrefix || !strings.HasPrefix(path, p.Path)) {
+			continue
+		}
+		if !hasMethod(p.Methods, method) {
+			continue
+		}
+		if out == nil || len(p.Path) > len(out.Path) {
+			out = p
+		}
+	}
+	return out
+}
+
+func hasMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method || m == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func writePurgeResult(w http.ResponseWriter, code int, pr *PurgeResult) {
+	b, _ := json.Marshal(pr)
+	w.Header().Set(headers.NameContentType, headers.ValueApplicationJSON)
+	w.Header().Set(headers.NameCacheControl, headers.ValueNoCache)
+	w.WriteHeader(code)
+	w.Write(b)
+}
@@ -0,0 +1,249 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/backends"
+	bo "github.com/trickstercache/trickster/pkg/backends/options"
+	"github.com/trickstercache/trickster/pkg/cache/index"
+	co "github.com/trickstercache/trickster/pkg/cache/options"
+	"github.com/trickstercache/trickster/pkg/cache/registration"
+	tl "github.com/trickstercache/trickster/pkg/observability/logging"
+	"github.com/trickstercache/trickster/pkg/proxy/engines"
+	"github.com/trickstercache/trickster/pkg/proxy/paths/matching"
+	po "github.com/trickstercache/trickster/pkg/proxy/paths/options"
+	"github.com/trickstercache/trickster/pkg/util/md5"
+)
+
+func newPurgeTestBackends(t *testing.T) backends.Backends {
+	return backends.Backends{"test": newPurgeTestBackend(t, "test", "default")}
+}
+
+func newPurgeTestBackend(t *testing.T, name, cacheName string) backends.Backend {
+	logger := tl.ConsoleLogger("error")
+	cc := co.New()
+	cc.Name = cacheName
+	c := registration.NewCache(cacheName, cc, logger)
+
+	p := po.New()
+	p.MatchType = matching.PathMatchTypePrefix
+	p.CacheKeyParams = []string{"q"}
+
+	o := bo.New()
+	o.Name = name
+	o.Provider = "rpc"
+	o.CacheName = cacheName
+	o.CacheKeyPrefix = name
+	o.Scheme = "http"
+	o.Host = "127.0.0.1"
+	o.Paths = map[string]*po.Options{"/-0000000001": p}
+
+	b, err := backends.New(name, o, nil, nil, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func doPurge(t *testing.T, clients backends.Backends, method, query string) (int, *PurgeResult) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(method, "http://0/trickster/purge?"+query, nil)
+	PurgeHandleFunc(clients, tl.ConsoleLogger("error"))(w, r)
+	pr := &PurgeResult{}
+	if err := json.Unmarshal(w.Body.Bytes(), pr); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, pr
+}
+
+func TestPurgeHandleFunc(t *testing.T) {
+
+	clients := newPurgeTestBackends(t)
+	c := clients["test"].Cache()
+
+	// derive the keys that a client request for this path would have used
+	r, err := purgeRequest(httptest.NewRequest(http.MethodPost, "http://0/", nil),
+		clients["test"], "/some/path?q=1&other=2", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := engines.DeriveCacheKeys(r)
+	if len(keys) != 1 {
+		t.Fatalf("expected %d got %d", 1, len(keys))
+	}
+
+	c.Store(keys[0], []byte("data"), time.Minute)
+	// the variant index and a variant of an object that varies by request headers
+	c.Store(keys[0]+".vary", []byte("Accept-Language"), time.Minute)
+	variant := keys[0] + "." + md5.Checksum("Accept-Language.en.")
+	c.Store(variant, []byte("data"), time.Minute)
+	c.Store("test.opc.other", []byte("data"), time.Minute)
+	c.Store("test.opc.other2", []byte("data"), time.Minute)
+	c.Store("test.dpc.other", []byte("data"), time.Minute)
+	c.Store("other.opc.test", []byte("data"), time.Minute)
+	// an object of another backend whose name begins with this backend's name
+	c.Store("test.x.opc.other", []byte("data"), time.Minute)
+
+	code, _ := doPurge(t, clients, http.MethodGet, "backend=test&key=test.opc.other2")
+	if code != http.StatusMethodNotAllowed {
+		t.Errorf("expected %d got %d", http.StatusMethodNotAllowed, code)
+	}
+
+	code, _ = doPurge(t, clients, http.MethodPost, "backend=invalid&key=test.opc.other2")
+	if code != http.StatusNotFound {
+		t.Errorf("expected %d got %d", http.StatusNotFound, code)
+	}
+
+	code, _ = doPurge(t, clients, http.MethodPost, "backend=test")
+	if code != http.StatusBadRequest {
+		t.Errorf("expected %d got %d", http.StatusBadRequest, code)
+	}
+
+	// a partial key does not purge the objects sharing its prefix
+	code, pr := doPurge(t, clients, http.MethodDelete, "backend=test&key=test.opc.oth")
+	if code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, code)
+	}
+	if pr.Removed != 0 || pr.Mode != "key" {
+		t.Errorf("unexpected result %v", pr)
+	}
+
+	code, pr = doPurge(t, clients, http.MethodDelete, "backend=test&key=test.opc.other2")
+	if code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, code)
+	}
+	if pr.Removed != 1 || pr.Mode != "key" {
+		t.Errorf("unexpected result %v", pr)
+	}
+	if _, _, err = c.Retrieve("test.opc.other", false); err != nil {
+		t.Error("expected object sharing the purged key's prefix to remain in cache")
+	}
+
+	// the order of the params should not matter, and the unkeyed param is ignored
+	code, pr = doPurge(t, clients, http.MethodPost,
+		"backend=test&path="+url.QueryEscape("/some/path?other=3&q=1"))
+	if code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, code)
+	}
+	if pr.Removed != 3 || pr.Mode != "path" {
+		t.Errorf("unexpected result %v", pr)
+	}
+	for _, k := range []string{keys[0], keys[0] + ".vary", variant} {
+		if _, _, err = c.Retrieve(k, false); err == nil {
+			t.Errorf("expected cache miss for purged key %s", k)
+		}
+	}
+
+	// index updates from single-key removals are asynchronous
+	time.Sleep(time.Millisecond * 100)
+
+	code, pr = doPurge(t, clients, http.MethodPost, "backend=test&bulk=true")
+	if code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, code)
+	}
+	if pr.Removed != 2 || pr.Mode != "bulk" {
+		t.Errorf("unexpected result %v", pr)
+	}
+	for _, k := range []string{"other.opc.test", "test.x.opc.other"} {
+		if _, _, err = c.Retrieve(k, false); err != nil {
+			t.Errorf("expected object %s of another backend to remain in cache", k)
+		}
+	}
+}
+
+func TestPurgeHandleFuncTag(t *testing.T) {
+
+	clients := backends.Backends{
+		"test1": newPurgeTestBackend(t, "test1", "purge-tag-1"),
+		"test2": newPurgeTestBackend(t, "test2", "purge-tag-2"),
+	}
+	c1 := clients["test1"].Cache()
+	c2 := clients["test2"].Cache()
+
+	c1.Store("test1.key1", []byte("data"), time.Minute)
+	c1.Store("test1.key2", []byte("data"), time.Minute)
+	c2.Store("test2.key1", []byte("data"), time.Minute)
+	index.TagIndexFor(c1).Tag("test1.key1", []string{"metric:up", "tenant:a"}, time.Minute)
+	index.TagIndexFor(c1).Tag("test1.key2", []string{"metric:down"}, time.Minute)
+	index.TagIndexFor(c2).Tag("test2.key1", []string{"metric:up"}, time.Minute)
+
+	// a tag purge for a single backend only purges its cache
+	code, pr := doPurge(t, clients, http.MethodPost, "backend=test2&tag=metric:up")
+	if code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, code)
+	}
+	if pr.Removed != 1 || pr.Mode != "tag" || pr.Tag != "metric:up" || pr.Cache != "purge-tag-2" {
+		t.Errorf("unexpected result %v", pr)
+	}
+	if _, _, err := c1.Retrieve("test1.key1", false); err != nil {
+		t.Error("expected object in another cache to remain in cache")
+	}
+
+	c2.Store("test2.key1", []byte("data"), time.Minute)
+	index.TagIndexFor(c2).Tag("test2.key1", []string{"metric:up"}, time.Minute)
+
+	// without a backend, the tag is purged from every cache
+	code, pr = doPurge(t, clients, http.MethodDelete, "tag=metric:up")
+	if code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, code)
+	}
+	if pr.Removed != 2 || pr.Mode != "tag" || len(pr.Caches) != 2 ||
+		pr.Caches[0] != "purge-tag-1" || pr.Caches[1] != "purge-tag-2" {
+		t.Errorf("unexpected result %v", pr)
+	}
+	for _, k := range []string{"test1.key1", "test2.key1"} {
+		c := c1
+		if k == "test2.key1" {
+			c = c2
+		}
+		if _, _, err := c.Retrieve(k, false); err == nil {
+			t.Errorf("expected cache miss for purged key %s", k)
+		}
+	}
+	if _, _, err := c1.Retrieve("test1.key2", false); err != nil {
+		t.Error("expected object with a different tag to remain in cache")
+	}
+	// the purged keys are removed from the tag index
+	if index.TagIndexFor(c1).Tags("test1.key1") != nil {
+		t.Error("expected purged key to be untagged")
+	}
+
+	code, pr = doPurge(t, clients, http.MethodPost, "tag=metric:none")
+	if code != http.StatusOK || pr.Removed != 0 {
+		t.Errorf("unexpected result %d %v", code, pr)
+	}
+
+	// the tag index is stored in the cache, so objects tagged by another Trickster
+	// instance sharing the cache, or before a restart, are purged by tag
+	c2.Configuration().Memory.SnapshotPath = "/tmp/purge-tag-2.snapshot"
+	c2.Store("test2.key2", []byte("data"), time.Minute)
+	index.NewTagIndex(c2).Tag("test2.key2", []string{"metric:up"}, time.Minute)
+	code, pr = doPurge(t, clients, http.MethodPost, "tag=metric:up")
+	if code != http.StatusOK || len(pr.Caches) != 2 || pr.Removed != 1 {
+		t.Errorf("unexpected result %d %v", code, pr)
+	}
+	if _, _, err := c2.Retrieve("test2.key2", false); err == nil {
+		t.Error("expected cache miss for purged key test2.key2")
+	}
+}
@@ -30,8 +30,14 @@ const (
 
 	// ValueApplicationCSV represents the HTTP Header Value of "application/csv"
 	ValueApplicationCSV = "application/csv"
+	// ValueApplicationFlux represents the HTTP Header Value of "application/vnd.flux"
+	ValueApplicationFlux = "application/vnd.flux"
 	// ValueApplicationJSON represents the HTTP Header Value of "application/json"
 	ValueApplicationJSON = "application/json"
+	// ValueApplicationNDJSON represents the HTTP Header Value of "application/x-ndjson"
+	ValueApplicationNDJSON = "application/x-ndjson"
+	// ValueApplicationProtobuf represents the HTTP Header Value of "application/x-protobuf"
+	ValueApplicationProtobuf = "application/x-protobuf"
 	// ValueChunked represents the HTTP Header Value of "chunked"
 	ValueChunked = "chunked"
 	// ValueMaxAge represents the HTTP Header Value of "max-age"
@@ -54,6 +60,10 @@ const (
 	ValuePublic = "public"
 	// ValueSharedMaxAge represents the HTTP Header Value of "s-maxage"
 	ValueSharedMaxAge = "s-maxage"
+	// ValueStaleIfError represents the HTTP Header Value of "stale-if-error"
+	ValueStaleIfError = "stale-if-error"
+	// ValueStaleWhileRevalidate represents the HTTP Header Value of "stale-while-revalidate"
+	ValueStaleWhileRevalidate = "stale-while-revalidate"
 	// ValueTextPlain represents the HTTP Header Value of "text/plain"
 	ValueTextPlain = "text/plain"
 	// ValueXFormURLEncoded represents the HTTP Header Value of "application/x-www-form-urlencoded"
@@ -86,6 +96,13 @@ const (
 	NameTricksterResult = "X-Trickster-Result"
 	// NameAcceptEncoding represents the HTTP Header Name of "Accept-Encoding"
 	NameAcceptEncoding = "Accept-Encoding"
+	// NameAllow represents the HTTP Header Name of "Allow"
+	NameAllow = "Allow"
+	// NameVary represents the HTTP Header Name of "Vary"
+	NameVary = "Vary"
+	// NameSurrogateKey represents the HTTP Header Name of "Surrogate-Key", which lists
+	// the tags an origin assigns to a response for purging by tag
+	NameSurrogateKey = "Surrogate-Key"
 	// NameSetCookie represents the HTTP Header Name of "Set-Cookie"
 	NameSetCookie = "Set-Cookie"
 	// NameRange represents the HTTP Header Name of "Range"
@@ -126,6 +143,9 @@ const (
 	NameTrailer = "Trailer"
 	// NameUpgrade represents the HTTP Header Name of "Upgrade"
 	NameUpgrade = "Upgrade"
+	// NameScopeOrgID represents the HTTP Header Name of "X-Scope-OrgID", which identifies
+	// the tenant in multi-tenant backends like Cortex and Mimir
+	NameScopeOrgID = "X-Scope-OrgID"
 
 	// NameTrkHCStatus represents the HTTP Header Name of "Trk-HC-Status"
 	NameTrkHCStatus = "Trk-HC-Status"
@@ -19,6 +19,8 @@ package merge
 import (
 	"net/http"
 
+	"github.com/trickstercache/trickster/pkg/proxy/handlers"
+	"github.com/trickstercache/trickster/pkg/proxy/headers"
 	"github.com/trickstercache/trickster/pkg/proxy/request"
 	"github.com/trickstercache/trickster/pkg/util/copiers"
 )
@@ -27,11 +29,12 @@ import (
 // before its respective response pool can be merged.
 type ResponseGate struct {
 	http.ResponseWriter
-	Request   *http.Request
-	Response  *http.Response
-	Resources *request.Resources
-	body      []byte
-	header    http.Header
+	Request    *http.Request
+	Response   *http.Response
+	Resources  *request.Resources
+	body       []byte
+	header     http.Header
+	statusCode int
 }
 
 // ResponseGates represents a slice of type *ResponseGate
@@ -51,8 +54,24 @@ func (rg *ResponseGate) Header() http.Header {
 	return rg.header
 }
 
-// WriteHeader is not used with a ResponseGate
+// WriteHeader records the status code written to the ResponseGate, which is
+// used when the response can't be merged
 func (rg *ResponseGate) WriteHeader(i int) {
+	if rg.statusCode == 0 {
+		rg.statusCode = i
+	}
+}
+
+// StatusCode returns the status code of the member response, or 0 if the member
+// did not respond
+func (rg *ResponseGate) StatusCode() int {
+	if rg.Resources != nil && rg.Resources.Response != nil {
+		return rg.Resources.Response.StatusCode
+	}
+	if rg.statusCode == 0 && rg.body != nil {
+		return http.StatusOK
+	}
+	return rg.statusCode
 }
 
 // Body returns the stored body for merging
@@ -77,3 +96,29 @@ func (rg *ResponseGate) Write(b []byte) (int, error) {
 
 	return len(b), nil
 }
+
+// BestResponse writes the response with the lowest status code in the provided
+// ResponseGates to the ResponseWriter, for use when the responses can't be merged
+func BestResponse(w http.ResponseWriter, r *http.Request, rgs ResponseGates) {
+	var best *ResponseGate
+	var bestCode int
+	for _, rg := range rgs {
+		if rg == nil {
+			continue
+		}
+		if sc := rg.StatusCode(); sc > 0 && (best == nil || sc < bestCode) {
+			best, bestCode = rg, sc
+		}
+	}
+	if best == nil {
+		handlers.HandleBadGateway(w, r)
+		return
+	}
+	h := w.Header()
+	if best.Resources != nil && best.Resources.Response != nil {
+		headers.Merge(h, best.Resources.Response.Header)
+	}
+	headers.Merge(h, best.Header())
+	w.WriteHeader(bestCode)
+	w.Write(best.Body())
+}
@@ -17,75 +17,55 @@
 package merge
 
 import (
-	"bytes"
-	"io"
 	"net/http"
 
-	"github.com/trickstercache/trickster/pkg/proxy/handlers"
 	"github.com/trickstercache/trickster/pkg/proxy/headers"
 	"github.com/trickstercache/trickster/pkg/timeseries"
 )
 
 // Timeseries merges the provided Responses into a single Timeseries Dataset
-// and writes it to the provided responsewriter
+// and writes it to the provided responsewriter. Members that did not return a
+// Timeseries (e.g., due to an upstream error) are omitted from the merged Dataset,
+// so long as at least one member did. The merged response's status code is the
+// lowest status code of the members that returned a Timeseries.
 func Timeseries(w http.ResponseWriter, r *http.Request, rgs ResponseGates) {
 
 	var ts timeseries.Timeseries
 	var f timeseries.MarshalWriterFunc
 	var rlo *timeseries.RequestOptions
 
-	responses := make([]int, len(rgs))
-	var bestResp *http.Response
-
 	h := w.Header()
-	tsm := make([]timeseries.Timeseries, 0)
-	for i, rg := range rgs {
+	tsm := make([]timeseries.Timeseries, 0, len(rgs))
+	statusCode := 0
+	for _, rg := range rgs {
 
 		if rg == nil || rg.Resources == nil ||
-			rg.Resources.Response == nil {
+			rg.Resources.Response == nil || rg.Resources.TS == nil {
 			continue
 		}
 
-		resp := rg.Resources.Response
-		responses[i] = resp.StatusCode
-
-		if rg.Resources.TS != nil {
-			headers.Merge(h, rg.Header())
-			if f == nil && rg.Resources.TSMarshaler != nil {
-				f = rg.Resources.TSMarshaler
-			}
-			if rlo == nil {
-				rlo = rg.Resources.TSReqestOptions
-			}
-			if ts == nil {
-				ts = rg.Resources.TS
-				continue
-			}
-			tsm = append(tsm, rg.Resources.TS)
+		headers.Merge(h, rg.Header())
+		if f == nil && rg.Resources.TSMarshaler != nil {
+			f = rg.Resources.TSMarshaler
+		}
+		if rlo == nil {
+			rlo = rg.Resources.TSReqestOptions
+		}
+		if sc := rg.Resources.Response.StatusCode; statusCode == 0 || sc < statusCode {
+			statusCode = sc
 		}
-		if bestResp == nil || resp.StatusCode < bestResp.StatusCode {
-			bestResp = resp
-			resp.Body = io.NopCloser(bytes.NewReader(rg.Body()))
+		if ts == nil {
+			ts = rg.Resources.TS
+			continue
 		}
+		tsm = append(tsm, rg.Resources.TS)
 	}
 
 	if ts == nil || f == nil {
-		if bestResp != nil {
-			h := w.Header()
-			headers.Merge(h, bestResp.Header)
-			w.WriteHeader(bestResp.StatusCode)
-			io.Copy(w, bestResp.Body)
-		} else {
-			handlers.HandleBadGateway(w, r)
-		}
+		BestResponse(w, r, rgs)
 		return
 	}
 
-	statusCode := 200
-	if bestResp != nil {
-		statusCode = bestResp.StatusCode
-	}
-
 	if len(tsm) > 0 {
 		ts.Merge(true, tsm...)
 	}
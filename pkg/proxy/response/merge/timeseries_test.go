@@ -0,0 +1,90 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package merge
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/proxy/request"
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+	"github.com/trickstercache/trickster/pkg/timeseries/epoch"
+)
+
+func testMarshaler(ts timeseries.Timeseries, rlo *timeseries.RequestOptions,
+	status int, w io.Writer) error {
+	if rw, ok := w.(http.ResponseWriter); ok {
+		rw.WriteHeader(status)
+	}
+	_, err := fmt.Fprintf(w, "%d", ts.SeriesCount())
+	return err
+}
+
+func testGate(name string, status int) *ResponseGate {
+	rsc := &request.Resources{
+		Response:    &http.Response{StatusCode: status},
+		TSMarshaler: testMarshaler,
+	}
+	if name != "" {
+		s := &dataset.Series{
+			Header: dataset.SeriesHeader{Name: name},
+			Points: dataset.Points{{Epoch: epoch.Epoch(time.Minute), Size: 16,
+				Values: []interface{}{1.0}}},
+		}
+		rsc.TS = &dataset.DataSet{Results: []*dataset.Result{{SeriesList: []*dataset.Series{s}}}}
+	}
+	return NewResponseGate(httptest.NewRecorder(), nil, rsc)
+}
+
+func TestTimeseries(t *testing.T) {
+
+	r, _ := http.NewRequest(http.MethodGet, "http://0/", nil)
+
+	// members with differing series are unioned, and failed members are omitted
+	w := httptest.NewRecorder()
+	Timeseries(w, r, ResponseGates{testGate("", http.StatusBadGateway),
+		testGate("a", http.StatusOK), testGate("b", http.StatusOK), nil})
+	if w.Code != http.StatusOK {
+		t.Errorf("expected %d got %d", http.StatusOK, w.Code)
+	}
+	if w.Body.String() != "2" {
+		t.Errorf("expected %s got %s", "2", w.Body.String())
+	}
+
+	// when no member returns a timeseries, the best response is used
+	w = httptest.NewRecorder()
+	rg := testGate("", http.StatusBadRequest)
+	rg.Write([]byte("bad request"))
+	Timeseries(w, r, ResponseGates{testGate("", http.StatusBadGateway), rg})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d got %d", http.StatusBadRequest, w.Code)
+	}
+	if w.Body.String() != "bad request" {
+		t.Errorf("expected %s got %s", "bad request", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	Timeseries(w, r, ResponseGates{nil})
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected %d got %d", http.StatusBadGateway, w.Code)
+	}
+}
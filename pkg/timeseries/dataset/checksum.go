@@ -0,0 +1,75 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dataset
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/trickstercache/trickster/pkg/util/fnv"
+)
+
+// Checksum returns the hex-encoded FNV64a hash of the DataSet's contents, including its
+// status, errors, warnings and the headers and points of each series
+func (ds *DataSet) Checksum() string {
+	hash := fnv.NewInlineFNV64a()
+	b := make([]byte, 8)
+	writeUint := func(i uint64) {
+		binary.LittleEndian.PutUint64(b, i)
+		hash.Write(b)
+	}
+	writeString := func(s string) {
+		hash.Write([]byte(s))
+		hash.Write([]byte{0})
+	}
+	writeString(ds.Status)
+	writeString(ds.Error)
+	writeString(ds.ErrorType)
+	for _, w := range ds.Warnings {
+		writeString(w)
+	}
+	for _, r := range ds.Results {
+		if r == nil {
+			continue
+		}
+		writeUint(uint64(r.StatementID))
+		writeString(r.Error)
+		for _, s := range r.SeriesList {
+			if s == nil {
+				continue
+			}
+			writeUint(uint64(s.Header.CalculateHash()))
+			writeUint(uint64(len(s.Points)))
+			for _, p := range s.Points {
+				writeUint(uint64(p.Epoch))
+				for _, v := range p.Values {
+					switch t := v.(type) {
+					case float64:
+						writeUint(math.Float64bits(t))
+					case string:
+						writeString(t)
+					default:
+						writeString(fmt.Sprint(v))
+					}
+				}
+			}
+		}
+	}
+	return strconv.FormatUint(hash.Sum64(), 16)
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dataset
+
+import (
+	"testing"
+
+	"github.com/trickstercache/trickster/pkg/timeseries/epoch"
+)
+
+func TestChecksum(t *testing.T) {
+
+	newDataSet := func(v interface{}) *DataSet {
+		s := &Series{
+			Header: SeriesHeader{Name: "test", Tags: Tags{"a": "1", "b": "2"}},
+			Points: Points{{Epoch: epoch.Epoch(1), Values: []interface{}{v}}},
+		}
+		return &DataSet{Status: "success", Results: []*Result{{SeriesList: []*Series{s, nil}}, nil}}
+	}
+
+	ds1 := newDataSet(1.5)
+	c1 := ds1.Checksum()
+	if c1 == "" {
+		t.Error("expected non-empty checksum")
+	}
+	if c := newDataSet(1.5).Checksum(); c != c1 {
+		t.Errorf("expected %s got %s", c1, c)
+	}
+	for _, v := range []interface{}{2.5, "1.5", int64(1)} {
+		if c := newDataSet(v).Checksum(); c == c1 {
+			t.Errorf("expected checksum to change for value %v", v)
+		}
+	}
+
+	ds1.Results[0].SeriesList[0].Header.Tags["b"] = "3"
+	if c := ds1.Checksum(); c == c1 {
+		t.Error("expected checksum to change for a different tag value")
+	}
+
+	ds2 := newDataSet(1.5)
+	ds2.Warnings = []string{"partial data"}
+	if c := ds2.Checksum(); c == c1 {
+		t.Error("expected checksum to change for a warning")
+	}
+}
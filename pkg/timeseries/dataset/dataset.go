@@ -241,6 +241,7 @@ func (ds *DataSet) DefaultMerger(sortSeries bool, collection ...timeseries.Times
 		if !ok {
 			continue
 		}
+		ds.mergeWarnings(ds2.Warnings)
 		var rmtx sync.RWMutex
 		var rwg sync.WaitGroup
 		for _, r := range ds2.Results {
@@ -405,13 +406,13 @@ func (ds *DataSet) DefaultRangeCropper(e timeseries.Extent) {
 		if len(ds.Results[i].SeriesList) == 0 {
 			continue
 		}
-		sl := make([]*Series, 0, len(ds.Results[i].SeriesList))
-		for _, s := range ds.Results[i].SeriesList {
+		sl := make([]*Series, len(ds.Results[i].SeriesList))
+		for j, s := range ds.Results[i].SeriesList {
 			if s == nil || len(s.Points) == 0 {
 				continue
 			}
 			wg.Add(1)
-			go func(s2 *Series) {
+			go func(j int, s2 *Series) {
 				var start, end, l = 0, -1, len(s2.Points)
 				var iwg sync.WaitGroup
 				iwg.Add(2)
@@ -428,12 +429,19 @@ func (ds *DataSet) DefaultRangeCropper(e timeseries.Extent) {
 					s2.Points = s2.Points.CloneRange(start, end)
 					s2.PointSize = s2.Points.Size()
 				}
+				sl[j] = s2
 				wg.Done()
-				sl = append(sl, s2)
-			}(s)
+			}(j, s)
 		}
 		wg.Wait()
-		ds.Results[i].SeriesList = sl
+		k := 0
+		for _, s := range sl {
+			if s != nil {
+				sl[k] = s
+				k++
+			}
+		}
+		ds.Results[i].SeriesList = sl[:k]
 	}
 }
 
@@ -515,8 +523,19 @@ func (ds *DataSet) Sort() {
 	}
 }
 
-// UnmarshalDataSet unmarshals the dataset from a msgpack-formatted byte slice
+// UnmarshalDataSet unmarshals the dataset from a msgpack-formatted or Gorilla-encoded
+// byte slice, detecting the encoding from the content
 func UnmarshalDataSet(b []byte, trq *timeseries.TimeRangeQuery) (timeseries.Timeseries, error) {
+	if IsGorillaEncoded(b) {
+		ds, err := unmarshalDataSetGorilla(b)
+		if err != nil {
+			return nil, err
+		}
+		if ds.TimeRangeQuery == nil {
+			ds.TimeRangeQuery = trq
+		}
+		return ds, nil
+	}
 	ds := &DataSet{}
 	_, err := ds.UnmarshalMsg(b)
 	if err == nil {
@@ -550,3 +569,35 @@ func (ds *DataSet) VolatileExtents() timeseries.ExtentList {
 func (ds *DataSet) SetVolatileExtents(e timeseries.ExtentList) {
 	ds.VolatileExtentList = e
 }
+
+// AddWarning appends the provided message to the DataSet's Warnings
+func (ds *DataSet) AddWarning(w string) {
+	ds.UpdateLock.Lock()
+	ds.Warnings = append(ds.Warnings, w)
+	ds.UpdateLock.Unlock()
+}
+
+// ResetWarnings clears the DataSet's Warnings and returns those that were cleared
+func (ds *DataSet) ResetWarnings() []string {
+	ds.UpdateLock.Lock()
+	w := ds.Warnings
+	ds.Warnings = nil
+	ds.UpdateLock.Unlock()
+	return w
+}
+
+// mergeWarnings appends any of the provided warnings not already in the DataSet's Warnings
+func (ds *DataSet) mergeWarnings(warnings []string) {
+	for _, w := range warnings {
+		var found bool
+		for _, w2 := range ds.Warnings {
+			if w == w2 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ds.Warnings = append(ds.Warnings, w)
+		}
+	}
+}
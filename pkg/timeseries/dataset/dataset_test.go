@@ -360,3 +360,30 @@ func TestCropToRange(t *testing.T) {
 		t.Error("invalid extent in crop", exs)
 	}
 }
+
+func TestAddWarning(t *testing.T) {
+	ds := testDataSet()
+	var w timeseries.Warner = ds
+	w.AddWarning("test warning")
+	if len(ds.Warnings) != 1 || ds.Warnings[0] != "test warning" {
+		t.Errorf("unexpected warnings %v", ds.Warnings)
+	}
+	wl := w.ResetWarnings()
+	if len(wl) != 1 || wl[0] != "test warning" {
+		t.Errorf("unexpected warnings %v", wl)
+	}
+	if len(ds.Warnings) != 0 {
+		t.Errorf("expected no warnings got %v", ds.Warnings)
+	}
+}
+
+func TestMergeWarnings(t *testing.T) {
+	ds := testDataSet2()
+	ds.Warnings = []string{"warning 1"}
+	ds2 := testDataSet2()
+	ds2.Warnings = []string{"warning 1", "warning 2"}
+	ds.Merge(false, ds2)
+	if len(ds.Warnings) != 2 || ds.Warnings[0] != "warning 1" || ds.Warnings[1] != "warning 2" {
+		t.Errorf("unexpected warnings %v", ds.Warnings)
+	}
+}
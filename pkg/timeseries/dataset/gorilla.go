@@ -0,0 +1,679 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dataset
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+	"strconv"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/epoch"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// Cache codec names, as used by the 'timeseries_cache_codec' backend option
+const (
+	// CacheCodecMsgpack encodes cached DataSets with msgpack, where every Point is
+	// encoded individually. This is the default codec
+	CacheCodecMsgpack = "msgpack"
+	// CacheCodecGorilla encodes the Points of each cached Series in compressed columns,
+	// using delta-of-delta timestamps and XOR float values (Gorilla-style)
+	CacheCodecGorilla = "gorilla"
+)
+
+// gorillaMagic prefixes Gorilla-encoded DataSets. A msgpack-encoded DataSet always starts
+// with a map header (0x80-0x8f, 0xde or 0xdf), so the leading 0x00 can never be confused
+// with an object that was cached before the codec was introduced
+var gorillaMagic = []byte{0x00, 'T', 'G', 0x01}
+
+// ErrInvalidGorillaEncoding is an error for when a Gorilla-encoded DataSet is malformed
+var ErrInvalidGorillaEncoding = errors.New("invalid gorilla-encoded dataset")
+
+// series encoding modes
+const (
+	gorillaSeriesColumnar = byte(0)
+	gorillaSeriesMsgp     = byte(1)
+)
+
+// value column kinds
+const (
+	gorillaColumnFloat       = byte(0)
+	gorillaColumnFloatString = byte(1)
+	gorillaColumnMsgp        = byte(2)
+)
+
+// point size encodings
+const (
+	gorillaSizesConstant = byte(0)
+	gorillaSizesDelta    = byte(1)
+)
+
+// IsGorillaEncoded returns true if the byte slice holds a Gorilla-encoded DataSet
+func IsGorillaEncoded(b []byte) bool {
+	if len(b) < len(gorillaMagic) {
+		return false
+	}
+	for i := range gorillaMagic {
+		if b[i] != gorillaMagic[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalDataSetGorilla marshals the dataset into a byte slice, where the Points of each
+// Series are compressed using delta-of-delta timestamps and XOR float values. All other
+// DataSet fields are encoded with msgpack
+func MarshalDataSetGorilla(ts timeseries.Timeseries,
+	rlo *timeseries.RequestOptions, status int) ([]byte, error) {
+	ds, ok := ts.(*DataSet)
+	if !ok || ds == nil {
+		return nil, timeseries.ErrUnknownFormat
+	}
+	shell, series := ds.gorillaShell()
+	sb, err := shell.MarshalMsg(nil)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 0, len(gorillaMagic)+binary.MaxVarintLen64+len(sb))
+	b = append(b, gorillaMagic...)
+	b = appendUvarint(b, uint64(len(sb)))
+	b = append(b, sb...)
+	for _, s := range series {
+		b, err = appendGorillaPoints(b, s.Points)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// gorillaShell returns a copy of the DataSet with no Points, for encoding with msgpack,
+// along with the source Series in the order they are encoded
+func (ds *DataSet) gorillaShell() (*DataSet, []*Series) {
+	shell := &DataSet{
+		Status:             ds.Status,
+		ExtentList:         ds.ExtentList,
+		Error:              ds.Error,
+		ErrorType:          ds.ErrorType,
+		Warnings:           ds.Warnings,
+		VolatileExtentList: ds.VolatileExtentList,
+	}
+	if ds.TimeRangeQuery != nil {
+		trq := *ds.TimeRangeQuery
+		trq.StepNS = trq.Step.Nanoseconds()
+		shell.TimeRangeQuery = &trq
+	}
+	var series []*Series
+	if ds.Results != nil {
+		shell.Results = make([]*Result, len(ds.Results))
+		for i, r := range ds.Results {
+			if r == nil {
+				continue
+			}
+			nr := &Result{StatementID: r.StatementID, Error: r.Error}
+			if r.SeriesList != nil {
+				nr.SeriesList = make([]*Series, len(r.SeriesList))
+				for j, s := range r.SeriesList {
+					if s == nil {
+						continue
+					}
+					nr.SeriesList[j] = &Series{Header: s.Header, PointSize: s.PointSize}
+					series = append(series, s)
+				}
+			}
+			shell.Results[i] = nr
+		}
+	}
+	return shell, series
+}
+
+// unmarshalDataSetGorilla unmarshals a Gorilla-encoded DataSet
+func unmarshalDataSetGorilla(b []byte) (*DataSet, error) {
+	b = b[len(gorillaMagic):]
+	l, n := binary.Uvarint(b)
+	if n <= 0 || l > uint64(len(b)-n) {
+		return nil, ErrInvalidGorillaEncoding
+	}
+	b = b[n:]
+	ds := &DataSet{}
+	if _, err := ds.UnmarshalMsg(b[:l]); err != nil {
+		return nil, err
+	}
+	b = b[l:]
+	if ds.TimeRangeQuery != nil {
+		ds.TimeRangeQuery.Step = time.Duration(ds.TimeRangeQuery.StepNS)
+	}
+	var err error
+	for _, r := range ds.Results {
+		if r == nil {
+			continue
+		}
+		for _, s := range r.SeriesList {
+			if s == nil {
+				continue
+			}
+			if s.Points, b, err = readGorillaPoints(b); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ds, nil
+}
+
+// appendGorillaPoints appends the encoded Points to the byte slice. Points are encoded
+// in columns when every Point has the same number of values; otherwise they fall back
+// to msgpack
+func appendGorillaPoints(b []byte, pts Points) ([]byte, error) {
+	width := -1
+	for _, p := range pts {
+		if width == -1 {
+			width = len(p.Values)
+		} else if width != len(p.Values) {
+			width = -2
+			break
+		}
+	}
+	if width < 0 && len(pts) > 0 {
+		b = append(b, gorillaSeriesMsgp)
+		return pts.MarshalMsg(b)
+	}
+	b = append(b, gorillaSeriesColumnar)
+	b = appendUvarint(b, uint64(len(pts)))
+	if len(pts) == 0 {
+		return b, nil
+	}
+	b = appendUvarint(b, uint64(width))
+	b = appendPointSizes(b, pts)
+	b = appendBlock(b, encodeTimestamps(pts))
+	var err error
+	for j := 0; j < width; j++ {
+		if b, err = appendValueColumn(b, pts, j); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// readGorillaPoints reads encoded Points from the byte slice, returning the remainder
+func readGorillaPoints(b []byte) (Points, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, ErrInvalidGorillaEncoding
+	}
+	mode := b[0]
+	b = b[1:]
+	if mode == gorillaSeriesMsgp {
+		var pts Points
+		b, err := pts.UnmarshalMsg(b)
+		return pts, b, err
+	}
+	if mode != gorillaSeriesColumnar {
+		return nil, nil, ErrInvalidGorillaEncoding
+	}
+	cnt, b, err := readUvarint(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	// every point occupies at least one bit of the timestamp block
+	if cnt > uint64(len(b))*8 {
+		return nil, nil, ErrInvalidGorillaEncoding
+	}
+	pts := make(Points, cnt)
+	if cnt == 0 {
+		return pts, b, nil
+	}
+	width, b, err := readUvarint(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if width > uint64(len(b)) {
+		return nil, nil, ErrInvalidGorillaEncoding
+	}
+	if b, err = readPointSizes(b, pts); err != nil {
+		return nil, nil, err
+	}
+	var blk []byte
+	if blk, b, err = readBlock(b); err != nil {
+		return nil, nil, err
+	}
+	if err = decodeTimestamps(blk, pts); err != nil {
+		return nil, nil, err
+	}
+	vals := make([]interface{}, int(cnt)*int(width))
+	for i := range pts {
+		pts[i].Values = vals[i*int(width) : (i+1)*int(width) : (i+1)*int(width)]
+	}
+	for j := 0; j < int(width); j++ {
+		if b, err = readValueColumn(b, pts, j); err != nil {
+			return nil, nil, err
+		}
+	}
+	return pts, b, nil
+}
+
+// appendPointSizes appends the Size of each Point, as a single value when all sizes
+// are the same, or otherwise as zigzag-encoded deltas
+func appendPointSizes(b []byte, pts Points) []byte {
+	constant := true
+	for _, p := range pts[1:] {
+		if p.Size != pts[0].Size {
+			constant = false
+			break
+		}
+	}
+	if constant {
+		b = append(b, gorillaSizesConstant)
+		return appendVarint(b, int64(pts[0].Size))
+	}
+	b = append(b, gorillaSizesDelta)
+	var prev int64
+	for _, p := range pts {
+		b = appendVarint(b, int64(p.Size)-prev)
+		prev = int64(p.Size)
+	}
+	return b
+}
+
+func readPointSizes(b []byte, pts Points) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, ErrInvalidGorillaEncoding
+	}
+	enc := b[0]
+	b = b[1:]
+	switch enc {
+	case gorillaSizesConstant:
+		v, n := binary.Varint(b)
+		if n <= 0 {
+			return nil, ErrInvalidGorillaEncoding
+		}
+		for i := range pts {
+			pts[i].Size = int(v)
+		}
+		return b[n:], nil
+	case gorillaSizesDelta:
+		var prev int64
+		for i := range pts {
+			v, n := binary.Varint(b)
+			if n <= 0 {
+				return nil, ErrInvalidGorillaEncoding
+			}
+			prev += v
+			pts[i].Size = int(prev)
+			b = b[n:]
+		}
+		return b, nil
+	}
+	return nil, ErrInvalidGorillaEncoding
+}
+
+// encodeTimestamps returns the delta-of-delta encoded timestamps of the Points
+func encodeTimestamps(pts Points) []byte {
+	w := &bitWriter{b: make([]byte, 0, 8+len(pts)/8+1)}
+	w.writeBits(uint64(pts[0].Epoch), 64)
+	var prev, delta int64
+	prev = int64(pts[0].Epoch)
+	for _, p := range pts[1:] {
+		d := int64(p.Epoch) - prev
+		dod := d - delta
+		switch {
+		case dod == 0:
+			w.writeBits(0, 1)
+		case fitsBits(dod, 7):
+			w.writeBits(0b10, 2)
+			w.writeBits(uint64(dod), 7)
+		case fitsBits(dod, 9):
+			w.writeBits(0b110, 3)
+			w.writeBits(uint64(dod), 9)
+		case fitsBits(dod, 12):
+			w.writeBits(0b1110, 4)
+			w.writeBits(uint64(dod), 12)
+		case fitsBits(dod, 32):
+			w.writeBits(0b11110, 5)
+			w.writeBits(uint64(dod), 32)
+		default:
+			w.writeBits(0b11111, 5)
+			w.writeBits(uint64(dod), 64)
+		}
+		prev = int64(p.Epoch)
+		delta = d
+	}
+	return w.b
+}
+
+func decodeTimestamps(blk []byte, pts Points) error {
+	r := &bitReader{b: blk}
+	v, err := r.readBits(64)
+	if err != nil {
+		return err
+	}
+	prev := int64(v)
+	pts[0].Epoch = epoch.Epoch(prev)
+	var delta int64
+	for i := 1; i < len(pts); i++ {
+		var size uint
+		for size = 0; size < 5; size++ {
+			bit, err := r.readBits(1)
+			if err != nil {
+				return err
+			}
+			if bit == 0 {
+				break
+			}
+		}
+		var dod int64
+		if size > 0 {
+			n := [...]uint{0, 7, 9, 12, 32, 64}[size]
+			v, err := r.readBits(n)
+			if err != nil {
+				return err
+			}
+			dod = signExtend(v, n)
+		}
+		delta += dod
+		prev += delta
+		pts[i].Epoch = epoch.Epoch(prev)
+	}
+	return nil
+}
+
+// appendValueColumn appends the j'th value of every Point. Columns of float64 values,
+// or of strings that losslessly represent float64 values, are XOR-compressed; any other
+// column falls back to msgpack
+func appendValueColumn(b []byte, pts Points, j int) ([]byte, error) {
+	kind := gorillaColumnMsgp
+	switch pts[0].Values[j].(type) {
+	case float64:
+		kind = gorillaColumnFloat
+	case string:
+		kind = gorillaColumnFloatString
+	}
+	floats := make([]float64, len(pts))
+	for i, p := range pts {
+		if kind == gorillaColumnMsgp {
+			break
+		}
+		f, ok := columnFloat(p.Values[j], kind)
+		if !ok {
+			kind = gorillaColumnMsgp
+			break
+		}
+		floats[i] = f
+	}
+	b = append(b, kind)
+	if kind != gorillaColumnMsgp {
+		return appendBlock(b, encodeFloats(floats)), nil
+	}
+	col := msgp.AppendArrayHeader(nil, uint32(len(pts)))
+	var err error
+	for _, p := range pts {
+		if col, err = msgp.AppendIntf(col, p.Values[j]); err != nil {
+			return nil, err
+		}
+	}
+	return appendBlock(b, col), nil
+}
+
+func readValueColumn(b []byte, pts Points, j int) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, ErrInvalidGorillaEncoding
+	}
+	kind := b[0]
+	blk, b, err := readBlock(b[1:])
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case gorillaColumnFloat, gorillaColumnFloatString:
+		floats := make([]float64, len(pts))
+		if err = decodeFloats(blk, floats); err != nil {
+			return nil, err
+		}
+		for i, f := range floats {
+			if kind == gorillaColumnFloat {
+				pts[i].Values[j] = f
+			} else {
+				pts[i].Values[j] = strconv.FormatFloat(f, 'f', -1, 64)
+			}
+		}
+	case gorillaColumnMsgp:
+		var n uint32
+		if n, blk, err = msgp.ReadArrayHeaderBytes(blk); err != nil {
+			return nil, err
+		}
+		if int(n) != len(pts) {
+			return nil, ErrInvalidGorillaEncoding
+		}
+		for i := range pts {
+			if pts[i].Values[j], blk, err = msgp.ReadIntfBytes(blk); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, ErrInvalidGorillaEncoding
+	}
+	return b, nil
+}
+
+// columnFloat returns the value as a float64, if it is of the column's kind. Strings are
+// only accepted when they can be reproduced exactly from their parsed value
+func columnFloat(v interface{}, kind byte) (float64, bool) {
+	switch kind {
+	case gorillaColumnFloat:
+		f, ok := v.(float64)
+		return f, ok
+	case gorillaColumnFloatString:
+		s, ok := v.(string)
+		if !ok {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || strconv.FormatFloat(f, 'f', -1, 64) != s {
+			return 0, false
+		}
+		return f, true
+	}
+	return 0, false
+}
+
+// encodeFloats returns the XOR-compressed float values
+func encodeFloats(floats []float64) []byte {
+	w := &bitWriter{b: make([]byte, 0, 8+len(floats)/4)}
+	prev := math.Float64bits(floats[0])
+	w.writeBits(prev, 64)
+	leading, trailing := ^uint(0), uint(0)
+	for _, f := range floats[1:] {
+		v := math.Float64bits(f)
+		x := v ^ prev
+		prev = v
+		if x == 0 {
+			w.writeBits(0, 1)
+			continue
+		}
+		w.writeBits(1, 1)
+		l, t := uint(bits.LeadingZeros64(x)), uint(bits.TrailingZeros64(x))
+		if l > 31 {
+			l = 31
+		}
+		if leading != ^uint(0) && l >= leading && t >= trailing {
+			// the meaningful bits fit within the previous window
+			w.writeBits(0, 1)
+			w.writeBits(x>>trailing, 64-leading-trailing)
+			continue
+		}
+		leading, trailing = l, t
+		sig := 64 - l - t
+		w.writeBits(1, 1)
+		w.writeBits(uint64(l), 5)
+		// 64 significant bits are written as 0, since 0 significant bits can't occur
+		w.writeBits(uint64(sig&63), 6)
+		w.writeBits(x>>t, sig)
+	}
+	return w.b
+}
+
+func decodeFloats(blk []byte, floats []float64) error {
+	r := &bitReader{b: blk}
+	prev, err := r.readBits(64)
+	if err != nil {
+		return err
+	}
+	floats[0] = math.Float64frombits(prev)
+	var leading, trailing uint
+	var window bool
+	for i := 1; i < len(floats); i++ {
+		bit, err := r.readBits(1)
+		if err != nil {
+			return err
+		}
+		if bit == 1 {
+			if bit, err = r.readBits(1); err != nil {
+				return err
+			}
+			if bit == 1 {
+				l, err := r.readBits(5)
+				if err != nil {
+					return err
+				}
+				sig, err := r.readBits(6)
+				if err != nil {
+					return err
+				}
+				if sig == 0 {
+					sig = 64
+				}
+				if uint(l)+uint(sig) > 64 {
+					return ErrInvalidGorillaEncoding
+				}
+				leading, trailing, window = uint(l), 64-uint(l)-uint(sig), true
+			} else if !window {
+				return ErrInvalidGorillaEncoding
+			}
+			x, err := r.readBits(64 - leading - trailing)
+			if err != nil {
+				return err
+			}
+			prev ^= x << trailing
+		}
+		floats[i] = math.Float64frombits(prev)
+	}
+	return nil
+}
+
+// fitsBits returns true if v can be represented as an n-bit two's complement integer
+func fitsBits(v int64, n uint) bool {
+	return v >= -(1<<(n-1)) && v < 1<<(n-1)
+}
+
+// signExtend converts an n-bit two's complement integer to an int64
+func signExtend(v uint64, n uint) int64 {
+	if n == 64 {
+		return int64(v)
+	}
+	return int64(v<<(64-n)) >> (64 - n)
+}
+
+func appendBlock(b, blk []byte) []byte {
+	b = appendUvarint(b, uint64(len(blk)))
+	return append(b, blk...)
+}
+
+func readBlock(b []byte) ([]byte, []byte, error) {
+	l, b, err := readUvarint(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if l > uint64(len(b)) {
+		return nil, nil, ErrInvalidGorillaEncoding
+	}
+	return b[:l], b[l:], nil
+}
+
+func readUvarint(b []byte) (uint64, []byte, error) {
+	v, n := binary.Uvarint(b)
+	if n <= 0 {
+		return 0, nil, ErrInvalidGorillaEncoding
+	}
+	return v, b[n:], nil
+}
+
+// bitWriter appends values of arbitrary bit widths to a byte slice
+type bitWriter struct {
+	b     []byte
+	nbits uint // number of unused bits in the last byte
+}
+
+func (w *bitWriter) writeBits(v uint64, n uint) {
+	if n < 64 {
+		v &= 1<<n - 1
+	}
+	for n > 0 {
+		if w.nbits == 0 {
+			w.b = append(w.b, 0)
+			w.nbits = 8
+		}
+		c := n
+		if c > w.nbits {
+			c = w.nbits
+		}
+		w.b[len(w.b)-1] |= byte(v>>(n-c)) << (w.nbits - c)
+		n -= c
+		w.nbits -= c
+		if n < 64 {
+			v &= 1<<n - 1
+		}
+	}
+}
+
+// bitReader reads values of arbitrary bit widths from a byte slice
+type bitReader struct {
+	b   []byte
+	pos uint // position of the next bit to read
+}
+
+func (r *bitReader) readBits(n uint) (uint64, error) {
+	if r.pos+n > uint(len(r.b))*8 {
+		return 0, ErrInvalidGorillaEncoding
+	}
+	var v uint64
+	for n > 0 {
+		avail := 8 - r.pos%8
+		c := n
+		if c > avail {
+			c = avail
+		}
+		cur := uint64(r.b[r.pos/8]>>(avail-c)) & (1<<c - 1)
+		v = v<<c | cur
+		n -= c
+		r.pos += c
+	}
+	return v, nil
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func appendVarint(b []byte, v int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutVarint(buf[:], v)]...)
+}
@@ -0,0 +1,302 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dataset
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/epoch"
+)
+
+// testGorillaDataSet returns a DataSet with seriesCount series of pointCount points at a
+// 15s step, with float64 values, or Prometheus-style string values when asStrings is true
+func testGorillaDataSet(seriesCount, pointCount int, asStrings bool) *DataSet {
+	step := 15 * time.Second
+	start := time.Unix(1600000000, 0)
+	sl := make([]*Series, seriesCount)
+	for i := range sl {
+		pts := make(Points, pointCount)
+		for j := range pts {
+			v := float64(1000+i) + math.Floor(math.Sin(float64(j)/10)*1000)/100
+			p := Point{Epoch: epoch.Epoch(start.Add(step * time.Duration(j)).UnixNano())}
+			if asStrings {
+				s := strconv.FormatFloat(v, 'f', -1, 64)
+				p.Values = []interface{}{s}
+				p.Size = len(s) + 32
+			} else {
+				p.Values = []interface{}{v}
+				p.Size = 32
+			}
+			pts[j] = p
+		}
+		sh := SeriesHeader{
+			Name: "test_metric",
+			Tags: Tags{"instance": "host-" + strconv.Itoa(i), "job": "test"},
+		}
+		sl[i] = &Series{Header: sh, Points: pts, PointSize: pts.Size()}
+	}
+	end := start.Add(step * time.Duration(pointCount-1))
+	return &DataSet{
+		Status:     "success",
+		Results:    []*Result{{SeriesList: sl}},
+		ExtentList: timeseries.ExtentList{timeseries.Extent{Start: start, End: end}},
+		TimeRangeQuery: &timeseries.TimeRangeQuery{
+			Statement: "test_metric", Step: step,
+			Extent: timeseries.Extent{Start: start, End: end},
+		},
+		VolatileExtentList: timeseries.ExtentList{timeseries.Extent{Start: end, End: end}},
+	}
+}
+
+func checkGorillaRoundTrip(t *testing.T, ds *DataSet) *DataSet {
+	t.Helper()
+	b, err := MarshalDataSetGorilla(ds, nil, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsGorillaEncoded(b) {
+		t.Fatal("expected gorilla encoding")
+	}
+	ts, err := UnmarshalDataSet(b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds2 := ts.(*DataSet)
+	if ds2.Status != ds.Status || ds2.Error != ds.Error ||
+		!reflect.DeepEqual(ds2.Warnings, ds.Warnings) ||
+		ds2.ExtentList.String() != ds.ExtentList.String() ||
+		ds2.VolatileExtentList.String() != ds.VolatileExtentList.String() {
+		t.Error("dataset fields mismatch")
+	}
+	if ds.TimeRangeQuery != nil && (ds2.TimeRangeQuery == nil ||
+		ds2.TimeRangeQuery.Step != ds.TimeRangeQuery.Step) {
+		t.Error("time range query mismatch")
+	}
+	if len(ds2.Results) != len(ds.Results) {
+		t.Fatalf("expected %d results got %d", len(ds.Results), len(ds2.Results))
+	}
+	for i, r := range ds.Results {
+		r2 := ds2.Results[i]
+		if r2.StatementID != r.StatementID || len(r2.SeriesList) != len(r.SeriesList) {
+			t.Fatalf("result %d mismatch", i)
+		}
+		for j, s := range r.SeriesList {
+			s2 := r2.SeriesList[j]
+			if s2.Header.Name != s.Header.Name || !reflect.DeepEqual(s2.Header.Tags, s.Header.Tags) ||
+				s2.PointSize != s.PointSize {
+				t.Errorf("series %d/%d header mismatch", i, j)
+			}
+			if len(s2.Points) != len(s.Points) {
+				t.Fatalf("series %d/%d expected %d points got %d", i, j, len(s.Points), len(s2.Points))
+			}
+			for k, p := range s.Points {
+				p2 := s2.Points[k]
+				if p2.Epoch != p.Epoch || p2.Size != p.Size || len(p2.Values) != len(p.Values) {
+					t.Fatalf("series %d/%d point %d mismatch: %v != %v", i, j, k, p2, p)
+				}
+				for l, v := range p.Values {
+					if f, ok := v.(float64); ok && math.IsNaN(f) {
+						if f2, ok := p2.Values[l].(float64); !ok || !math.IsNaN(f2) {
+							t.Errorf("expected NaN got %v", p2.Values[l])
+						}
+						continue
+					}
+					if p2.Values[l] != v {
+						t.Errorf("series %d/%d point %d value %d: expected %v (%T) got %v (%T)",
+							i, j, k, l, v, v, p2.Values[l], p2.Values[l])
+					}
+				}
+			}
+		}
+	}
+	return ds2
+}
+
+func TestMarshalDataSetGorilla(t *testing.T) {
+
+	_, err := MarshalDataSetGorilla(nil, nil, 200)
+	if err != timeseries.ErrUnknownFormat {
+		t.Errorf("expected %v got %v", timeseries.ErrUnknownFormat, err)
+	}
+
+	t.Run("floats", func(t *testing.T) {
+		checkGorillaRoundTrip(t, testGorillaDataSet(3, 500, false))
+	})
+
+	t.Run("strings", func(t *testing.T) {
+		checkGorillaRoundTrip(t, testGorillaDataSet(3, 500, true))
+	})
+
+	t.Run("special values", func(t *testing.T) {
+		ds := testGorillaDataSet(1, 8, false)
+		pts := ds.Results[0].SeriesList[0].Points
+		pts[1].Values[0] = math.NaN()
+		pts[2].Values[0] = math.Inf(1)
+		pts[3].Values[0] = math.Inf(-1)
+		pts[4].Values[0] = 0.0
+		pts[5].Values[0] = -math.MaxFloat64
+		pts[6].Values[0] = math.SmallestNonzeroFloat64
+		checkGorillaRoundTrip(t, ds)
+		ds = testGorillaDataSet(1, 4, true)
+		pts = ds.Results[0].SeriesList[0].Points
+		pts[1].Values[0] = "NaN"
+		pts[2].Values[0] = "+Inf"
+		pts[3].Values[0] = "-Inf"
+		checkGorillaRoundTrip(t, ds)
+	})
+
+	t.Run("irregular timestamps", func(t *testing.T) {
+		ds := testGorillaDataSet(1, 10, false)
+		pts := ds.Results[0].SeriesList[0].Points
+		offsets := []int64{0, 1, -1, 100, -300, 5000, -1 << 20, 1 << 40, -1 << 40, 3}
+		for i := range pts {
+			pts[i].Epoch = epoch.Epoch(int64(pts[i].Epoch) + offsets[i])
+		}
+		checkGorillaRoundTrip(t, ds)
+	})
+
+	t.Run("mixed values", func(t *testing.T) {
+		ds := testGorillaDataSet(2, 6, false)
+		pts := ds.Results[0].SeriesList[0].Points
+		for i := range pts {
+			pts[i].Values = append(pts[i].Values, int64(i), "label", nil, true)
+			pts[i].Size = 64 + i
+		}
+		// a string column that can't be reproduced from a float falls back to msgpack
+		pts[2].Values[2] = "1.50"
+		checkGorillaRoundTrip(t, ds)
+		// unequal value counts fall back to msgpack for the whole series
+		pts = ds.Results[0].SeriesList[1].Points
+		pts[3].Values = append(pts[3].Values, 2.0)
+		checkGorillaRoundTrip(t, ds)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		ds := testGorillaDataSet(2, 1, false)
+		ds.Results[0].SeriesList[1].Points = Points{}
+		ds.Results = append(ds.Results, &Result{StatementID: 1, Error: "test"})
+		ds.Warnings = []string{"test warning"}
+		checkGorillaRoundTrip(t, ds)
+		checkGorillaRoundTrip(t, &DataSet{})
+	})
+}
+
+func TestUnmarshalDataSetMsgpack(t *testing.T) {
+	// objects cached with msgpack must remain readable
+	ds := testGorillaDataSet(2, 20, true)
+	b, err := MarshalDataSet(ds, nil, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsGorillaEncoded(b) {
+		t.Error("expected msgpack encoding")
+	}
+	ts, err := UnmarshalDataSet(b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := ts.(*DataSet).Results[0].SeriesList[1].Points[19].Values[0]; v !=
+		ds.Results[0].SeriesList[1].Points[19].Values[0] {
+		t.Errorf("unexpected value %v", v)
+	}
+}
+
+func TestUnmarshalDataSetGorillaInvalid(t *testing.T) {
+	b, err := MarshalDataSetGorilla(testGorillaDataSet(2, 50, true), nil, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every truncation must fail cleanly
+	for i := len(gorillaMagic); i < len(b); i++ {
+		if _, err := UnmarshalDataSet(b[:i], nil); err == nil {
+			t.Errorf("expected error for truncation at %d of %d", i, len(b))
+		}
+	}
+}
+
+func TestGorillaSize(t *testing.T) {
+	ds := testGorillaDataSet(10, 720, true)
+	mb, _ := MarshalDataSet(ds, nil, 200)
+	gb, _ := MarshalDataSetGorilla(ds, nil, 200)
+	if len(gb) >= len(mb)/2 {
+		t.Errorf("expected gorilla encoding (%d bytes) to be less than half of msgpack (%d bytes)",
+			len(gb), len(mb))
+	}
+}
+
+func benchmarkMarshal(b *testing.B, m timeseries.MarshalerFunc, asStrings bool) {
+	ds := testGorillaDataSet(10, 720, asStrings)
+	var out []byte
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		out, _ = m(ds, nil, 200)
+	}
+	b.ReportMetric(float64(len(out)), "encoded-bytes")
+}
+
+func benchmarkUnmarshal(b *testing.B, m timeseries.MarshalerFunc, asStrings bool) {
+	enc, err := m(testGorillaDataSet(10, 720, asStrings), nil, 200)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := UnmarshalDataSet(enc, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(len(enc)), "encoded-bytes")
+}
+
+func BenchmarkMarshalDataSetMsgpack(b *testing.B) {
+	benchmarkMarshal(b, MarshalDataSet, false)
+}
+
+func BenchmarkMarshalDataSetGorilla(b *testing.B) {
+	benchmarkMarshal(b, MarshalDataSetGorilla, false)
+}
+
+func BenchmarkMarshalDataSetMsgpackStrings(b *testing.B) {
+	benchmarkMarshal(b, MarshalDataSet, true)
+}
+
+func BenchmarkMarshalDataSetGorillaStrings(b *testing.B) {
+	benchmarkMarshal(b, MarshalDataSetGorilla, true)
+}
+
+func BenchmarkUnmarshalDataSetMsgpack(b *testing.B) {
+	benchmarkUnmarshal(b, MarshalDataSet, false)
+}
+
+func BenchmarkUnmarshalDataSetGorilla(b *testing.B) {
+	benchmarkUnmarshal(b, MarshalDataSetGorilla, false)
+}
+
+func BenchmarkUnmarshalDataSetMsgpackStrings(b *testing.B) {
+	benchmarkUnmarshal(b, MarshalDataSet, true)
+}
+
+func BenchmarkUnmarshalDataSetGorillaStrings(b *testing.B) {
+	benchmarkUnmarshal(b, MarshalDataSetGorilla, true)
+}
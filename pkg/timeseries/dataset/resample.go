@@ -0,0 +1,62 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dataset
+
+import (
+	"sort"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/epoch"
+)
+
+// Resample changes the DataSet from sourceStep to step. For each step-aligned timestamp
+// whose sourceStep interval falls within the provided Extent, each Series retains the
+// value of the Point at the start of that interval, re-timestamped to the new step.
+// Timestamps having no source Point are omitted.
+func (ds *DataSet) Resample(step, sourceStep time.Duration, e timeseries.Extent) {
+	if step <= 0 || sourceStep <= 0 || step == sourceStep {
+		return
+	}
+	start := e.Start.Truncate(step)
+	if start.Before(e.Start) {
+		start = start.Add(step)
+	}
+	end := e.End.Add(sourceStep)
+	ds.forEachSeries(func(s *Series) {
+		pts := make(Points, 0, int(e.End.Sub(start)/step)+1)
+		for t := start; t.Before(end); t = t.Add(step) {
+			src := epoch.Epoch(t.Truncate(sourceStep).UnixNano())
+			i := sort.Search(len(s.Points), func(j int) bool {
+				return s.Points[j].Epoch >= src
+			})
+			if i == len(s.Points) || s.Points[i].Epoch != src {
+				continue
+			}
+			p := s.Points[i].Clone()
+			p.Epoch = epoch.Epoch(t.UnixNano())
+			pts = append(pts, p)
+		}
+		s.Points = pts
+		s.PointSize = pts.Size()
+	})
+	if ds.TimeRangeQuery != nil {
+		trq := ds.TimeRangeQuery.Clone()
+		trq.Step = step
+		ds.TimeRangeQuery = trq
+	}
+}
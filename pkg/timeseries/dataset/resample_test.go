@@ -0,0 +1,77 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dataset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/epoch"
+)
+
+func TestResample(t *testing.T) {
+
+	// 15s points from 0s through 120s, with the point at 60s missing
+	s := &Series{}
+	for i := 0; i <= 8; i++ {
+		if i == 4 {
+			continue
+		}
+		s.Points = append(s.Points, Point{Epoch: epoch.Epoch(time.Duration(i) * 15 * time.Second),
+			Size: 32, Values: []interface{}{float64(i)}})
+	}
+	ds := &DataSet{Results: []*Result{{SeriesList: []*Series{s, nil}}, nil},
+		TimeRangeQuery: &timeseries.TimeRangeQuery{Step: 15 * time.Second}}
+	trq := ds.TimeRangeQuery
+
+	// a 20s step is resampled from the source points at 0s, 15s, 30s, 75s and 90s,
+	// while 60s is omitted
+	ds.Resample(20*time.Second, 15*time.Second,
+		timeseries.Extent{Start: time.Unix(0, 0), End: time.Unix(105, 0)})
+	expected := []struct {
+		e epoch.Epoch
+		v float64
+	}{
+		{0, 0}, {epoch.Epoch(20 * time.Second), 1}, {epoch.Epoch(40 * time.Second), 2},
+		{epoch.Epoch(80 * time.Second), 5}, {epoch.Epoch(100 * time.Second), 6},
+	}
+	if len(s.Points) != len(expected) {
+		t.Fatalf("expected %d got %d", len(expected), len(s.Points))
+	}
+	for i, p := range s.Points {
+		if p.Epoch != expected[i].e || p.Values[0] != expected[i].v {
+			t.Errorf("expected %d/%v got %d/%v", expected[i].e, expected[i].v, p.Epoch, p.Values[0])
+		}
+	}
+	if s.PointSize != s.Points.Size() {
+		t.Errorf("expected %d got %d", s.Points.Size(), s.PointSize)
+	}
+	if ds.Step() != 20*time.Second {
+		t.Errorf("expected %s got %s", 20*time.Second, ds.Step())
+	}
+	if trq.Step != 15*time.Second {
+		t.Errorf("expected %s got %s", 15*time.Second, trq.Step)
+	}
+
+	// an unchanged step is a no-op
+	ds.Resample(20*time.Second, 20*time.Second,
+		timeseries.Extent{Start: time.Unix(0, 0), End: time.Unix(105, 0)})
+	if len(s.Points) != len(expected) {
+		t.Errorf("expected %d got %d", len(expected), len(s.Points))
+	}
+}
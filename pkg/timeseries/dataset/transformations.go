@@ -0,0 +1,126 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dataset
+
+import (
+	"math"
+	"strconv"
+)
+
+// RenameTag renames the provided tag key of each Series in the DataSet
+func (ds *DataSet) RenameTag(from, to string) {
+	ds.forEachSeries(func(s *Series) {
+		if v, ok := s.Header.Tags[from]; ok {
+			delete(s.Header.Tags, from)
+			s.Header.Tags[to] = v
+		}
+	})
+}
+
+// DropTags removes the provided tag keys from each Series in the DataSet
+func (ds *DataSet) DropTags(keys ...string) {
+	ds.forEachSeries(func(s *Series) {
+		for _, k := range keys {
+			delete(s.Header.Tags, k)
+		}
+	})
+}
+
+// FilterSeries removes each Series from the DataSet for which keep returns false
+func (ds *DataSet) FilterSeries(keep func(*Series) bool) {
+	for _, r := range ds.Results {
+		if r == nil {
+			continue
+		}
+		sl := r.SeriesList[:0]
+		for _, s := range r.SeriesList {
+			if s != nil && keep(s) {
+				sl = append(sl, s)
+			}
+		}
+		r.SeriesList = sl
+	}
+}
+
+// ScaleValues multiplies each numeric value in the DataSet by the provided factor.
+// Values of numeric strings are also scaled, while all other values are unchanged
+func (ds *DataSet) ScaleValues(factor float64) {
+	ds.forEachSeries(func(s *Series) {
+		for i := range s.Points {
+			for j, v := range s.Points[i].Values {
+				s.Points[i].Values[j] = scaleValue(v, factor)
+			}
+		}
+	})
+}
+
+func scaleValue(v interface{}, factor float64) interface{} {
+	switch t := v.(type) {
+	case float64:
+		return t * factor
+	case float32:
+		return float32(float64(t) * factor)
+	case int64:
+		return int64(math.Round(float64(t) * factor))
+	case int:
+		return int(math.Round(float64(t) * factor))
+	case int32:
+		return int32(math.Round(float64(t) * factor))
+	case int16:
+		return int16(math.Round(float64(t) * factor))
+	case string:
+		if f, err := strconv.ParseFloat(t, 64); err == nil {
+			return strconv.FormatFloat(f*factor, 'f', -1, 64)
+		}
+	}
+	return v
+}
+
+// Downsample reduces each Series in the DataSet to no more than maxPoints Points,
+// by retaining the first Point of each group of consecutive Points
+func (ds *DataSet) Downsample(maxPoints int) {
+	if maxPoints < 1 {
+		return
+	}
+	ds.forEachSeries(func(s *Series) {
+		l := len(s.Points)
+		if l <= maxPoints {
+			return
+		}
+		stride := (l + maxPoints - 1) / maxPoints
+		pts := make(Points, 0, maxPoints)
+		for i := 0; i < l; i += stride {
+			pts = append(pts, s.Points[i])
+		}
+		s.Points = pts
+		s.PointSize = pts.Size()
+	})
+}
+
+// forEachSeries calls fn for each non-nil Series in the DataSet
+func (ds *DataSet) forEachSeries(fn func(*Series)) {
+	for _, r := range ds.Results {
+		if r == nil {
+			continue
+		}
+		for _, s := range r.SeriesList {
+			if s != nil {
+				fn(s)
+			}
+		}
+	}
+}
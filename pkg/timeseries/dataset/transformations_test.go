@@ -0,0 +1,88 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dataset
+
+import (
+	"testing"
+
+	"github.com/trickstercache/trickster/pkg/timeseries/epoch"
+)
+
+func TestScaleValues(t *testing.T) {
+	s := &Series{Points: Points{{Epoch: epoch.Epoch(1), Values: []interface{}{
+		float64(1.5), float32(2), int64(3), int(4), int32(5), int16(6), "7.5", "abc", true,
+	}}}}
+	ds := &DataSet{Results: []*Result{{SeriesList: []*Series{s, nil}}, nil}}
+	ds.ScaleValues(2)
+	expected := []interface{}{
+		float64(3), float32(4), int64(6), int(8), int32(10), int16(12), "15", "abc", true,
+	}
+	for i, v := range s.Points[0].Values {
+		if v != expected[i] {
+			t.Errorf("expected %v got %v", expected[i], v)
+		}
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	s := &Series{}
+	for i := 0; i < 10; i++ {
+		s.Points = append(s.Points, Point{Epoch: epoch.Epoch(i), Size: 32,
+			Values: []interface{}{float64(i)}})
+	}
+	ds := &DataSet{Results: []*Result{{SeriesList: []*Series{s}}}}
+
+	ds.Downsample(0)
+	ds.Downsample(10)
+	if len(s.Points) != 10 {
+		t.Errorf("expected %d got %d", 10, len(s.Points))
+	}
+
+	ds.Downsample(4)
+	if len(s.Points) != 4 {
+		t.Fatalf("expected %d got %d", 4, len(s.Points))
+	}
+	for i, e := range []epoch.Epoch{0, 3, 6, 9} {
+		if s.Points[i].Epoch != e {
+			t.Errorf("expected %d got %d", e, s.Points[i].Epoch)
+		}
+	}
+	if s.PointSize != s.Points.Size() {
+		t.Errorf("expected %d got %d", s.Points.Size(), s.PointSize)
+	}
+}
+
+func TestTagTransformations(t *testing.T) {
+	s1 := &Series{Header: SeriesHeader{Tags: Tags{"a": "1", "b": "2"}}}
+	s2 := &Series{Header: SeriesHeader{Tags: Tags{"b": "3"}}}
+	ds := &DataSet{Results: []*Result{{SeriesList: []*Series{s1, s2}}}}
+
+	ds.RenameTag("a", "c")
+	if s1.Header.Tags["c"] != "1" || len(s1.Header.Tags) != 2 || len(s2.Header.Tags) != 1 {
+		t.Errorf("unexpected tags %v %v", s1.Header.Tags, s2.Header.Tags)
+	}
+
+	ds.DropTags("b")
+	if len(s1.Header.Tags) != 1 || len(s2.Header.Tags) != 0 {
+		t.Errorf("unexpected tags %v %v", s1.Header.Tags, s2.Header.Tags)
+	}
+
+	ds.FilterSeries(func(s *Series) bool { return s.Header.Tags["c"] != "" })
+	if len(ds.Results[0].SeriesList) != 1 || ds.Results[0].SeriesList[0] != s1 {
+		t.Errorf("unexpected series list %v", ds.Results[0].SeriesList)
+	}
+}
@@ -56,6 +56,9 @@ type TimeRangeQuery struct {
 	ValueFieldDefinitions []FieldDefinition `msg:"vfdefs"`
 	// ParsedQuery is a member for the vendor-specific query object
 	ParsedQuery interface{} `msg:"-"`
+	// RequestedStep is the Step requested by the client, when Step has been normalized
+	// to a canonical step
+	RequestedStep time.Duration `msg:"-"`
 }
 
 // Clone returns an exact copy of a TimeRangeQuery
@@ -64,6 +67,7 @@ func (trq *TimeRangeQuery) Clone() *TimeRangeQuery {
 		Statement:           trq.Statement,
 		Step:                trq.Step,
 		StepNS:              trq.StepNS,
+		RequestedStep:       trq.RequestedStep,
 		Extent:              Extent{Start: trq.Extent.Start, End: trq.Extent.End},
 		IsOffset:            trq.IsOffset,
 		TimestampDefinition: trq.TimestampDefinition.Clone(),
@@ -101,6 +105,29 @@ func (trq *TimeRangeQuery) NormalizeExtent() {
 	}
 }
 
+// NormalizeStep changes the Step to the largest of the provided canonical steps that is
+// less than or equal to the requested Step, so that queries with similar steps share
+// cached data. The Extent is first normalized to the requested Step, which is retained
+// as the RequestedStep. NormalizeStep returns true if the Step was changed.
+func (trq *TimeRangeQuery) NormalizeStep(steps []time.Duration) bool {
+	if trq.Step <= 0 {
+		return false
+	}
+	var c time.Duration
+	for _, s := range steps {
+		if s <= trq.Step && s > c {
+			c = s
+		}
+	}
+	if c == 0 || c == trq.Step {
+		return false
+	}
+	trq.NormalizeExtent()
+	trq.RequestedStep = trq.Step
+	trq.Step = c
+	return true
+}
+
 func (trq *TimeRangeQuery) String() string {
 	return fmt.Sprintf(`{ "statement": "%s", "step": "%s", "extent": "%s", "tsd": "%s", "td": %s, "vd": %s }`,
 		strings.Replace(trq.Statement, `"`, `\"`, -1), trq.Step.String(),
@@ -72,6 +72,50 @@ func TestNormalizeExtent(t *testing.T) {
 	}
 }
 
+func TestNormalizeStep(t *testing.T) {
+
+	steps := []time.Duration{15 * time.Second, time.Minute, 5 * time.Second}
+
+	tests := []struct {
+		step, expected time.Duration
+		changed        bool
+	}{
+		{0, 0, false},
+		{time.Second, time.Second, false},
+		{15 * time.Second, 15 * time.Second, false},
+		{20 * time.Second, 15 * time.Second, true},
+		{90 * time.Second, time.Minute, true},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			trq := TimeRangeQuery{Statement: "up", Extent: Extent{Start: time.Unix(1010, 0),
+				End: time.Unix(1990, 0)}, Step: test.step}
+			changed := trq.NormalizeStep(steps)
+			if changed != test.changed {
+				t.Errorf("expected %t got %t", test.changed, changed)
+			}
+			if trq.Step != test.expected {
+				t.Errorf("expected %s got %s", test.expected, trq.Step)
+			}
+			if !changed {
+				if trq.RequestedStep != 0 {
+					t.Errorf("expected %d got %d", 0, trq.RequestedStep)
+				}
+				return
+			}
+			if trq.RequestedStep != test.step {
+				t.Errorf("expected %s got %s", test.step, trq.RequestedStep)
+			}
+			// the extent is aligned to the requested step
+			if trq.Extent.Start.UnixNano()%int64(test.step) != 0 ||
+				trq.Extent.End.UnixNano()%int64(test.step) != 0 {
+				t.Errorf("expected extent %s aligned to %s", trq.Extent, test.step)
+			}
+		})
+	}
+}
+
 func TestClone(t *testing.T) {
 	u, _ := url.Parse("http://127.0.0.1/")
 	trq := &TimeRangeQuery{Statement: "1234", Extent: Extent{Start: time.Unix(5, 0),
@@ -80,3 +80,25 @@ type Timeseries interface {
 	// SetTimeRangeQuery sets the TimeRangeQuery associated with the Timeseries
 	SetTimeRangeQuery(*TimeRangeQuery)
 }
+
+// Warner is implemented by Timeseries that can convey warnings to the client in their
+// serialized response
+type Warner interface {
+	// AddWarning appends the provided message to the Timeseries's warnings
+	AddWarning(string)
+	// ResetWarnings clears the Timeseries's warnings and returns those that were cleared
+	ResetWarnings() []string
+}
+
+// Resampler is implemented by Timeseries that can be resampled to a different step
+type Resampler interface {
+	// Resample changes the Timeseries from sourceStep to step, for each step-aligned
+	// timestamp whose sourceStep-aligned interval is within the provided Extent
+	Resample(step, sourceStep time.Duration, e Extent)
+}
+
+// Checksummer is implemented by Timeseries that can calculate a checksum of their contents
+type Checksummer interface {
+	// Checksum returns a checksum of the Timeseries contents, suitable for use as an ETag
+	Checksum() string
+}
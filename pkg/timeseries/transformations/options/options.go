@@ -0,0 +1,130 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package options provides the options for Time Series Transformations
+package options
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Transformation Types
+const (
+	// TypeRenameLabel renames the Label of each series to NewLabel
+	TypeRenameLabel = "rename_label"
+	// TypeDropLabel removes the Label from each series
+	TypeDropLabel = "drop_label"
+	// TypeKeepSeries removes each series whose Label value does not match the Regex
+	TypeKeepSeries = "keep_series"
+	// TypeDropSeries removes each series whose Label value matches the Regex
+	TypeDropSeries = "drop_series"
+	// TypeScale multiplies each numeric value by the Factor
+	TypeScale = "scale"
+	// TypeDownsample reduces each series to no more than MaxPoints points
+	TypeDownsample = "downsample"
+)
+
+// Options defines a single step in a backend's Time Series Transformation pipeline
+type Options struct {
+	// Type is the type of transformation: rename_label, drop_label, keep_series,
+	// drop_series, scale or downsample
+	Type string `yaml:"type,omitempty"`
+	// Label is the label name used by the rename_label, drop_label, keep_series
+	// and drop_series transformations
+	Label string `yaml:"label,omitempty"`
+	// NewLabel is the new label name used by the rename_label transformation
+	NewLabel string `yaml:"new_label,omitempty"`
+	// Regex is the fully-anchored regular expression that the Label value is matched
+	// against by the keep_series and drop_series transformations
+	Regex string `yaml:"regex,omitempty"`
+	// Factor is the multiplier used by the scale transformation
+	Factor float64 `yaml:"factor,omitempty"`
+	// MaxPoints is the maximum number of points per series retained by the downsample
+	// transformation
+	MaxPoints int `yaml:"max_points,omitempty"`
+
+	// CompiledRegex is the compiled version of Regex
+	CompiledRegex *regexp.Regexp `yaml:"-"`
+}
+
+// List is an ordered list of transformations
+type List []*Options
+
+// Clone returns a perfect copy of the Options
+func (o *Options) Clone() *Options {
+	return &Options{
+		Type:          o.Type,
+		Label:         o.Label,
+		NewLabel:      o.NewLabel,
+		Regex:         o.Regex,
+		Factor:        o.Factor,
+		MaxPoints:     o.MaxPoints,
+		CompiledRegex: o.CompiledRegex,
+	}
+}
+
+// Clone returns a perfect copy of the List
+func (l List) Clone() List {
+	if l == nil {
+		return nil
+	}
+	nl := make(List, len(l))
+	for i, o := range l {
+		nl[i] = o.Clone()
+	}
+	return nl
+}
+
+// Validate ensures each transformation in the List is valid, and compiles its Regex
+func (l List) Validate() error {
+	for i, o := range l {
+		if o == nil {
+			return fmt.Errorf("transformation %d is empty", i)
+		}
+		switch o.Type {
+		case TypeRenameLabel:
+			if o.Label == "" || o.NewLabel == "" {
+				return fmt.Errorf("transformation %d: 'label' and 'new_label' are required for type '%s'",
+					i, o.Type)
+			}
+		case TypeDropLabel:
+			if o.Label == "" {
+				return fmt.Errorf("transformation %d: 'label' is required for type '%s'", i, o.Type)
+			}
+		case TypeKeepSeries, TypeDropSeries:
+			if o.Label == "" {
+				return fmt.Errorf("transformation %d: 'label' is required for type '%s'", i, o.Type)
+			}
+			re, err := regexp.Compile("^(?:" + o.Regex + ")$")
+			if err != nil {
+				return fmt.Errorf("transformation %d: invalid 'regex': %w", i, err)
+			}
+			o.CompiledRegex = re
+		case TypeScale:
+			if o.Factor == 0 {
+				return fmt.Errorf("transformation %d: 'factor' must be non-zero for type '%s'", i, o.Type)
+			}
+		case TypeDownsample:
+			if o.MaxPoints < 1 {
+				return fmt.Errorf("transformation %d: 'max_points' must be >= 1 for type '%s'", i, o.Type)
+			}
+		default:
+			return fmt.Errorf("transformation %d: invalid type '%s'", i, o.Type)
+		}
+	}
+	return nil
+}
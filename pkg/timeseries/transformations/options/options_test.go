@@ -0,0 +1,77 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+
+	l := List{
+		{Type: TypeRenameLabel, Label: "a", NewLabel: "b"},
+		{Type: TypeDropLabel, Label: "a"},
+		{Type: TypeKeepSeries, Label: "a", Regex: "x|y"},
+		{Type: TypeDropSeries, Label: "a", Regex: "z"},
+		{Type: TypeScale, Factor: 0.001},
+		{Type: TypeDownsample, MaxPoints: 10},
+	}
+	if err := l.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	// regular expressions are fully anchored
+	if re := l[2].CompiledRegex; re == nil || !re.MatchString("x") || re.MatchString("xx") {
+		t.Error("expected anchored regex")
+	}
+
+	tests := []struct {
+		o        *Options
+		expected string
+	}{
+		{nil, "is empty"},
+		{&Options{Type: "invalid"}, "invalid type"},
+		{&Options{Type: TypeRenameLabel, Label: "a"}, "'new_label'"},
+		{&Options{Type: TypeDropLabel}, "'label'"},
+		{&Options{Type: TypeKeepSeries, Label: "a", Regex: "("}, "'regex'"},
+		{&Options{Type: TypeDropSeries, Regex: "a"}, "'label'"},
+		{&Options{Type: TypeScale}, "'factor'"},
+		{&Options{Type: TypeDownsample}, "'max_points'"},
+	}
+	for _, test := range tests {
+		err := List{test.o}.Validate()
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("expected error containing %s got %v", test.expected, err)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	if List(nil).Clone() != nil {
+		t.Error("expected nil")
+	}
+	l := List{{Type: TypeKeepSeries, Label: "a", Regex: "x"}}
+	l.Validate()
+	l2 := l.Clone()
+	l2[0].Label = "b"
+	if l[0].Label != "a" {
+		t.Errorf("expected %s got %s", "a", l[0].Label)
+	}
+	if l2[0].CompiledRegex != l[0].CompiledRegex {
+		t.Error("expected compiled regex to be cloned")
+	}
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package transformations applies a backend's configured post-processing
+// transformations to Time Series query results
+package transformations
+
+import (
+	"github.com/trickstercache/trickster/pkg/timeseries"
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+	"github.com/trickstercache/trickster/pkg/timeseries/transformations/options"
+)
+
+// Process applies the provided transformations, in order, to the Timeseries.
+// The List must have been validated.
+func Process(ts timeseries.Timeseries, l options.List) {
+	if len(l) == 0 {
+		return
+	}
+	ds, ok := ts.(*dataset.DataSet)
+	if !ok || ds == nil {
+		return
+	}
+	for _, o := range l {
+		switch o.Type {
+		case options.TypeRenameLabel:
+			ds.RenameTag(o.Label, o.NewLabel)
+		case options.TypeDropLabel:
+			ds.DropTags(o.Label)
+		case options.TypeKeepSeries, options.TypeDropSeries:
+			if o.CompiledRegex == nil {
+				continue
+			}
+			keep := o.Type == options.TypeKeepSeries
+			ds.FilterSeries(func(s *dataset.Series) bool {
+				return o.CompiledRegex.MatchString(s.Header.Tags[o.Label]) == keep
+			})
+		case options.TypeScale:
+			ds.ScaleValues(o.Factor)
+		case options.TypeDownsample:
+			ds.Downsample(o.MaxPoints)
+		}
+	}
+}
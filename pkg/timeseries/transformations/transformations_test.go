@@ -0,0 +1,93 @@
+/*
+ * Copyright 2018 The Trickster Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transformations
+
+import (
+	"testing"
+
+	"github.com/trickstercache/trickster/pkg/timeseries/dataset"
+	"github.com/trickstercache/trickster/pkg/timeseries/epoch"
+	"github.com/trickstercache/trickster/pkg/timeseries/transformations/options"
+)
+
+func testDataSet() *dataset.DataSet {
+	newSeries := func(job string) *dataset.Series {
+		s := &dataset.Series{Header: dataset.SeriesHeader{
+			Tags: dataset.Tags{"job": job, "instance": "i1"}}}
+		for i := 0; i < 10; i++ {
+			s.Points = append(s.Points, dataset.Point{Epoch: epoch.Epoch(i), Size: 32,
+				Values: []interface{}{float64(i)}})
+		}
+		return s
+	}
+	return &dataset.DataSet{Results: []*dataset.Result{{SeriesList: []*dataset.Series{
+		newSeries("api"), newSeries("web"), newSeries("db"),
+	}}}}
+}
+
+func TestProcess(t *testing.T) {
+
+	l := options.List{
+		{Type: options.TypeDropSeries, Label: "job", Regex: "db"},
+		{Type: options.TypeRenameLabel, Label: "job", NewLabel: "service"},
+		{Type: options.TypeDropLabel, Label: "instance"},
+		{Type: options.TypeScale, Factor: 2},
+		{Type: options.TypeDownsample, MaxPoints: 5},
+	}
+	if err := l.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	ds := testDataSet()
+	Process(ds, l)
+
+	sl := ds.Results[0].SeriesList
+	if len(sl) != 2 {
+		t.Fatalf("expected %d got %d", 2, len(sl))
+	}
+	for _, s := range sl {
+		if _, ok := s.Header.Tags["instance"]; ok {
+			t.Error("expected instance tag to be dropped")
+		}
+		if _, ok := s.Header.Tags["job"]; ok || s.Header.Tags["service"] == "" {
+			t.Error("expected job tag to be renamed")
+		}
+		if len(s.Points) != 5 {
+			t.Errorf("expected %d got %d", 5, len(s.Points))
+		}
+		if v := s.Points[1].Values[0].(float64); v != 4 {
+			t.Errorf("expected %d got %f", 4, v)
+		}
+	}
+
+	// keep_series retains only matching series
+	ds = testDataSet()
+	l = options.List{{Type: options.TypeKeepSeries, Label: "job", Regex: "api|web"}}
+	l.Validate()
+	Process(ds, l)
+	if len(ds.Results[0].SeriesList) != 2 {
+		t.Errorf("expected %d got %d", 2, len(ds.Results[0].SeriesList))
+	}
+
+	// unsupported timeseries and empty lists are unaffected
+	Process(nil, l)
+	ds = testDataSet()
+	Process(ds, nil)
+	if len(ds.Results[0].SeriesList) != 3 {
+		t.Errorf("expected %d got %d", 3, len(ds.Results[0].SeriesList))
+	}
+}
@@ -34,8 +34,9 @@ import (
 
 // WFDocument the Wire Format Document for the timeseries
 type WFDocument struct {
-	Status string `json:"status"`
-	Data   WFData `json:"data"`
+	Status   string   `json:"status"`
+	Data     WFData   `json:"data"`
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // WFData is the data section of the WFD
@@ -81,6 +82,7 @@ func UnmarshalTimeseriesReader(reader io.Reader, trq *timeseries.TimeRangeQuery)
 		Status:         wfd.Status,
 		Results:        []*dataset.Result{{}},
 		TimeRangeQuery: trq,
+		Warnings:       wfd.Warnings,
 	}
 	if trq != nil {
 		ds.ExtentList = timeseries.ExtentList{trq.Extent}